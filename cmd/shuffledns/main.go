@@ -1,19 +1,140 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/projectdiscovery/gologger"
 	"github.com/mohammadanaraki/shuffledns/pkg/runner"
 )
 
 func main() {
+	// Handle the subcommands which have their own set of flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "merge":
+			options, err := runner.ParseMergeOptions(os.Args[2:])
+			if err != nil {
+				exitParsing(err)
+			}
+			if err := runner.RunMerge(options); err != nil {
+				gologger.Fatal().Msgf("Could not merge outputs: %s\n", err)
+			}
+			return
+		case "daemon":
+			options, err := runner.ParseDaemonOptions(os.Args[2:])
+			if err != nil {
+				exitParsing(err)
+			}
+			if err := runner.RunDaemon(options); err != nil {
+				gologger.Fatal().Msgf("Could not run daemon: %s\n", err)
+			}
+			return
+		case "split":
+			options, err := runner.ParseSplitOptions(os.Args[2:])
+			if err != nil {
+				exitParsing(err)
+			}
+			if err := runner.RunSplit(options); err != nil {
+				gologger.Fatal().Msgf("Could not split wordlist: %s\n", err)
+			}
+			return
+		case "wordlist":
+			options, err := runner.ParseWordlistOptions(os.Args[2:])
+			if err != nil {
+				exitParsing(err)
+			}
+			if err := runner.RunWordlist(options); err != nil {
+				gologger.Fatal().Msgf("Could not process wordlist: %s\n", err)
+			}
+			return
+		case "healthcheck":
+			options, err := runner.ParseHealthcheckOptions(os.Args[2:])
+			if err != nil {
+				exitParsing(err)
+			}
+			if err := runner.RunHealthcheck(options); err != nil {
+				gologger.Fatal().Msgf("Healthcheck failed: %s\n", err)
+			}
+			return
+		case "verify":
+			options, err := runner.ParseVerifyOptions(os.Args[2:])
+			if err != nil {
+				exitParsing(err)
+			}
+			if err := runner.RunVerify(options); err != nil {
+				gologger.Fatal().Msgf("Verification failed: %s\n", err)
+			}
+			return
+		case "store":
+			options, err := runner.ParseStoreOptions(os.Args[2:])
+			if err != nil {
+				exitParsing(err)
+			}
+			if err := runner.RunStoreQuery(options); err != nil {
+				gologger.Fatal().Msgf("Could not query history store: %s\n", err)
+			}
+			return
+		}
+	}
+
 	// Parse the command line flags and read config files
-	options := runner.ParseOptions()
+	options, err := runner.ParseOptions()
+	if err != nil {
+		exitParsing(err)
+	}
+	if options.Version {
+		return
+	}
 
 	massdnsRunner, err := runner.New(options)
 	if err != nil {
 		gologger.Fatal().Msgf("Could not create runner: %s\n", err)
 	}
 
-	massdnsRunner.RunEnumeration()
+	// An interrupt stops the run, letting it clean up its temporary
+	// files, and a second one ends the process right away
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	interrupted := make(chan os.Signal, 1)
+	go func() {
+		sig := <-signals
+		gologger.Info().Msgf("Received %s, stopping the run\n", sig)
+		interrupted <- sig
+		massdnsRunner.Stop()
+		<-signals
+		os.Exit(1)
+	}()
+
+	stopSignals := handleSignals(massdnsRunner)
+	err = massdnsRunner.RunEnumeration()
+	stopSignals()
 	massdnsRunner.Close()
+
+	// The process ends as it would have on the signal without stopping
+	select {
+	case sig := <-interrupted:
+		signal.Reset(sig)
+		if process, err := os.FindProcess(os.Getpid()); err == nil && process.Signal(sig) == nil {
+			time.Sleep(time.Second)
+		}
+		os.Exit(1)
+	default:
+	}
+	if err != nil {
+		gologger.Fatal().Msgf("Could not run enumeration: %s\n", err)
+	}
+}
+
+// exitParsing ends the process on an error parsing the command line,
+// successfully if the help was requested
+func exitParsing(err error) {
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	gologger.Fatal().Msgf("Program exiting: %s\n", err)
 }
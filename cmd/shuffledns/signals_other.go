@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package main
+
+import "github.com/mohammadanaraki/shuffledns/pkg/runner"
+
+// handleSignals does nothing as SIGUSR1 and SIGHUP are not available
+func handleSignals(massdnsRunner *runner.Runner) func() {
+	return func() {}
+}
@@ -0,0 +1,44 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/runner"
+)
+
+// handleSignals dumps the state of the enumeration on SIGUSR1 and
+// reopens the files written during the run on SIGHUP, until the
+// returned function is called.
+func handleSignals(massdnsRunner *runner.Runner) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGHUP)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-signals:
+				if sig == syscall.SIGUSR1 {
+					massdnsRunner.DumpState()
+				} else {
+					massdnsRunner.ReopenFiles()
+				}
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		<-stopped
+	}
+}
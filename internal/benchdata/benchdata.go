@@ -0,0 +1,80 @@
+package benchdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Domain is the apex domain of the generated hostnames
+const Domain = "example.com"
+
+// WildcardRoot is the wildcard domain of the fixtures: the hostnames
+// below it all resolve to WildcardIP.
+const WildcardRoot = "dev." + Domain
+
+// WildcardIP is the ip every name below WildcardRoot resolves to
+const WildcardIP = "10.255.255.255"
+
+// commonWords are frequent subdomain words the fixtures are built from
+var commonWords = []string{
+	"www", "mail", "api", "dev", "staging", "test", "admin", "portal",
+	"vpn", "cdn", "static", "app", "auth", "login", "shop", "blog",
+	"docs", "status", "git", "jenkins", "grafana", "kibana", "db", "m",
+}
+
+// environments are the intermediate labels the hostnames are spread under
+var environments = []string{"", "dev.", "stg.", "prod.eu.", "prod.us."}
+
+// Words returns n words, the common words followed by numbered variations
+func Words(n int) []string {
+	words := make([]string, 0, n)
+	for i := 0; len(words) < n; i++ {
+		word := commonWords[i%len(commonWords)]
+		if i >= len(commonWords) {
+			word = fmt.Sprintf("%s%d", word, i/len(commonWords))
+		}
+		words = append(words, word)
+	}
+	return words
+}
+
+// Hostnames returns n hostnames below the apex domain
+func Hostnames(n int) []string {
+	hostnames := make([]string, 0, n)
+	for i, word := range Words(n) {
+		hostnames = append(hostnames, word+"."+environments[i%len(environments)]+Domain)
+	}
+	return hostnames
+}
+
+// IP returns the ip the i-th hostname resolves to, WildcardIP for the
+// hostnames below WildcardRoot and one of 4096 ips for the others.
+func IP(i int) string {
+	if environments[i%len(environments)] == "dev." {
+		return WildcardIP
+	}
+	return fmt.Sprintf("10.0.%d.%d", (i/256)%16, i%256)
+}
+
+// MassdnsOutput returns the massdns simple text output (`-o Snl`)
+// of n hostnames, every fifth one answering with a CNAME first.
+func MassdnsOutput(n int) string {
+	var builder strings.Builder
+	for i, hostname := range Hostnames(n) {
+		if i%5 == 0 {
+			fmt.Fprintf(&builder, "%s. CNAME edge.cdn.example.net.\nedge.cdn.example.net. A %s\n\n", hostname, IP(i))
+			continue
+		}
+		fmt.Fprintf(&builder, "%s. A %s\n\n", hostname, IP(i))
+	}
+	return builder.String()
+}
+
+// MassdnsJSON returns the massdns ndjson output (`-o J`) of n hostnames
+func MassdnsJSON(n int) string {
+	var builder strings.Builder
+	for i, hostname := range Hostnames(n) {
+		fmt.Fprintf(&builder, `{"name":"%s.","type":"A","class":"IN","status":"NOERROR","rx_ts":1,"data":{"answers":[{"ttl":300,"type":"A","class":"IN","name":"%s.","data":"%s"}]},"flags":["rd","ra"],"resolver":"1.1.1.1:53"}`+"\n", hostname, hostname, IP(i))
+	}
+	return builder.String()
+}
@@ -0,0 +1,7 @@
+// Package benchdata generates the fixture data of the benchmarks.
+//
+// The data is generated deterministically so that the results of
+// different runs and commits can be compared, and mimics an actual
+// enumeration: common subdomain words with numeric variations, spread
+// over a few environments of the same apex domain.
+package benchdata
@@ -0,0 +1,140 @@
+package store
+
+import "strings"
+
+// ID identifies a hostname interned in a name table
+type ID uint32
+
+// node is a label below its parent domain in the name table.
+// The label is stored as a slice of the table arena.
+type node struct {
+	parent ID
+	offset uint32
+	length uint32
+}
+
+// Names is a table interning hostnames as a trie of reversed labels:
+// each hostname is a node pointing to the node of its parent domain,
+// so that the hostnames under the same domains share their suffixes.
+// Labels are packed in a single byte arena and nodes are found through
+// an open addressing table of IDs hashed by parent and label, so that
+// a hostname costs about 20 bytes more than its leftmost label and is
+// referenced by a 4-byte ID instead of a string.
+type Names struct {
+	arena []byte
+	nodes []node
+	table []ID
+}
+
+// minTableSize is the initial size of the hash table, a power of 2
+const minTableSize = 1024
+
+// NewNames creates an empty name table
+func NewNames() *Names {
+	return &Names{
+		// The node 0 is the root of the trie, and marks free table slots
+		nodes: []node{{}},
+		table: make([]ID, minTableSize),
+	}
+}
+
+// Intern returns the ID of a hostname, adding it to the table
+func (n *Names) Intern(hostname string) ID {
+	parent := ID(0)
+	for end := len(hostname); end > 0; {
+		start := strings.LastIndexByte(hostname[:end], '.') + 1
+		label := hostname[start:end]
+
+		slot := n.find(parent, label)
+		if n.table[slot] == 0 {
+			n.table[slot] = ID(len(n.nodes))
+			n.nodes = append(n.nodes, node{parent: parent, offset: uint32(len(n.arena)), length: uint32(len(label))})
+			n.arena = append(n.arena, label...)
+			// Keep the table at most half full
+			if len(n.nodes)*2 > len(n.table) {
+				n.grow()
+			}
+			parent = ID(len(n.nodes) - 1)
+		} else {
+			parent = n.table[slot]
+		}
+		end = start - 1
+	}
+	return parent
+}
+
+// Lookup returns the ID of a hostname, false if it was never interned
+func (n *Names) Lookup(hostname string) (ID, bool) {
+	parent := ID(0)
+	for end := len(hostname); end > 0; {
+		start := strings.LastIndexByte(hostname[:end], '.') + 1
+
+		parent = n.table[n.find(parent, hostname[start:end])]
+		if parent == 0 {
+			return 0, false
+		}
+		end = start - 1
+	}
+	return parent, parent != 0
+}
+
+// String returns the hostname of an ID
+func (n *Names) String(id ID) string {
+	var builder strings.Builder
+	for id != 0 {
+		current := n.nodes[id]
+		if builder.Len() > 0 {
+			builder.WriteByte('.')
+		}
+		builder.Write(n.label(id))
+		id = current.parent
+	}
+	return builder.String()
+}
+
+// find returns the table slot of a label below a parent,
+// or the free slot where it should be inserted.
+func (n *Names) find(parent ID, label string) int {
+	mask := len(n.table) - 1
+	slot := int(hashLabel(parent, label)) & mask
+	for {
+		id := n.table[slot]
+		if id == 0 || (n.nodes[id].parent == parent && string(n.label(id)) == label) {
+			return slot
+		}
+		slot = (slot + 1) & mask
+	}
+}
+
+// grow doubles the size of the hash table
+func (n *Names) grow() {
+	n.table = make([]ID, len(n.table)*2)
+	mask := len(n.table) - 1
+	for id := 1; id < len(n.nodes); id++ {
+		slot := int(hashLabel(n.nodes[id].parent, string(n.label(ID(id))))) & mask
+		for n.table[slot] != 0 {
+			slot = (slot + 1) & mask
+		}
+		n.table[slot] = ID(id)
+	}
+}
+
+// label returns the label of a node
+func (n *Names) label(id ID) []byte {
+	current := n.nodes[id]
+	return n.arena[current.offset : current.offset+current.length]
+}
+
+// hashLabel returns the FNV-1a hash of a label below a parent
+func hashLabel(parent ID, label string) uint64 {
+	hash := uint64(14695981039346656037)
+	for i := 0; i < 4; i++ {
+		hash ^= uint64(byte(parent >> (8 * i)))
+		hash *= 1099511628211
+	}
+	for i := 0; i < len(label); i++ {
+		hash ^= uint64(label[i])
+		hash *= 1099511628211
+	}
+	return hash
+}
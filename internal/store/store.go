@@ -3,6 +3,11 @@ package store
 // Store is a storage for ip based wildcard removal
 type Store struct {
 	IP map[string]*IPMeta
+
+	// names interns the hostnames referenced by the store
+	names *Names
+	// hosts contains additional meta-information about hostnames
+	hosts map[ID]*HostMeta
 }
 
 // IPMeta contains meta-information about a single
@@ -11,22 +16,39 @@ type IPMeta struct {
 	// we store also the ip itself as we will need it later for filtering
 	IP string
 	// Hostnames contains the list of hostnames for the IP
-	Hostnames map[string]struct{}
+	Hostnames *Hostnames
 	// Counter is the number of times the same ip was found for hosts
 	Counter int
 }
 
+// Hostnames is a set of hostnames interned in the name table of a store
+type Hostnames struct {
+	names *Names
+	ids   map[ID]struct{}
+}
+
+// HostMeta contains meta-information about a single
+// hostname found during enumeration.
+type HostMeta struct {
+	// CNAME contains the CNAME chain followed for the hostname
+	CNAME []string
+	// Resolver is the resolver which produced the accepted answer
+	Resolver string
+}
+
 // New creates a new storage for ip based wildcard removal
 func New() *Store {
 	return &Store{
-		IP: make(map[string]*IPMeta),
+		IP:    make(map[string]*IPMeta),
+		names: NewNames(),
+		hosts: make(map[ID]*HostMeta),
 	}
 }
 
 // New creates a new ip-hostname pair in the map
 func (s *Store) New(ip, hostname string) {
-	hostnames := make(map[string]struct{})
-	hostnames[hostname] = struct{}{}
+	hostnames := &Hostnames{names: s.names, ids: make(map[ID]struct{})}
+	hostnames.Add(hostname)
 	s.IP[ip] = &IPMeta{IP: ip, Hostnames: hostnames, Counter: 1}
 }
 
@@ -46,9 +68,69 @@ func (s *Store) Delete(ip string) {
 	delete(s.IP, ip)
 }
 
+// SetHost sets the meta-information for a hostname
+func (s *Store) SetHost(hostname string, meta *HostMeta) {
+	s.hosts[s.names.Intern(hostname)] = meta
+}
+
+// GetHost gets the meta-information for a hostname from the map.
+// It returns nil if no meta-information was stored for the hostname.
+func (s *Store) GetHost(hostname string) *HostMeta {
+	id, ok := s.names.Lookup(hostname)
+	if !ok {
+		return nil
+	}
+	return s.hosts[id]
+}
+
+// RangeHosts calls fn for each hostname with meta-information
+func (s *Store) RangeHosts(fn func(hostname string, meta *HostMeta)) {
+	for id, meta := range s.hosts {
+		fn(s.names.String(id), meta)
+	}
+}
+
 // Close removes all the references to arrays and releases memory to the gc
 func (s *Store) Close() {
 	for ip := range s.IP {
 		s.IP[ip].Hostnames = nil
 	}
+	s.hosts = nil
+	s.names = nil
+}
+
+// Add adds a hostname to the set
+func (h *Hostnames) Add(hostname string) {
+	h.ids[h.names.Intern(hostname)] = struct{}{}
+}
+
+// Has indicates if a hostname is in the set
+func (h *Hostnames) Has(hostname string) bool {
+	id, ok := h.names.Lookup(hostname)
+	if !ok {
+		return false
+	}
+	_, ok = h.ids[id]
+	return ok
+}
+
+// Remove removes a hostname from the set
+func (h *Hostnames) Remove(hostname string) {
+	if id, ok := h.names.Lookup(hostname); ok {
+		delete(h.ids, id)
+	}
+}
+
+// Len returns the number of hostnames in the set
+func (h *Hostnames) Len() int {
+	return len(h.ids)
+}
+
+// List returns the hostnames in the set, in no particular order
+func (h *Hostnames) List() []string {
+	hostnames := make([]string, 0, len(h.ids))
+	for id := range h.ids {
+		hostnames = append(hostnames, h.names.String(id))
+	}
+	return hostnames
 }
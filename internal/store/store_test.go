@@ -0,0 +1,103 @@
+package store
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/internal/benchdata"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamesIntern(t *testing.T) {
+	names := NewNames()
+
+	id := names.Intern("www.example.com")
+	require.Equal(t, id, names.Intern("www.example.com"), "Could not reuse an interned hostname")
+	require.Equal(t, "www.example.com", names.String(id), "Could not rebuild an interned hostname")
+	require.NotEqual(t, id, names.Intern("www.example.org"), "Could not tell hostnames apart")
+
+	parent, ok := names.Lookup("example.com")
+	require.True(t, ok, "Could not look up a parent domain")
+	require.Equal(t, "example.com", names.String(parent), "Could not rebuild a parent domain")
+
+	_, ok = names.Lookup("api.example.com")
+	require.False(t, ok, "Could not ignore a hostname never interned")
+	_, ok = names.Lookup("www.example.net")
+	require.False(t, ok, "Could not ignore an unknown label")
+
+	// Labels are shared across the names
+	require.Len(t, names.nodes, 7, "Could not share the suffixes")
+}
+
+func TestNamesGrow(t *testing.T) {
+	names := NewNames()
+
+	ids := make(map[string]ID)
+	for i := 0; i < 5000; i++ {
+		hostname := fmt.Sprintf("host%d.sub%d.example.com", i, i%7)
+		ids[hostname] = names.Intern(hostname)
+	}
+	for hostname, id := range ids {
+		found, ok := names.Lookup(hostname)
+		require.True(t, ok, "Could not look up a hostname after growing")
+		require.Equal(t, id, found, "Could not keep the id of a hostname")
+		require.Equal(t, hostname, names.String(id), "Could not rebuild a hostname after growing")
+	}
+}
+
+func TestStoreHostnames(t *testing.T) {
+	st := New()
+	st.New("1.1.1.1", "a.example.com")
+	record := st.Get("1.1.1.1")
+	record.Hostnames.Add("b.example.com")
+	record.Hostnames.Add("a.example.com")
+
+	require.Equal(t, 2, record.Hostnames.Len(), "Could not deduplicate hostnames")
+	require.True(t, record.Hostnames.Has("b.example.com"), "Could not find a hostname")
+	require.False(t, record.Hostnames.Has("c.example.com"), "Could not ignore a missing hostname")
+
+	hostnames := record.Hostnames.List()
+	sort.Strings(hostnames)
+	require.Equal(t, []string{"a.example.com", "b.example.com"}, hostnames, "Could not list hostnames")
+
+	record.Hostnames.Remove("a.example.com")
+	require.False(t, record.Hostnames.Has("a.example.com"), "Could not remove a hostname")
+
+	st.SetHost("b.example.com", &HostMeta{Resolver: "1.1.1.1"})
+	require.Equal(t, "1.1.1.1", st.GetHost("b.example.com").Resolver, "Could not get host meta")
+	require.Nil(t, st.GetHost("a.example.com"), "Could not ignore a host without meta")
+	require.Nil(t, st.GetHost("z.example.org"), "Could not ignore an unknown host")
+
+	var hosts []string
+	st.RangeHosts(func(hostname string, meta *HostMeta) {
+		hosts = append(hosts, hostname)
+	})
+	require.Equal(t, []string{"b.example.com"}, hosts, "Could not range over hosts")
+}
+
+func BenchmarkStoreHostnames(b *testing.B) {
+	hostnames := benchdata.Hostnames(1000000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		st := New()
+		for j, hostname := range hostnames {
+			ip := benchdata.IP(j)
+			if !st.Exists(ip) {
+				st.New(ip, hostname)
+				continue
+			}
+			st.Get(ip).Hostnames.Add(hostname)
+		}
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc)/1e6, "MB")
+		runtime.KeepAlive(st)
+	}
+}
@@ -0,0 +1,64 @@
+package backoff
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// DefaultPolicy is the policy used when none is specified
+var DefaultPolicy = Policy{
+	Initial:    100 * time.Millisecond,
+	Multiplier: 2,
+	Jitter:     0.5,
+	Max:        5 * time.Second,
+}
+
+// Policy describes the delays between retries
+type Policy struct {
+	// Initial is the delay before the first retry (0 to retry immediately)
+	Initial time.Duration
+	// Multiplier is the factor applied to the delay after each retry
+	Multiplier float64
+	// Jitter is the fraction of the delay randomized in both directions
+	Jitter float64
+	// Max is the maximum delay between two retries
+	Max time.Duration
+}
+
+// Validate checks that the policy values are consistent
+func (p Policy) Validate() error {
+	if p.Initial < 0 || p.Max < 0 {
+		return errors.New("backoff delays can't be negative")
+	}
+	if p.Multiplier < 1 {
+		return errors.New("backoff multiplier must be at least 1")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return errors.New("backoff jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// Delay returns the delay before a retry, the first one being 1
+func (p Policy) Delay(retry int) time.Duration {
+	if p.Initial <= 0 || retry < 1 {
+		return 0
+	}
+	delay := float64(p.Initial) * math.Pow(p.Multiplier, float64(retry-1))
+	if p.Max > 0 && delay > float64(p.Max) {
+		delay = float64(p.Max)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// Sleep waits for the delay before a retry
+func (p Policy) Sleep(retry int) {
+	if delay := p.Delay(retry); delay > 0 {
+		time.Sleep(delay)
+	}
+}
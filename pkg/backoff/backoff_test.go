@@ -0,0 +1,32 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{Initial: 100 * time.Millisecond, Multiplier: 2, Max: time.Second}
+
+	require.Equal(t, time.Duration(0), policy.Delay(0), "Could not skip delay before first attempt")
+	require.Equal(t, 100*time.Millisecond, policy.Delay(1), "Could not get initial delay")
+	require.Equal(t, 400*time.Millisecond, policy.Delay(3), "Could not grow delay")
+	require.Equal(t, time.Second, policy.Delay(10), "Could not cap delay")
+}
+
+func TestPolicyJitter(t *testing.T) {
+	policy := Policy{Initial: time.Second, Multiplier: 1, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		delay := policy.Delay(1)
+		require.True(t, delay >= 500*time.Millisecond && delay <= 1500*time.Millisecond, "Could not bound jitter")
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	require.Nil(t, DefaultPolicy.Validate(), "Could not validate default policy")
+	require.NotNil(t, Policy{Multiplier: 0.5}.Validate(), "Could not reject shrinking multiplier")
+	require.NotNil(t, Policy{Multiplier: 2, Jitter: 2}.Validate(), "Could not reject invalid jitter")
+}
@@ -0,0 +1,4 @@
+// Package backoff computes the delays between the retries of dns
+// queries, growing exponentially with random jitter so that retries
+// of concurrent queries don't hit the resolvers all at once.
+package backoff
@@ -0,0 +1,69 @@
+package bundled
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Version is the massdns release embedded in the bundled builds
+const Version = "1.0.0"
+
+// ErrNotBundled is returned when no massdns binary is embedded for the
+// platform of the build
+var ErrNotBundled = fmt.Errorf("no bundled massdns for %s/%s in this build", runtime.GOOS, runtime.GOARCH)
+
+// Available returns true if a massdns binary is embedded in the build
+func Available() bool {
+	return len(binary) > 0
+}
+
+// Extract writes the bundled massdns binary to a directory, returning
+// its path. A binary extracted by a previous run is reused when it's
+// identical to the embedded one.
+func Extract(dir string) (string, error) {
+	if !Available() {
+		return "", ErrNotBundled
+	}
+	sum := sha256.Sum256(binary)
+	// The digest in the name keeps binaries of different builds apart
+	name := fmt.Sprintf("massdns-%s-%s-%s-%s", Version, runtime.GOOS, runtime.GOARCH, hex.EncodeToString(sum[:4]))
+	path := filepath.Join(dir, name)
+
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, binary) {
+		return path, nil
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	// The binary is renamed in place once complete, so that concurrent
+	// runs never execute a partially written one
+	file, err := os.CreateTemp(dir, name+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(binary); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Chmod(0755); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,36 @@
+package bundled
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	embedded := binary
+	defer func() { binary = embedded }()
+
+	binary = nil
+	_, err := Extract(t.TempDir())
+	require.ErrorIs(t, err, ErrNotBundled, "Could not detect missing bundled binary")
+
+	binary = []byte("#!/bin/sh\necho massdns\n")
+	dir := filepath.Join(t.TempDir(), "shuffledns")
+	path, err := Extract(dir)
+	require.Nil(t, err, "Could not extract bundled binary")
+	data, err := os.ReadFile(path)
+	require.Nil(t, err, "Could not read extracted binary")
+	require.Equal(t, binary, data, "Could not get bundled binary")
+	info, err := os.Stat(path)
+	require.Nil(t, err, "Could not stat extracted binary")
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm(), "Could not make binary executable")
+
+	again, err := Extract(dir)
+	require.Nil(t, err, "Could not reuse extracted binary")
+	require.Equal(t, path, again, "Could not reuse extracted binary")
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err, "Could not read directory")
+	require.Len(t, entries, 1, "Could not clean up temporary binary")
+}
@@ -0,0 +1,10 @@
+// Package bundled embeds a prebuilt static massdns binary in the
+// release builds of shuffledns, so that it runs without installing
+// massdns first.
+//
+// The binaries are only embedded for linux/amd64 and linux/arm64 when
+// building with the bundled tag, after `make bundled-massdns` placed
+// them in the bin directory. The other builds have no bundled binary.
+// The binary is extracted to a directory on first use and reused by
+// the next runs as long as it's unchanged.
+package bundled
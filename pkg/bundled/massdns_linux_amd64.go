@@ -0,0 +1,9 @@
+//go:build bundled
+// +build bundled
+
+package bundled
+
+import _ "embed"
+
+//go:embed bin/massdns_linux_amd64
+var binary []byte
@@ -0,0 +1,7 @@
+//go:build !bundled || !linux || (!amd64 && !arm64)
+// +build !bundled !linux !amd64,!arm64
+
+package bundled
+
+// binary is empty, no massdns binary being bundled for the platform
+var binary []byte
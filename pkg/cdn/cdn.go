@@ -0,0 +1,85 @@
+package cdn
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// None is the provider returned for ips not belonging to a CDN
+const None = "none"
+
+//go:embed ranges.txt
+var builtinRanges string
+
+// network is an ip range of a CDN provider
+type network struct {
+	provider string
+	network  *net.IPNet
+}
+
+// Checker checks whether ips belong to a CDN
+type Checker struct {
+	networks []network
+}
+
+// New creates a checker with the built-in ranges extended with the
+// ones contained in the optional extra file.
+func New(extraFile string) (*Checker, error) {
+	checker := &Checker{}
+	if err := checker.read(strings.NewReader(builtinRanges)); err != nil {
+		return nil, err
+	}
+
+	if extraFile != "" {
+		f, err := os.Open(extraFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		if err := checker.read(f); err != nil {
+			return nil, err
+		}
+	}
+	return checker, nil
+}
+
+// read reads ranges in the "provider cidr" per line format
+func (c *Checker) read(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid cdn range line: %s", line)
+		}
+		_, ipnet, err := net.ParseCIDR(parts[1])
+		if err != nil {
+			return fmt.Errorf("invalid cdn range: %s", parts[1])
+		}
+		c.networks = append(c.networks, network{provider: strings.ToLower(parts[0]), network: ipnet})
+	}
+	return scanner.Err()
+}
+
+// Check returns the CDN provider an ip belongs to or None
+func (c *Checker) Check(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return None
+	}
+	for _, n := range c.networks {
+		if n.network.Contains(parsed) {
+			return n.provider
+		}
+	}
+	return None
+}
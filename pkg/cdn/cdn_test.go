@@ -0,0 +1,28 @@
+package cdn
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckerCheck(t *testing.T) {
+	checker, err := New("")
+	require.Nil(t, err, "Could not load built-in ranges")
+
+	require.Equal(t, "cloudflare", checker.Check("104.16.1.1"), "Could not detect cloudflare")
+	require.Equal(t, "fastly", checker.Check("151.101.1.69"), "Could not detect fastly")
+	require.Equal(t, "akamai", checker.Check("23.45.67.89"), "Could not detect akamai")
+	require.Equal(t, None, checker.Check("8.8.8.8"), "Could not detect non cdn ip")
+}
+
+func TestCheckerExtraRanges(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ranges.txt")
+	require.Nil(t, os.WriteFile(file, []byte("cloudfront 13.32.0.0/15\n"), 0644))
+
+	checker, err := New(file)
+	require.Nil(t, err, "Could not load extra ranges")
+	require.Equal(t, "cloudfront", checker.Check("13.32.1.1"), "Could not detect extra provider")
+}
@@ -0,0 +1,3 @@
+// Package cdn detects whether ips belong to a content delivery
+// network using a built-in database of the providers ip ranges.
+package cdn
@@ -0,0 +1,104 @@
+package dnsgen
+
+import (
+	"sort"
+	"strings"
+)
+
+// minWordLength is the minimum length of the words extracted from the
+// known subdomains.
+const minWordLength = 3
+
+// Generate returns the candidates built from the known subdomains, in
+// the subdomain part only form, by:
+//   - inserting each word as a new label at every position
+//   - joining each word to every label with a dash
+//   - replacing every word of the labels with each other word
+//
+// The words of the known subdomains are used along with the given ones.
+// The known subdomains themselves are never returned.
+func Generate(subdomains, words []string) []string {
+	known := make(map[string]struct{})
+	for _, subdomain := range subdomains {
+		known[subdomain] = struct{}{}
+	}
+	words = allWords(subdomains, words)
+
+	seen := make(map[string]struct{})
+	var candidates []string
+	add := func(labels []string) {
+		candidate := strings.Join(labels, ".")
+		if _, ok := known[candidate]; ok {
+			return
+		}
+		if _, ok := seen[candidate]; ok {
+			return
+		}
+		seen[candidate] = struct{}{}
+		candidates = append(candidates, candidate)
+	}
+
+	for _, subdomain := range subdomains {
+		labels := strings.Split(subdomain, ".")
+		for _, word := range words {
+			// Insert the word as a new label
+			for i := 0; i <= len(labels); i++ {
+				add(insert(labels, i, word))
+			}
+			for i, label := range labels {
+				// Join the word to the label with a dash
+				add(replace(labels, i, word+"-"+label))
+				add(replace(labels, i, label+"-"+word))
+
+				// Replace each word of the label with the word
+				tokens := strings.Split(label, "-")
+				for j := range tokens {
+					swapped := append([]string{}, tokens...)
+					swapped[j] = word
+					add(replace(labels, i, strings.Join(swapped, "-")))
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// allWords returns the unique given words and the ones extracted from
+// the labels of the known subdomains.
+func allWords(subdomains, words []string) []string {
+	unique := make(map[string]struct{})
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			unique[word] = struct{}{}
+		}
+	}
+	for _, subdomain := range subdomains {
+		for _, word := range strings.FieldsFunc(subdomain, func(r rune) bool { return r == '.' || r == '-' }) {
+			if len(word) >= minWordLength {
+				unique[word] = struct{}{}
+			}
+		}
+	}
+
+	all := make([]string, 0, len(unique))
+	for word := range unique {
+		all = append(all, word)
+	}
+	sort.Strings(all)
+	return all
+}
+
+// insert returns a copy of the labels with a label inserted at a position
+func insert(labels []string, position int, label string) []string {
+	result := make([]string, 0, len(labels)+1)
+	result = append(result, labels[:position]...)
+	result = append(result, label)
+	return append(result, labels[position:]...)
+}
+
+// replace returns a copy of the labels with the label at a position replaced
+func replace(labels []string, position int, label string) []string {
+	result := append([]string{}, labels...)
+	result[position] = label
+	return result
+}
@@ -0,0 +1,31 @@
+package dnsgen
+
+import (
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/internal/benchdata"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	candidates := Generate([]string{"dev-api.eu", "www"}, []string{"stg"})
+
+	for _, expected := range []string{
+		"stg.dev-api.eu", "dev-api.stg.eu", "dev-api.eu.stg", // inserted labels
+		"stg-dev-api.eu", "dev-api-stg.eu", "dev-api.eu-stg", // dashed words
+		"stg-api.eu", "dev-stg.eu", "dev-api.stg", // swapped words
+		"www-api.eu", "dev-www.eu", // swapped extracted words
+	} {
+		require.Contains(t, candidates, expected, "Could not generate candidate")
+	}
+	require.NotContains(t, candidates, "www", "Could not exclude known subdomain")
+	require.NotContains(t, candidates, "eu.eu", "Could not ignore short extracted words")
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	subdomains, words := benchdata.Words(200), benchdata.Words(20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Generate(subdomains, words)
+	}
+}
@@ -0,0 +1,3 @@
+// Package dnsgen generates candidates by combining the labels of the
+// known subdomains of a target with words, in the style of dnsgen.
+package dnsgen
@@ -0,0 +1,117 @@
+package dnsname
+
+import (
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// MaxLabelLength is the maximum length of a label
+	MaxLabelLength = 63
+	// MaxNameLength is the maximum length of a name in text form,
+	// without the trailing dot
+	MaxNameLength = 253
+)
+
+// Reasons returned for invalid names
+const (
+	ReasonEmptyLabel  = "empty label"
+	ReasonLabelLength = "label too long"
+	ReasonNameLength  = "name too long"
+	ReasonCharacters  = "invalid characters"
+	ReasonHyphen      = "label starting or ending with a hyphen"
+)
+
+// Normalize returns the canonical form of a name: lowercase, without
+// surrounding spaces and trailing dot. Names compare equal in the dns
+// whatever their case (RFC 4343) and with or without trailing dot, so
+// names are normalized before being deduplicated or written.
+func Normalize(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+// Reverse returns a name with its labels in reverse order, e.g.
+// com.example.api for api.example.com, so that sorting reversed names
+// groups the names of the same domain together.
+func Reverse(name string) string {
+	labels := strings.Split(name, ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return strings.Join(labels, ".")
+}
+
+// IsPublicSuffix returns true if a name is a suffix under which names
+// can be registered according to the public suffix list, like co.uk or
+// github.io.
+func IsPublicSuffix(name string) bool {
+	name = Normalize(name)
+	suffix, _ := publicsuffix.PublicSuffix(name)
+	return suffix == name
+}
+
+// RegisteredDomain returns the registered domain of a name, which is
+// its public suffix with one more label (e.g. example.co.uk for
+// www.example.co.uk), or the name itself if it's a public suffix.
+func RegisteredDomain(name string) string {
+	name = Normalize(name)
+	domain, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return name
+	}
+	return domain
+}
+
+// Depth returns the number of labels of a name below its registered
+// domain, which is the given domain when the name is under it, or the
+// registered domain of the name from the public suffix list otherwise.
+// The registered domain itself has a depth of 0.
+func Depth(name, domain string) int {
+	name, domain = Normalize(name), Normalize(domain)
+	if domain == "" || (name != domain && !strings.HasSuffix(name, "."+domain)) {
+		domain = RegisteredDomain(name)
+	}
+	if name == domain {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(name, "."+domain), ".") + 1
+}
+
+// Validate returns the reason a name is invalid, or an empty string if
+// it's valid. Labels contain letters, digits, hyphens and underscores,
+// the latter being used by service names like _dmarc.
+func Validate(name string) string {
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > MaxNameLength {
+		return ReasonNameLength
+	}
+	// The labels are walked without splitting the name, which is
+	// validated for every line of the massdns output
+	for start := 0; start <= len(name); {
+		end := strings.IndexByte(name[start:], '.')
+		if end < 0 {
+			end = len(name)
+		} else {
+			end += start
+		}
+		label := name[start:end]
+		start = end + 1
+
+		switch {
+		case label == "":
+			return ReasonEmptyLabel
+		case len(label) > MaxLabelLength:
+			return ReasonLabelLength
+		case label[0] == '-' || label[len(label)-1] == '-':
+			return ReasonHyphen
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return ReasonCharacters
+			}
+		}
+	}
+	return ""
+}
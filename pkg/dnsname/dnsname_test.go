@@ -0,0 +1,57 @@
+package dnsname
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	for _, name := range []string{"www.example.com", "WWW.Example.com.", "_dmarc.example.com", "x-1.example.com", strings.Repeat("a", 63) + ".example.com"} {
+		require.Equal(t, "", Validate(name), "Could not accept valid name %s", name)
+	}
+
+	tests := map[string]string{
+		"":                                       ReasonEmptyLabel,
+		"dev..example.com":                       ReasonEmptyLabel,
+		strings.Repeat("a", 64) + ".example.com": ReasonLabelLength,
+		strings.Repeat("a.", 125) + "example.com": ReasonNameLength,
+		"*.example.com":       ReasonCharacters,
+		"café.example.com":    ReasonCharacters,
+		"dev api.example.com": ReasonCharacters,
+		"-dev.example.com":    ReasonHyphen,
+		"dev-.example.com":    ReasonHyphen,
+	}
+	for name, reason := range tests {
+		require.Equal(t, reason, Validate(name), "Could not reject invalid name %s", name)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	require.Equal(t, "www.example.com", Normalize(" WWW.Example.COM. "), "Could not normalize name")
+	require.Equal(t, "www.example.com", Normalize("www.example.com"), "Could not keep normalized name")
+}
+
+func TestDepth(t *testing.T) {
+	require.Equal(t, 0, Depth("example.com", "example.com"), "Could not get depth of registered domain")
+	require.Equal(t, 1, Depth("www.example.com", "example.com"), "Could not get depth of third-level name")
+	require.Equal(t, 3, Depth("a.b.c.example.com", "example.com"), "Could not get depth of deep name")
+	require.Equal(t, 2, Depth("a.b.other.com", "example.com"), "Could not get depth of name outside of domain")
+	require.Equal(t, 1, Depth("www.example.co.uk", ""), "Could not get depth below multi-label public suffix")
+	require.Equal(t, 0, Depth("localhost", ""), "Could not get depth of single label name")
+}
+
+func TestRegisteredDomain(t *testing.T) {
+	require.Equal(t, "example.com", RegisteredDomain("a.b.example.com"), "Could not get registered domain")
+	require.Equal(t, "example.co.uk", RegisteredDomain("www.Example.co.uk."), "Could not get registered domain under multi-label suffix")
+	require.Equal(t, "user.github.io", RegisteredDomain("blog.user.github.io"), "Could not get registered domain under private suffix")
+	require.Equal(t, "co.uk", RegisteredDomain("co.uk"), "Could not keep public suffix")
+	require.True(t, IsPublicSuffix("github.io"), "Could not detect public suffix")
+	require.False(t, IsPublicSuffix("example.com"), "Could not detect registered domain")
+}
+
+func TestReverse(t *testing.T) {
+	require.Equal(t, "com.example.api", Reverse("api.example.com"), "Could not reverse name")
+	require.Equal(t, "localhost", Reverse("localhost"), "Could not reverse single label name")
+}
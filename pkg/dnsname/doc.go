@@ -0,0 +1,8 @@
+// Package dnsname validates the hostnames resolved, so that names
+// which can't exist in the dns are skipped instead of being sent to
+// massdns, where their failures are indistinguishable from NXDOMAIN.
+//
+// It also finds the registered domains of names with the public suffix
+// list, so that targets like example.co.uk or user.github.io are not
+// mistaken for subdomains of co.uk or github.io.
+package dnsname
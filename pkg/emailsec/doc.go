@@ -0,0 +1,3 @@
+// Package emailsec collects the email security posture of domains
+// from their SPF, DKIM and DMARC TXT records.
+package emailsec
@@ -0,0 +1,163 @@
+package emailsec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultSelectors are the DKIM selectors guessed for the domains, the
+// ones of the most common mail providers and platforms, since the
+// selectors in use can't be listed.
+var DefaultSelectors = []string{
+	"default", "dkim", "mail", "email", "k1", "k2", "k3", "s1", "s2",
+	"selector1", "selector2", "google", "mandrill", "mailjet", "mxvault",
+	"everlytickey1", "everlytickey2", "smtpapi", "sendgrid", "pm", "zendesk1",
+	"zendesk2", "amazonses", "hs1", "hs2", "protonmail", "fm1", "fm2", "fm3",
+}
+
+// ParseSelectors parses a comma separated list of DKIM selectors,
+// returning the default selectors if it is empty.
+func ParseSelectors(value string) []string {
+	var selectors []string
+	for _, selector := range strings.Split(value, ",") {
+		if selector = strings.ToLower(strings.TrimSpace(selector)); selector != "" {
+			selectors = append(selectors, selector)
+		}
+	}
+	if len(selectors) == 0 {
+		return DefaultSelectors
+	}
+	return selectors
+}
+
+// TXTLookup returns the TXT records of a name
+type TXTLookup func(name string) ([]string, error)
+
+// Posture is the email security posture of a domain
+type Posture struct {
+	Domain string `json:"domain"`
+	// SPF is the SPF record of the domain
+	SPF string `json:"spf,omitempty"`
+	// DMARC is the DMARC record of the domain
+	DMARC string `json:"dmarc,omitempty"`
+	// DMARCPolicy is the policy requested by the DMARC record for the
+	// messages failing authentication (none, quarantine or reject)
+	DMARCPolicy string `json:"dmarc_policy,omitempty"`
+	// DKIMSelectors are the guessed selectors having a DKIM key
+	DKIMSelectors []string `json:"dkim_selectors,omitempty"`
+	// Issues are the weaknesses found in the records
+	Issues []string `json:"issues,omitempty"`
+}
+
+// Collect looks up the SPF and DMARC records of a domain and the DKIM
+// keys of the guessed selectors, then checks the records for issues.
+// Failed SPF and DMARC lookups are reported as issues rather than as
+// missing records.
+func Collect(domain string, selectors []string, lookup TXTLookup) *Posture {
+	posture := &Posture{Domain: domain}
+
+	records, err := lookup(domain)
+	spf := withPrefix(records, "v=spf1")
+	switch {
+	case err != nil:
+		posture.Issues = append(posture.Issues, "could not look up the SPF record")
+	case len(spf) == 0:
+		posture.Issues = append(posture.Issues, "no SPF record")
+	case len(spf) > 1:
+		posture.SPF = spf[0]
+		posture.Issues = append(posture.Issues, fmt.Sprintf("%d SPF records, which makes SPF fail", len(spf)))
+	default:
+		posture.SPF = spf[0]
+		posture.Issues = append(posture.Issues, spfIssues(spf[0])...)
+	}
+
+	records, err = lookup("_dmarc." + domain)
+	dmarc := withPrefix(records, "v=DMARC1")
+	switch {
+	case err != nil:
+		posture.Issues = append(posture.Issues, "could not look up the DMARC record")
+	case len(dmarc) == 0:
+		posture.Issues = append(posture.Issues, "no DMARC record")
+	case len(dmarc) > 1:
+		posture.DMARC = dmarc[0]
+		posture.Issues = append(posture.Issues, fmt.Sprintf("%d DMARC records, which disables DMARC", len(dmarc)))
+	default:
+		posture.DMARC = dmarc[0]
+		tags := dmarcTags(dmarc[0])
+		posture.DMARCPolicy = tags["p"]
+		switch posture.DMARCPolicy {
+		case "quarantine", "reject":
+		case "none":
+			posture.Issues = append(posture.Issues, "DMARC policy none only monitors spoofed messages")
+		default:
+			posture.Issues = append(posture.Issues, "DMARC record without a valid policy")
+		}
+		if pct, ok := tags["pct"]; ok && pct != "100" && posture.DMARCPolicy != "none" {
+			posture.Issues = append(posture.Issues, fmt.Sprintf("DMARC policy applied to %s%% of the messages only", pct))
+		}
+	}
+
+	for _, selector := range selectors {
+		records, _ := lookup(selector + "._domainkey." + domain)
+		for _, record := range records {
+			if strings.Contains(record, "p=") {
+				posture.DKIMSelectors = append(posture.DKIMSelectors, selector)
+				break
+			}
+		}
+	}
+	sort.Strings(posture.DKIMSelectors)
+	if len(posture.DKIMSelectors) == 0 && len(selectors) > 0 {
+		posture.Issues = append(posture.Issues, "no DKIM key found for the guessed selectors")
+	}
+	return posture
+}
+
+// withPrefix returns the records starting with a version tag
+func withPrefix(records []string, prefix string) []string {
+	prefix = strings.ToLower(prefix)
+
+	var matching []string
+	for _, record := range records {
+		record = strings.TrimSpace(record)
+		lower := strings.ToLower(record)
+		if lower == prefix || strings.HasPrefix(lower, prefix+" ") || strings.HasPrefix(lower, prefix+";") {
+			matching = append(matching, record)
+		}
+	}
+	return matching
+}
+
+// spfIssues returns the issues of the final all mechanism of a SPF
+// record, which decides what happens to the other senders.
+func spfIssues(record string) []string {
+	for _, term := range strings.Fields(strings.ToLower(record))[1:] {
+		switch term {
+		case "+all", "all":
+			return []string{"SPF allows any sender (+all)"}
+		case "?all":
+			return []string{"SPF is neutral about other senders (?all)"}
+		case "~all", "-all":
+			return nil
+		}
+		if strings.HasPrefix(term, "redirect=") {
+			return nil
+		}
+	}
+	return []string{"SPF record without an all mechanism"}
+}
+
+// dmarcTags parses the tag=value pairs of a DMARC record
+func dmarcTags(record string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(record, ";") {
+		index := strings.Index(part, "=")
+		if index == -1 {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(part[:index]))
+		tags[name] = strings.ToLower(strings.TrimSpace(part[index+1:]))
+	}
+	return tags
+}
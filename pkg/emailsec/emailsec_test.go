@@ -0,0 +1,68 @@
+package emailsec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLookup returns the TXT records of a fixed zone, failing for the
+// names of the broken domain
+func fakeLookup(zone map[string][]string) TXTLookup {
+	return func(name string) ([]string, error) {
+		if strings.HasSuffix(name, "broken.example") {
+			return nil, errors.New("timeout")
+		}
+		return zone[name], nil
+	}
+}
+
+func TestCollectStrict(t *testing.T) {
+	lookup := fakeLookup(map[string][]string{
+		"example.com":                      {"google-site-verification=abc", "v=spf1 include:_spf.google.com -all"},
+		"_dmarc.example.com":               {"v=DMARC1; p=reject; rua=mailto:dmarc@example.com"},
+		"google._domainkey.example.com":    {"v=DKIM1; k=rsa; p=MIIBIjANBg"},
+		"selector1._domainkey.example.com": {"v=DKIM1; p=MIGfMA0GCS"},
+	})
+
+	posture := Collect("example.com", DefaultSelectors, lookup)
+	require.Equal(t, "v=spf1 include:_spf.google.com -all", posture.SPF, "Could not get the spf record")
+	require.Equal(t, "reject", posture.DMARCPolicy, "Could not get the dmarc policy")
+	require.Equal(t, []string{"google", "selector1"}, posture.DKIMSelectors, "Could not find the dkim selectors")
+	require.Empty(t, posture.Issues, "Could not accept a strict posture")
+}
+
+func TestCollectIssues(t *testing.T) {
+	lookup := fakeLookup(map[string][]string{
+		"example.org":        {"v=spf1 ip4:192.0.2.1 +all"},
+		"_dmarc.example.org": {"v=DMARC1; p=none"},
+	})
+
+	posture := Collect("example.org", []string{"default"}, lookup)
+	require.Equal(t, []string{
+		"SPF allows any sender (+all)",
+		"DMARC policy none only monitors spoofed messages",
+		"no DKIM key found for the guessed selectors",
+	}, posture.Issues, "Could not report the issues")
+
+	posture = Collect("example.net", nil, fakeLookup(map[string][]string{
+		"example.net": {"v=spf1 -all", "v=spf1 mx -all"},
+	}))
+	require.Equal(t, []string{"2 SPF records, which makes SPF fail", "no DMARC record"}, posture.Issues, "Could not report missing and duplicate records")
+
+	posture = Collect("broken.example", nil, fakeLookup(nil))
+	require.Equal(t, []string{"could not look up the SPF record", "could not look up the DMARC record"}, posture.Issues, "Could not report failed lookups")
+}
+
+func TestSPFIssues(t *testing.T) {
+	require.Empty(t, spfIssues("v=spf1 redirect=_spf.example.com"), "Could not accept a redirect")
+	require.Equal(t, []string{"SPF is neutral about other senders (?all)"}, spfIssues("v=spf1 mx ?all"), "Could not report a neutral spf")
+	require.Equal(t, []string{"SPF record without an all mechanism"}, spfIssues("v=spf1 mx"), "Could not report a missing all")
+}
+
+func TestParseSelectors(t *testing.T) {
+	require.Equal(t, DefaultSelectors, ParseSelectors(""), "Could not default the selectors")
+	require.Equal(t, []string{"s1", "mta"}, ParseSelectors(" S1, ,mta"), "Could not parse the selectors")
+}
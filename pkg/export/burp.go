@@ -0,0 +1,50 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+)
+
+// burpRule is a rule of the burp target scope in advanced mode
+type burpRule struct {
+	Enabled  bool   `json:"enabled"`
+	File     string `json:"file"`
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+// burpConfig is the structure of the burp project options with the
+// target scope, as imported from Project options > Load.
+type burpConfig struct {
+	Target struct {
+		Scope struct {
+			AdvancedMode bool        `json:"advanced_mode"`
+			Exclude      []*burpRule `json:"exclude"`
+			Include      []*burpRule `json:"include"`
+		} `json:"scope"`
+	} `json:"target"`
+}
+
+// WriteBurpScope writes a burp target scope including every host on
+// any port and protocol.
+func WriteBurpScope(w io.Writer, hosts []Host) error {
+	config := &burpConfig{}
+	config.Target.Scope.AdvancedMode = true
+	config.Target.Scope.Exclude = []*burpRule{}
+	config.Target.Scope.Include = make([]*burpRule, 0, len(hosts))
+	for _, host := range hosts {
+		config.Target.Scope.Include = append(config.Target.Scope.Include, &burpRule{
+			Enabled:  true,
+			File:     "^/.*",
+			Host:     "^" + regexp.QuoteMeta(host.Name) + "$",
+			Port:     "^[0-9]+$",
+			Protocol: "any",
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(config)
+}
@@ -0,0 +1,6 @@
+// Package export writes the validated hosts in the formats consumed by
+// other tools: hosts files, urls for screenshotting tools like
+// Aquatone and EyeWitness, and target lists for port scanners like
+// nmap and masscan, the hostnames sharing each ip for virtual host
+// discovery, and the target scope of Burp Suite.
+package export
@@ -0,0 +1,108 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Host is a validated hostname with the ips it resolved to
+type Host struct {
+	Name string
+	IPs  []string
+}
+
+// WriteHosts writes the hosts in hosts file format, an ip and a
+// hostname per line for each ip.
+func WriteHosts(w io.Writer, hosts []Host) error {
+	bw := bufio.NewWriter(w)
+	for _, host := range hosts {
+		for _, ip := range host.IPs {
+			_, _ = bw.WriteString(ip + " " + host.Name + "\n")
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteTargets writes the unique ips of the hosts, one per line, as
+// consumed by nmap -iL and masscan -iL. With hostnames, each ip is
+// followed by a comment listing the hostnames resolving to it.
+func WriteTargets(w io.Writer, hosts []Host, hostnames bool) error {
+	var ips []string
+	ipHosts := make(map[string][]string)
+	for _, host := range hosts {
+		for _, ip := range host.IPs {
+			if _, ok := ipHosts[ip]; !ok {
+				ips = append(ips, ip)
+			}
+			ipHosts[ip] = append(ipHosts[ip], host.Name)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, ip := range ips {
+		_, _ = bw.WriteString(ip)
+		if hostnames {
+			_, _ = bw.WriteString(" # " + strings.Join(ipHosts[ip], ","))
+		}
+		_, _ = bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+// conventionPorts are the ports of the web services conventionally
+// exposed on hostnames containing a label or a word of the label.
+var conventionPorts = map[string][]string{
+	"jenkins":    {"http://%s:8080"},
+	"tomcat":     {"http://%s:8080"},
+	"proxy":      {"http://%s:8080", "https://%s:8443"},
+	"grafana":    {"http://%s:3000"},
+	"kibana":     {"http://%s:5601"},
+	"elastic":    {"http://%s:9200"},
+	"sonar":      {"http://%s:9000"},
+	"sonarqube":  {"http://%s:9000"},
+	"portainer":  {"http://%s:9000"},
+	"prometheus": {"http://%s:9090"},
+	"rancher":    {"https://%s:8443"},
+	"vcenter":    {"https://%s:9443"},
+	"webmin":     {"https://%s:10000"},
+	"plesk":      {"https://%s:8443"},
+	"cpanel":     {"https://%s:2083"},
+	"whm":        {"https://%s:2087"},
+}
+
+// URLs returns the urls of the web services likely exposed on a
+// hostname: http and https on the default ports, along with the
+// ports conventionally used by the services named in its labels.
+func URLs(hostname string) []string {
+	urls := []string{"http://" + hostname, "https://" + hostname}
+
+	seen := make(map[string]struct{})
+	labels := strings.Split(hostname, ".")
+	for _, label := range labels[:len(labels)-1] {
+		for _, word := range strings.FieldsFunc(label, func(r rune) bool {
+			return r == '-' || r == '_' || (r >= '0' && r <= '9')
+		}) {
+			for _, format := range conventionPorts[word] {
+				url := strings.Replace(format, "%s", hostname, 1)
+				if _, ok := seen[url]; !ok {
+					seen[url] = struct{}{}
+					urls = append(urls, url)
+				}
+			}
+		}
+	}
+	return urls
+}
+
+// WriteURLs writes the urls of the web services likely exposed on the
+// hosts, one per line, as consumed by Aquatone and EyeWitness.
+func WriteURLs(w io.Writer, hosts []Host) error {
+	bw := bufio.NewWriter(w)
+	for _, host := range hosts {
+		for _, url := range URLs(host.Name) {
+			_, _ = bw.WriteString(url + "\n")
+		}
+	}
+	return bw.Flush()
+}
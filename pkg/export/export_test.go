@@ -0,0 +1,72 @@
+package export
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHosts(t *testing.T) {
+	output := &strings.Builder{}
+	err := WriteHosts(output, []Host{{Name: "a.example.com", IPs: []string{"192.0.2.1", "192.0.2.2"}}})
+	require.Nil(t, err, "Could not write hosts")
+	require.Equal(t, "192.0.2.1 a.example.com\n192.0.2.2 a.example.com\n", output.String(), "Could not get hosts file")
+}
+
+func TestWriteTargets(t *testing.T) {
+	hosts := []Host{
+		{Name: "a.example.com", IPs: []string{"192.0.2.1", "192.0.2.2"}},
+		{Name: "b.example.com", IPs: []string{"192.0.2.1"}},
+	}
+
+	output := &strings.Builder{}
+	require.Nil(t, WriteTargets(output, hosts, false), "Could not write targets")
+	require.Equal(t, "192.0.2.1\n192.0.2.2\n", output.String(), "Could not get unique ips")
+
+	output.Reset()
+	require.Nil(t, WriteTargets(output, hosts, true), "Could not write targets")
+	require.Equal(t, "192.0.2.1 # a.example.com,b.example.com\n192.0.2.2 # a.example.com\n", output.String(), "Could not get commented ips")
+}
+
+func TestURLs(t *testing.T) {
+	require.Equal(t, []string{"http://www.example.com", "https://www.example.com"}, URLs("www.example.com"), "Could not get default urls")
+	require.Equal(t, []string{
+		"http://ci-jenkins2.grafana.example.com",
+		"https://ci-jenkins2.grafana.example.com",
+		"http://ci-jenkins2.grafana.example.com:8080",
+		"http://ci-jenkins2.grafana.example.com:3000",
+	}, URLs("ci-jenkins2.grafana.example.com"), "Could not get convention urls")
+}
+
+func TestWriteBurpScope(t *testing.T) {
+	output := &strings.Builder{}
+	require.Nil(t, WriteBurpScope(output, []Host{{Name: "a.example.com"}}), "Could not write burp scope")
+
+	var config burpConfig
+	require.Nil(t, json.Unmarshal([]byte(output.String()), &config), "Could not parse burp scope")
+	require.True(t, config.Target.Scope.AdvancedMode, "Could not enable advanced mode")
+	require.Len(t, config.Target.Scope.Include, 1, "Could not get include rules")
+	require.Equal(t, `^a\.example\.com$`, config.Target.Scope.Include[0].Host, "Could not get host rule")
+}
+
+func TestWriteIPMap(t *testing.T) {
+	hosts := []Host{
+		{Name: "b.example.com", IPs: []string{"192.0.2.1"}},
+		{Name: "a.example.com", IPs: []string{"192.0.2.2", "192.0.2.1"}},
+	}
+
+	output := &strings.Builder{}
+	require.Nil(t, WriteIPMap(output, hosts), "Could not write ip map")
+	var ipMap []IPHosts
+	require.Nil(t, json.Unmarshal([]byte(output.String()), &ipMap), "Could not decode ip map")
+	require.Equal(t, []IPHosts{
+		{IP: "192.0.2.1", Count: 2, Hostnames: []string{"a.example.com", "b.example.com"}},
+		{IP: "192.0.2.2", Count: 1, Hostnames: []string{"a.example.com"}},
+	}, ipMap, "Could not get ip map")
+
+	output.Reset()
+	require.Nil(t, WriteIPMapCSV(output, hosts), "Could not write ip map")
+	require.Equal(t, "ip,count,hostnames\n192.0.2.1,2,a.example.com b.example.com\n192.0.2.2,1,a.example.com\n", output.String(), "Could not get csv ip map")
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IPHosts is a unique ip with the hostnames resolving to it
+type IPHosts struct {
+	IP        string   `json:"ip"`
+	Count     int      `json:"count"`
+	Hostnames []string `json:"hostnames"`
+}
+
+// IPMap returns the unique ips of the hosts with the hostnames
+// resolving to them, the ips shared by the most hostnames first, which
+// are the candidates for virtual host discovery.
+func IPMap(hosts []Host) []IPHosts {
+	ipHosts := make(map[string][]string)
+	for _, host := range hosts {
+		for _, ip := range host.IPs {
+			ipHosts[ip] = append(ipHosts[ip], host.Name)
+		}
+	}
+
+	ipMap := make([]IPHosts, 0, len(ipHosts))
+	for ip, hostnames := range ipHosts {
+		sort.Strings(hostnames)
+		ipMap = append(ipMap, IPHosts{IP: ip, Count: len(hostnames), Hostnames: hostnames})
+	}
+	sort.Slice(ipMap, func(i, j int) bool {
+		if ipMap[i].Count != ipMap[j].Count {
+			return ipMap[i].Count > ipMap[j].Count
+		}
+		return ipMap[i].IP < ipMap[j].IP
+	})
+	return ipMap
+}
+
+// WriteIPMap writes the mapping of the unique ips to their hostnames
+// as a json array.
+func WriteIPMap(w io.Writer, hosts []Host) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(IPMap(hosts))
+}
+
+// WriteIPMapCSV writes the mapping of the unique ips to their hostnames
+// as csv with an ip, count and space separated hostnames column.
+func WriteIPMapCSV(w io.Writer, hosts []Host) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"ip", "count", "hostnames"})
+	for _, entry := range IPMap(hosts) {
+		_ = writer.Write([]string{entry.IP, strconv.Itoa(entry.Count), strings.Join(entry.Hostnames, " ")})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,8 @@
+// Package extsort sorts lines which may not fit in memory with an
+// external merge sort: the lines are sorted in chunks of bounded size
+// written to temporary files, which are then merged.
+//
+// Lines are ordered by a key computed from each line, then by the
+// lines themselves, e.g. by the reversed labels of a hostname so that
+// the hosts of the same domain are grouped together.
+package extsort
@@ -0,0 +1,216 @@
+package extsort
+
+import (
+	"bufio"
+	"container/heap"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/tmpcrypt"
+)
+
+// DefaultChunkSize is the size in bytes of the lines sorted in memory
+// before being written to a temporary file.
+const DefaultChunkSize = 64 * 1024 * 1024
+
+// entry is a line with its sort key
+type entry struct {
+	key  string
+	line string
+}
+
+// less returns true if an entry is ordered before another one
+func (e entry) less(other entry) bool {
+	if e.key != other.key {
+		return e.key < other.key
+	}
+	return e.line < other.line
+}
+
+// Sorter sorts lines with an external merge sort. Lines must not
+// contain newlines.
+type Sorter struct {
+	key       func(line string) string
+	tempDir   string
+	chunkSize int
+	// encryption encrypts the chunks if set
+	encryption *tmpcrypt.Key
+
+	entries []entry
+	size    int
+	chunks  []string
+}
+
+// New creates a sorter ordering the lines by key, keeping at most
+// chunkSize bytes of lines in memory and writing the sorted chunks to
+// the temporary directory.
+func New(tempDir string, chunkSize int, key func(line string) string) *Sorter {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &Sorter{key: key, tempDir: tempDir, chunkSize: chunkSize}
+}
+
+// Encrypt encrypts the chunks written to the temporary directory with
+// a key, nil to write them in plaintext.
+func (s *Sorter) Encrypt(key *tmpcrypt.Key) {
+	s.encryption = key
+}
+
+// Add adds a line to sort
+func (s *Sorter) Add(line string) error {
+	s.entries = append(s.entries, entry{key: s.key(line), line: line})
+	s.size += len(line)
+	if s.size >= s.chunkSize {
+		return s.flush()
+	}
+	return nil
+}
+
+// flush writes the lines in memory sorted to a temporary file
+func (s *Sorter) flush() error {
+	if len(s.entries) == 0 {
+		return nil
+	}
+	sortEntries(s.entries)
+
+	temp, err := os.CreateTemp(s.tempDir, "extsort")
+	if err != nil {
+		return err
+	}
+	temp.Close()
+	s.chunks = append(s.chunks, temp.Name())
+	file, err := s.encryption.Create(temp.Name())
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	for _, e := range s.entries {
+		_, _ = w.WriteString(e.line)
+		_, _ = w.WriteString("\n")
+	}
+	err = w.Flush()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	s.entries, s.size = nil, 0
+	return err
+}
+
+// Sort calls emit with the lines added in order
+func (s *Sorter) Sort(emit func(line string) error) error {
+	// The lines fitting in memory are sorted without temporary file
+	if len(s.chunks) == 0 {
+		sortEntries(s.entries)
+		for _, e := range s.entries {
+			if err := emit(e.line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+
+	h := &mergeHeap{}
+	for _, chunk := range s.chunks {
+		file, err := s.encryption.Open(chunk)
+		if err != nil {
+			h.close()
+			return err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		source := &mergeSource{file: file, scanner: scanner}
+		if err := s.next(source); err != nil {
+			file.Close()
+			h.close()
+			return err
+		}
+		if source.ok {
+			h.sources = append(h.sources, source)
+		} else {
+			file.Close()
+		}
+	}
+	heap.Init(h)
+	defer h.close()
+
+	for h.Len() > 0 {
+		source := h.sources[0]
+		if err := emit(source.current.line); err != nil {
+			return err
+		}
+		if err := s.next(source); err != nil {
+			return err
+		}
+		if source.ok {
+			heap.Fix(h, 0)
+		} else {
+			source.file.Close()
+			heap.Pop(h)
+		}
+	}
+	return nil
+}
+
+// next reads the next line of a chunk
+func (s *Sorter) next(source *mergeSource) error {
+	source.ok = source.scanner.Scan()
+	if !source.ok {
+		return source.scanner.Err()
+	}
+	line := strings.TrimSuffix(source.scanner.Text(), "\r")
+	source.current = entry{key: s.key(line), line: line}
+	return nil
+}
+
+// Close removes the temporary files of the sorter
+func (s *Sorter) Close() {
+	for _, chunk := range s.chunks {
+		os.Remove(chunk)
+	}
+	s.chunks, s.entries = nil, nil
+}
+
+// sortEntries sorts entries in place
+func sortEntries(entries []entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].less(entries[j])
+	})
+}
+
+// mergeSource is a sorted chunk being merged
+type mergeSource struct {
+	file    io.ReadCloser
+	scanner *bufio.Scanner
+	current entry
+	ok      bool
+}
+
+// mergeHeap orders the chunks being merged by their current line
+type mergeHeap struct {
+	sources []*mergeSource
+}
+
+func (h *mergeHeap) Len() int           { return len(h.sources) }
+func (h *mergeHeap) Less(i, j int) bool { return h.sources[i].current.less(h.sources[j].current) }
+func (h *mergeHeap) Swap(i, j int)      { h.sources[i], h.sources[j] = h.sources[j], h.sources[i] }
+func (h *mergeHeap) Push(x interface{}) { h.sources = append(h.sources, x.(*mergeSource)) }
+func (h *mergeHeap) Pop() interface{} {
+	last := h.sources[len(h.sources)-1]
+	h.sources = h.sources[:len(h.sources)-1]
+	return last
+}
+
+// close closes the files of the chunks still being merged
+func (h *mergeHeap) close() {
+	for _, source := range h.sources {
+		source.file.Close()
+	}
+	h.sources = nil
+}
@@ -0,0 +1,45 @@
+package extsort
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sortLines(t *testing.T, chunkSize int, lines []string) ([]string, *Sorter) {
+	sorter := New(t.TempDir(), chunkSize, func(line string) string {
+		return strings.ToLower(line)
+	})
+	for _, line := range lines {
+		require.Nil(t, sorter.Add(line), "Could not add line")
+	}
+	var sorted []string
+	require.Nil(t, sorter.Sort(func(line string) error {
+		sorted = append(sorted, line)
+		return nil
+	}), "Could not sort lines")
+	return sorted, sorter
+}
+
+func TestSortInMemory(t *testing.T) {
+	sorted, sorter := sortLines(t, 0, []string{"c", "B", "a"})
+	defer sorter.Close()
+
+	require.Equal(t, []string{"a", "B", "c"}, sorted, "Could not sort lines by key")
+	require.Empty(t, sorter.chunks, "Could not sort without temporary files")
+}
+
+func TestSortExternal(t *testing.T) {
+	lines := []string{"delta", "alpha", "echo", "charlie", "bravo", "alpha", "foxtrot"}
+	sorted, sorter := sortLines(t, 10, lines)
+
+	require.Equal(t, []string{"alpha", "alpha", "bravo", "charlie", "delta", "echo", "foxtrot"}, sorted, "Could not merge sorted chunks")
+	require.Greater(t, len(sorter.chunks), 1, "Could not write sorted chunks")
+
+	chunk := sorter.chunks[0]
+	sorter.Close()
+	_, err := os.Stat(chunk)
+	require.True(t, os.IsNotExist(err), "Could not remove temporary files")
+}
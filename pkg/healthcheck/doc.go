@@ -0,0 +1,8 @@
+// Package healthcheck contains the preflight checks of the environment
+// shuffledns runs in: the massdns binary, the reachability of the
+// resolvers and of dns servers over udp and tcp, the temporary
+// directory and the open files limit.
+//
+// Each check returns a Result describing whether it passed, so that
+// runs silently producing nothing can be diagnosed before starting.
+package healthcheck
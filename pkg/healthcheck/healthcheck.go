@@ -0,0 +1,199 @@
+package healthcheck
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/mohammadanaraki/shuffledns/pkg/massdns"
+	"github.com/mohammadanaraki/shuffledns/pkg/resolvers"
+	"github.com/mohammadanaraki/shuffledns/pkg/rlimit"
+)
+
+// Result is the outcome of a check
+type Result struct {
+	// Name is the name of the check
+	Name string
+	// OK is true if the check passed
+	OK bool
+	// Detail describes what was found
+	Detail string
+}
+
+// MassdnsBinary checks that the massdns binary can be executed,
+// reporting its version when it is shown in its help. It fails if the
+// version is not supported.
+func MassdnsBinary(path string) Result {
+	result := Result{Name: "massdns binary"}
+	if path == "" {
+		result.Detail = "massdns binary not found, install it or pass -massdns"
+		return result
+	}
+	if _, err := os.Stat(path); err != nil {
+		result.Detail = fmt.Sprintf("could not find %s: %s", path, err)
+		return result
+	}
+
+	// Massdns exits with an error status after showing its help
+	output, err := exec.Command(path, "--help").CombinedOutput()
+	if len(output) == 0 && err != nil {
+		result.Detail = fmt.Sprintf("could not execute %s: %s", path, err)
+		return result
+	}
+	version := massdns.ParseVersion(string(output))
+	if err := massdns.CheckVersion(version); err != nil {
+		result.Detail = fmt.Sprintf("%s: %s", path, err)
+		return result
+	}
+	result.OK = true
+	if version != "" {
+		result.Detail = fmt.Sprintf("%s (version %s)", path, version)
+	} else {
+		result.Detail = fmt.Sprintf("%s (unknown version)", path)
+	}
+	return result
+}
+
+// Resolvers checks how many resolvers of a file or inline list answer
+// a query for the root servers within the timeout. It passes if at
+// least one does.
+func Resolvers(list string, timeout time.Duration, threads int) Result {
+	result := Result{Name: "resolvers"}
+	if list == "" {
+		result.Detail = "no resolver list provided"
+		return result
+	}
+	servers, err := resolvers.Load(list)
+	if err != nil {
+		result.Detail = fmt.Sprintf("could not read resolvers: %s", err)
+		return result
+	}
+	if len(servers) == 0 {
+		result.Detail = "blank resolver list specified"
+		return result
+	}
+
+	var mutex sync.Mutex
+	var reachable int
+	queue := make(chan string)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for server := range queue {
+				if query("udp", server, timeout) == nil {
+					mutex.Lock()
+					reachable++
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	for _, server := range servers {
+		queue <- server
+	}
+	close(queue)
+	wg.Wait()
+
+	result.OK = reachable > 0
+	result.Detail = fmt.Sprintf("%d/%d resolvers answered within %s", reachable, len(servers), timeout)
+	return result
+}
+
+// Outbound checks that a dns server can be queried over a network
+// (udp or tcp), detecting firewalls blocking outbound port 53.
+func Outbound(network, server string, timeout time.Duration) Result {
+	result := Result{Name: fmt.Sprintf("outbound %s/53", network)}
+	if err := query(network, server, timeout); err != nil {
+		result.Detail = fmt.Sprintf("could not query %s: %s", server, err)
+		return result
+	}
+	result.OK = true
+	result.Detail = fmt.Sprintf("%s answered", server)
+	return result
+}
+
+// TempDir checks that temporary files can be written to a directory
+// (the system one if empty) with at least minFree bytes available.
+func TempDir(dir string, minFree uint64) Result {
+	result := Result{Name: "temporary directory"}
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	file, err := ioutil.TempFile(dir, "shuffledns-healthcheck")
+	if err != nil {
+		result.Detail = fmt.Sprintf("%s is not writable: %s", dir, err)
+		return result
+	}
+	_, err = file.WriteString("healthcheck\n")
+	file.Close()
+	os.Remove(file.Name())
+	if err != nil {
+		result.Detail = fmt.Sprintf("could not write to %s: %s", dir, err)
+		return result
+	}
+
+	free, err := freeSpace(dir)
+	if err != nil {
+		result.OK = true
+		result.Detail = fmt.Sprintf("%s is writable (free space unknown: %s)", dir, err)
+		return result
+	}
+	result.OK = free >= minFree
+	result.Detail = fmt.Sprintf("%s is writable with %s free", dir, formatBytes(free))
+	if !result.OK {
+		result.Detail += fmt.Sprintf(", at least %s recommended", formatBytes(minFree))
+	}
+	return result
+}
+
+// FileLimit checks that the open files limit is at least min
+func FileLimit(min uint64) Result {
+	result := Result{Name: "open files limit"}
+	limit, _, err := rlimit.Files()
+	if err != nil {
+		result.OK = true
+		result.Detail = fmt.Sprintf("unknown: %s", err)
+		return result
+	}
+	result.OK = limit >= min
+	result.Detail = fmt.Sprintf("%d", limit)
+	if !result.OK {
+		result.Detail += fmt.Sprintf(", at least %d recommended (ulimit -n %d)", min, min)
+	}
+	return result
+}
+
+// query sends a query for the root servers to a server
+func query(network, server string, timeout time.Duration) error {
+	server, err := resolvers.Parse(server)
+	if err != nil {
+		return err
+	}
+	m := new(dns.Msg)
+	m.SetQuestion(".", dns.TypeNS)
+
+	client := &dns.Client{Net: network, Timeout: timeout}
+	_, _, err = client.Exchange(m, server)
+	return err
+}
+
+// formatBytes formats a number of bytes with a binary unit
+func formatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
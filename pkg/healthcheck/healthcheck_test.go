@@ -0,0 +1,41 @@
+package healthcheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMassdnsBinary(t *testing.T) {
+	require.False(t, MassdnsBinary("").OK, "Could not detect missing binary")
+	require.False(t, MassdnsBinary(filepath.Join(t.TempDir(), "massdns")).OK, "Could not detect nonexistent binary")
+
+	binary := filepath.Join(t.TempDir(), "massdns")
+	require.Nil(t, os.WriteFile(binary, []byte("#!/bin/sh\necho 'massdns v1.0.0 Usage: massdns [options]'\nexit 1\n"), 0755))
+	result := MassdnsBinary(binary)
+	require.True(t, result.OK, "Could not execute binary")
+	require.Contains(t, result.Detail, "version 1.0.0", "Could not get version")
+
+	require.Nil(t, os.WriteFile(binary, []byte("#!/bin/sh\necho 'massdns v0.1 Usage: massdns [options]'\nexit 1\n"), 0755))
+	result = MassdnsBinary(binary)
+	require.False(t, result.OK, "Could not detect unsupported version")
+	require.Contains(t, result.Detail, "supported versions are", "Could not list supported versions")
+}
+
+func TestTempDir(t *testing.T) {
+	require.True(t, TempDir(t.TempDir(), 0).OK, "Could not check writable directory")
+	require.False(t, TempDir(filepath.Join(t.TempDir(), "missing"), 0).OK, "Could not detect missing directory")
+}
+
+func TestResolversBlank(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "resolvers.txt")
+	require.Nil(t, os.WriteFile(file, []byte("\n"), 0644))
+	require.False(t, Resolvers(file, 0, 1).OK, "Could not detect blank resolvers")
+}
+
+func TestFormatBytes(t *testing.T) {
+	require.Equal(t, "512 B", formatBytes(512), "Could not format bytes")
+	require.Equal(t, "1.5 GiB", formatBytes(3<<29), "Could not format gigabytes")
+}
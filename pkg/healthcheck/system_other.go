@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package healthcheck
+
+import "errors"
+
+// errUnsupported is returned for the checks unsupported on the platform
+var errUnsupported = errors.New("not supported on this platform")
+
+// freeSpace returns the bytes available to the user in a directory
+func freeSpace(dir string) (uint64, error) {
+	return 0, errUnsupported
+}
@@ -0,0 +1,8 @@
+// Package history implements a lightweight datastore of the assets
+// discovered across enumeration runs.
+//
+// For each hostname the first and last time it was seen is recorded
+// along with the history of the ips and CNAME targets it resolved to.
+// The datastore is kept in memory and persisted as a json file, saved
+// under a lock and merged with the updates of concurrent runs.
+package history
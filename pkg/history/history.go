@@ -0,0 +1,271 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/safefile"
+)
+
+// Observation is a single value observed for a hostname
+type Observation struct {
+	Value     string    `json:"value"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Asset contains the history of a single hostname
+type Asset struct {
+	Hostname  string         `json:"hostname"`
+	FirstSeen time.Time      `json:"first_seen"`
+	LastSeen  time.Time      `json:"last_seen"`
+	IPs       []*Observation `json:"ips,omitempty"`
+	CNAMEs    []*Observation `json:"cnames,omitempty"`
+}
+
+// Status describes how a hostname compares to its history
+type Status string
+
+// Statuses returned when recording a hostname
+const (
+	// StatusNew is returned for hostnames never seen before
+	StatusNew Status = "new"
+	// StatusRecurring is returned for hostnames seen before with the same answers
+	StatusRecurring Status = "recurring"
+	// StatusChanged is returned for hostnames whose answers differ from the previous sighting
+	StatusChanged Status = "changed"
+)
+
+// DB is a datastore of discovered assets
+type DB struct {
+	path   string
+	assets map[string]*Asset
+	mutex  sync.RWMutex
+}
+
+// Open opens the datastore at a path. If the file doesn't
+// exist, an empty datastore is returned which will be created
+// on the first call to Save.
+func Open(path string) (*DB, error) {
+	db := &DB{path: path, assets: make(map[string]*Asset)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []*Asset
+	if err := json.Unmarshal(data, &assets); err != nil {
+		return nil, fmt.Errorf("could not decode history store: %w", err)
+	}
+	for _, asset := range assets {
+		db.assets[asset.Hostname] = asset
+	}
+	return db, nil
+}
+
+// Change describes how the answers of a hostname compare to
+// the ones found the previous time it was seen.
+type Change struct {
+	Hostname       string    `json:"hostname"`
+	Status         Status    `json:"status"`
+	Timestamp      time.Time `json:"timestamp"`
+	PreviousIPs    []string  `json:"previous_ip,omitempty"`
+	IPs            []string  `json:"ip,omitempty"`
+	PreviousCNAMEs []string  `json:"previous_cname,omitempty"`
+	CNAMEs         []string  `json:"cname,omitempty"`
+}
+
+// Record records a hostname seen at a time with the ips and
+// CNAME targets it resolved to, returning how the hostname
+// compares to the previous time it was seen.
+func (db *DB) Record(hostname string, ips, cnames []string, now time.Time) *Change {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	change := &Change{Hostname: hostname, Timestamp: now, IPs: ips, CNAMEs: cnames}
+
+	asset, ok := db.assets[hostname]
+	if !ok {
+		asset = &Asset{Hostname: hostname, FirstSeen: now}
+		db.assets[hostname] = asset
+		change.Status = StatusNew
+	} else {
+		// The answers of the previous sighting are the ones
+		// last seen at the same time as the hostname itself.
+		change.PreviousIPs = seenAt(asset.IPs, asset.LastSeen)
+		change.PreviousCNAMEs = seenAt(asset.CNAMEs, asset.LastSeen)
+
+		change.Status = StatusRecurring
+		if !sameValues(change.PreviousIPs, ips) || !sameValues(change.PreviousCNAMEs, cnames) {
+			change.Status = StatusChanged
+		}
+	}
+	asset.LastSeen = now
+	asset.IPs = observe(asset.IPs, ips, now)
+	asset.CNAMEs = observe(asset.CNAMEs, cnames, now)
+
+	return change
+}
+
+// seenAt returns the values of the observations last seen at a time
+func seenAt(observations []*Observation, at time.Time) []string {
+	var values []string
+	for _, observation := range observations {
+		if observation.LastSeen.Equal(at) {
+			values = append(values, observation.Value)
+		}
+	}
+	return values
+}
+
+// sameValues returns true if two lists contain the same values
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	values := make(map[string]struct{}, len(a))
+	for _, value := range a {
+		values[value] = struct{}{}
+	}
+	for _, value := range b {
+		if _, ok := values[value]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// observe updates a list of observations with the values seen now
+func observe(observations []*Observation, values []string, now time.Time) []*Observation {
+	for _, value := range values {
+		var found bool
+		for _, observation := range observations {
+			if observation.Value == value {
+				observation.LastSeen = now
+				found = true
+				break
+			}
+		}
+		if !found {
+			observations = append(observations, &Observation{Value: value, FirstSeen: now, LastSeen: now})
+		}
+	}
+	return observations
+}
+
+// Get returns the history of a hostname or nil if it was never seen
+func (db *DB) Get(hostname string) *Asset {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	return db.assets[hostname]
+}
+
+// Assets returns all the assets in the datastore sorted by hostname
+func (db *DB) Assets() []*Asset {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	assets := make([]*Asset, 0, len(db.assets))
+	for _, asset := range db.assets {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool {
+		return assets[i].Hostname < assets[j].Hostname
+	})
+	return assets
+}
+
+// Save persists the datastore to disk. It is saved under an exclusive
+// lock of a lock file next to it, merged with the assets saved by the
+// other runs since it was opened, so that concurrent runs sharing a
+// datastore never lose the updates of each other. The file is written
+// to a temporary file first and renamed to avoid corruption.
+func (db *DB) Save() error {
+	unlock, err := lockStore(db.path)
+	if err != nil {
+		return fmt.Errorf("could not lock history store: %w", err)
+	}
+	defer unlock()
+
+	saved, err := Open(db.path)
+	if err != nil {
+		return err
+	}
+	db.mutex.Lock()
+	for hostname, asset := range saved.assets {
+		if own, ok := db.assets[hostname]; ok {
+			mergeAsset(own, asset)
+		} else {
+			db.assets[hostname] = asset
+		}
+	}
+	db.mutex.Unlock()
+
+	data, err := json.Marshal(db.Assets())
+	if err != nil {
+		return err
+	}
+	file, err := safefile.Create(db.path)
+	if err != nil {
+		return err
+	}
+	defer file.Abort()
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	return file.Commit()
+}
+
+// mergeAsset merges the history of a hostname saved by another run
+// into an asset, keeping the earliest first and the latest last times.
+func mergeAsset(asset, saved *Asset) {
+	asset.FirstSeen = earliest(asset.FirstSeen, saved.FirstSeen)
+	asset.LastSeen = latest(asset.LastSeen, saved.LastSeen)
+	asset.IPs = mergeObservations(asset.IPs, saved.IPs)
+	asset.CNAMEs = mergeObservations(asset.CNAMEs, saved.CNAMEs)
+}
+
+// mergeObservations merges the observations saved by another run into
+// a list of observations of the same values
+func mergeObservations(observations, saved []*Observation) []*Observation {
+	for _, other := range saved {
+		var found bool
+		for _, observation := range observations {
+			if observation.Value == other.Value {
+				observation.FirstSeen = earliest(observation.FirstSeen, other.FirstSeen)
+				observation.LastSeen = latest(observation.LastSeen, other.LastSeen)
+				found = true
+				break
+			}
+		}
+		if !found {
+			observations = append(observations, other)
+		}
+	}
+	return observations
+}
+
+// earliest returns the earliest of two times
+func earliest(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// latest returns the latest of two times
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,84 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryRecordAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assets.db")
+
+	db, err := Open(path)
+	require.Nil(t, err, "Could not open history store")
+
+	first := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+	require.Equal(t, StatusNew, db.Record("docs.example.com", []string{"1.1.1.1"}, nil, first).Status)
+	require.Equal(t, StatusChanged, db.Record("docs.example.com", []string{"1.1.1.1", "2.2.2.2"}, []string{"example.github.io"}, second).Status)
+	require.Nil(t, db.Save(), "Could not save history store")
+
+	db, err = Open(path)
+	require.Nil(t, err, "Could not reopen history store")
+
+	asset := db.Get("docs.example.com")
+	require.NotNil(t, asset, "Could not get asset")
+	require.True(t, first.Equal(asset.FirstSeen), "Could not get first seen")
+	require.True(t, second.Equal(asset.LastSeen), "Could not get last seen")
+	require.Len(t, asset.IPs, 2, "Could not get ip history")
+	require.True(t, first.Equal(asset.IPs[0].FirstSeen), "Could not keep ip first seen")
+	require.True(t, second.Equal(asset.IPs[0].LastSeen), "Could not update ip last seen")
+	require.Equal(t, "example.github.io", asset.CNAMEs[0].Value, "Could not get cname history")
+}
+
+func TestHistoryRecordChanges(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "assets.db"))
+	require.Nil(t, err, "Could not open history store")
+
+	first := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	db.Record("www.example.com", []string{"1.1.1.1", "2.2.2.2"}, nil, first)
+
+	change := db.Record("www.example.com", []string{"2.2.2.2", "1.1.1.1"}, nil, first.Add(time.Hour))
+	require.Equal(t, StatusRecurring, change.Status, "Could not detect recurring answers")
+
+	change = db.Record("www.example.com", nil, []string{"bucket.s3.amazonaws.com"}, first.Add(2*time.Hour))
+	require.Equal(t, StatusChanged, change.Status, "Could not detect changed answers")
+	require.ElementsMatch(t, []string{"1.1.1.1", "2.2.2.2"}, change.PreviousIPs, "Could not get previous ips")
+	require.Equal(t, []string{"bucket.s3.amazonaws.com"}, change.CNAMEs, "Could not get new cname")
+}
+
+func TestHistoryConcurrentSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "assets.db")
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Every run opens the datastore before any of them saves it
+	var dbs []*DB
+	for i := 0; i < 8; i++ {
+		db, err := Open(path)
+		require.Nil(t, err, "Could not open history store")
+		db.Record(fmt.Sprintf("host%d.example.com", i), []string{"1.1.1.1"}, nil, now.Add(time.Duration(i)*time.Hour))
+		db.Record("shared.example.com", []string{fmt.Sprintf("2.2.2.%d", i)}, nil, now.Add(time.Duration(i)*time.Hour))
+		dbs = append(dbs, db)
+	}
+	var wg sync.WaitGroup
+	for _, db := range dbs {
+		wg.Add(1)
+		go func(db *DB) {
+			defer wg.Done()
+			require.Nil(t, db.Save(), "Could not save history store")
+		}(db)
+	}
+	wg.Wait()
+
+	db, err := Open(path)
+	require.Nil(t, err, "Could not reopen history store")
+	require.Len(t, db.Assets(), 9, "Could not keep the assets of every run")
+	shared := db.Get("shared.example.com")
+	require.Len(t, shared.IPs, 8, "Could not merge the ips of every run")
+	require.True(t, now.Equal(shared.FirstSeen), "Could not keep the earliest first seen")
+	require.True(t, now.Add(7*time.Hour).Equal(shared.LastSeen), "Could not keep the latest last seen")
+}
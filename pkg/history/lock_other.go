@@ -0,0 +1,35 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package history
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// staleLockAge is the age after which a lock file left by a crashed
+// process is taken over
+const staleLockAge = time.Minute
+
+// lockStore takes an exclusive lock of a datastore, held by creating a
+// lock file next to it, and returns the function releasing it.
+func lockStore(path string) (func(), error) {
+	lock := path + ".lock"
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { _ = os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(lock)
+			continue
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
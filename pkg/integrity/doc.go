@@ -0,0 +1,8 @@
+// Package integrity writes and verifies the manifest of the output
+// files of a run.
+//
+// The manifest lists the SHA-256 digest and the size of every output
+// file along with the run they come from, so that results handed off
+// between team members or to clients are tamper-evident. Signing the
+// manifest, e.g. with minisign or cosign, covers the files it lists.
+package integrity
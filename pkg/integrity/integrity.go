@@ -0,0 +1,132 @@
+package integrity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Manifest lists the output files of a run with their digests
+type Manifest struct {
+	RunID      string    `json:"run_id,omitempty"`
+	ConfigHash string    `json:"config_hash,omitempty"`
+	Domain     string    `json:"domain,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	Files      []*File   `json:"files"`
+}
+
+// File is an output file listed in a manifest. The path is relative to
+// the directory of the manifest when the file is below it.
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Mismatch is a file whose content doesn't match the manifest
+type Mismatch struct {
+	Path   string
+	Reason string
+}
+
+// Hash returns the SHA-256 digest and the size of a file
+func Hash(file string) (string, int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), size, nil
+}
+
+// Add hashes a file and adds it to the manifest written to manifestFile
+func (m *Manifest) Add(manifestFile, file string) error {
+	digest, size, err := Hash(file)
+	if err != nil {
+		return err
+	}
+	m.Files = append(m.Files, &File{Path: relativePath(manifestFile, file), Size: size, SHA256: digest})
+	return nil
+}
+
+// relativePath returns the path of a file relative to the directory of
+// the manifest, or its absolute path if it's outside of it.
+func relativePath(manifestFile, file string) string {
+	dir, err := filepath.Abs(filepath.Dir(manifestFile))
+	if err != nil {
+		return file
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return file
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return abs
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Write writes the manifest to a file as indented json
+func (m *Manifest) Write(file string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// Read reads a manifest from a file
+func Read(file string) (*Manifest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("could not parse manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// Verify checks the files listed in a manifest file, resolving the
+// relative paths from its directory, and returns the ones which are
+// missing or whose content changed.
+func Verify(file string) (*Manifest, []Mismatch, error) {
+	manifest, err := Read(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var mismatches []Mismatch
+	for _, listed := range manifest.Files {
+		path := filepath.FromSlash(listed.Path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		digest, size, err := Hash(path)
+		switch {
+		case os.IsNotExist(err):
+			mismatches = append(mismatches, Mismatch{Path: listed.Path, Reason: "missing"})
+		case err != nil:
+			return nil, nil, err
+		case size != listed.Size:
+			mismatches = append(mismatches, Mismatch{Path: listed.Path, Reason: fmt.Sprintf("size %d instead of %d", size, listed.Size)})
+		case digest != listed.SHA256:
+			mismatches = append(mismatches, Mismatch{Path: listed.Path, Reason: "sha256 mismatch"})
+		}
+	}
+	return manifest, mismatches, nil
+}
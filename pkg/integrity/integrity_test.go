@@ -0,0 +1,51 @@
+package integrity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestVerify(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "results", "output.txt")
+	require.Nil(t, os.MkdirAll(filepath.Dir(output), 0755), "Could not create directory")
+	require.Nil(t, os.WriteFile(output, []byte("www.example.com\n"), 0644), "Could not write output")
+	outside := filepath.Join(t.TempDir(), "wildcards.txt")
+	require.Nil(t, os.WriteFile(outside, []byte("192.0.2.1\n"), 0644), "Could not write wildcards")
+
+	file := filepath.Join(dir, "manifest.json")
+	manifest := &Manifest{RunID: "run", CreatedAt: time.Now().UTC()}
+	require.Nil(t, manifest.Add(file, output), "Could not add output")
+	require.Nil(t, manifest.Add(file, outside), "Could not add wildcards")
+	require.Nil(t, manifest.Write(file), "Could not write manifest")
+
+	require.Equal(t, "results/output.txt", manifest.Files[0].Path, "Could not make path relative to the manifest")
+	require.True(t, filepath.IsAbs(manifest.Files[1].Path), "Could not keep path outside of the manifest directory absolute")
+
+	read, mismatches, err := Verify(file)
+	require.Nil(t, err, "Could not verify manifest")
+	require.Empty(t, mismatches, "Could not verify unchanged files")
+	require.Equal(t, "run", read.RunID, "Could not read manifest")
+
+	require.Nil(t, os.WriteFile(output, []byte("ftp.example.com\n"), 0644), "Could not tamper with output")
+	require.Nil(t, os.Remove(outside), "Could not remove wildcards")
+	_, mismatches, err = Verify(file)
+	require.Nil(t, err, "Could not verify manifest")
+	require.Equal(t, []Mismatch{
+		{Path: "results/output.txt", Reason: "sha256 mismatch"},
+		{Path: manifest.Files[1].Path, Reason: "missing"},
+	}, mismatches, "Could not detect tampered files")
+}
+
+func TestHash(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "empty.txt")
+	require.Nil(t, os.WriteFile(file, nil, 0644), "Could not write file")
+	digest, size, err := Hash(file)
+	require.Nil(t, err, "Could not hash file")
+	require.Equal(t, int64(0), size, "Could not get file size")
+	require.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", digest, "Could not hash file")
+}
@@ -0,0 +1,3 @@
+// Package markov generates plausible subdomain names with a character
+// level markov chain trained on the known subdomains of a target.
+package markov
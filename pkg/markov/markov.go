@@ -0,0 +1,139 @@
+package markov
+
+import (
+	"math/rand"
+	"strings"
+)
+
+const (
+	// start pads the beginning of the names
+	start = '^'
+	// end terminates the names
+	end = '$'
+	// maxLength is the maximum length of a generated name
+	maxLength = 63
+	// maxAttempts is the number of walks tried for each name to generate
+	maxAttempts = 50
+)
+
+// transitions are the characters following a state and their counts
+type transitions struct {
+	next   []rune
+	counts []int
+	total  int
+}
+
+// Chain is a markov chain of characters
+type Chain struct {
+	order  int
+	states map[string]*transitions
+	known  map[string]struct{}
+}
+
+// New creates a chain where each character depends on the previous
+// order characters.
+func New(order int) *Chain {
+	return &Chain{
+		order:  order,
+		states: make(map[string]*transitions),
+		known:  make(map[string]struct{}),
+	}
+}
+
+// Train adds a name to the chain
+func (c *Chain) Train(name string) {
+	name = strings.ToLower(name)
+	if name == "" {
+		return
+	}
+	c.known[name] = struct{}{}
+
+	runes := append([]rune(strings.Repeat(string(start), c.order)+name), end)
+	for i := c.order; i < len(runes); i++ {
+		c.add(string(runes[i-c.order:i]), runes[i])
+	}
+}
+
+// add records a transition from a state to a character
+func (c *Chain) add(state string, next rune) {
+	t, ok := c.states[state]
+	if !ok {
+		t = &transitions{}
+		c.states[state] = t
+	}
+	t.total++
+	for i, r := range t.next {
+		if r == next {
+			t.counts[i]++
+			return
+		}
+	}
+	t.next = append(t.next, next)
+	t.counts = append(t.counts, 1)
+}
+
+// Generate returns at most n unique names which are not part of the
+// training names.
+func (c *Chain) Generate(n int, random *rand.Rand) []string {
+	seen := make(map[string]struct{})
+
+	var names []string
+	for attempts := 0; len(names) < n && attempts < n*maxAttempts; attempts++ {
+		name := c.walk(random)
+		if name == "" {
+			continue
+		}
+		if _, ok := c.known[name]; ok {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names
+}
+
+// walk generates a single name, returning an empty string for names
+// which are too long or not valid.
+func (c *Chain) walk(random *rand.Rand) string {
+	state := []rune(strings.Repeat(string(start), c.order))
+
+	var builder strings.Builder
+	for builder.Len() <= maxLength {
+		t, ok := c.states[string(state)]
+		if !ok {
+			return ""
+		}
+		next := t.pick(random)
+		if next == end {
+			return valid(builder.String())
+		}
+		builder.WriteRune(next)
+		state = append(state[1:], next)
+	}
+	return ""
+}
+
+// pick returns a random following character weighted by its count
+func (t *transitions) pick(random *rand.Rand) rune {
+	value := random.Intn(t.total)
+	for i, count := range t.counts {
+		if value < count {
+			return t.next[i]
+		}
+		value -= count
+	}
+	return end
+}
+
+// valid returns the name if all of its labels are valid
+func valid(name string) string {
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return ""
+		}
+	}
+	return name
+}
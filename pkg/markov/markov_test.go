@@ -0,0 +1,24 @@
+package markov
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainGenerate(t *testing.T) {
+	chain := New(2)
+	for _, name := range []string{"app-dev", "app-stg", "wapp-dev"} {
+		chain.Train(name)
+	}
+
+	// The only name which can be generated is not a training name
+	names := chain.Generate(3, rand.New(rand.NewSource(1)))
+	require.Equal(t, []string{"wapp-stg"}, names, "Could not generate names")
+}
+
+func TestChainUntrained(t *testing.T) {
+	chain := New(3)
+	require.Empty(t, chain.Generate(5, rand.New(rand.NewSource(1))), "Could not handle untrained chain")
+}
@@ -0,0 +1,129 @@
+package massdns
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/rs/xid"
+)
+
+// resolveAdditional resolves names discovered after the main massdns
+// run and adds the valid ones to the store, applying the same known
+// answer checks, quarantine, sinkhole and wildcard filtering as for
+// the main results.
+func (c *Client) resolveAdditional(names []string, st *store.Store) error {
+	var valid []string
+	for _, name := range names {
+		if c.validName(name) && c.pluginCandidate(name) && c.newQuery(name) {
+			valid = append(valid, name)
+		}
+	}
+	names = valid
+
+	if c.config.Scope != nil {
+		var inScope []string
+		for _, name := range names {
+			if c.config.Scope.InScope(name) {
+				inScope = append(inScope, name)
+			} else {
+				c.scopeDropped++
+			}
+		}
+		names = inScope
+	}
+	if c.depthLimited() {
+		var inDepth []string
+		for _, name := range names {
+			if c.inDepth(name) {
+				inDepth = append(inDepth, name)
+			} else {
+				c.depthDropped++
+			}
+		}
+		names = inDepth
+	}
+	if c.config.PruneWildcards {
+		c.addWildcardRoots()
+		var kept []string
+		for _, name := range names {
+			if c.prunedName(name) {
+				c.prunedDropped++
+			} else {
+				kept = append(kept, name)
+			}
+		}
+		names = kept
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	inputFile := filepath.Join(c.config.TempDir, xid.New().String())
+	file, err := c.config.TempKey.Create(inputFile)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	for _, name := range names {
+		_, _ = w.WriteString(name + "\n")
+	}
+	err = w.Flush()
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	// The known answer checks are interleaved in the additional names
+	// too, so that the resolvers lying on them are caught as well
+	if c.knownAnswersEnabled() {
+		inputFile, err = c.addKnownAnswers(inputFile)
+		if err != nil {
+			return fmt.Errorf("could not add known answer checks: %w", err)
+		}
+	}
+
+	// Run massdns on the additional names only, without mutating them
+	mainInputFile, mutator := c.config.InputFile, c.config.Mutator
+	c.config.InputFile, c.config.Mutator = inputFile, nil
+	defer func() {
+		c.config.InputFile, c.config.Mutator = mainInputFile, mutator
+	}()
+
+	// The results already in the store were verified before
+	verified := knownHostnames(st)
+	output := filepath.Join(c.config.TempDir, xid.New().String())
+	if err := c.runMassDNS(output, st); err != nil {
+		return fmt.Errorf("could not execute massdns: %w", err)
+	}
+	if err := c.parseMassDNSOutput(output, st); err != nil {
+		return fmt.Errorf("could not parse massdns output: %w", err)
+	}
+
+	c.processQuarantine(st, verified)
+
+	if c.config.Sinkholes != nil {
+		c.filterSinkholes(st)
+	}
+	if c.config.Domain != "" {
+		if err := c.filterWildcards(st); err != nil {
+			return err
+		}
+	}
+	if c.config.Parking != nil {
+		c.detectParked(st)
+	}
+	return nil
+}
+
+// knownHostnames returns all the hostnames present in the store
+func knownHostnames(st *store.Store) map[string]struct{} {
+	hostnames := make(map[string]struct{})
+	for _, record := range st.IP {
+		for _, hostname := range record.Hostnames.List() {
+			hostnames[hostname] = struct{}{}
+		}
+	}
+	return hostnames
+}
@@ -0,0 +1,139 @@
+package massdns
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/resolvers"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// checkAgreement asks other resolvers of the list for every result and
+// drops the results whose answer wasn't confirmed by ResolverAgreement
+// distinct resolvers, counting the one which answered massdns. Answers
+// agree when they share an ip or the target of their CNAME chain.
+func (c *Client) checkAgreement(st *store.Store) error {
+	servers, err := resolvers.ReadFile(c.config.ResolversFile)
+	if err != nil {
+		return err
+	}
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	candidates := newAgreementResolvers(servers, random)
+
+	hostIPs := make(map[string]map[string]struct{})
+	for ip, record := range st.IP {
+		for _, hostname := range record.Hostnames.List() {
+			if _, ok := hostIPs[hostname]; !ok {
+				hostIPs[hostname] = make(map[string]struct{})
+			}
+			hostIPs[hostname][ip] = struct{}{}
+		}
+	}
+
+	var mutex sync.Mutex
+	var rejected []string
+	wg := sizedwaitgroup.New(c.config.WildcardsThreads)
+	for hostname, ips := range hostIPs {
+		var answered string
+		var chain []string
+		if meta := st.GetHost(hostname); meta != nil {
+			answered, chain = meta.Resolver, meta.CNAME
+		}
+
+		// Ask distinct resolvers other than the one which answered,
+		// from a random one of the list
+		var start int
+		if len(candidates) > 0 {
+			start = random.Intn(len(candidates))
+		}
+
+		wg.Add()
+		go func(hostname, answered string, ips map[string]struct{}, chain []string, start int) {
+			defer wg.Done()
+
+			agreeing := 1
+			var disagreeing []string
+			candidates.walk(start, answered, func(server string) bool {
+				if agreeing >= c.config.ResolverAgreement {
+					return false
+				}
+				otherIPs, otherChain, err := c.wildcardResolver.ResolveFrom(server, hostname)
+				if err == nil && answersAgree(ips, chain, otherIPs, otherChain) {
+					agreeing++
+				} else {
+					disagreeing = append(disagreeing, server)
+				}
+				return true
+			})
+			if agreeing >= c.config.ResolverAgreement {
+				return
+			}
+			c.log().Verbose().Msgf("Rejected %s confirmed by %d/%d resolvers (disagreeing: %s)\n", hostname, agreeing, c.config.ResolverAgreement, strings.Join(disagreeing, ", "))
+			mutex.Lock()
+			rejected = append(rejected, hostname)
+			mutex.Unlock()
+		}(hostname, answered, ips, chain, start)
+	}
+	wg.Wait()
+
+	for _, hostname := range rejected {
+		removeHostname(st, hostname)
+	}
+	c.log().Info().Msgf("Resolver agreement: %d/%d results rejected without %d agreeing resolvers\n", len(rejected), len(hostIPs), c.config.ResolverAgreement)
+	return nil
+}
+
+// agreementResolvers are the distinct resolvers asked to confirm the
+// results, listed once in a random order for all of them.
+type agreementResolvers []string
+
+// newAgreementResolvers returns the distinct resolvers in a random order
+func newAgreementResolvers(servers []string, random *rand.Rand) agreementResolvers {
+	seen := make(map[string]struct{}, len(servers))
+	distinct := make(agreementResolvers, 0, len(servers))
+	for _, server := range servers {
+		if _, ok := seen[server]; !ok {
+			seen[server] = struct{}{}
+			distinct = append(distinct, server)
+		}
+	}
+	random.Shuffle(len(distinct), func(i, j int) {
+		distinct[i], distinct[j] = distinct[j], distinct[i]
+	})
+	return distinct
+}
+
+// walk calls fn with the resolvers other than the excluded one, from
+// the one at the start index and wrapping around the list, until fn
+// returns false.
+func (a agreementResolvers) walk(start int, exclude string, fn func(server string) bool) {
+	if address, err := resolvers.Parse(exclude); err == nil {
+		exclude = address
+	}
+	for i := range a {
+		server := a[(start+i)%len(a)]
+		if server == exclude {
+			continue
+		}
+		if !fn(server) {
+			return
+		}
+	}
+}
+
+// answersAgree returns true if two answers share an ip or the target
+// of their CNAME chain.
+func answersAgree(ips map[string]struct{}, chain, otherIPs, otherChain []string) bool {
+	for _, ip := range otherIPs {
+		if _, ok := ips[ip]; ok {
+			return true
+		}
+	}
+	if len(chain) > 0 && len(otherChain) > 0 {
+		return strings.EqualFold(chain[len(chain)-1], otherChain[len(otherChain)-1])
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package massdns
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgreementResolversWalk(t *testing.T) {
+	candidates := newAgreementResolvers([]string{"1.1.1.1:53", "8.8.8.8:53", "1.1.1.1:53", "9.9.9.9:53"}, rand.New(rand.NewSource(1)))
+	require.ElementsMatch(t, []string{"1.1.1.1:53", "8.8.8.8:53", "9.9.9.9:53"}, []string(candidates), "Could not list the distinct resolvers")
+
+	for start := range candidates {
+		var walked []string
+		candidates.walk(start, "8.8.8.8", func(server string) bool {
+			walked = append(walked, server)
+			return true
+		})
+		require.ElementsMatch(t, []string{"1.1.1.1:53", "9.9.9.9:53"}, walked, "Could not walk the other resolvers")
+		if candidates[start] != "8.8.8.8:53" {
+			require.Equal(t, candidates[start], walked[0], "Could not start at the index")
+		}
+	}
+
+	var walked int
+	candidates.walk(0, "", func(server string) bool {
+		walked++
+		return walked < 2
+	})
+	require.Equal(t, 2, walked, "Could not stop walking")
+}
@@ -0,0 +1,50 @@
+package massdns
+
+import (
+	"sync"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// collectAnyRecords queries the records of all types of the validated
+// hosts, or of the selected types only, remembering them to include
+// them in the output.
+func (c *Client) collectAnyRecords(st *store.Store) {
+	var mutex sync.Mutex
+	var fallbacks int
+	wg := sizedwaitgroup.New(c.config.WildcardsThreads)
+
+	hostnames := knownHostnames(st)
+	for hostname := range hostnames {
+		wg.Add()
+		go func(hostname string) {
+			defer wg.Done()
+
+			var records map[string][]string
+			var fallback bool
+			var err error
+			if c.config.AnyRecords {
+				records, fallback, err = c.wildcardResolver.LookupAny(hostname)
+			} else {
+				records, err = c.wildcardResolver.LookupTypes(hostname, c.config.RecordTypes)
+			}
+			if err != nil || len(records) == 0 {
+				return
+			}
+			mutex.Lock()
+			c.anyRecords[hostname] = records
+			if fallback {
+				fallbacks++
+			}
+			mutex.Unlock()
+		}(hostname)
+	}
+	wg.Wait()
+
+	if !c.config.AnyRecords {
+		c.log().Info().Msgf("Collected the records of %d/%d hosts\n", len(c.anyRecords), len(hostnames))
+		return
+	}
+	c.log().Info().Msgf("Collected the records of %d/%d hosts (%d with per-type queries after ANY was refused)\n", len(c.anyRecords), len(hostnames), fallbacks)
+}
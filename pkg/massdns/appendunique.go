@@ -0,0 +1,118 @@
+package massdns
+
+import (
+	"bufio"
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/mohammadanaraki/shuffledns/pkg/safefile"
+)
+
+// hostnameSet is a set of hostnames stored as hashes, so that the
+// hostnames of large output files fit in memory.
+type hostnameSet map[uint64]struct{}
+
+// hashHostname returns the hash of the canonical form of a hostname
+func hashHostname(hostname string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(dnsname.Normalize(hostname)))
+	return h.Sum64()
+}
+
+// Add adds a hostname to the set returning false if it was present
+func (s hostnameSet) Add(hostname string) bool {
+	hash := hashHostname(hostname)
+	if _, ok := s[hash]; ok {
+		return false
+	}
+	s[hash] = struct{}{}
+	return true
+}
+
+// readHostnameSet streams the hostnames of an output file, either in
+// plain or json format, into a set. A missing file is an empty set.
+func readHostnameSet(file string) (hostnameSet, error) {
+	set := make(hostnameSet)
+
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return set, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if hostname := lineHostname(scanner.Text()); hostname != "" {
+			set.Add(hostname)
+		}
+	}
+	return set, scanner.Err()
+}
+
+// lineHostname returns the hostname of an output line, either in plain,
+// csv or json format, or an empty string if there is none.
+func lineHostname(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return ""
+	}
+	if strings.HasPrefix(line, "{") {
+		var record struct {
+			Hostname string `json:"hostname"`
+		}
+		if json.Unmarshal([]byte(line), &record) != nil {
+			return ""
+		}
+		return record.Hostname
+	}
+	// The plain lines written by older versions may be followed by the
+	// cdn group and status and the csv lines start with the hostname
+	// column
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// openAppendUnique opens an output file for a journaled append, making
+// sure the new lines don't continue a last incomplete line, and returns
+// the set of the hostnames already present in it. An incomplete append
+// must have been rolled back before.
+func openAppendUnique(file string) (*safefile.File, hostnameSet, error) {
+	existing, err := readHostnameSet(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output, err := safefile.Append(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := output.Stat()
+	if err != nil {
+		output.Abort()
+		return nil, nil, err
+	}
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := output.ReadAt(last, info.Size()-1); err != nil && err != io.EOF {
+			output.Abort()
+			return nil, nil, err
+		}
+		if last[0] != '\n' {
+			_, _ = output.WriteString("\n")
+		}
+	}
+	return output, existing, nil
+}
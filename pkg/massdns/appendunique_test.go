@@ -0,0 +1,37 @@
+package massdns
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAppendUnique(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "output.txt")
+	data := "www.example.com [new]\n,\n, \t\n{\"hostname\":\"api.example.com\"}\nMail.Example.com"
+	require.Nil(t, os.WriteFile(file, []byte(data), 0644))
+
+	output, existing, err := openAppendUnique(file)
+	require.Nil(t, err, "Could not open output file")
+	require.False(t, existing.Add("www.example.com"), "Could not read plain hostname")
+	require.False(t, existing.Add("api.example.com"), "Could not read json hostname")
+	require.False(t, existing.Add("mail.example.com"), "Could not read normalized hostname")
+	require.True(t, existing.Add("dev.example.com"), "Could not add new hostname")
+
+	_, err = output.WriteString("dev.example.com\n")
+	require.Nil(t, err, "Could not append to output file")
+	require.Nil(t, output.Commit())
+
+	content, err := os.ReadFile(file)
+	require.Nil(t, err, "Could not read output file")
+	require.Equal(t, data+"\ndev.example.com\n", string(content), "Could not append after incomplete last line")
+}
+
+func TestLineHostname(t *testing.T) {
+	for _, line := range []string{"", ",", ", \t", "{not json"} {
+		require.Equal(t, "", lineHostname(line), "Could not ignore line %q", line)
+	}
+	require.Equal(t, "www.example.com", lineHostname("www.example.com [new]"), "Could not get hostname of annotated line")
+}
@@ -0,0 +1,76 @@
+package massdns
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/rs/xid"
+)
+
+// addCanaries writes a copy of the input file with the canary names
+// appended to it, returning the path of the new input file. Canaries
+// are random names which are guaranteed not to exist for the domain.
+func (c *Client) addCanaries(inputFile string) (string, error) {
+	canaryFile := filepath.Join(c.config.TempDir, xid.New().String())
+
+	output, err := c.config.TempKey.Create(canaryFile)
+	if err != nil {
+		return "", err
+	}
+	defer output.Close()
+
+	input, err := c.config.TempKey.Open(inputFile)
+	if err != nil {
+		return "", err
+	}
+	defer input.Close()
+
+	w := bufio.NewWriter(output)
+	if _, err := io.Copy(w, input); err != nil {
+		return "", err
+	}
+	// Make sure the canaries start on their own line
+	_, _ = w.WriteString("\n")
+
+	for i := 0; i < c.config.Canaries; i++ {
+		canary := "canary-" + xid.New().String() + "." + c.config.Domain
+		c.canaries[canary] = struct{}{}
+		_, _ = w.WriteString(canary + "\n")
+	}
+	return canaryFile, w.Flush()
+}
+
+// countCanaries returns the number of canaries present in the store
+func (c *Client) countCanaries(st *store.Store) int {
+	found := make(map[string]struct{})
+	for _, record := range st.IP {
+		for _, hostname := range record.Hostnames.List() {
+			if _, ok := c.canaries[hostname]; ok {
+				found[hostname] = struct{}{}
+			}
+		}
+	}
+	return len(found)
+}
+
+// removeCanaries removes the canaries from the store
+func (c *Client) removeCanaries(st *store.Store) {
+	for ip, record := range st.IP {
+		for _, hostname := range record.Hostnames.List() {
+			if _, ok := c.canaries[hostname]; ok {
+				record.Hostnames.Remove(hostname)
+			}
+		}
+		if record.Hostnames.Len() == 0 {
+			st.Delete(ip)
+		}
+	}
+}
+
+// reportCanaries logs the false-positive estimate given by the canaries
+func (c *Client) reportCanaries(resolved, survived int) {
+	total := len(c.canaries)
+	c.log().Info().Msgf("Canaries: %d/%d resolved (estimated false-positive rate %.2f%%), %d survived wildcard filtering\n", resolved, total, float64(resolved)*100/float64(total), survived)
+}
@@ -0,0 +1,141 @@
+package massdns
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/netblocks"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// clustersSummary is the number of clusters logged at the end of a run
+const clustersSummary = 5
+
+// ipClusters contains the clusters of the ips of the results
+type ipClusters struct {
+	Netblocks []*netblocks.Cluster `json:"netblocks"`
+	ASNs      []*netblocks.Cluster `json:"asns,omitempty"`
+}
+
+// writeIPClusters clusters the ips of the results per netblock, and
+// per asn if asked, writing the clusters to the clusters file and
+// logging the ones concentrating the most hosts.
+func (c *Client) writeIPClusters(st *store.Store) error {
+	hostIPs := make(map[string][]string)
+	for ip, record := range st.IP {
+		for _, hostname := range record.Hostnames.List() {
+			hostIPs[hostname] = append(hostIPs[hostname], ip)
+		}
+	}
+
+	clusters := &ipClusters{Netblocks: netblocks.Group(hostIPs, netblocks.Prefix)}
+	c.log().Info().Msgf("IP clusters: %d netblocks, %s\n", len(clusters.Netblocks), clustersLine(clusters.Netblocks))
+
+	if c.config.ClusterASN {
+		asns, names := c.lookupASNs(clusters.Netblocks)
+		clusters.ASNs = netblocks.Group(hostIPs, func(ip string) string {
+			return asns[netblocks.Prefix(ip)]
+		})
+		for _, cluster := range clusters.ASNs {
+			cluster.Name = names[cluster.Key]
+		}
+		c.log().Info().Msgf("IP clusters: %d asns, %s\n", len(clusters.ASNs), clustersLine(clusters.ASNs))
+	}
+
+	data, err := json.MarshalIndent(clusters, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.config.IPClustersFile, data, 0644)
+}
+
+// lookupASNs returns the asn of each netblock, looked up for the first
+// ip of the netblock, and the names of the asns. The netblocks already
+// looked up during the run aren't looked up again.
+func (c *Client) lookupASNs(clusters []*netblocks.Cluster) (map[string]string, map[string]string) {
+	var mutex sync.Mutex
+	asns, names := c.asns, c.asnNames
+
+	var lookups int
+	wg := sizedwaitgroup.New(c.config.WildcardsThreads)
+	for _, cluster := range clusters {
+		mutex.Lock()
+		_, ok := asns[cluster.Key]
+		if !ok {
+			asns[cluster.Key] = ""
+		}
+		mutex.Unlock()
+		if ok {
+			continue
+		}
+		lookups++
+
+		wg.Add()
+		go func(cluster *netblocks.Cluster) {
+			defer wg.Done()
+
+			name, err := netblocks.OriginName(cluster.IPs[0])
+			if err != nil {
+				return
+			}
+			records, err := c.wildcardResolver.LookupTXT(name)
+			if err != nil || len(records) == 0 {
+				return
+			}
+			asn := netblocks.ParseOrigin(records[0])
+			if asn == "" {
+				return
+			}
+
+			mutex.Lock()
+			asns[cluster.Key] = asn
+			_, known := names[asn]
+			names[asn] = ""
+			mutex.Unlock()
+			if known {
+				return
+			}
+			if records, err := c.wildcardResolver.LookupTXT(netblocks.ASNName(asn)); err == nil && len(records) > 0 {
+				mutex.Lock()
+				names[asn] = netblocks.ParseASN(records[0])
+				mutex.Unlock()
+			}
+		}(cluster)
+	}
+	wg.Wait()
+
+	if lookups > 0 {
+		var found int
+		for _, cluster := range clusters {
+			if asns[cluster.Key] != "" {
+				found++
+			}
+		}
+		c.log().Info().Msgf("Found the asn of %d/%d netblocks\n", found, len(clusters))
+	}
+	return asns, names
+}
+
+// clustersLine returns the clusters concentrating the most hosts with
+// their number of hosts
+func clustersLine(clusters []*netblocks.Cluster) string {
+	if len(clusters) == 0 {
+		return "none"
+	}
+	var parts []string
+	for i, cluster := range clusters {
+		if i == clustersSummary {
+			break
+		}
+		key := cluster.Key
+		if cluster.Name != "" {
+			key += " " + cluster.Name
+		}
+		parts = append(parts, fmt.Sprintf("%s (%d hosts)", key, cluster.HostCount))
+	}
+	return "top " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,95 @@
+package massdns
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/wildcards"
+)
+
+// enumerateCNAMEDomains bruteforces the in-scope domains targeted by
+// the CNAMEs of the found hosts, following the CNAMEs of the newly
+// found hosts for at most CNAMEDepth levels.
+func (c *Client) enumerateCNAMEDomains(st *store.Store) error {
+	words, err := readWords(c.config.Wordlist)
+	if err != nil {
+		return err
+	}
+
+	enumerated := map[string]struct{}{c.config.Domain: {}}
+	for depth := 0; depth < c.config.CNAMEDepth; depth++ {
+		var domains []string
+		for hostname := range knownHostnames(st) {
+			meta := st.GetHost(hostname)
+			if meta == nil {
+				continue
+			}
+			for _, target := range meta.CNAME {
+				domain := c.config.Scope.Domain(target)
+				if _, ok := enumerated[domain]; ok || domain == "" {
+					continue
+				}
+				enumerated[domain] = struct{}{}
+				domains = append(domains, domain)
+			}
+		}
+		if len(domains) == 0 {
+			break
+		}
+		sort.Strings(domains)
+
+		var names []string
+		for _, domain := range domains {
+			c.domainResolvers[domain] = c.wildcardResolver.ForDomain(domain)
+
+			names = append(names, domain)
+			for _, word := range words {
+				names = append(names, word+"."+domain)
+			}
+		}
+
+		c.log().Info().Msgf("Enumerating %d in-scope domains targeted by cnames: %s\n", len(domains), strings.Join(domains, ", "))
+		if err := c.resolveAdditional(names, st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolverFor returns the wildcard resolver for the domain of a host
+func (c *Client) resolverFor(host string) *wildcards.Resolver {
+	var found string
+	for domain := range c.domainResolvers {
+		if strings.HasSuffix(host, "."+domain) && len(domain) > len(found) {
+			found = domain
+		}
+	}
+	if found == "" {
+		return c.wildcardResolver
+	}
+	return c.domainResolvers[found]
+}
+
+// readWords reads the words of a bruteforce wordlist
+func readWords(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// RFC4343 - case insensitive domain
+		word := strings.ToLower(scanner.Text())
+		if word == "" {
+			continue
+		}
+		words = append(words, word)
+	}
+	return words, scanner.Err()
+}
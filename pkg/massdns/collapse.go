@@ -0,0 +1,91 @@
+package massdns
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/cdn"
+)
+
+// cdnGroup is a group of hostnames resolving to the same CDN
+// ips through the same CNAME target.
+type cdnGroup struct {
+	// ID identifies the group in json output
+	ID string
+	// Provider is the CDN provider of the group
+	Provider string
+	// Representative is the hostname representing the group
+	Representative string
+	// Size is the number of hostnames in the group
+	Size int
+}
+
+// groupCDNHosts groups the hostnames resolving to a CDN by their
+// CNAME target and ip set. Only hostnames belonging to groups of
+// more than one hostname are returned along with their group.
+func (c *Client) groupCDNHosts(st *store.Store, hostnames []string, hostIPs map[string][]string) map[string]*cdnGroup {
+	groups := make(map[string]*cdnGroup)
+	hostKeys := make(map[string]string)
+
+	for _, hostname := range hostnames {
+		ips := hostIPs[hostname]
+		provider := c.cdnProvider(ips)
+		if provider == cdn.None {
+			continue
+		}
+
+		var target string
+		if meta := st.GetHost(hostname); meta != nil && len(meta.CNAME) > 0 {
+			target = meta.CNAME[len(meta.CNAME)-1]
+		}
+		sorted := make([]string, len(ips))
+		copy(sorted, ips)
+		sort.Strings(sorted)
+		key := target + "|" + strings.Join(sorted, ",")
+		hostKeys[hostname] = key
+
+		group, ok := groups[key]
+		if !ok {
+			sum := sha1.Sum([]byte(key))
+			group = &cdnGroup{ID: hex.EncodeToString(sum[:])[:12], Provider: provider, Representative: hostname}
+			groups[key] = group
+		}
+		group.Size++
+		if hostname < group.Representative {
+			group.Representative = hostname
+		}
+	}
+
+	byHostname := make(map[string]*cdnGroup)
+	for hostname, key := range hostKeys {
+		if group := groups[key]; group.Size > 1 {
+			byHostname[hostname] = group
+		}
+	}
+	return byHostname
+}
+
+// logCDNGroups logs the number of hostnames collapsed into cdn groups,
+// and each group with its representative with -v, the plain output
+// only containing the representatives.
+func (c *Client) logCDNGroups(groups map[string]*cdnGroup) {
+	if len(groups) == 0 {
+		return
+	}
+	var representatives []*cdnGroup
+	for hostname, group := range groups {
+		if hostname == group.Representative {
+			representatives = append(representatives, group)
+		}
+	}
+	sort.Slice(representatives, func(i, j int) bool {
+		return representatives[i].Representative < representatives[j].Representative
+	})
+	for _, group := range representatives {
+		c.log().Verbose().Msgf("%s represents %d hosts of %s (cdn group %s)\n", group.Representative, group.Size, group.Provider, group.ID)
+	}
+	c.log().Info().Msgf("Collapsed %d hosts into %d cdn groups\n", len(groups), len(representatives))
+}
@@ -0,0 +1,140 @@
+package massdns
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/extsort"
+	"github.com/rs/xid"
+)
+
+// positionWidth is the width of the zero padded positions of the names
+// deduplicated on disk, sorting them in numeric order
+const positionWidth = 20
+
+// dedupEnabled returns true if the duplicate names are skipped with
+// their hashes kept in memory
+func (c *Client) dedupEnabled() bool {
+	return !c.config.NoDedup && !c.config.DiskDedup
+}
+
+// newQuery returns true if a name wasn't resolved yet during the run,
+// recording it as resolved, and counts it as a duplicate otherwise.
+func (c *Client) newQuery(name string) bool {
+	if !c.dedupEnabled() {
+		return true
+	}
+	if _, ok := c.expanded[hashHostname(name)]; ok || !c.queried.Add(name) {
+		c.duplicateNames++
+		return false
+	}
+	return true
+}
+
+// uniqueNames returns the names not already in a set, adding them to
+// it. The canaries and known answer checks are always kept since the
+// checks are interleaved several times on purpose.
+func (c *Client) uniqueNames(names []string, seen hostnameSet) []string {
+	unique := names[:0]
+	for _, name := range names {
+		_, canary := c.canaries[name]
+		_, known := c.config.KnownAnswers[name]
+		if canary || known || seen.Add(name) {
+			unique = append(unique, name)
+			continue
+		}
+		c.duplicateNames++
+	}
+	return unique
+}
+
+// expandUnique returns the names to resolve for a line of the input
+// skipping the variations already resolved, which happens when the
+// variations of different names collide (e.g. dev-api from api with
+// the dev prefix and from the input itself).
+func (c *Client) expandUnique(line string) []string {
+	names := c.expandName(line)
+	if c.config.Mutator == nil || !c.dedupEnabled() {
+		return names
+	}
+	return c.uniqueNames(names, c.expanded)
+}
+
+// reportDuplicates logs the number of duplicate names not resolved
+func (c *Client) reportDuplicates() {
+	if c.duplicateNames > 0 {
+		c.log().Info().Msgf("Skipped %d duplicate names\n", c.duplicateNames)
+	}
+}
+
+// dedupOnDisk writes a copy of the input file without the duplicate
+// names, keeping the first occurrences in their order, and returns the
+// path of the new input file. The names are sorted along with their
+// position to find the duplicates, then sorted back to their position.
+func (c *Client) dedupOnDisk(inputFile string) (string, error) {
+	byName := extsort.New(c.config.TempDir, c.config.SortChunkSize, func(line string) string {
+		return line[:strings.IndexByte(line, '\t')]
+	})
+	byName.Encrypt(c.config.TempKey)
+	defer byName.Close()
+
+	input, err := c.config.TempKey.Open(inputFile)
+	if err != nil {
+		return "", err
+	}
+	defer input.Close()
+
+	// The first occurrence of a name is sorted first by its position
+	var position int
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		if err := byName.Add(fmt.Sprintf("%s\t%0*d", scanner.Text(), positionWidth, position)); err != nil {
+			return "", err
+		}
+		position++
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	byPosition := extsort.New(c.config.TempDir, c.config.SortChunkSize, func(line string) string {
+		return line[:positionWidth]
+	})
+	byPosition.Encrypt(c.config.TempKey)
+	defer byPosition.Close()
+
+	// The names are never empty, so the first one differs from last
+	var last string
+	err = byName.Sort(func(line string) error {
+		tab := strings.IndexByte(line, '\t')
+		name := line[:tab]
+		if name == last {
+			c.duplicateNames++
+			return nil
+		}
+		last = name
+		return byPosition.Add(line[tab+1:] + "\t" + name)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	uniqueFile := filepath.Join(c.config.TempDir, xid.New().String())
+	output, err := c.config.TempKey.Create(uniqueFile)
+	if err != nil {
+		return "", err
+	}
+	defer output.Close()
+
+	w := bufio.NewWriter(output)
+	err = byPosition.Sort(func(line string) error {
+		_, err := w.WriteString(line[positionWidth+1:] + "\n")
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return uniqueFile, w.Flush()
+}
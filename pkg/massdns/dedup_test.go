@@ -0,0 +1,67 @@
+package massdns
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/internal/benchdata"
+	"github.com/mohammadanaraki/shuffledns/pkg/mutations"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandUnique(t *testing.T) {
+	c := &Client{
+		config: Config{
+			Mutator:      mutations.New("dev", "", "-"),
+			KnownAnswers: map[string][]string{"known.example.org": {"1.1.1.1"}},
+		},
+		queried:      make(hostnameSet),
+		expanded:     make(hostnameSet),
+		invalidNames: make(map[string]int),
+	}
+
+	require.Equal(t, []string{"api.example.com", "dev-api.example.com"}, c.expandUnique("api.example.com"), "Could not expand a name")
+	require.Equal(t, []string{"dev-dev-api.example.com"}, c.expandUnique("dev-api.example.com"), "Could not skip a colliding variation")
+	require.Equal(t, []string{"known.example.org"}, c.expandUnique("known.example.org"), "Could not keep a known answer check")
+	require.Equal(t, []string{"known.example.org"}, c.expandUnique("known.example.org"), "Could not keep a repeated known answer check")
+	require.Equal(t, 1, c.duplicateNames, "Could not count the duplicates")
+
+	require.False(t, c.newQuery("dev-api.example.com"), "Could not skip an expanded name in later rounds")
+	require.True(t, c.newQuery("new.example.com"), "Could not accept a new name")
+	require.False(t, c.newQuery("NEW.example.com."), "Could not skip a duplicate in another form")
+	require.Equal(t, 3, c.duplicateNames, "Could not count the duplicates")
+}
+
+func TestDedupOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	c := &Client{config: Config{TempDir: dir, DiskDedup: true, SortChunkSize: 16}}
+
+	input := filepath.Join(dir, "input")
+	require.Nil(t, os.WriteFile(input, []byte("www.example.com\napi.example.com\nwww.example.com\nmail.example.com\napi.example.com\n"), 0644))
+	output, err := c.dedupOnDisk(input)
+	require.Nil(t, err, "Could not deduplicate input on disk")
+	data, err := os.ReadFile(output)
+	require.Nil(t, err, "Could not read deduplicated input")
+	require.Equal(t, "www.example.com\napi.example.com\nmail.example.com\n", string(data), "Could not keep the first occurrences in order")
+	require.Equal(t, 2, c.duplicateNames, "Could not count the duplicates")
+	require.True(t, c.newQuery("www.example.com"), "Could keep hashes in memory")
+}
+
+func BenchmarkExpandUnique(b *testing.B) {
+	hostnames := benchdata.Hostnames(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c := &Client{
+			config:       Config{Mutator: mutations.New("dev,stg", "01", "-")},
+			queried:      make(hostnameSet),
+			expanded:     make(hostnameSet),
+			invalidNames: make(map[string]int),
+		}
+		for _, hostname := range hostnames {
+			for _, name := range c.expandUnique(hostname) {
+				c.newQuery(name)
+			}
+		}
+	}
+}
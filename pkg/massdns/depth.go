@@ -0,0 +1,77 @@
+package massdns
+
+import (
+	"bufio"
+	"path/filepath"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/rs/xid"
+)
+
+// depthLimited returns true if the names are filtered on their depth
+func (c *Client) depthLimited() bool {
+	return c.config.MinDepth > 0 || c.config.MaxDepth > 0
+}
+
+// inDepth returns true if the number of labels of a name below its
+// registered domain is within the depth limits.
+func (c *Client) inDepth(name string) bool {
+	depth := dnsname.Depth(name, c.config.Domain)
+	if c.config.MinDepth > 0 && depth < c.config.MinDepth {
+		return false
+	}
+	return c.config.MaxDepth <= 0 || depth <= c.config.MaxDepth
+}
+
+// filterDepthInput writes a copy of the input file without the names
+// outside of the depth limits, returning the path of the new input file
+// and the number of names dropped.
+func (c *Client) filterDepthInput(inputFile string) (string, int, error) {
+	depthFile := filepath.Join(c.config.TempDir, xid.New().String())
+
+	output, err := c.config.TempKey.Create(depthFile)
+	if err != nil {
+		return "", 0, err
+	}
+	defer output.Close()
+
+	input, err := c.config.TempKey.Open(inputFile)
+	if err != nil {
+		return "", 0, err
+	}
+	defer input.Close()
+
+	dropped := 0
+	w := bufio.NewWriter(output)
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" {
+			continue
+		}
+		if !c.inDepth(name) {
+			dropped++
+			continue
+		}
+		_, _ = w.WriteString(name + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", 0, err
+	}
+	return depthFile, dropped, w.Flush()
+}
+
+// filterDepthResults removes the hostnames outside of the depth limits
+// from the store, returning the number of hostnames dropped.
+func (c *Client) filterDepthResults(st *store.Store) int {
+	var dropped int
+	for hostname := range knownHostnames(st) {
+		if !c.inDepth(hostname) {
+			removeHostname(st, hostname)
+			dropped++
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,128 @@
+package massdns
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diagnosticClass is a known class of massdns diagnostics
+type diagnosticClass struct {
+	name     string
+	patterns []string
+	hint     string
+	// fatal diagnostics are logged as errors, the others as warnings
+	fatal bool
+}
+
+// diagnosticClasses are the known classes of massdns stderr lines
+var diagnosticClasses = []diagnosticClass{
+	{
+		name:     "permission",
+		patterns: []string{"permission denied", "operation not permitted"},
+		hint:     "check the permissions of the massdns binary and of the input, output and resolvers files",
+		fatal:    true,
+	},
+	{
+		name:     "file-limit",
+		patterns: []string{"too many open files"},
+		hint:     "raise the open files limit (ulimit -n or -raise-fd-limit) or lower the number of concurrent resolves (-t)",
+		fatal:    true,
+	},
+	{
+		name:     "resolvers",
+		patterns: []string{"resolvers exhausted", "no resolvers", "resolver file", "no valid nameservers"},
+		hint:     "check that the resolvers file (-r) exists and contains valid ip addresses",
+		fatal:    true,
+	},
+	{
+		name:     "memory",
+		patterns: []string{"cannot allocate memory", "out of memory"},
+		hint:     "lower the number of concurrent resolves (-t)",
+		fatal:    true,
+	},
+	{
+		name:     "socket",
+		patterns: []string{"socket", "sendto", "network is unreachable", "cannot assign requested address", "no buffer space available"},
+		hint:     "check the network connectivity and lower the query rate (-t or -max-bandwidth)",
+	},
+	{
+		name:     "usage",
+		patterns: []string{"unknown argument", "invalid argument", "usage:"},
+		hint:     "check that the massdns binary is recent enough for shuffledns",
+		fatal:    true,
+	},
+}
+
+// diagnostic is a class of diagnostics found in massdns stderr
+type diagnostic struct {
+	class *diagnosticClass
+	count int
+	// example is the first line of the class found
+	example string
+}
+
+// classifyDiagnostics classifies the lines of massdns stderr, logging
+// each class found with its remediation hint. Lines of unknown class,
+// like the progress statistics, are ignored.
+func (c *Client) classifyDiagnostics(stderr string) {
+	found := make(map[string]*diagnostic)
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		class := classifyLine(line)
+		if class == nil {
+			continue
+		}
+		d, ok := found[class.name]
+		if !ok {
+			d = &diagnostic{class: class, example: line}
+			found[class.name] = d
+		}
+		d.count++
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d := found[name]
+		if d.class.fatal {
+			c.log().Error().Msgf("Massdns %s error (%d lines, e.g. %q): %s\n", name, d.count, d.example, d.class.hint)
+		} else {
+			c.log().Info().Msgf("Massdns %s warning (%d lines, e.g. %q): %s\n", name, d.count, d.example, d.class.hint)
+		}
+		c.diagnostics[name] += d.count
+	}
+}
+
+// classifyLine returns the class of a massdns stderr line, or nil if
+// the line doesn't belong to a known class.
+func classifyLine(line string) *diagnosticClass {
+	line = strings.ToLower(line)
+	for i := range diagnosticClasses {
+		for _, pattern := range diagnosticClasses[i].patterns {
+			if strings.Contains(line, pattern) {
+				return &diagnosticClasses[i]
+			}
+		}
+	}
+	return nil
+}
+
+// reportDiagnostics logs the summary of the massdns diagnostics
+func (c *Client) reportDiagnostics() {
+	if len(c.diagnostics) == 0 {
+		return
+	}
+	var parts []string
+	for name, count := range c.diagnostics {
+		parts = append(parts, fmt.Sprintf("%s: %d", name, count))
+	}
+	sort.Strings(parts)
+	c.log().Info().Msgf("Massdns diagnostics: %s\n", strings.Join(parts, ", "))
+}
@@ -0,0 +1,15 @@
+package massdns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyDiagnostics(t *testing.T) {
+	client := &Client{diagnostics: make(map[string]int)}
+	client.classifyDiagnostics("Processed queries: 100\nsendto: Network is unreachable\nsendto: Network is unreachable\nFailed to open resolver file: Permission denied\nNo resolvers given.\n")
+
+	require.Equal(t, map[string]int{"socket": 2, "permission": 1, "resolvers": 1}, client.diagnostics, "Could not classify diagnostics")
+	require.Nil(t, classifyLine("Processed queries: 100"), "Could not ignore statistics")
+}
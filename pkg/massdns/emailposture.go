@@ -0,0 +1,67 @@
+package massdns
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/mohammadanaraki/shuffledns/pkg/emailsec"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// apexDomains returns the registered domains of the target domain, of
+// the scope domains and of the additional domains enumerated.
+func (c *Client) apexDomains() []string {
+	candidates := []string{c.config.Domain}
+	if c.config.Scope != nil {
+		candidates = append(candidates, c.config.Scope.Domains...)
+	}
+	for domain := range c.domainResolvers {
+		candidates = append(candidates, domain)
+	}
+
+	seen := make(map[string]struct{})
+	var apexes []string
+	for _, candidate := range candidates {
+		apex := dnsname.RegisteredDomain(candidate)
+		if _, ok := seen[apex]; ok || apex == "" {
+			continue
+		}
+		seen[apex] = struct{}{}
+		apexes = append(apexes, apex)
+	}
+	sort.Strings(apexes)
+	return apexes
+}
+
+// collectEmailPostures collects the email security posture of the apex
+// domains with the trusted resolvers and logs their issues.
+func (c *Client) collectEmailPostures() {
+	selectors := c.config.DKIMSelectors
+	if len(selectors) == 0 {
+		selectors = emailsec.DefaultSelectors
+	}
+
+	apexes := c.apexDomains()
+	postures := make([]*emailsec.Posture, len(apexes))
+
+	wg := sizedwaitgroup.New(c.config.WildcardsThreads)
+	for i, apex := range apexes {
+		wg.Add()
+		go func(i int, apex string) {
+			defer wg.Done()
+
+			postures[i] = emailsec.Collect(apex, selectors, c.wildcardResolver.LookupTXT)
+		}(i, apex)
+	}
+	wg.Wait()
+
+	for _, posture := range postures {
+		if len(posture.Issues) == 0 {
+			c.log().Info().Msgf("Email security of %s: no issue found\n", posture.Domain)
+			continue
+		}
+		c.log().Info().Msgf("Email security of %s: %s\n", posture.Domain, strings.Join(posture.Issues, ", "))
+	}
+	c.emailPostures = postures
+}
@@ -0,0 +1,58 @@
+package massdns
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/export"
+)
+
+// writeExports writes the results to the export files requested by
+// the user, in the order they were written to the output.
+func (c *Client) writeExports(hostnames []string, hostIPs map[string][]string) error {
+	exports := []struct {
+		file  string
+		write func(io.Writer, []export.Host) error
+	}{
+		{c.config.HostsFile, export.WriteHosts},
+		{c.config.URLsFile, export.WriteURLs},
+		{c.config.BurpScopeFile, export.WriteBurpScope},
+		{c.config.TargetsFile, func(w io.Writer, hosts []export.Host) error {
+			return export.WriteTargets(w, hosts, c.config.TargetsHostnames)
+		}},
+		{c.config.IPMapFile, export.WriteIPMap},
+	}
+	if strings.EqualFold(filepath.Ext(c.config.IPMapFile), ".csv") {
+		exports[len(exports)-1].write = export.WriteIPMapCSV
+	}
+
+	hosts := make([]export.Host, 0, len(hostnames))
+	for _, hostname := range hostnames {
+		hosts = append(hosts, export.Host{Name: hostname, IPs: hostIPs[hostname]})
+	}
+	for _, e := range exports {
+		if e.file == "" {
+			continue
+		}
+		if err := writeExport(e.file, hosts, e.write); err != nil {
+			return fmt.Errorf("could not write %s: %w", e.file, err)
+		}
+	}
+	return nil
+}
+
+// writeExport creates a file and writes the hosts to it
+func writeExport(file string, hosts []export.Host, write func(io.Writer, []export.Host) error) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := write(f, hosts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
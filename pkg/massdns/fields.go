@@ -0,0 +1,226 @@
+package massdns
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/cdn"
+)
+
+// Output fields that can be selected for the json output
+const (
+	FieldHost     = "host"
+	FieldIP       = "ip"
+	FieldCNAME    = "cname"
+	FieldResolver = "resolver"
+	FieldCDN      = "cdn"
+	FieldVendor   = "vendor"
+	// FieldRunID and FieldConfigHash identify the run a record comes from
+	FieldRunID      = "run_id"
+	FieldConfigHash = "config_hash"
+	// FieldTimestamp is the time the record was written, which
+	// decides the freshest record when merging outputs
+	FieldTimestamp = "timestamp"
+)
+
+// DefaultFields are the fields written when none are specified
+var DefaultFields = []string{FieldHost}
+
+// availableFields contains all the fields supported in output
+var availableFields = map[string]struct{}{
+	FieldHost:       {},
+	FieldIP:         {},
+	FieldCNAME:      {},
+	FieldResolver:   {},
+	FieldCDN:        {},
+	FieldVendor:     {},
+	FieldRunID:      {},
+	FieldConfigHash: {},
+	FieldTimestamp:  {},
+}
+
+// ParseFields parses a comma separated list of output fields
+func ParseFields(value string) ([]string, error) {
+	if value == "" {
+		return DefaultFields, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if _, ok := availableFields[field]; !ok {
+			return nil, fmt.Errorf("unknown output field: %s", field)
+		}
+		fields = append(fields, field)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no output fields specified")
+	}
+	return fields, nil
+}
+
+// jsonRecord builds the json output record for a hostname
+// containing only the fields selected by the user.
+func (c *Client) jsonRecord(st *store.Store, hostname string, ips []string) map[string]interface{} {
+	fields := c.config.Fields
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+
+	record := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		switch field {
+		case FieldHost:
+			record["hostname"] = hostname
+		case FieldIP:
+			record["ip"] = ips
+		case FieldCNAME:
+			if meta := st.GetHost(hostname); meta != nil && len(meta.CNAME) > 0 {
+				record["cname"] = meta.CNAME
+			}
+		case FieldResolver:
+			if meta := st.GetHost(hostname); meta != nil && meta.Resolver != "" {
+				record["resolver"] = meta.Resolver
+			}
+		case FieldCDN:
+			record["cdn"] = c.cdnProvider(ips)
+		case FieldVendor:
+			if meta := st.GetHost(hostname); meta != nil && c.config.Vendors != nil {
+				if vendor := c.config.Vendors.MatchChain(meta.CNAME); vendor != "" {
+					record["vendor"] = vendor
+				}
+			}
+		case FieldRunID:
+			if c.config.RunID != "" {
+				record["run_id"] = c.config.RunID
+			}
+		case FieldConfigHash:
+			if c.config.ConfigHash != "" {
+				record["config_hash"] = c.config.ConfigHash
+			}
+		case FieldTimestamp:
+			record["timestamp"] = c.outputTime.UTC().Format(time.RFC3339)
+		}
+	}
+	if c.config.PTREnrich {
+		if names := c.ptrRecords(ips); len(names) > 0 {
+			record["ptr"] = names
+		}
+	}
+	if records, ok := c.anyRecords[hostname]; ok {
+		record["records"] = records
+	}
+
+	// Flag the records resolving to a sinkhole ip
+	for _, ip := range ips {
+		if _, ok := c.sinkholeIPs[ip]; ok {
+			record["sinkhole"] = true
+			break
+		}
+	}
+
+	// Flag the records served by a domain-parking provider
+	if _, ok := c.parkedHosts[hostname]; ok {
+		record["parked"] = true
+	}
+
+	// Tag the records resolving to a private ip, which are leaked
+	// internal records
+	if hasPrivateIP(ips) {
+		record["private"] = true
+	}
+	return record
+}
+
+// csvColumns returns the columns of the csv output, the hostname
+// always being the first one so that the lines can be keyed by it.
+func (c *Client) csvColumns() []string {
+	columns := []string{FieldHost}
+	for _, field := range c.config.Fields {
+		if field != FieldHost {
+			columns = append(columns, field)
+		}
+	}
+	return columns
+}
+
+// csvLine formats the values as a csv line terminated by a newline
+func csvLine(values []string) (string, error) {
+	var buffer bytes.Buffer
+	w := csv.NewWriter(&buffer)
+	if err := w.Write(values); err != nil {
+		return "", err
+	}
+	w.Flush()
+	return buffer.String(), w.Error()
+}
+
+// csvRecord builds the csv output line for a hostname with a value
+// for each of the columns. The ips and cnames are separated by a space.
+func (c *Client) csvRecord(st *store.Store, hostname string, ips []string) (string, error) {
+	meta := st.GetHost(hostname)
+
+	var values []string
+	for _, column := range c.csvColumns() {
+		var value string
+		switch column {
+		case FieldHost:
+			value = hostname
+		case FieldIP:
+			value = strings.Join(ips, " ")
+		case FieldCNAME:
+			if meta != nil {
+				value = strings.Join(meta.CNAME, " ")
+			}
+		case FieldResolver:
+			if meta != nil {
+				value = meta.Resolver
+			}
+		case FieldCDN:
+			value = c.cdnProvider(ips)
+		case FieldVendor:
+			if meta != nil && c.config.Vendors != nil {
+				value = c.config.Vendors.MatchChain(meta.CNAME)
+			}
+		case FieldRunID:
+			value = c.config.RunID
+		case FieldConfigHash:
+			value = c.config.ConfigHash
+		case FieldTimestamp:
+			value = c.outputTime.UTC().Format(time.RFC3339)
+		}
+		values = append(values, value)
+	}
+	return csvLine(values)
+}
+
+// hasField returns true if a field was selected for output
+func (c *Client) hasField(field string) bool {
+	for _, f := range c.config.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// cdnProvider returns the CDN provider of the first ip belonging
+// to a CDN or cdn.None if none of them does.
+func (c *Client) cdnProvider(ips []string) string {
+	if c.config.CDN == nil {
+		return cdn.None
+	}
+	for _, ip := range ips {
+		if provider := c.config.CDN.Check(ip); provider != cdn.None {
+			return provider
+		}
+	}
+	return cdn.None
+}
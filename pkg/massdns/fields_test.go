@@ -0,0 +1,48 @@
+package massdns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVRecord(t *testing.T) {
+	st := store.New()
+	defer st.Close()
+	st.SetHost("www.example.com", &store.HostMeta{CNAME: []string{"a.cdn.net", "b.cdn.net"}, Resolver: "1.1.1.1:53"})
+
+	c := &Client{config: Config{CSV: true, Fields: []string{FieldIP, FieldHost, FieldCNAME, FieldResolver}}}
+	header, err := csvLine(c.csvColumns())
+	require.Nil(t, err, "Could not format csv header")
+	require.Equal(t, "host,ip,cname,resolver\n", header, "Could not put hostname column first")
+
+	line, err := c.csvRecord(st, "www.example.com", []string{"1.2.3.4", "5.6.7.8"})
+	require.Nil(t, err, "Could not format csv line")
+	require.Equal(t, "www.example.com,1.2.3.4 5.6.7.8,a.cdn.net b.cdn.net,1.1.1.1:53\n", line, "Could not get csv line")
+	require.Equal(t, "www.example.com", lineHostname(line), "Could not get hostname of csv line")
+
+	line, err = c.csvRecord(st, "api.example.com", []string{"1.2.3.4"})
+	require.Nil(t, err, "Could not format csv line")
+	require.Equal(t, "api.example.com,1.2.3.4,,\n", line, "Could not get csv line without metadata")
+}
+
+func TestJSONRecordRunFields(t *testing.T) {
+	st := store.New()
+	defer st.Close()
+
+	c := &Client{config: Config{Fields: []string{FieldHost}, RunID: "run", ConfigHash: "cff8742ecaf3"}}
+	record := c.jsonRecord(st, "www.example.com", []string{"1.2.3.4"})
+	require.Equal(t, map[string]interface{}{"hostname": "www.example.com"}, record, "Could not leave out the unselected run fields")
+
+	c.config.Fields = []string{FieldHost, FieldRunID, FieldConfigHash}
+	record = c.jsonRecord(st, "www.example.com", []string{"1.2.3.4"})
+	require.Equal(t, "run", record["run_id"], "Could not write the selected run id")
+	require.Equal(t, "cff8742ecaf3", record["config_hash"], "Could not write the selected config hash")
+
+	c.config.Fields = []string{FieldHost, FieldTimestamp}
+	c.outputTime = time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	record = c.jsonRecord(st, "www.example.com", []string{"1.2.3.4"})
+	require.Equal(t, "2022-06-01T10:00:00Z", record["timestamp"], "Could not write the timestamp")
+}
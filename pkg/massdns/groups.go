@@ -0,0 +1,89 @@
+package massdns
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+// domainGroup contains the json records of the hosts of a registered
+// domain along with the set of their ips
+type domainGroup struct {
+	hosts map[string]map[string]interface{}
+	ips   map[string]struct{}
+}
+
+// domainGroups groups the json records of the hosts per registered
+// domain for the grouped json output.
+type domainGroups map[string]*domainGroup
+
+// add adds the json record of a hostname to the group of its
+// registered domain
+func (g domainGroups) add(hostname string, record map[string]interface{}, ips []string) {
+	domain := dnsname.RegisteredDomain(hostname)
+	group, ok := g[domain]
+	if !ok {
+		group = &domainGroup{hosts: make(map[string]map[string]interface{}), ips: make(map[string]struct{})}
+		g[domain] = group
+	}
+	// The run is tagged once on the group
+	delete(record, "run_id")
+	delete(record, "config_hash")
+	group.hosts[hostname] = record
+	for _, ip := range ips {
+		group.ips[ip] = struct{}{}
+	}
+}
+
+// groupLines returns one json line per registered domain, ordered by
+// domain, with the records of its hosts ordered by hostname, along with
+// the hostnames of each line.
+func (c *Client) groupLines(groups domainGroups) ([]string, [][]string, error) {
+	domains := make([]string, 0, len(groups))
+	for domain := range groups {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	lines := make([]string, 0, len(domains))
+	lineHosts := make([][]string, 0, len(domains))
+	for _, domain := range domains {
+		group := groups[domain]
+
+		hostnames := make([]string, 0, len(group.hosts))
+		for hostname := range group.hosts {
+			hostnames = append(hostnames, hostname)
+		}
+		sort.Strings(hostnames)
+		hosts := make([]map[string]interface{}, len(hostnames))
+		for i, hostname := range hostnames {
+			hosts[i] = group.hosts[hostname]
+		}
+		ips := make([]string, 0, len(group.ips))
+		for ip := range group.ips {
+			ips = append(ips, ip)
+		}
+		sort.Strings(ips)
+
+		record := map[string]interface{}{
+			"domain":     domain,
+			"host_count": len(hosts),
+			"hosts":      hosts,
+			"ips":        ips,
+		}
+		if c.config.RunID != "" && c.hasField(FieldRunID) {
+			record["run_id"] = c.config.RunID
+		}
+		if c.config.ConfigHash != "" && c.hasField(FieldConfigHash) {
+			record["config_hash"] = c.config.ConfigHash
+		}
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, nil, err
+		}
+		lines = append(lines, string(data))
+		lineHosts = append(lineHosts, hostnames)
+	}
+	return lines, lineHosts, nil
+}
@@ -0,0 +1,23 @@
+package massdns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupLines(t *testing.T) {
+	c := &Client{config: Config{RunID: "run", ConfigHash: "cff8742ecaf3", Fields: []string{FieldHost, FieldRunID}}}
+	groups := make(domainGroups)
+	groups.add("www.example.co.uk", map[string]interface{}{"hostname": "www.example.co.uk", "run_id": "run"}, []string{"2.2.2.2"})
+	groups.add("api.example.co.uk", map[string]interface{}{"hostname": "api.example.co.uk"}, []string{"2.2.2.2", "1.1.1.1"})
+	groups.add("www.example.com", map[string]interface{}{"hostname": "www.example.com"}, []string{"3.3.3.3"})
+
+	lines, hosts, err := c.groupLines(groups)
+	require.Nil(t, err, "Could not group hosts")
+	require.Equal(t, []string{
+		`{"domain":"example.co.uk","host_count":2,"hosts":[{"hostname":"api.example.co.uk"},{"hostname":"www.example.co.uk"}],"ips":["1.1.1.1","2.2.2.2"],"run_id":"run"}`,
+		`{"domain":"example.com","host_count":1,"hosts":[{"hostname":"www.example.com"}],"ips":["3.3.3.3"],"run_id":"run"}`,
+	}, lines, "Could not get grouped lines")
+	require.Equal(t, [][]string{{"api.example.co.uk", "www.example.co.uk"}, {"www.example.com"}}, hosts, "Could not get hosts of grouped lines")
+}
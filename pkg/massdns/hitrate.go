@@ -0,0 +1,205 @@
+package massdns
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/rs/xid"
+)
+
+// DefaultHitRateWindow is the default number of names over which the
+// marginal hit rate of a domain is computed
+const DefaultHitRateWindow = 5000
+
+// hitRateSegment is the number of names of a domain resolved in a
+// segment and the number of them found
+type hitRateSegment struct {
+	names int
+	hits  int
+}
+
+// hitRateStats contains the recent segments of a domain
+type hitRateStats struct {
+	segments  []hitRateSegment
+	exhausted bool
+}
+
+// add records a segment and returns the hit rate over the most recent
+// segments covering at least window names, false if the domain didn't
+// have that many names resolved yet.
+func (s *hitRateStats) add(segment hitRateSegment, window int) (float64, bool) {
+	if segment.names > 0 {
+		s.segments = append(s.segments, segment)
+	}
+
+	var names, hits int
+	for i := len(s.segments) - 1; i >= 0; i-- {
+		names += s.segments[i].names
+		hits += s.segments[i].hits
+		if names >= window {
+			// The older segments are out of the window for good
+			s.segments = s.segments[i:]
+			return float64(hits) / float64(names), true
+		}
+	}
+	return 0, false
+}
+
+// hitRateDomain returns the domain whose hit rate a name counts for
+func (c *Client) hitRateDomain(name string) string {
+	if c.config.Domain != "" && underDomain(name, c.config.Domain) {
+		return c.config.Domain
+	}
+	return dnsname.RegisteredDomain(name)
+}
+
+// runHitRateSegments runs massdns on the input in segments of the size
+// of the hit rate window, the names of the domains whose marginal hit
+// rate dropped below the minimum being skipped in the next segments.
+// The outputs of the segments are appended to the output file.
+func (c *Client) runHitRateSegments(output string, st *store.Store) error {
+	window := c.config.HitRateWindow
+	if window <= 0 {
+		window = DefaultHitRateWindow
+	}
+
+	// The output exists even if no segment is resolved
+	if err := os.WriteFile(output, nil, 0644); err != nil {
+		return err
+	}
+
+	input, err := c.config.TempKey.Open(c.config.InputFile)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	// The names of the segments are already expanded
+	mainInputFile, mutator := c.config.InputFile, c.config.Mutator
+	defer func() {
+		c.config.InputFile, c.config.Mutator = mainInputFile, mutator
+	}()
+
+	stats := make(map[string]*hitRateStats)
+	scanner := bufio.NewScanner(input)
+	for {
+		segmentFile, names, done, err := c.writeHitRateSegment(scanner, stats, window)
+		if err != nil {
+			return err
+		}
+		if len(names) > 0 {
+			c.config.InputFile, c.config.Mutator = segmentFile, nil
+			segmentOutput := filepath.Join(c.config.TempDir, xid.New().String())
+			if err := c.runMassDNS(segmentOutput, st); err != nil {
+				return err
+			}
+			if err := c.updateHitRates(segmentOutput, names, stats, window); err != nil {
+				return err
+			}
+			if err := appendFile(c.config.TempKey, output, segmentOutput); err != nil {
+				return err
+			}
+			c.removeTemp(segmentOutput)
+		}
+		c.removeTemp(segmentFile)
+		if done {
+			break
+		}
+	}
+
+	var exhausted []string
+	for domain, stat := range stats {
+		if stat.exhausted {
+			exhausted = append(exhausted, domain)
+		}
+	}
+	if c.hitRateSkipped > 0 {
+		sort.Strings(exhausted)
+		c.log().Info().Msgf("Hit rate: skipped %d names of %s after their hit rate dropped below %.2f%%\n", c.hitRateSkipped, strings.Join(exhausted, ", "), c.config.MinHitRate*100)
+	}
+	return nil
+}
+
+// writeHitRateSegment writes the next window names of the input, which
+// don't belong to exhausted domains, to a segment file. It returns the
+// segment names with their domain and whether the input is over.
+func (c *Client) writeHitRateSegment(scanner *bufio.Scanner, stats map[string]*hitRateStats, window int) (string, map[string]string, bool, error) {
+	segmentFile := filepath.Join(c.config.TempDir, xid.New().String())
+	file, err := c.config.TempKey.Create(segmentFile)
+	if err != nil {
+		return "", nil, false, err
+	}
+	defer file.Close()
+
+	names := make(map[string]string)
+	w := bufio.NewWriter(file)
+	for len(names) < window {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", nil, false, err
+			}
+			return segmentFile, names, true, w.Flush()
+		}
+		for _, name := range c.expandUnique(scanner.Text()) {
+			if name == "" {
+				continue
+			}
+			// The canaries and known answer checks are always resolved
+			// and don't count for the hit rates
+			_, canary := c.canaries[name]
+			_, known := c.config.KnownAnswers[name]
+			if !canary && !known {
+				domain := c.hitRateDomain(name)
+				if stat, ok := stats[domain]; ok && stat.exhausted {
+					c.hitRateSkipped++
+					continue
+				}
+				names[dnsname.Normalize(name)] = domain
+			}
+			_, _ = w.WriteString(name + "\n")
+		}
+	}
+	return segmentFile, names, false, w.Flush()
+}
+
+// updateHitRates counts the names of a segment found per domain and
+// marks the domains whose marginal hit rate is below the minimum as
+// exhausted.
+func (c *Client) updateHitRates(output string, names map[string]string, stats map[string]*hitRateStats, window int) error {
+	answered, err := answeredNames(c.config.TempKey, output)
+	if err != nil {
+		return err
+	}
+
+	segments := make(map[string]*hitRateSegment)
+	for name, domain := range names {
+		segment, ok := segments[domain]
+		if !ok {
+			segment = &hitRateSegment{}
+			segments[domain] = segment
+		}
+		segment.names++
+		if _, ok := answered[name]; ok {
+			segment.hits++
+		}
+	}
+
+	for domain, segment := range segments {
+		stat, ok := stats[domain]
+		if !ok {
+			stat = &hitRateStats{}
+			stats[domain] = stat
+		}
+		rate, full := stat.add(*segment, window)
+		if full && rate < c.config.MinHitRate {
+			stat.exhausted = true
+			c.log().Info().Msgf("Hit rate of %s dropped to %.2f%% over its recent names, skipping its remaining names\n", domain, rate*100)
+		}
+	}
+	return nil
+}
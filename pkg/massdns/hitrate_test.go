@@ -0,0 +1,23 @@
+package massdns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHitRateStats(t *testing.T) {
+	stats := &hitRateStats{}
+
+	_, full := stats.add(hitRateSegment{names: 4, hits: 2}, 10)
+	require.False(t, full, "Could not wait for a full window")
+
+	rate, full := stats.add(hitRateSegment{names: 6, hits: 1}, 10)
+	require.True(t, full, "Could not fill the window")
+	require.Equal(t, 0.3, rate, "Could not compute the hit rate over the window")
+
+	rate, full = stats.add(hitRateSegment{names: 10, hits: 0}, 10)
+	require.True(t, full, "Could not slide the window")
+	require.Equal(t, 0.0, rate, "Could not compute the marginal hit rate")
+	require.Len(t, stats.segments, 1, "Could not drop the segments out of the window")
+}
@@ -0,0 +1,93 @@
+package massdns
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/rs/xid"
+)
+
+// validName returns true if a name can exist in the dns, counting the
+// invalid names per reason otherwise.
+func (c *Client) validName(name string) bool {
+	reason := dnsname.Validate(name)
+	if reason == "" {
+		return true
+	}
+	c.invalidNames[reason]++
+	return false
+}
+
+// pluginCandidate returns true if the plugins accept a candidate name,
+// counting the names filtered otherwise.
+func (c *Client) pluginCandidate(name string) bool {
+	if c.config.Plugins.FilterCandidate(name) {
+		return true
+	}
+	c.pluginDropped++
+	return false
+}
+
+// filterInvalidInput writes a copy of the input file with the names in
+// their canonical form and without the invalid or duplicate ones,
+// returning the path of the new input file.
+func (c *Client) filterInvalidInput(inputFile string) (string, error) {
+	validFile := filepath.Join(c.config.TempDir, xid.New().String())
+
+	output, err := c.config.TempKey.Create(validFile)
+	if err != nil {
+		return "", err
+	}
+	defer output.Close()
+
+	input, err := c.config.TempKey.Open(inputFile)
+	if err != nil {
+		return "", err
+	}
+	defer input.Close()
+
+	w := bufio.NewWriter(output)
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		name := dnsname.Normalize(scanner.Text())
+		if name == "" || !c.validName(name) || !c.pluginCandidate(name) || !c.newQuery(name) {
+			continue
+		}
+		_, _ = w.WriteString(name + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+	if c.config.DiskDedup && !c.config.NoDedup {
+		return c.dedupOnDisk(validFile)
+	}
+	return validFile, nil
+}
+
+// reportInvalidNames logs the number of invalid names skipped per reason
+func (c *Client) reportInvalidNames() {
+	if len(c.invalidNames) == 0 {
+		return
+	}
+
+	reasons := make([]string, 0, len(c.invalidNames))
+	var total int
+	for reason, count := range c.invalidNames {
+		reasons = append(reasons, reason)
+		total += count
+	}
+	sort.Strings(reasons)
+
+	counts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		counts[i] = fmt.Sprintf("%d %s", c.invalidNames[reason], reason)
+	}
+	c.log().Info().Msgf("Skipped %d invalid names: %s\n", total, strings.Join(counts, ", "))
+}
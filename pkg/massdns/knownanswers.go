@@ -0,0 +1,150 @@
+package massdns
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/mohammadanaraki/shuffledns/pkg/parser"
+	"github.com/rs/xid"
+)
+
+// DefaultKnownAnswers contains names with well-known and stable
+// answers used to detect resolvers returning wrong answers.
+var DefaultKnownAnswers = map[string][]string{
+	"one.one.one.one": {"1.1.1.1", "1.0.0.1"},
+	"dns.google":      {"8.8.8.8", "8.8.4.4"},
+	"dns.quad9.net":   {"9.9.9.9", "149.112.112.112"},
+}
+
+// ReadKnownAnswers reads known answers from a file where each line
+// contains a name followed by its comma separated ips.
+func ReadKnownAnswers(file string) (map[string][]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	knownAnswers := make(map[string][]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) == 0 || strings.HasPrefix(parts[0], "#") {
+			continue
+		}
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid known answer line: %s", scanner.Text())
+		}
+		knownAnswers[dnsname.Normalize(parts[0])] = strings.Split(parts[1], ",")
+	}
+	return knownAnswers, scanner.Err()
+}
+
+// knownAnswersEnabled returns true if known answer checks are enabled
+func (c *Client) knownAnswersEnabled() bool {
+	return c.config.KnownAnswersEvery > 0 && len(c.config.KnownAnswers) > 0
+}
+
+// knownAnswerStats contains the known answer checks of a resolver
+type knownAnswerStats struct {
+	checks int
+	wrong  int
+}
+
+// addKnownAnswers writes a copy of the input file interleaving a
+// known answer name every KnownAnswersEvery names, returning the
+// path of the new input file. The known answer names already in the
+// input are remembered, so that their results aren't skipped.
+func (c *Client) addKnownAnswers(inputFile string) (string, error) {
+	names := make([]string, 0, len(c.config.KnownAnswers))
+	for name := range c.config.KnownAnswers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	knownFile := filepath.Join(c.config.TempDir, xid.New().String())
+	output, err := c.config.TempKey.Create(knownFile)
+	if err != nil {
+		return "", err
+	}
+	defer output.Close()
+
+	input, err := c.config.TempKey.Open(inputFile)
+	if err != nil {
+		return "", err
+	}
+	defer input.Close()
+
+	w := bufio.NewWriter(output)
+	var lines, next int
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		if lines%c.config.KnownAnswersEvery == 0 {
+			_, _ = w.WriteString(names[next%len(names)] + "\n")
+			next++
+		}
+		if name := dnsname.Normalize(scanner.Text()); c.config.KnownAnswers[name] != nil {
+			c.knownAnswerInputs[name] = struct{}{}
+		}
+		_, _ = w.WriteString(scanner.Text() + "\n")
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return knownFile, w.Flush()
+}
+
+// checkKnownAnswer checks a result for a known answer name returning
+// true if the result was a known answer check and has to be skipped,
+// the names which are part of the input being kept.
+func (c *Client) checkKnownAnswer(result *parser.Result) bool {
+	expected, ok := c.config.KnownAnswers[result.Domain]
+	if !ok {
+		return false
+	}
+
+	stats, ok := c.knownAnswerStats[result.Resolver]
+	if !ok {
+		stats = &knownAnswerStats{}
+		c.knownAnswerStats[result.Resolver] = stats
+	}
+	stats.checks++
+
+	for _, ip := range result.IP {
+		if !contains(expected, ip) {
+			stats.wrong++
+			break
+		}
+	}
+	_, input := c.knownAnswerInputs[result.Domain]
+	return !input
+}
+
+// lyingResolvers returns the resolvers which gave wrong answers
+// to known answer checks, reporting them to the user.
+func (c *Client) lyingResolvers() map[string]struct{} {
+	lying := make(map[string]struct{})
+	for resolver, stats := range c.knownAnswerStats {
+		if stats.wrong == 0 {
+			continue
+		}
+		lying[resolver] = struct{}{}
+		c.log().Info().Msgf("Resolver %s returned wrong answers for %d/%d known answer checks\n", resolver, stats.wrong, stats.checks)
+	}
+	return lying
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
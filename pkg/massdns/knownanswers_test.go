@@ -0,0 +1,29 @@
+package massdns
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnownAnswersInput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	require.Nil(t, os.WriteFile(input, []byte("www.example.com\nDNS.google\n"), 0644))
+
+	c := &Client{
+		config:            Config{TempDir: dir, KnownAnswers: DefaultKnownAnswers, KnownAnswersEvery: 10},
+		knownAnswerStats:  make(map[string]*knownAnswerStats),
+		knownAnswerInputs: make(map[string]struct{}),
+	}
+	_, err := c.addKnownAnswers(input)
+	require.Nil(t, err, "Could not add known answer checks")
+
+	require.False(t, c.checkKnownAnswer(&parser.Result{Domain: "dns.google", Resolver: "1.1.1.1:53", IP: []string{"8.8.8.8"}}), "Could not keep input name")
+	require.True(t, c.checkKnownAnswer(&parser.Result{Domain: "dns.quad9.net", Resolver: "1.1.1.1:53", IP: []string{"6.6.6.6"}}), "Could not skip known answer check")
+	require.False(t, c.checkKnownAnswer(&parser.Result{Domain: "www.example.com", Resolver: "1.1.1.1:53"}), "Could not keep regular name")
+	require.Equal(t, &knownAnswerStats{checks: 2, wrong: 1}, c.knownAnswerStats["1.1.1.1:53"], "Could not count known answer checks")
+}
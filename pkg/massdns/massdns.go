@@ -1,19 +1,113 @@
 package massdns
 
 import (
+	"io"
 	"sync"
+	"time"
 
+	"github.com/mohammadanaraki/shuffledns/pkg/backoff"
+	"github.com/mohammadanaraki/shuffledns/pkg/cdn"
+	"github.com/mohammadanaraki/shuffledns/pkg/emailsec"
+	"github.com/mohammadanaraki/shuffledns/pkg/history"
+	"github.com/mohammadanaraki/shuffledns/pkg/mockdns"
+	"github.com/mohammadanaraki/shuffledns/pkg/mutations"
+	"github.com/mohammadanaraki/shuffledns/pkg/parking"
+	"github.com/mohammadanaraki/shuffledns/pkg/plugins"
+	"github.com/mohammadanaraki/shuffledns/pkg/resolvers"
+	"github.com/mohammadanaraki/shuffledns/pkg/scope"
+	"github.com/mohammadanaraki/shuffledns/pkg/tmpcrypt"
+	"github.com/mohammadanaraki/shuffledns/pkg/vendors"
 	"github.com/mohammadanaraki/shuffledns/pkg/wildcards"
+	"github.com/projectdiscovery/gologger"
 )
 
 // Client is a client for running massdns on a target
 type Client struct {
 	config Config
+	// compat describes how the massdns binary is driven
+	compat compat
 
 	wildcardIPMap   map[string]struct{}
 	wildcardIPMutex *sync.RWMutex
 
 	wildcardResolver *wildcards.Resolver
+
+	// canaries contains the nonexistent names added to the input
+	canaries map[string]struct{}
+	// knownAnswerStats contains the known answer checks per resolver
+	knownAnswerStats map[string]*knownAnswerStats
+	// knownAnswerInputs are the known answer names which are part of
+	// the names to resolve, whose results are kept
+	knownAnswerInputs map[string]struct{}
+	// sinkholeIPs contains the sinkhole ips flagged in the output
+	sinkholeIPs map[string]struct{}
+	// parkedHosts contains the parked hosts flagged in the output
+	parkedHosts map[string]struct{}
+	// parkingDomains contains the parking provider of the registered
+	// domains whose nameservers were looked up, empty if none
+	parkingDomains map[string]string
+	// ptrNames contains the reverse names of the resolved ips
+	ptrNames map[string][]string
+	// anyRecords contains the records per type of the hosts
+	anyRecords map[string]map[string][]string
+	// scopeDropped is the number of out-of-scope names not resolved
+	scopeDropped int
+	// depthDropped is the number of names outside of the depth limits
+	// not resolved
+	depthDropped int
+	// rateLimits summarizes the rate limiting detected while resolving
+	rateLimits rateLimitStats
+	// pluginDropped is the number of names filtered by the plugins not
+	// resolved
+	pluginDropped int
+	// invalidNames counts the invalid names not resolved per reason
+	invalidNames map[string]int
+	// resolverStats contains the outcomes of the queries per resolver
+	resolverStats map[string]*ResolverStats
+	// resolverTimeouts is the number of names without any response
+	resolverTimeouts int
+	// diagnostics counts the massdns stderr lines per diagnostic class
+	diagnostics map[string]int
+	// domainResolvers contains the wildcard resolvers of the additional
+	// domains enumerated
+	domainResolvers map[string]*wildcards.Resolver
+	// wildcardParents are the wildcard levels below which no name is
+	// resolved when pruning
+	wildcardParents map[string]struct{}
+	// probedWildcards are the wildcards found at the wildcard parents
+	// probed before resolving
+	probedWildcards map[string]*wildcards.Wildcard
+	// prunedDropped is the number of names below wildcard parents
+	prunedDropped int
+	// hitRateSkipped is the number of names skipped after the hit rate
+	// of their domain dropped below the minimum
+	hitRateSkipped int
+	// queried contains the names of the input and of the additional
+	// rounds, and expanded the variations of the input names, to skip
+	// the duplicate names
+	queried  hostnameSet
+	expanded hostnameSet
+	// duplicateNames is the number of duplicate names not resolved
+	duplicateNames int
+	// outputTime is the time the results are written, which is the
+	// timestamp of the records
+	outputTime time.Time
+	// emailPostures are the email security postures of the apex domains
+	emailPostures []*emailsec.Posture
+	// asns are the asns of the netblocks looked up during the run, the
+	// netblocks without asn being mapped to an empty string, and
+	// asnNames are the names of the asns
+	asns     map[string]string
+	asnNames map[string]string
+	// progress tracks the current stage of the enumeration
+	progress *progress
+	// stop interrupts the processing when the client is stopped
+	stop stopper
+	// stream contains the results written with the chunks of a stream,
+	// nil unless the input is streamed
+	stream *streamResults
+	// canariesReported is true once the canaries resolved are reported
+	canariesReported bool
 }
 
 // Config contains configuration options for the massdns client
@@ -24,26 +118,238 @@ type Config struct {
 	Retries int
 	// MassdnsPath is the path to the binary
 	MassdnsPath string
+	// MassdnsVersion is the version of the binary, empty if unknown.
+	// The flags and the output parsing are adapted to it.
+	MassdnsVersion string
+	// MockDNS answers the queries instead of massdns when set, its
+	// address being used as the resolver (see package mockdns)
+	MockDNS *mockdns.Server
 	// Threads is the hashmap size for massdns
 	Threads int
+	// MaxQPS is the maximum number of names sent to massdns per second (0 for unlimited)
+	MaxQPS int
+	// SpreadDuration spreads the queries evenly over a duration (0 for no spreading)
+	SpreadDuration time.Duration
+	// Jitter randomizes the delay between the names sent to massdns
+	Jitter bool
+	// AdaptiveRate slows down the names sent to massdns when the
+	// resolvers are detected rate limiting
+	AdaptiveRate bool
+	// RateLimitWindow is the interval between two checks of the
+	// responses for rate limiting (5 seconds if 0)
+	RateLimitWindow time.Duration
+	// NSMaxQPS is the maximum number of names sent per second to the
+	// zones served by the same authoritative nameservers (0 for unlimited)
+	NSMaxQPS int
 	// InputFile is the file to use for massdns input
 	InputFile string
 	// ResolversFile is the file with the resolvers
 	ResolversFile string
 	// TempDir is a temporary directory for storing massdns misc files
 	TempDir string
+	// TempKey encrypts the files of the temporary directory, nil to
+	// keep them in plaintext
+	TempKey *tmpcrypt.Key
+	// RemoveTemp removes a temporary file once it's no longer needed,
+	// e.g. shredding it (os.Remove if nil)
+	RemoveTemp func(file string) error
 	// OutputFile is the file to use for massdns output
 	OutputFile string
+	// OutputCompress writes the output file gzip-compressed
+	OutputCompress bool
+	// GroupByDomain writes one json record per registered domain with
+	// the records of its hosts instead of one per host
+	GroupByDomain bool
+	// Sorted orders the output by hostname (SortAlphabetical) or by
+	// reversed labels (SortReversed), unordered if empty
+	Sorted string
+	// OutputAppendUnique appends to the output file the hostnames not
+	// already present in it instead of overwriting it
+	OutputAppendUnique bool
+	// HostsFile is the file where the results are written in hosts
+	// file format, an ip and a hostname per line
+	HostsFile string
+	// URLsFile is the file where the urls of the web services likely
+	// exposed on the results are written
+	URLsFile string
+	// BurpScopeFile is the file where a burp target scope including
+	// the results is written
+	BurpScopeFile string
+	// TargetsFile is the file where the unique ips of the results are
+	// written for port scanners
+	TargetsFile string
+	// IPMapFile is the file where the unique ips of the results are
+	// written with the hostnames resolving to them, in csv if its
+	// extension is .csv and in json otherwise
+	IPMapFile string
+	// TargetsHostnames comments each ip of the targets file with the
+	// hostnames resolving to it
+	TargetsHostnames bool
+	// IPClustersFile is the file where the clusters of the ips of the
+	// results per netblock and asn are written
+	IPClustersFile string
+	// ClusterASN looks up the asn of the netblocks to cluster the ips
+	// per asn and to break the hosts of the report down per asn
+	ClusterASN bool
+	// MinHitRate is the fraction of names found under which the names
+	// of a domain stop being resolved (0 to resolve all of them)
+	MinHitRate float64
+	// HitRateWindow is the number of recent names of a domain over
+	// which its hit rate is computed
+	HitRateWindow int
+	// NoDedup resolves the duplicate names of the input, the variations
+	// and the additional rounds instead of skipping them, saving the
+	// memory of their hashes
+	NoDedup bool
+	// DiskDedup skips the duplicate names of the input with external
+	// sorts instead of keeping their hashes in memory. The variations
+	// and the additional rounds are not deduplicated then.
+	DiskDedup bool
+	// SortChunkSize is the size in bytes of the lines sorted in memory
+	// by the external sorts, the default one if 0
+	SortChunkSize int
+	// PruneWildcards skips the names below the wildcard levels found
+	// before and during the run instead of resolving them
+	PruneWildcards bool
+	// EmailPosture collects the SPF, DKIM and DMARC records of the apex
+	// domains to report their email security posture
+	EmailPosture bool
+	// DKIMSelectors are the DKIM selectors guessed for the apex domains
+	DKIMSelectors []string
+	// ReportFile is the file where the html report of the results is written
+	ReportFile string
+	// ReportMarkdownFile is the file where the markdown summary of the
+	// results is written
+	ReportMarkdownFile string
 	// Json is format ouput to ndjson format
 	Json bool
+	// CSV writes the output as csv, with a header and a column per field
+	CSV bool
+	// Fields contains the fields to write in json or csv output
+	Fields []string
+	// CDN detects the results resolving to a CDN
+	CDN *cdn.Checker
+	// Vendors detects the SaaS vendors of the results from their
+	// CNAME targets
+	Vendors *vendors.Fingerprints
+	// HangTimeout is the time after which massdns is restarted if it
+	// made no progress (0 to disable)
+	HangTimeout time.Duration
+	// MaxRestarts is the maximum number of times massdns is restarted
+	// on the remaining names after crashing or hanging
+	MaxRestarts int
+	// Mutator generates the variations of the names to resolve
+	Mutator *mutations.Mutator
+	// Wordlist is the wordlist used to bruteforce additional domains
+	Wordlist string
+	// Scope contains the rules for the names in scope of the enumeration
+	Scope *scope.Scope
+	// Plugins filter the candidates, enrich the json records and
+	// transform the output lines
+	Plugins *plugins.Set
+	// CNAMEDepth is the maximum number of levels of in-scope domains
+	// targeted by cnames to enumerate (0 to disable)
+	CNAMEDepth int
+	// TLSIterations is the maximum number of rounds resolving the names
+	// found in the tls certificates of the hosts (0 to disable)
+	TLSIterations int
+	// PTREnrich performs reverse lookups of the resolved ips
+	PTREnrich bool
+	// AnyRecords collects the records of all types of the hosts
+	AnyRecords bool
+	// RecordTypes are the types of the records of the hosts collected
+	// besides A, unless all of them are collected with AnyRecords
+	RecordTypes []uint16
+	// CollapseCDN writes one representative entry for the hostnames
+	// resolving to the same CDN ips through the same CNAME target
+	CollapseCDN bool
+	// RunID is the unique identifier of the run, written when selected
+	RunID string
+	// ConfigHash is the hash of the run configuration, written when selected
+	ConfigHash string
 	// WildcardsThreads is the number of wildcards concurrent threads
 	WildcardsThreads int
+	// Canaries is the number of nonexistent names added to the input to
+	// estimate the false-positive rate of the run
+	Canaries int
+	// KnownAnswers contains names with known answers interleaved in the
+	// input to detect resolvers returning wrong answers
+	KnownAnswers map[string][]string
+	// KnownAnswersEvery is the number of names between known answer checks
+	KnownAnswersEvery int
+	// SuspiciousIPs contains ips whose results are re-verified with
+	// trusted resolvers before being written
+	SuspiciousIPs map[string]struct{}
+	// Sinkholes matches the ips of known sinkholes (nil to disable the filter)
+	Sinkholes *Sinkholes
+	// FlagSinkholes flags the results resolving to sinkholes instead of dropping them
+	FlagSinkholes bool
+	// Parking detects the results served by domain-parking providers
+	// (nil to disable the detection)
+	Parking *parking.Fingerprints
+	// ExcludeParked drops the parked results instead of flagging them
+	ExcludeParked bool
 	// MassdnsRaw perform wildcards filtering from an existing massdns output file
 	MassdnsRaw string
 	// StrictWildcard controls whether the wildcard check should be performed on each result
 	StrictWildcard bool
+	// MinDepth is the minimum number of labels of the names below their
+	// registered domain (0 for no minimum)
+	MinDepth int
+	// MaxDepth is the maximum number of labels of the names below their
+	// registered domain (0 for no maximum)
+	MaxDepth int
+	// ExcludePrivate drops the results with a private answer
+	ExcludePrivate bool
+	// OnlyPrivate drops the results without a private answer
+	OnlyPrivate bool
+	// ResolverAgreement is the number of distinct resolvers which must
+	// agree on the answer of a result to accept it (0 to disable)
+	ResolverAgreement int
+	// VerifySample is the fraction of the results re-resolved with the
+	// trusted resolvers to estimate the disagreement rate (0 to disable)
+	VerifySample float64
+	// Backoff is the policy for the delays between the retries of the
+	// wildcard probes and verification queries (default if nil)
+	Backoff *backoff.Policy
+	// WildcardResolvers is the file containing the resolvers used
+	// for wildcard probes and verification
+	WildcardResolvers string
+	// ResolverFamily is the address family of the default trusted
+	// resolvers used without wildcard resolvers
+	ResolverFamily resolvers.Family
+	// ResolverStatsFile is the file where the statistics per resolver
+	// are written
+	ResolverStatsFile string
 	// WildcardOutputFile is the file where the list of wildcards is dumped
 	WildcardOutputFile string
+	// WildcardStrategy decides whether hosts are answered by wildcards
+	WildcardStrategy wildcards.WildcardStrategy
+	// History is the datastore where the found hostnames are recorded
+	History *history.DB
+	// OnChange is called for hostnames whose answers changed since the
+	// previous time they were recorded in the history datastore
+	OnChange func(change *history.Change)
+	// OnResult is called for each validated hostname written to the output,
+	// once the cdn groups are collapsed, the lines dropped by the plugins
+	// and the hosts already in the appended output skipped
+	OnResult func(result *Result)
+	// Logger receives the messages of the client (gologger.DefaultLogger if nil)
+	Logger *gologger.Logger
+	// ResultsWriter receives the found hostnames instead of stdout if set
+	ResultsWriter io.Writer
+	// Progress receives the progress events as json lines if set
+	Progress io.Writer
+	// ProgressInterval is the interval between the progress events
+	// written during a stage (5 seconds if 0)
+	ProgressInterval time.Duration
+}
+
+// Result is a validated hostname along with its answers
+type Result struct {
+	Host  string   `json:"host"`
+	IP    []string `json:"ip"`
+	CNAME []string `json:"cname,omitempty"`
 }
 
 // excellentResolvers contains some resolvers used in dns verification step
@@ -54,22 +360,97 @@ var excellentResolvers = []string{
 	"8.8.4.4",
 }
 
+// excellentResolvers6 contains the ipv6 addresses of the excellent resolvers
+var excellentResolvers6 = []string{
+	"2606:4700:4700::1111",
+	"2606:4700:4700::1001",
+	"2001:4860:4860::8888",
+	"2001:4860:4860::8844",
+}
+
+// TrustedResolvers returns the addresses of the trusted resolvers used
+// for the wildcard probes and the verification when there are no
+// dedicated ones.
+func TrustedResolvers(family resolvers.Family) []string {
+	entries := excellentResolvers
+	if family == resolvers.IPv6 {
+		entries = excellentResolvers6
+	}
+	addresses, _ := resolvers.ParseList(entries)
+	return addresses
+}
+
+// log returns the logger receiving the messages of the client
+func (c *Client) log() *gologger.Logger {
+	if c.config.Logger != nil {
+		return c.config.Logger
+	}
+	return gologger.DefaultLogger
+}
+
+// NewWildcardResolver returns the resolver of the wildcard probes and
+// the verification of a configuration: the dedicated wildcard resolvers
+// if any, or the trusted public ones otherwise.
+func NewWildcardResolver(config Config) (*wildcards.Resolver, error) {
+	resolver, err := wildcards.NewResolver(config.Domain, config.Retries)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.WildcardResolvers != "" {
+		if err := resolver.AddServersFromFile(config.WildcardResolvers); err != nil {
+			return nil, err
+		}
+	} else if config.ResolverFamily == resolvers.IPv6 {
+		_ = resolver.AddServersFromList(excellentResolvers6)
+	} else {
+		_ = resolver.AddServersFromList(excellentResolvers)
+	}
+	if config.Backoff != nil {
+		resolver.SetBackoff(*config.Backoff)
+	}
+	if config.WildcardStrategy != nil {
+		resolver.SetStrategy(config.WildcardStrategy)
+	}
+	return resolver, nil
+}
+
 // New returns a new massdns client for running enumeration
 // on a target.
 func New(config Config) (*Client, error) {
-	// Create a resolver and load resolverrs from list
-	resolver, err := wildcards.NewResolver(config.Domain, config.Retries)
+	resolver, err := NewWildcardResolver(config)
+	if err != nil {
+		return nil, err
+	}
+	compat, err := compatFor(config.MassdnsVersion)
 	if err != nil {
 		return nil, err
 	}
-
-	resolver.AddServersFromList(excellentResolvers)
 
 	return &Client{
 		config: config,
+		compat: compat,
 
-		wildcardIPMap:    make(map[string]struct{}),
-		wildcardIPMutex:  &sync.RWMutex{},
-		wildcardResolver: resolver,
+		wildcardIPMap:     make(map[string]struct{}),
+		wildcardIPMutex:   &sync.RWMutex{},
+		wildcardResolver:  resolver,
+		canaries:          make(map[string]struct{}),
+		knownAnswerStats:  make(map[string]*knownAnswerStats),
+		knownAnswerInputs: make(map[string]struct{}),
+		sinkholeIPs:       make(map[string]struct{}),
+		parkedHosts:       make(map[string]struct{}),
+		parkingDomains:    make(map[string]string),
+		ptrNames:          make(map[string][]string),
+		anyRecords:        make(map[string]map[string][]string),
+		asns:              make(map[string]string),
+		asnNames:          make(map[string]string),
+		domainResolvers:   make(map[string]*wildcards.Resolver),
+		wildcardParents:   make(map[string]struct{}),
+		probedWildcards:   make(map[string]*wildcards.Wildcard),
+		queried:           make(hostnameSet),
+		expanded:          make(hostnameSet),
+		diagnostics:       make(map[string]int),
+		invalidNames:      make(map[string]int),
+		resolverStats:     make(map[string]*ResolverStats),
 	}, nil
 }
@@ -0,0 +1,42 @@
+package massdns
+
+import (
+	"fmt"
+	"io"
+)
+
+// execMock answers the input names with the mock backend instead of
+// running massdns. The names are fed the same way as to massdns, so
+// that throttling and mutations apply.
+func (c *Client) execMock(output, outputFormat string, t *throttle) error {
+	file, err := c.config.TempKey.Create(output)
+	if err != nil {
+		return fmt.Errorf("could not create massdns output: %w", err)
+	}
+	defer file.Close()
+
+	reader, writer := io.Pipe()
+	throttleErr := make(chan error, 1)
+	go func() {
+		throttleErr <- throttleInput(c.config.TempKey, c.config.InputFile, writer, t, c.config.Jitter, c.expandUnique)
+	}()
+
+	// Stopping ends the input, and so the answers
+	c.setCancel(func() { reader.CloseWithError(ErrStopped) })
+	defer c.setCancel(nil)
+
+	c.countProgress(fedCounter(t))
+	stopRateLimits := c.monitorRateLimits(output, t)
+	err = c.config.MockDNS.Massdns(reader, file, outputFormat)
+	// Unblock the input if the answers stopped early
+	reader.Close()
+	stopRateLimits()
+	c.writeProgress("")
+	if err != nil {
+		return fmt.Errorf("could not execute mock massdns: %w", err)
+	}
+	if err := <-throttleErr; err != nil {
+		return fmt.Errorf("could not write massdns input: %w", err)
+	}
+	return nil
+}
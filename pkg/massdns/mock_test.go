@@ -0,0 +1,167 @@
+package massdns
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/mockdns"
+	"github.com/mohammadanaraki/shuffledns/pkg/tmpcrypt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessMockDNS(t *testing.T) {
+	zone, err := mockdns.Parse(strings.NewReader(`www.example.com A 192.0.2.1
+api.example.com CNAME edge.cdn.example.net
+edge.cdn.example.net A 192.0.2.2
+*.dev.example.com A 192.0.2.3
+`))
+	require.Nil(t, err, "Could not parse fixture")
+	server, err := mockdns.Start(zone)
+	require.Nil(t, err, "Could not start mock dns")
+	defer server.Close()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	names := []string{"www.example.com", "api.example.com", "mail.example.com"}
+	for _, word := range []string{"a", "b", "c", "d", "e", "f"} {
+		names = append(names, word+".dev.example.com")
+	}
+	require.Nil(t, os.WriteFile(input, []byte(strings.Join(names, "\n")+"\n"), 0644), "Could not write input")
+	resolvers := filepath.Join(dir, "resolvers.txt")
+	require.Nil(t, os.WriteFile(resolvers, []byte(server.Addr()+"\n"), 0644), "Could not write resolvers")
+
+	output := filepath.Join(dir, "output.txt")
+	var results []*Result
+	client, err := New(Config{
+		Domain:            "example.com",
+		Retries:           1,
+		MockDNS:           server,
+		Threads:           10,
+		InputFile:         input,
+		ResolversFile:     resolvers,
+		WildcardResolvers: resolvers,
+		TempDir:           dir,
+		OutputFile:        output,
+		WildcardsThreads:  5,
+		OnResult: func(result *Result) {
+			results = append(results, result)
+		},
+	})
+	require.Nil(t, err, "Could not create client")
+	require.Nil(t, client.Process(), "Could not process names")
+
+	data, err := os.ReadFile(output)
+	require.Nil(t, err, "Could not read output")
+	found := strings.Fields(string(data))
+	require.ElementsMatch(t, []string{"www.example.com", "api.example.com"}, found, "Could not filter the wildcards")
+	require.ElementsMatch(t, []*Result{
+		{Host: "www.example.com", IP: []string{"192.0.2.1"}},
+		{Host: "api.example.com", IP: []string{"192.0.2.2"}, CNAME: []string{"edge.cdn.example.net"}},
+	}, results, "Could not report the results")
+}
+
+func TestProcessEncryptedTemp(t *testing.T) {
+	zone, err := mockdns.Parse(strings.NewReader(`www.example.com A 192.0.2.1
+*.dev.example.com A 192.0.2.3
+`))
+	require.Nil(t, err, "Could not parse fixture")
+	server, err := mockdns.Start(zone)
+	require.Nil(t, err, "Could not start mock dns")
+	defer server.Close()
+
+	dir, tempDir := t.TempDir(), t.TempDir()
+	key, err := tmpcrypt.New(tempDir)
+	require.Nil(t, err, "Could not create key")
+
+	// The input created in the temporary directory is encrypted too
+	input := filepath.Join(tempDir, "input.txt")
+	w, err := key.Create(input)
+	require.Nil(t, err, "Could not create input")
+	names := []string{"www.example.com", "mail.example.com"}
+	for _, word := range []string{"a", "b", "c", "d", "e", "f"} {
+		names = append(names, word+".dev.example.com")
+	}
+	_, err = w.Write([]byte(strings.Join(names, "\n") + "\n"))
+	require.Nil(t, err, "Could not write input")
+	require.Nil(t, w.Close(), "Could not close input")
+	resolvers := filepath.Join(dir, "resolvers.txt")
+	require.Nil(t, os.WriteFile(resolvers, []byte(server.Addr()+"\n"), 0644), "Could not write resolvers")
+
+	// The temporary files of the chunk are checked before their removal
+	checkTemp := func(file string) {
+		data, err := os.ReadFile(file)
+		require.Nil(t, err, "Could not read temporary file")
+		require.NotContains(t, string(data), "example.com", "Could not encrypt temporary file %s", file)
+	}
+	var removed int
+	output := filepath.Join(dir, "output.txt")
+	client, err := New(Config{
+		Domain:            "example.com",
+		Retries:           1,
+		MockDNS:           server,
+		Threads:           10,
+		InputFile:         input,
+		ResolversFile:     resolvers,
+		WildcardResolvers: resolvers,
+		TempDir:           tempDir,
+		TempKey:           key,
+		OutputFile:        output,
+		WildcardsThreads:  5,
+		Sorted:            "alpha",
+		RemoveTemp: func(file string) error {
+			checkTemp(file)
+			removed++
+			return os.Remove(file)
+		},
+	})
+	require.Nil(t, err, "Could not create client")
+	require.Nil(t, client.Process(), "Could not process names")
+
+	data, err := os.ReadFile(output)
+	require.Nil(t, err, "Could not read output")
+	require.Equal(t, []string{"www.example.com"}, strings.Fields(string(data)), "Could not process encrypted input")
+
+	require.Greater(t, removed, 0, "Could not remove the temporary files of the chunk")
+	files, err := os.ReadDir(tempDir)
+	require.Nil(t, err, "Could not list temporary files")
+	for _, file := range files {
+		checkTemp(filepath.Join(tempDir, file.Name()))
+	}
+}
+
+func TestProcessStopped(t *testing.T) {
+	zone, err := mockdns.Parse(strings.NewReader("www.example.com A 192.0.2.1\n"))
+	require.Nil(t, err, "Could not parse fixture")
+	server, err := mockdns.Start(zone)
+	require.Nil(t, err, "Could not start mock dns")
+	defer server.Close()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	require.Nil(t, os.WriteFile(input, []byte("www.example.com\n"), 0644), "Could not write input")
+	resolvers := filepath.Join(dir, "resolvers.txt")
+	require.Nil(t, os.WriteFile(resolvers, []byte(server.Addr()+"\n"), 0644), "Could not write resolvers")
+
+	output := filepath.Join(dir, "output.txt")
+	client, err := New(Config{
+		Domain:            "example.com",
+		Retries:           1,
+		MockDNS:           server,
+		Threads:           10,
+		InputFile:         input,
+		ResolversFile:     resolvers,
+		WildcardResolvers: resolvers,
+		TempDir:           dir,
+		OutputFile:        output,
+		WildcardsThreads:  5,
+	})
+	require.Nil(t, err, "Could not create client")
+
+	// A stopped client doesn't resolve nor write the results
+	client.Stop()
+	require.ErrorIs(t, client.Process(), ErrStopped, "Could not stop processing")
+	_, err = os.Stat(output)
+	require.True(t, os.IsNotExist(err), "Could not skip the output of a stopped run")
+}
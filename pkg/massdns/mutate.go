@@ -0,0 +1,41 @@
+package massdns
+
+import "strings"
+
+// expandName returns the names to resolve for a line of the input,
+// which are the variations of its first label if mutations are enabled.
+func (c *Client) expandName(name string) []string {
+	if c.config.Mutator == nil || name == "" {
+		return []string{name}
+	}
+	// The canaries and known answer checks are resolved as they are
+	if _, ok := c.canaries[name]; ok {
+		return []string{name}
+	}
+	if _, ok := c.config.KnownAnswers[name]; ok {
+		return []string{name}
+	}
+
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 {
+		return []string{name}
+	}
+
+	var names []string
+	for _, label := range c.config.Mutator.Mutate(parts[0]) {
+		name := label + "." + parts[1]
+		if !c.validName(name) || !c.pluginCandidate(name) {
+			continue
+		}
+		if c.config.Scope != nil && !c.config.Scope.InScope(name) {
+			c.scopeDropped++
+			continue
+		}
+		if c.depthLimited() && !c.inDepth(name) {
+			c.depthDropped++
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
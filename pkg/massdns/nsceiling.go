@@ -0,0 +1,123 @@
+package massdns
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+// unknownNameservers is the key of the names whose zone nameservers
+// couldn't be found, which share the same ceiling
+const unknownNameservers = "unknown"
+
+// nsBucket spaces the names sent to the zones of a nameserver set
+type nsBucket struct {
+	next    time.Time
+	names   int
+	delayed int
+}
+
+// nsLimiter enforces a ceiling on the names sent per second to the
+// zones served by the same set of authoritative nameservers.
+type nsLimiter struct {
+	interval time.Duration
+	// jitter randomizes the spacing between half and one and a half
+	// times the interval
+	jitter bool
+	random *rand.Rand
+	// lookup returns the nameservers of a zone apex, none otherwise
+	lookup func(name string) ([]string, error)
+	// zones contains the nameserver set of the parents of the names
+	zones   map[string]string
+	buckets map[string]*nsBucket
+}
+
+// newNSLimiter creates a limiter sending at most qps names per second
+// to the zones of each nameserver set.
+func newNSLimiter(qps int, lookup func(name string) ([]string, error)) *nsLimiter {
+	return &nsLimiter{
+		interval: time.Second / time.Duration(qps),
+		random:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		lookup:   lookup,
+		zones:    make(map[string]string),
+		buckets:  make(map[string]*nsBucket),
+	}
+}
+
+// nameservers returns the key of the nameserver set of the closest zone
+// enclosing a name, looked up once per parent domain.
+func (l *nsLimiter) nameservers(name string) string {
+	parent := dnsname.Normalize(name)
+	if index := strings.IndexByte(parent, '.'); index >= 0 {
+		parent = parent[index+1:]
+	}
+
+	// Walk up the parents until a zone apex, remembering the nameserver
+	// set of all the parents walked
+	var walked []string
+	key := unknownNameservers
+	for domain := parent; domain != ""; {
+		if known, ok := l.zones[domain]; ok {
+			key = known
+			break
+		}
+		walked = append(walked, domain)
+		if nameservers, err := l.lookup(domain); err == nil && len(nameservers) > 0 {
+			sort.Strings(nameservers)
+			key = strings.Join(nameservers, ",")
+			break
+		}
+		index := strings.IndexByte(domain, '.')
+		if index < 0 {
+			break
+		}
+		domain = domain[index+1:]
+	}
+	for _, domain := range walked {
+		l.zones[domain] = key
+	}
+	return key
+}
+
+// reserve returns the time to wait before sending a name so that its
+// nameserver set receives at most the ceiling, reserving its slot.
+func (l *nsLimiter) reserve(name string) time.Duration {
+	key := l.nameservers(name)
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &nsBucket{}
+		l.buckets[key] = bucket
+	}
+	bucket.names++
+
+	now := time.Now()
+	if bucket.next.Before(now) {
+		bucket.next = now
+	}
+	wait := bucket.next.Sub(now)
+	if wait > 0 {
+		bucket.delayed++
+	}
+	spacing := l.interval
+	if l.jitter {
+		spacing = l.interval/2 + time.Duration(l.random.Int63n(int64(l.interval)+1))
+	}
+	bucket.next = bucket.next.Add(spacing)
+	return wait
+}
+
+// reportNSCeiling logs the names sent and delayed per nameserver set
+func (c *Client) reportNSCeiling(l *nsLimiter) {
+	keys := make([]string, 0, len(l.buckets))
+	for key := range l.buckets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		bucket := l.buckets[key]
+		c.log().Info().Msgf("Nameservers %s: sent %d names, %d delayed by the ceiling of %d names/sec\n", key, bucket.names, bucket.delayed, c.config.NSMaxQPS)
+	}
+}
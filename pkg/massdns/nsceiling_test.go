@@ -0,0 +1,67 @@
+package massdns
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/mockdns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNSLimiterNameservers(t *testing.T) {
+	lookups := make(map[string]int)
+	limiter := newNSLimiter(10, func(name string) ([]string, error) {
+		lookups[name]++
+		switch name {
+		case "example.com":
+			return []string{"ns2.example.net", "ns1.example.net"}, nil
+		case "corp.example.com":
+			return []string{"ns.corp.example.com"}, nil
+		}
+		return nil, nil
+	})
+
+	require.Equal(t, "ns1.example.net,ns2.example.net", limiter.nameservers("www.example.com"), "Could not find zone nameservers")
+	require.Equal(t, "ns1.example.net,ns2.example.net", limiter.nameservers("a.dev.example.com"), "Could not walk up to the zone apex")
+	require.Equal(t, "ns1.example.net,ns2.example.net", limiter.nameservers("b.dev.example.com"), "Could not find cached nameservers")
+	require.Equal(t, "ns.corp.example.com", limiter.nameservers("vpn.corp.example.com"), "Could not find delegated zone nameservers")
+	require.Equal(t, unknownNameservers, limiter.nameservers("www.example.org"), "Could not group unknown nameservers")
+
+	require.Equal(t, 1, lookups["example.com"], "Could not cache the zone apex")
+	require.Equal(t, 1, lookups["dev.example.com"], "Could not cache the walked parents")
+}
+
+func TestNSLimiterReserve(t *testing.T) {
+	limiter := newNSLimiter(10, func(name string) ([]string, error) {
+		return []string{"ns." + name}, nil
+	})
+
+	require.Equal(t, time.Duration(0), limiter.reserve("a.example.com"), "Could not send the first name")
+	require.InDelta(t, float64(100*time.Millisecond), float64(limiter.reserve("b.example.com")), float64(10*time.Millisecond), "Could not space the names of a set")
+	require.Equal(t, time.Duration(0), limiter.reserve("a.example.org"), "Could not send the names of another set")
+	require.Equal(t, 1, limiter.buckets["ns.example.com"].delayed, "Could not count the delayed names")
+
+	limiter.jitter = true
+	previous := limiter.reserve("c.example.com")
+	spacing := limiter.reserve("d.example.com") - previous
+	require.True(t, spacing >= 40*time.Millisecond && spacing <= 160*time.Millisecond, "Could not randomize the spacing of the names of a set")
+}
+
+func TestNSLimiterMockDNS(t *testing.T) {
+	zone, err := mockdns.Parse(strings.NewReader("example.com NS ns1.example.net\nexample.com NS ns2.example.net\n"))
+	require.Nil(t, err, "Could not parse fixture")
+	server, err := mockdns.Start(zone)
+	require.Nil(t, err, "Could not start mock dns")
+	defer server.Close()
+
+	resolvers := filepath.Join(t.TempDir(), "resolvers.txt")
+	require.Nil(t, os.WriteFile(resolvers, []byte(server.Addr()+"\n"), 0644), "Could not write resolvers")
+	client, err := New(Config{Domain: "example.com", Retries: 1, WildcardResolvers: resolvers})
+	require.Nil(t, err, "Could not create client")
+
+	limiter := newNSLimiter(10, client.wildcardResolver.LookupNS)
+	require.Equal(t, "ns1.example.net,ns2.example.net", limiter.nameservers("www.example.com"), "Could not look up zone nameservers")
+}
@@ -0,0 +1,42 @@
+package massdns
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/cdn"
+	"github.com/mohammadanaraki/shuffledns/pkg/history"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteOutputPlainHistory(t *testing.T) {
+	db, err := history.Open(filepath.Join(t.TempDir(), "assets.json"))
+	require.Nil(t, err, "Could not open history")
+
+	st := store.New()
+	defer st.Close()
+	st.New("1.2.3.4", "www.example.com")
+
+	var results strings.Builder
+	c := &Client{config: Config{History: db, ResultsWriter: &results}}
+	require.Nil(t, c.writeOutput(st), "Could not write output")
+	require.Equal(t, "www.example.com\n", results.String(), "Could not keep the plain output one hostname per line")
+	require.NotNil(t, db.Get("www.example.com"), "Could not record the hostname")
+}
+
+func TestWriteOutputPlainCollapseCDN(t *testing.T) {
+	checker, err := cdn.New("")
+	require.Nil(t, err, "Could not load cdn ranges")
+
+	st := store.New()
+	defer st.Close()
+	st.New("104.16.1.1", "www.example.com")
+	st.Get("104.16.1.1").Hostnames.Add("api.example.com")
+
+	var results strings.Builder
+	c := &Client{config: Config{CDN: checker, CollapseCDN: true, ResultsWriter: &results}}
+	require.Nil(t, c.writeOutput(st), "Could not write output")
+	require.Equal(t, "api.example.com\n", results.String(), "Could not write the bare representative of the cdn group")
+}
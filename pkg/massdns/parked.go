@@ -0,0 +1,111 @@
+package massdns
+
+import (
+	"sync"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+// detectParked finds the results served by domain-parking providers
+// from their ips, their CNAME chain and the nameservers of their
+// registered domain, then drops them or, unless the user asked to
+// exclude them, remembers them to tag the output.
+func (c *Client) detectParked(st *store.Store) {
+	c.lookupParkingDomains(st)
+
+	// The results flagged by a previous round are skipped
+	providers := make(map[string]string)
+	for ip, record := range st.IP {
+		ipProvider := c.config.Parking.MatchIP(ip)
+		for _, hostname := range record.Hostnames.List() {
+			if _, ok := providers[hostname]; ok {
+				continue
+			}
+			if _, ok := c.parkedHosts[hostname]; ok {
+				continue
+			}
+			provider := ipProvider
+			if provider == "" {
+				if meta := st.GetHost(hostname); meta != nil {
+					provider = c.config.Parking.MatchCNAME(meta.CNAME)
+				}
+			}
+			if provider == "" {
+				provider = c.parkingDomains[dnsname.RegisteredDomain(hostname)]
+			}
+			if provider != "" {
+				providers[hostname] = provider
+			}
+		}
+	}
+	if len(providers) == 0 {
+		return
+	}
+
+	if c.config.ExcludeParked {
+		for ip, record := range st.IP {
+			for _, hostname := range record.Hostnames.List() {
+				if _, ok := providers[hostname]; ok {
+					record.Hostnames.Remove(hostname)
+				}
+			}
+			if record.Hostnames.Len() == 0 {
+				st.Delete(ip)
+			}
+		}
+		c.log().Info().Msgf("Dropped %d parked results\n", len(providers))
+		return
+	}
+	for hostname, provider := range providers {
+		c.parkedHosts[hostname] = struct{}{}
+		c.log().Debug().Msgf("Parked result %s (%s)\n", hostname, provider)
+	}
+	c.log().Info().Msgf("Flagged %d parked results\n", len(providers))
+}
+
+// lookupParkingDomains finds the parking providers of the registered
+// domains of the results from their nameservers, looking each domain
+// up once per run.
+func (c *Client) lookupParkingDomains(st *store.Store) {
+	if !c.config.Parking.HasNameservers() {
+		return
+	}
+
+	domains := make(map[string]struct{})
+	for _, record := range st.IP {
+		for _, hostname := range record.Hostnames.List() {
+			domain := dnsname.RegisteredDomain(hostname)
+			if _, ok := c.parkingDomains[domain]; !ok {
+				domains[domain] = struct{}{}
+			}
+		}
+	}
+
+	threads := c.config.WildcardsThreads
+	if threads <= 0 {
+		threads = 1
+	}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan string)
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for domain := range queue {
+				// A failed lookup is remembered as not parked
+				nameservers, _ := c.wildcardResolver.LookupNS(domain)
+				provider := c.config.Parking.MatchNameservers(nameservers)
+				mutex.Lock()
+				c.parkingDomains[domain] = provider
+				mutex.Unlock()
+			}
+		}()
+	}
+	for domain := range domains {
+		queue <- domain
+	}
+	close(queue)
+	wg.Wait()
+}
@@ -0,0 +1,54 @@
+package massdns
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/mockdns"
+	"github.com/mohammadanaraki/shuffledns/pkg/parking"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectParked(t *testing.T) {
+	zone, err := mockdns.Parse(strings.NewReader("parked.example NS ns1.sedoparking.com\nexample.org NS ns1.example.net\n"))
+	require.Nil(t, err, "Could not parse fixture")
+	server, err := mockdns.Start(zone)
+	require.Nil(t, err, "Could not start mock dns")
+	defer server.Close()
+
+	resolvers := filepath.Join(t.TempDir(), "resolvers.txt")
+	require.Nil(t, os.WriteFile(resolvers, []byte(server.Addr()+"\n"), 0644), "Could not write resolvers")
+	fingerprints, err := parking.New("")
+	require.Nil(t, err, "Could not load parking fingerprints")
+
+	newStore := func() *store.Store {
+		st := store.New()
+		st.New("91.195.240.94", "ip.example.org")
+		st.New("192.0.2.2", "shop.example.org")
+		st.SetHost("shop.example.org", &store.HostMeta{CNAME: []string{"park.parkingcrew.net"}})
+		st.New("192.0.2.3", "www.parked.example")
+		st.New("192.0.2.4", "www.example.org")
+		return st
+	}
+
+	for _, exclude := range []bool{false, true} {
+		client, err := New(Config{Retries: 1, WildcardResolvers: resolvers, WildcardsThreads: 2, Parking: fingerprints, ExcludeParked: exclude})
+		require.Nil(t, err, "Could not create client")
+
+		st := newStore()
+		client.detectParked(st)
+		if !exclude {
+			require.Equal(t, map[string]struct{}{"ip.example.org": {}, "shop.example.org": {}, "www.parked.example": {}}, client.parkedHosts, "Could not flag the parked hosts")
+			require.Len(t, st.IP, 4, "Could not keep the flagged hosts")
+			require.Equal(t, true, client.jsonRecord(st, "shop.example.org", []string{"192.0.2.2"})["parked"], "Could not tag a parked host")
+			require.NotContains(t, client.jsonRecord(st, "www.example.org", []string{"192.0.2.4"}), "parked", "Could not skip a regular host")
+			continue
+		}
+		require.Empty(t, client.parkedHosts, "Could not skip flagging the excluded hosts")
+		require.Len(t, st.IP, 1, "Could not drop the parked hosts")
+		require.True(t, st.Get("192.0.2.4").Hostnames.Has("www.example.org"), "Could not keep a regular host")
+	}
+}
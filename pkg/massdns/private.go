@@ -0,0 +1,53 @@
+package massdns
+
+import (
+	"net"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+)
+
+// sharedAddressSpace is the carrier-grade nat range of RFC 6598
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPrivateIP returns true if an ip is not publicly routable: RFC1918
+// and unique local addresses, loopback, link-local, unspecified and
+// the carrier-grade nat range.
+func isPrivateIP(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	return parsed.IsPrivate() || parsed.IsLoopback() || parsed.IsLinkLocalUnicast() ||
+		parsed.IsUnspecified() || sharedAddressSpace.Contains(parsed)
+}
+
+// hasPrivateIP returns true if any of the ips is private
+func hasPrivateIP(ips []string) bool {
+	for _, ip := range ips {
+		if isPrivateIP(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPrivate drops the results with a private answer if the user
+// asked to exclude them, or the other ones if the user asked only for
+// them. It returns the number of dropped results.
+func (c *Client) filterPrivate(st *store.Store) int {
+	hostIPs := make(map[string][]string)
+	for ip, record := range st.IP {
+		for _, hostname := range record.Hostnames.List() {
+			hostIPs[hostname] = append(hostIPs[hostname], ip)
+		}
+	}
+
+	var dropped int
+	for hostname, ips := range hostIPs {
+		if hasPrivateIP(ips) == c.config.ExcludePrivate {
+			removeHostname(st, hostname)
+			dropped++
+		}
+	}
+	return dropped
+}
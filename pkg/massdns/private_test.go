@@ -0,0 +1,16 @@
+package massdns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPrivateIP(t *testing.T) {
+	for _, ip := range []string{"10.1.2.3", "172.16.0.1", "192.168.1.1", "127.0.0.1", "169.254.1.1", "100.64.0.1", "0.0.0.0", "fd00::1", "fe80::1", "::1"} {
+		require.True(t, isPrivateIP(ip), "Could not detect private ip %s", ip)
+	}
+	for _, ip := range []string{"1.1.1.1", "172.32.0.1", "100.128.0.1", "2606:4700:4700::1111", "invalid"} {
+		require.False(t, isPrivateIP(ip), "Could not detect public ip %s", ip)
+	}
+}
@@ -2,127 +2,579 @@ package massdns
 
 import (
 	"bufio"
-	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
+	"io"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/projectdiscovery/gologger"
 	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/extsort"
+	"github.com/mohammadanaraki/shuffledns/pkg/history"
 	"github.com/mohammadanaraki/shuffledns/pkg/parser"
+	"github.com/mohammadanaraki/shuffledns/pkg/safefile"
 	"github.com/remeh/sizedwaitgroup"
 	"github.com/rs/xid"
 )
 
+// ChunkFunc returns the next input file to resolve and whether other
+// chunks follow it
+type ChunkFunc func() (string, bool, error)
+
 // Process runs the actual enumeration process returning a file
 func (c *Client) Process() error {
-	// Process a created list or the massdns input
 	inputFile := c.config.InputFile
+	return c.ProcessChunks(func() (string, bool, error) {
+		return inputFile, false, nil
+	})
+}
+
+// ProcessChunks runs the enumeration on the input files returned one
+// after the other by next, e.g. while the names are still being read.
+// When next announces more chunks with the first one, the input is a
+// stream which may never end: the results of each chunk are filtered
+// and written as soon as it's resolved, the hostnames already written
+// being skipped, and the files summarizing the whole run are written
+// once the stream is over.
+func (c *Client) ProcessChunks(next ChunkFunc) error {
+	stopProgress := c.startProgress()
+	err := c.process(next)
+	stopProgress(err)
+	return err
+}
+
+// process runs the stages of the enumeration
+func (c *Client) process(next ChunkFunc) error {
+	// Create a store for storing ip metadata
+	shstore := store.New()
+	defer shstore.Close()
+
+	// Only parse the massdns output if the user gave one
 	if c.config.MassdnsRaw != "" {
-		inputFile = c.config.MassdnsRaw
+		blank, err := isBlankFile(c.config.TempKey, c.config.MassdnsRaw)
+		if err != nil {
+			return err
+		}
+		if blank {
+			return errors.New("blank input file specified")
+		}
+		if err := c.parseOutput(c.config.MassdnsRaw, shstore); err != nil {
+			return err
+		}
+		return c.processResults(shstore)
 	}
 
-	// Check for blank input file or non-existent input file
-	blank, err := IsBlankFile(inputFile)
-	if err != nil {
-		return err
+	var resolved int
+	for first, more := true, true; more; first = false {
+		if c.stopped() {
+			return ErrStopped
+		}
+		inputFile, hasMore, err := next()
+		if err != nil {
+			return err
+		}
+		more = hasMore
+		if first && more {
+			c.stream = newStreamResults()
+			defer c.stream.store.Close()
+		}
+
+		// Check for blank input file or non-existent input file, the
+		// blank chunks of a stream being skipped
+		blank, err := isBlankFile(c.config.TempKey, inputFile)
+		if err != nil {
+			return err
+		}
+		if blank {
+			continue
+		}
+		// Each chunk of a stream has its own store, released once its
+		// results are written
+		chunkStore := shstore
+		if c.stream != nil {
+			chunkStore = store.New()
+		}
+		err = c.resolveChunk(inputFile, chunkStore)
+		if err == nil && c.stream != nil {
+			err = c.processChunkResults(chunkStore)
+			chunkStore.Close()
+		}
+		if err != nil {
+			if c.stopped() {
+				return ErrStopped
+			}
+			return err
+		}
+		resolved++
+	}
+	if c.stopped() {
+		return ErrStopped
 	}
-	if blank {
+	if resolved == 0 {
 		return errors.New("blank input file specified")
 	}
+	if c.stream != nil {
+		return c.finishStream()
+	}
+	return c.processResults(shstore)
+}
 
-	// Create a store for storing ip metadata
-	shstore := store.New()
-	defer shstore.Close()
+// resolveChunk resolves the names of an input file with massdns and
+// adds the answers to the store
+func (c *Client) resolveChunk(inputFile string, shstore *store.Store) error {
+	// The filtered copies of the input and the massdns output are
+	// removed once the chunk is parsed, so that the temporary files of
+	// a stream don't pile up until the end of the run
+	var temps []string
+	defer func() {
+		c.removeTemp(temps...)
+	}()
+	filtered := func() {
+		if file := c.config.InputFile; file != inputFile && (len(temps) == 0 || temps[len(temps)-1] != file) {
+			temps = append(temps, file)
+		}
+	}
 
-	// Set the correct target file
-	massDNSOutput := filepath.Join(c.config.TempDir, xid.New().String())
-	if c.config.MassdnsRaw != "" {
-		massDNSOutput = c.config.MassdnsRaw
+	// Normalize the names and skip the ones which can't exist
+	// instead of resolving them
+	var err error
+	c.config.InputFile, err = c.filterInvalidInput(inputFile)
+	if err != nil {
+		return fmt.Errorf("could not filter invalid names: %w", err)
 	}
+	filtered()
 
-	// Check if we need to run massdns
-	if c.config.MassdnsRaw == "" {
-		// Create a temporary file for the massdns output
-		gologger.Info().Msgf("Creating temporary massdns output file: %s\n", massDNSOutput)
-		err = c.runMassDNS(massDNSOutput, shstore)
+	// Drop the out-of-scope names before resolving them
+	if c.config.Scope != nil {
+		var dropped int
+		c.config.InputFile, dropped, err = c.filterScopeInput(c.config.InputFile)
+		if err != nil {
+			return fmt.Errorf("could not filter out-of-scope names: %w", err)
+		}
+		c.scopeDropped += dropped
+	}
+	filtered()
+
+	// Drop the names too shallow or too deep before resolving them
+	if c.depthLimited() {
+		var dropped int
+		c.config.InputFile, dropped, err = c.filterDepthInput(c.config.InputFile)
+		if err != nil {
+			return fmt.Errorf("could not filter names by depth: %w", err)
+		}
+		c.depthDropped += dropped
+	}
+	filtered()
+
+	// Skip the names below wildcard parents instead of resolving
+	// them and filtering them afterwards
+	if c.config.PruneWildcards && c.config.Domain != "" {
+		if err := c.probeWildcardParents(c.config.InputFile); err != nil {
+			return fmt.Errorf("could not probe wildcard parents: %w", err)
+		}
+		if len(c.wildcardParents) > 0 {
+			var dropped int
+			c.config.InputFile, dropped, err = c.filterPrunedInput(c.config.InputFile)
+			if err != nil {
+				return fmt.Errorf("could not prune wildcard names: %w", err)
+			}
+			c.prunedDropped += dropped
+			c.log().Info().Msgf("Wildcard pruning: skipping %d candidates below wildcard parents %s\n", dropped, strings.Join(c.sortedWildcardParents(), ", "))
+		}
+	}
+	filtered()
+
+	// Add the canaries to the names to resolve, once for all the
+	// chunks, if asked
+	if c.config.Canaries > 0 && c.config.Domain != "" && len(c.canaries) == 0 {
+		c.config.InputFile, err = c.addCanaries(c.config.InputFile)
 		if err != nil {
-			return fmt.Errorf("could not execute massdns: %w", err)
+			return fmt.Errorf("could not add canaries: %w", err)
 		}
 	}
+	filtered()
 
-	gologger.Info().Msgf("Started parsing massdns output\n")
+	// Interleave the known answer checks in the names to resolve
+	if c.knownAnswersEnabled() {
+		c.config.InputFile, err = c.addKnownAnswers(c.config.InputFile)
+		if err != nil {
+			return fmt.Errorf("could not add known answer checks: %w", err)
+		}
+	}
+	filtered()
 
-	err = c.parseMassDNSOutput(massDNSOutput, shstore)
+	// Create a temporary file for the massdns output
+	massDNSOutput := filepath.Join(c.config.TempDir, xid.New().String())
+	c.log().Info().Msgf("Creating temporary massdns output file: %s\n", massDNSOutput)
+	temps = append(temps, massDNSOutput)
+	if c.config.MinHitRate > 0 {
+		err = c.runHitRateSegments(massDNSOutput, shstore)
+	} else {
+		err = c.runMassDNS(massDNSOutput, shstore)
+	}
 	if err != nil {
+		return fmt.Errorf("could not execute massdns: %w", err)
+	}
+	return c.parseOutput(massDNSOutput, shstore)
+}
+
+// parseOutput adds the answers of a massdns output to the store
+func (c *Client) parseOutput(massDNSOutput string, shstore *store.Store) error {
+	c.log().Info().Msgf("Started parsing massdns output\n")
+	c.setStage(StageParse, 0)
+
+	if err := c.parseMassDNSOutput(massDNSOutput, shstore); err != nil {
 		return fmt.Errorf("could not parse massdns output: %w", err)
 	}
 
-	gologger.Info().Msgf("Massdns output parsing completed\n")
+	c.log().Info().Msgf("Massdns output parsing completed\n")
+	c.setResults(shstore)
+	return nil
+}
+
+// processResults filters the answers of the store and writes the
+// validated results
+func (c *Client) processResults(shstore *store.Store) error {
+	if err := c.filterResults(shstore); err != nil {
+		return err
+	}
+	if err := c.summarizeResults(shstore); err != nil {
+		return err
+	}
+
+	c.log().Info().Msgf("Finished enumeration, started writing output\n")
+	c.setResults(shstore)
+	c.setStage(StageOutput, 0)
+
+	// Write the final elaborated list out
+	return c.writeOutput(shstore)
+}
+
+// processChunkResults filters the answers of a chunk of a stream and
+// writes its new results, which are kept to summarize the stream
+func (c *Client) processChunkResults(chunkStore *store.Store) error {
+	if err := c.filterResults(chunkStore); err != nil {
+		return err
+	}
+	c.setResults(chunkStore)
+	hostnames, hostIPs, statuses, err := c.writeResults(chunkStore)
+	if err != nil {
+		return err
+	}
+	c.stream.add(chunkStore, hostnames, hostIPs, statuses)
+	return nil
+}
+
+// finishStream summarizes the results of all the chunks of a stream
+// once it's over and writes the files describing the whole run
+func (c *Client) finishStream() error {
+	results := c.stream.store
+	if err := c.summarizeResults(results); err != nil {
+		return err
+	}
+
+	c.log().Info().Msgf("Finished enumeration, started writing output\n")
+	c.setResults(results)
+	c.setStage(StageOutput, 0)
+
+	hostnames, hostIPs := gatherHosts(results)
+	return c.writeExtraOutputs(results, hostnames, hostIPs, c.stream.statuses)
+}
+
+// filterResults filters the answers of the store, keeping only the
+// validated results
+func (c *Client) filterResults(shstore *store.Store) error {
+	var err error
+
+	// Re-verify the suspicious results with the trusted resolvers
+	c.processQuarantine(shstore, nil)
+
+	// Handle the results resolving to known sinkholes
+	if c.config.Sinkholes != nil {
+		c.filterSinkholes(shstore)
+	}
+
+	resolvedCanaries := c.countCanaries(shstore)
 
 	// Perform wildcard filtering only if domain name has been specified
 	if c.config.Domain != "" {
-		gologger.Info().Msgf("Started removing wildcards records\n")
+		c.log().Info().Msgf("Started removing wildcards records\n")
 		err = c.filterWildcards(shstore)
 		if err != nil {
 			return fmt.Errorf("could not parse massdns output: %w", err)
 		}
-		gologger.Info().Msgf("Wildcard removal completed\n")
+		c.log().Info().Msgf("Wildcard removal completed\n")
 	}
 
-	gologger.Info().Msgf("Finished enumeration, started writing output\n")
+	// Report and drop the canaries as they are false positives by
+	// definition, the canaries being resolved with the first chunk only
+	if len(c.canaries) > 0 {
+		if !c.canariesReported {
+			c.reportCanaries(resolvedCanaries, c.countCanaries(shstore))
+			c.canariesReported = true
+		}
+		c.removeCanaries(shstore)
+	}
 
-	// Write the final elaborated list out
-	return c.writeOutput(shstore)
+	// Handle the results served by domain-parking providers
+	if c.config.Parking != nil {
+		c.detectParked(shstore)
+	}
+
+	// Drop the results not confirmed by enough resolvers
+	if c.config.ResolverAgreement > 1 {
+		c.setResults(shstore)
+		c.setStage(StageVerify, 0)
+		if err := c.checkAgreement(shstore); err != nil {
+			return fmt.Errorf("could not check resolver agreement: %w", err)
+		}
+	}
+
+	if c.config.CNAMEDepth > 0 || c.config.TLSIterations > 0 || c.config.PTREnrich {
+		c.setResults(shstore)
+		c.setStage(StageEnrich, 0)
+	}
+
+	// Bruteforce the in-scope domains targeted by the cnames of the hosts
+	if c.config.CNAMEDepth > 0 {
+		if err := c.enumerateCNAMEDomains(shstore); err != nil {
+			return fmt.Errorf("could not enumerate cname domains: %w", err)
+		}
+	}
+
+	// Resolve the new names found in the tls certificates of the hosts
+	if c.config.TLSIterations > 0 {
+		if err := c.harvestTLSNames(shstore); err != nil {
+			return fmt.Errorf("could not resolve tls certificate names: %w", err)
+		}
+	}
+
+	// Enrich the results with the reverse names of their ips
+	if c.config.PTREnrich {
+		c.enrichPTR(shstore)
+	}
+
+	// Drop the out-of-scope results and report the dropped names
+	if c.config.Scope != nil {
+		dropped := c.filterScopeResults(shstore)
+		c.log().Info().Msgf("Scope: dropped %d out-of-scope candidates and %d out-of-scope results\n", c.scopeDropped, dropped)
+	}
+
+	// Drop the results too shallow or too deep, like the names found
+	// in cnames and certificates
+	if c.depthLimited() {
+		dropped := c.filterDepthResults(shstore)
+		c.log().Info().Msgf("Depth: dropped %d candidates and %d results outside of the depth limits\n", c.depthDropped, dropped)
+	}
+
+	if c.pluginDropped > 0 {
+		c.log().Info().Msgf("Plugins: skipped %d candidates\n", c.pluginDropped)
+	}
+
+	if c.config.PruneWildcards && c.prunedDropped > 0 {
+		c.log().Info().Msgf("Wildcard pruning: skipped %d candidates below %d wildcard parents\n", c.prunedDropped, len(c.wildcardParents))
+	}
+
+	// Drop the results with private answers or the public ones
+	if c.config.ExcludePrivate || c.config.OnlyPrivate {
+		dropped := c.filterPrivate(shstore)
+		if c.config.ExcludePrivate {
+			c.log().Info().Msgf("Dropped %d results with private answers\n", dropped)
+		} else {
+			c.log().Info().Msgf("Dropped %d results without private answers\n", dropped)
+		}
+	}
+
+	// Estimate the quality of the results on a sample of them
+	if c.config.VerifySample > 0 {
+		c.verifySample(shstore)
+	}
+
+	// Collect the records of all or the selected types of the validated hosts
+	if c.config.AnyRecords || len(c.config.RecordTypes) > 0 {
+		c.collectAnyRecords(shstore)
+	}
+	return nil
+}
+
+// summarizeResults reports the statistics of the run and writes the
+// summaries of the validated results of the store
+func (c *Client) summarizeResults(shstore *store.Store) error {
+	c.reportInvalidNames()
+	c.reportDuplicates()
+	c.reportDiagnostics()
+
+	// Cluster the ips of the results to show the hosting ranges
+	if c.config.IPClustersFile != "" {
+		if err := c.writeIPClusters(shstore); err != nil {
+			return fmt.Errorf("could not write ip clusters: %w", err)
+		}
+	}
+
+	if c.config.EmailPosture {
+		c.collectEmailPostures()
+	}
+
+	if c.config.ResolverStatsFile != "" {
+		if err := c.writeResolverStats(); err != nil {
+			return fmt.Errorf("could not write resolver statistics: %w", err)
+		}
+	}
+	return nil
 }
 
 func (c *Client) runMassDNS(output string, store *store.Store) error {
 	if c.config.Domain != "" {
-		gologger.Info().Msgf("Executing massdns on %s\n", c.config.Domain)
+		c.log().Info().Msgf("Executing massdns on %s\n", c.config.Domain)
 	} else {
-		gologger.Info().Msgf("Executing massdns\n")
+		c.log().Info().Msgf("Executing massdns\n")
 	}
 	now := time.Now()
 	// Run the command on a temp file and wait for the output
-	cmd := exec.Command(c.config.MassdnsPath, []string{"-r", c.config.ResolversFile, "-o", "Snl", "-t", "A", c.config.InputFile, "-w", output, "-s", strconv.Itoa(c.config.Threads)}...)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	err := cmd.Run()
+	// The json output format is needed to know which resolver answered
+	outputFormat := c.compat.textFormat
+	// The json output contains the failed responses too, needed to
+	// detect the rate limiting
+	if c.hasField(FieldResolver) || c.needsResolver() || c.config.AdaptiveRate {
+		if !c.compat.json {
+			return fmt.Errorf("massdns %s has no json output, needed to know the resolver of the results and to adapt the rate", c.config.MassdnsVersion)
+		}
+		outputFormat = "J"
+	}
+	interval, err := c.queryInterval()
 	if err != nil {
-		return fmt.Errorf("could not execute massdns: %w\ndetailed error: %s", err, stderr.String())
+		return fmt.Errorf("could not read massdns input: %w", err)
+	}
+	if err := c.startMassdnsStage(); err != nil {
+		return fmt.Errorf("could not read massdns input: %w", err)
+	}
+
+	// The input slowed down on rate limiting stays slow on restarts
+	t := newThrottle(interval)
+	if c.config.NSMaxQPS > 0 {
+		t.limiter = newNSLimiter(c.config.NSMaxQPS, c.wildcardResolver.LookupNS)
+		t.limiter.jitter = c.config.Jitter
+	}
+
+	// Restore the input changed to resolve the remaining names
+	mainInputFile, mutator := c.config.InputFile, c.config.Mutator
+	defer func() {
+		c.config.InputFile, c.config.Mutator = mainInputFile, mutator
+	}()
+
+	// When massdns crashes or hangs, it's restarted on the names it
+	// didn't answer yet, appending its output to the previous one.
+	for restarts := 0; ; restarts++ {
+		attemptOutput := output
+		if restarts > 0 {
+			attemptOutput = filepath.Join(c.config.TempDir, xid.New().String())
+		}
+		err := c.execMassDNS(attemptOutput, outputFormat, t)
+		if restarts > 0 {
+			if appendErr := appendFile(c.config.TempKey, output, attemptOutput); appendErr != nil {
+				return fmt.Errorf("could not merge massdns output: %w", appendErr)
+			}
+		}
+		if err == nil {
+			break
+		}
+		if c.stopped() {
+			return ErrStopped
+		}
+		if restarts >= c.config.MaxRestarts {
+			return err
+		}
+
+		// The last line may have been cut when massdns stopped
+		if trimErr := trimPartialLine(c.config.TempKey, output); trimErr != nil {
+			return fmt.Errorf("could not read massdns output: %w", trimErr)
+		}
+		remaining, count, remainingErr := c.remainingNames(output)
+		if remainingErr != nil {
+			return fmt.Errorf("could not read massdns output: %w", remainingErr)
+		}
+		if count == 0 {
+			break
+		}
+		c.log().Error().Msgf("Massdns failed: %s\n", strings.TrimSpace(err.Error()))
+		c.log().Info().Msgf("Restarting massdns on the %d remaining names (%d/%d)\n", count, restarts+1, c.config.MaxRestarts)
+		// The remaining names are already expanded
+		c.config.InputFile, c.config.Mutator = remaining, nil
+	}
+	c.log().Info().Msgf("Massdns execution took %s\n", time.Since(now))
+	if c.config.AdaptiveRate {
+		c.reportRateLimits()
+	}
+	if t.limiter != nil {
+		c.reportNSCeiling(t.limiter)
+	}
+
+	if c.config.ResolverStatsFile != "" {
+		if err := c.collectResolverStats(output); err != nil {
+			return fmt.Errorf("could not collect resolver statistics: %w", err)
+		}
 	}
-	gologger.Info().Msgf("Massdns execution took %s\n", time.Since(now))
 	return nil
 }
 
-func (c *Client) parseMassDNSOutput(output string, store *store.Store) error {
-	massdnsOutput, err := os.Open(output)
+func (c *Client) parseMassDNSOutput(output string, st *store.Store) error {
+	massdnsOutput, err := c.config.TempKey.Open(output)
 	if err != nil {
 		return fmt.Errorf("could not open massdns output file: %w", err)
 	}
 	defer massdnsOutput.Close()
+	size, err := massdnsOutput.Size()
+	if err != nil {
+		return fmt.Errorf("could not read massdns output file: %w", err)
+	}
+
+	// Detect whether massdns wrote simple text or ndjson output
+	isJSON, err := parser.IsJSON(massdnsOutput)
+	if err != nil {
+		return fmt.Errorf("could not read massdns output file: %w", err)
+	}
+	if _, err := massdnsOutput.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("could not read massdns output file: %w", err)
+	}
+	parse := parser.ParseResultsStats
+	if isJSON {
+		parse = parser.ParseJSONStats
+	}
+	var reader io.Reader = massdnsOutput
+	if !isJSON && !c.compat.separated {
+		reader = parser.SeparateReplies(massdnsOutput)
+	}
 
 	// at first we need the full structure in memory to elaborate it in parallell
-	err = parser.Parse(massdnsOutput, func(domain string, ip []string) {
-		for _, ip := range ip {
+	start := time.Now()
+	var results int
+	stats, err := parse(reader, func(result *parser.Result) {
+		results++
+		if c.knownAnswersEnabled() && c.checkKnownAnswer(result) {
+			return
+		}
+		domain := result.Domain
+		if len(result.CNAME) > 0 || result.Resolver != "" {
+			st.SetHost(domain, &store.HostMeta{CNAME: result.CNAME, Resolver: result.Resolver})
+		}
+		for _, ip := range result.IP {
 			// Check if ip exists in the store. If not,
 			// add the ip to the map and continue with the next ip.
-			if !store.Exists(ip) {
-				store.New(ip, domain)
+			if !st.Exists(ip) {
+				st.New(ip, domain)
 				continue
 			}
 
 			// Get the IP meta-information from the store.
-			record := store.Get(ip)
+			record := st.Get(ip)
 
 			// Put the new hostname and increment the counter by 1.
-			record.Hostnames[domain] = struct{}{}
+			record.Hostnames.Add(domain)
 			record.Counter++
 		}
 	})
@@ -131,10 +583,26 @@ func (c *Client) parseMassDNSOutput(output string, store *store.Store) error {
 		return fmt.Errorf("could not parse massdns output: %w", err)
 	}
 
+	elapsed := time.Since(start)
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+	megabytes := float64(size) / 1e6
+	c.log().Info().Msgf("Parsed %d results from %.1f MB of massdns output in %s (%.1f MB/s)\n", results, megabytes, elapsed.Round(time.Millisecond), megabytes/seconds)
+	if stats.Skipped() > 0 {
+		c.log().Info().Msgf("Skipped %d malformed lines and %d records of unexpected types in massdns output %s\n", stats.Malformed, stats.UnexpectedTypes, output)
+	}
+	if stats.Truncated {
+		c.log().Info().Msgf("Massdns output %s doesn't end with a newline, its last line may be truncated\n", output)
+	}
 	return nil
 }
 
 func (c *Client) filterWildcards(st *store.Store) error {
+	c.setResults(st)
+	c.setStage(StageWildcards, int64(len(st.IP)))
+
 	// Start to work in parallel on wildcards
 	wildcardWg := sizedwaitgroup.New(c.config.WildcardsThreads)
 
@@ -143,6 +611,7 @@ func (c *Client) filterWildcards(st *store.Store) error {
 		c.wildcardIPMutex.Lock()
 		if _, ok := c.wildcardIPMap[record.IP]; ok {
 			c.wildcardIPMutex.Unlock()
+			c.addProgress(1)
 			continue
 		}
 		c.wildcardIPMutex.Unlock()
@@ -153,9 +622,10 @@ func (c *Client) filterWildcards(st *store.Store) error {
 			wildcardWg.Add()
 			go func(record *store.IPMeta) {
 				defer wildcardWg.Done()
+				defer c.addProgress(1)
 
-				for host := range record.Hostnames {
-					isWildcard, ips := c.wildcardResolver.LookupHost(host)
+				for _, host := range record.Hostnames.List() {
+					isWildcard, ips := c.resolverFor(host).LookupHost(host)
 					if len(ips) > 0 {
 						c.wildcardIPMutex.Lock()
 						for ip := range ips {
@@ -178,6 +648,7 @@ func (c *Client) filterWildcards(st *store.Store) error {
 	}
 
 	wildcardWg.Wait()
+	c.writeProgress("")
 
 	// drop all wildcard from the store
 	for wildcardIP := range c.wildcardIPMap {
@@ -187,59 +658,307 @@ func (c *Client) filterWildcards(st *store.Store) error {
 	return nil
 }
 
+// writeOutput writes the validated results of the store to the output
+// and the files summarizing them
 func (c *Client) writeOutput(store *store.Store) error {
+	hostnames, hostIPs, statuses, err := c.writeResults(store)
+	if err != nil {
+		return err
+	}
+	return c.writeExtraOutputs(store, hostnames, hostIPs, statuses)
+}
+
+// writeExtraOutputs writes the files summarizing the results, like the
+// exports and the reports, and logs their statuses
+func (c *Client) writeExtraOutputs(store *store.Store, hostnames []string, hostIPs map[string][]string, statuses map[string]history.Status) error {
+	if err := c.writeExports(hostnames, hostIPs); err != nil {
+		return err
+	}
+	c.logStatuses(hostnames, statuses)
+	return c.writeReport(store, hostnames, hostIPs, statuses)
+}
+
+// gatherHosts returns the unique hostnames of the store along with the
+// sorted ips they resolved to
+func gatherHosts(store *store.Store) ([]string, map[string][]string) {
+	var hostnames []string
+	hostIPs := make(map[string][]string)
+	for _, record := range store.IP {
+		for _, hostname := range record.Hostnames.List() {
+			if _, ok := hostIPs[hostname]; !ok {
+				hostnames = append(hostnames, hostname)
+			}
+			hostIPs[hostname] = append(hostIPs[hostname], record.IP)
+		}
+	}
+	// The ips are gathered in the random order of the store
+	for _, hostname := range hostnames {
+		sort.Strings(hostIPs[hostname])
+	}
+	return hostnames, hostIPs
+}
+
+// writeResults writes the unique deduplicated results of the store to
+// the output file and stdout, returning the hostnames written with
+// their ips and history statuses. The results of the chunks of a
+// stream after the first one are appended to the output file, the
+// hostnames written with the previous chunks being skipped.
+func (c *Client) writeResults(store *store.Store) ([]string, map[string][]string, map[string]history.Status, error) {
 	// Write the unique deduplicated output to the file or stdout
 	// depending on what the user has asked.
-	var output *os.File
+	// The output file is only replaced or extended once complete, so
+	// that a crash never leaves a partial file behind
+	var output *safefile.File
+	var gzipWriter *gzip.Writer
 	var w *bufio.Writer
+	var existing hostnameSet
 	var err error
 
+	// The hostnames of the previous chunks of a stream are skipped
+	hostnames, hostIPs := gatherHosts(store)
+	if c.stream != nil {
+		hostnames = c.stream.unwritten(hostnames)
+	}
+	appended := c.stream != nil && c.stream.chunks > 0
+
 	if c.config.OutputFile != "" {
-		output, err = os.Create(c.config.OutputFile)
+		if appended {
+			// The compressed output is extended with a new gzip member
+			output, err = safefile.Append(c.config.OutputFile)
+			existing = c.stream.existing
+		} else if c.config.OutputAppendUnique {
+			// The hosts left by an append which didn't complete are
+			// rolled back, the remaining ones being trusted
+			var recovered bool
+			recovered, err = safefile.Recover(c.config.OutputFile)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("could not recover massdns output file: %v", err)
+			}
+			if recovered {
+				c.log().Info().Msgf("Rolled back the incomplete append of a previous run to %s\n", c.config.OutputFile)
+			}
+			output, existing, err = openAppendUnique(c.config.OutputFile)
+		} else {
+			output, err = safefile.Create(c.config.OutputFile)
+		}
 		if err != nil {
-			return fmt.Errorf("could not create massdns output file: %v", err)
+			return nil, nil, nil, fmt.Errorf("could not create massdns output file: %v", err)
+		}
+		defer output.Abort()
+		if c.stream != nil {
+			c.stream.existing = existing
+		}
+		// Compress the output on the fly if asked by the user
+		if c.config.OutputCompress {
+			gzipWriter = gzip.NewWriter(output)
+			w = bufio.NewWriter(gzipWriter)
+		} else {
+			w = bufio.NewWriter(output)
 		}
-		w = bufio.NewWriter(output)
 	}
 	buffer := &strings.Builder{}
+	statuses := make(map[string]history.Status)
+
+	// emit writes the line of hostnames to the output file and stdout.
+	// The hostnames already present in the appended file are skipped and
+	// the lines dropped by the plugins aren't written. The result callback
+	// is called for the hostnames of the lines written, so that it sees
+	// the same results as the output.
+	emit := func(lineHostnames []string, data string) error {
+		if c.config.Plugins.HasTransformers() {
+			line, ok := c.config.Plugins.TransformOutput(strings.TrimSuffix(data, "\n"))
+			if !ok {
+				return nil
+			}
+			data = line + "\n"
+		}
+		isNew := existing == nil || len(lineHostnames) != 1 || existing.Add(lineHostnames[0])
+		if output != nil && isNew {
+			_, _ = w.WriteString(data)
+		}
+		if c.config.OnResult != nil && isNew {
+			for _, hostname := range lineHostnames {
+				var cnames []string
+				if meta := store.GetHost(hostname); meta != nil {
+					cnames = meta.CNAME
+				}
+				c.config.OnResult(&Result{Host: hostname, IP: hostIPs[hostname], CNAME: cnames})
+			}
+		}
+		if c.config.ResultsWriter != nil {
+			if _, err := io.WriteString(c.config.ResultsWriter, data); err != nil {
+				return fmt.Errorf("could not write results: %w", err)
+			}
+		} else {
+			gologger.Silent().Msgf("%s", data)
+		}
+		return nil
+	}
 
-	uniqueMap := make(map[string]struct{})
+	// The csv header is written before the lines, bypassing the plugins,
+	// and only once for a stream
+	if c.config.CSV && !appended {
+		header, err := csvLine(c.csvColumns())
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("could not write csv header: %v", err)
+		}
+		if output != nil {
+			_, _ = w.WriteString(header)
+		}
+		if c.config.ResultsWriter != nil {
+			if _, err := io.WriteString(c.config.ResultsWriter, header); err != nil {
+				return nil, nil, nil, fmt.Errorf("could not write results: %w", err)
+			}
+		} else {
+			gologger.Silent().Msgf("%s", header)
+		}
+	}
 
-	for _, record := range store.IP {
-		for hostname := range record.Hostnames {
-			// Skip if we already printed this subdomain once
-			if _, ok := uniqueMap[hostname]; ok {
-				continue
+	// The grouped records are written once all the hosts are known
+	var groups domainGroups
+	if c.config.Json && c.config.GroupByDomain {
+		groups = make(domainGroups)
+	}
+
+	// The sorted lines are written once all of them are known
+	var sorter *extsort.Sorter
+	if c.config.Sorted != "" && groups == nil {
+		sorter = extsort.New(c.config.TempDir, c.config.SortChunkSize, c.sortKey)
+		sorter.Encrypt(c.config.TempKey)
+		defer sorter.Close()
+	}
+
+	// Group the hostnames fronted by the same CDN configuration
+	var cdnGroups map[string]*cdnGroup
+	if c.config.CollapseCDN {
+		cdnGroups = c.groupCDNHosts(store, hostnames, hostIPs)
+	}
+
+	c.outputTime = time.Now()
+	now := c.outputTime
+	for _, hostname := range hostnames {
+		group := cdnGroups[hostname]
+
+		var cnames []string
+		if meta := store.GetHost(hostname); meta != nil {
+			cnames = meta.CNAME
+		}
+		// Compare the hostname with its history, if any
+		var status history.Status
+		if c.config.History != nil {
+			change := c.config.History.Record(hostname, hostIPs[hostname], cnames, now)
+			status = change.Status
+			statuses[hostname] = status
+			if status == history.StatusChanged && c.config.OnChange != nil {
+				c.config.OnChange(change)
 			}
-			uniqueMap[hostname] = struct{}{}
+		}
 
-			if c.config.Json {
-				hostnameJson, err := json.Marshal(map[string]interface{}{"hostname": hostname})
-				if err != nil {
-					return fmt.Errorf("could not marshal output as json: %v", err)
-				}
+		if c.config.Json {
+			record := c.jsonRecord(store, hostname, hostIPs[hostname])
+			c.config.Plugins.EnrichResult(hostname, hostIPs[hostname], record)
+			if status != "" {
+				record["status"] = status
+				record["first_seen"] = c.config.History.Get(hostname).FirstSeen
+			}
+			if group != nil {
+				record["cdn_group"] = group.ID
+				record["cdn_group_size"] = group.Size
+			}
+			if groups != nil {
+				groups.add(hostname, record, hostIPs[hostname])
+				continue
+			}
+			hostnameJson, err := json.Marshal(record)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("could not marshal output as json: %v", err)
+			}
 
-				buffer.WriteString(string(hostnameJson))
-				buffer.WriteString("\n")
-			} else {
-				buffer.WriteString(hostname)
-				buffer.WriteString("\n")
+			buffer.WriteString(string(hostnameJson))
+			buffer.WriteString("\n")
+		} else if c.config.CSV {
+			line, err := c.csvRecord(store, hostname, hostIPs[hostname])
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("could not write output as csv: %v", err)
+			}
+			buffer.WriteString(line)
+		} else {
+			// Only the representative of a CDN group is written
+			if group != nil && group.Representative != hostname {
+				continue
 			}
+			buffer.WriteString(hostname)
+			buffer.WriteString("\n")
+		}
 
-			data := buffer.String()
+		data := buffer.String()
+		buffer.Reset()
 
-			if output != nil {
-				_, _ = w.WriteString(data)
+		if sorter != nil {
+			if err := sorter.Add(strings.TrimSuffix(data, "\n")); err != nil {
+				return nil, nil, nil, fmt.Errorf("could not sort output: %w", err)
 			}
-			gologger.Silent().Msgf("%s", data)
-			buffer.Reset()
+			continue
+		}
+		if err := emit([]string{hostname}, data); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if groups != nil {
+		lines, lineHosts, err := c.groupLines(groups)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("could not marshal output as json: %v", err)
+		}
+		for i, line := range lines {
+			if err := emit(lineHosts[i], line+"\n"); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+	if sorter != nil {
+		err := sorter.Sort(func(line string) error {
+			return emit([]string{lineHostname(line)}, line+"\n")
+		})
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("could not sort output: %w", err)
 		}
 	}
 
-	// Close the files and return
+	// Commit the output file once all the lines are written
 	if output != nil {
-		w.Flush()
-		output.Close()
+		if err := w.Flush(); err != nil {
+			return nil, nil, nil, fmt.Errorf("could not write massdns output file: %v", err)
+		}
+		if gzipWriter != nil {
+			if err := gzipWriter.Close(); err != nil {
+				return nil, nil, nil, fmt.Errorf("could not write massdns output file: %v", err)
+			}
+		}
+		if err := output.Commit(); err != nil {
+			return nil, nil, nil, fmt.Errorf("could not write massdns output file: %v", err)
+		}
 	}
-	return nil
+	if c.stream != nil {
+		c.stream.chunks++
+	}
+	c.logCDNGroups(cdnGroups)
+	return hostnames, hostIPs, statuses, nil
+}
+
+// logStatuses logs the new and changed hostnames found with a history
+// on stderr, along with the number of hostnames of each status, so that
+// the plain output stays one hostname per line.
+func (c *Client) logStatuses(hostnames []string, statuses map[string]history.Status) {
+	if len(statuses) == 0 {
+		return
+	}
+	counts := make(map[history.Status]int)
+	for _, hostname := range hostnames {
+		status := statuses[hostname]
+		counts[status]++
+		if status == history.StatusNew || status == history.StatusChanged {
+			c.log().Verbose().Msgf("%s [%s]\n", hostname, status)
+		}
+	}
+	c.log().Info().Msgf("Found %d new, %d changed and %d recurring hosts\n", counts[history.StatusNew], counts[history.StatusChanged], counts[history.StatusRecurring])
 }
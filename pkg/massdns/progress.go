@@ -0,0 +1,288 @@
+package massdns
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/tmpcrypt"
+)
+
+// Stages of the enumeration reported in the progress events
+const (
+	StageMassdns   = "massdns"
+	StageParse     = "parse"
+	StageWildcards = "wildcards"
+	StageVerify    = "verify"
+	StageEnrich    = "enrich"
+	StageOutput    = "output"
+	StageDone      = "done"
+	StageFailed    = "failed"
+)
+
+// ProgressEvent is a progress event written as a json line to the
+// progress writer, at each stage and periodically during the stages
+// whose progress is measurable.
+type ProgressEvent struct {
+	Time  time.Time `json:"time"`
+	RunID string    `json:"run_id,omitempty"`
+	Stage string    `json:"stage"`
+	// Done and Total are the items processed by the stage, the names
+	// resolved or the ips checked for wildcards (0 if not measurable)
+	Done    int64   `json:"done"`
+	Total   int64   `json:"total"`
+	Percent float64 `json:"percent"`
+	// Results is the number of hosts found so far
+	Results int     `json:"results"`
+	Elapsed float64 `json:"elapsed"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// progress tracks the current stage of the enumeration
+type progress struct {
+	mutex   sync.Mutex
+	start   time.Time
+	stage   string
+	total   int64
+	results int
+	// done counts the items processed by the stage, and count returns
+	// the ones not counted yet if set
+	done  int64
+	count func() int64
+}
+
+// startProgress starts writing the progress events periodically until
+// the returned function is called with the result of the enumeration.
+func (c *Client) startProgress() func(err error) {
+	c.progress = &progress{start: time.Now()}
+	if c.config.Progress == nil {
+		return func(error) {}
+	}
+
+	interval := c.config.ProgressInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c.writeProgress("")
+			}
+		}
+	}()
+
+	return func(err error) {
+		close(done)
+		<-stopped
+		if err != nil {
+			c.setStage(StageFailed, 0)
+			c.writeProgress(err.Error())
+		} else {
+			c.setStage(StageDone, 0)
+		}
+	}
+}
+
+// setStage starts a new stage processing total items (0 if not
+// measurable) and writes its progress event.
+func (c *Client) setStage(stage string, total int64) {
+	if c.progress == nil {
+		return
+	}
+	c.progress.mutex.Lock()
+	c.progress.stage, c.progress.total, c.progress.count = stage, total, nil
+	atomic.StoreInt64(&c.progress.done, 0)
+	c.progress.mutex.Unlock()
+	if stage != StageFailed {
+		c.writeProgress("")
+	}
+}
+
+// startMassdnsStage starts the massdns stage, counting the names to
+// resolve for the progress events if they're written. Reading the whole
+// input is skipped otherwise, the state only showing the names done.
+func (c *Client) startMassdnsStage() error {
+	if c.config.Progress == nil {
+		c.setStage(StageMassdns, 0)
+		return nil
+	}
+	lines, err := countLines(c.config.TempKey, c.config.InputFile)
+	if err != nil {
+		return err
+	}
+	if c.config.Mutator != nil {
+		lines *= c.config.Mutator.Variations()
+	}
+	c.setStage(StageMassdns, int64(lines))
+	return nil
+}
+
+// setResults records the number of hosts found so far
+func (c *Client) setResults(st *store.Store) {
+	if c.progress == nil {
+		return
+	}
+	hosts := make(map[string]struct{})
+	for _, record := range st.IP {
+		for _, hostname := range record.Hostnames.List() {
+			hosts[hostname] = struct{}{}
+		}
+	}
+	c.progress.mutex.Lock()
+	c.progress.results = len(hosts)
+	c.progress.mutex.Unlock()
+}
+
+// addProgress counts items processed by the current stage
+func (c *Client) addProgress(n int64) {
+	if c.progress != nil {
+		atomic.AddInt64(&c.progress.done, n)
+	}
+}
+
+// countProgress sets the function counting the items processed by the
+// current stage not counted yet, replacing the previous one.
+func (c *Client) countProgress(count func() int64) {
+	if c.progress == nil {
+		return
+	}
+	c.progress.mutex.Lock()
+	if c.progress.count != nil {
+		atomic.AddInt64(&c.progress.done, c.progress.count())
+	}
+	c.progress.count = count
+	c.progress.mutex.Unlock()
+}
+
+// State returns the progress of the current stage of the enumeration,
+// with an empty stage if it's not running.
+func (c *Client) State() ProgressEvent {
+	event := ProgressEvent{Time: time.Now(), RunID: c.config.RunID}
+	if c.progress == nil {
+		return event
+	}
+
+	c.progress.mutex.Lock()
+	if c.progress.count != nil {
+		atomic.AddInt64(&c.progress.done, c.progress.count())
+	}
+	event.Stage = c.progress.stage
+	event.Done = atomic.LoadInt64(&c.progress.done)
+	event.Total = c.progress.total
+	event.Results = c.progress.results
+	event.Elapsed = time.Since(c.progress.start).Seconds()
+	c.progress.mutex.Unlock()
+
+	if event.Total > 0 {
+		if event.Done > event.Total {
+			event.Done = event.Total
+		}
+		event.Percent = float64(int(float64(event.Done)/float64(event.Total)*10000)) / 100
+	}
+	return event
+}
+
+// writeProgress writes the progress event of the current stage
+func (c *Client) writeProgress(errorMessage string) {
+	if c.progress == nil || c.config.Progress == nil {
+		return
+	}
+	event := c.State()
+	event.Error = errorMessage
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if _, err := c.config.Progress.Write(append(data, '\n')); err != nil {
+		c.log().Debug().Msgf("Could not write progress event: %s\n", err)
+	}
+}
+
+// fedCounter returns the function counting the names fed to massdns
+// since its previous call, whatever their answer
+func fedCounter(t *throttle) func() int64 {
+	last := t.Fed()
+	return func() int64 {
+		fed := t.Fed()
+		names := fed - last
+		last = fed
+		return names
+	}
+}
+
+// namesCounter counts the names answered in a massdns output file as
+// it grows, the records of a name being consecutive in the output.
+type namesCounter struct {
+	key     *tmpcrypt.Key
+	file    string
+	offset  int64
+	partial []byte
+	last    []byte
+}
+
+// count returns the names answered since the previous call
+func (n *namesCounter) count() int64 {
+	f, err := n.key.Open(n.file)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	if _, err := f.Seek(n.offset, io.SeekStart); err != nil {
+		return 0
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return 0
+	}
+	n.offset += int64(len(data))
+
+	var names int64
+	data = append(n.partial, data...)
+	for {
+		index := bytes.IndexByte(data, '\n')
+		if index < 0 {
+			break
+		}
+		if name := outputName(data[:index]); len(name) > 0 && !bytes.Equal(name, n.last) {
+			n.last = append(n.last[:0], name...)
+			names++
+		}
+		data = data[index+1:]
+	}
+	n.partial = append([]byte(nil), data...)
+	return names
+}
+
+// outputName returns the name of a massdns output line, in simple or
+// json format.
+func outputName(line []byte) []byte {
+	line = bytes.TrimSpace(line)
+	if bytes.HasPrefix(line, []byte("{")) {
+		const key = `"name":"`
+		index := bytes.Index(line, []byte(key))
+		if index < 0 {
+			return nil
+		}
+		name := line[index+len(key):]
+		if end := bytes.IndexByte(name, '"'); end >= 0 {
+			return name[:end]
+		}
+		return nil
+	}
+	if fields := bytes.Fields(line); len(fields) > 0 {
+		return fields[0]
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package massdns
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamesCounter(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "output")
+	counter := &namesCounter{file: output}
+	require.Equal(t, int64(0), counter.count(), "Could not count a missing output")
+
+	file, err := os.Create(output)
+	require.Nil(t, err, "Could not create output")
+	defer file.Close()
+
+	_, _ = file.WriteString("a.example.com. CNAME b.example.com.\na.example.com. A 1.1.1.1\nc.example.com. A 2.2.2.2\n{\"name\":\"d.exa")
+	require.Equal(t, int64(2), counter.count(), "Could not count the simple output names")
+
+	_, _ = file.WriteString("mple.com.\",\"status\":\"NOERROR\"}\n")
+	require.Equal(t, int64(1), counter.count(), "Could not count a line written in two parts")
+	require.Equal(t, int64(0), counter.count(), "Could not count the names only once")
+}
+
+func TestProgressEvents(t *testing.T) {
+	var buffer bytes.Buffer
+	c := &Client{config: Config{Progress: &buffer, RunID: "run"}}
+	stop := c.startProgress()
+	c.setStage(StageWildcards, 4)
+	c.addProgress(3)
+	c.writeProgress("")
+	stop(nil)
+
+	var events []ProgressEvent
+	decoder := json.NewDecoder(&buffer)
+	for decoder.More() {
+		var event ProgressEvent
+		require.Nil(t, decoder.Decode(&event), "Could not decode progress event")
+		events = append(events, event)
+	}
+	require.Len(t, events, 3, "Could not write the progress events")
+	require.Equal(t, StageWildcards, events[1].Stage, "Could not write the stage")
+	require.Equal(t, 75.0, events[1].Percent, "Could not compute the percent")
+	require.Equal(t, "run", events[1].RunID, "Could not write the run id")
+	require.Equal(t, StageDone, events[2].Stage, "Could not write the final stage")
+}
+
+func TestFedCounter(t *testing.T) {
+	throttle := newThrottle(0)
+	atomic.AddInt64(&throttle.fed, 2)
+	count := fedCounter(throttle)
+	require.Equal(t, int64(0), count(), "Could not skip the names fed before")
+
+	// The names are counted when fed, whether they get an answer or not
+	atomic.AddInt64(&throttle.fed, 5)
+	require.Equal(t, int64(5), count(), "Could not count the names fed")
+	require.Equal(t, int64(0), count(), "Could not count the names only once")
+}
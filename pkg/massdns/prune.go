@@ -0,0 +1,149 @@
+package massdns
+
+import (
+	"bufio"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/wildcards"
+	"github.com/remeh/sizedwaitgroup"
+	"github.com/rs/xid"
+)
+
+// parentName returns the name without its first label, or an empty
+// string for single label names.
+func parentName(name string) string {
+	index := strings.Index(name, ".")
+	if index == -1 {
+		return ""
+	}
+	return name[index+1:]
+}
+
+// underDomain returns true if a name is the domain or one of its
+// subdomains
+func underDomain(name, domain string) bool {
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
+
+// prunedName returns true if a name is below one of the wildcard
+// parents, and won't be resolved.
+func (c *Client) prunedName(name string) bool {
+	for parent := parentName(name); parent != ""; parent = parentName(parent) {
+		if _, ok := c.wildcardParents[parent]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// probeWildcardParents checks the parents of the names of the input
+// file under the domain for wildcards before resolving anything.
+func (c *Client) probeWildcardParents(inputFile string) error {
+	input, err := c.config.TempKey.Open(inputFile)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	parents := make(map[string]struct{})
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		parent := parentName(strings.TrimSpace(scanner.Text()))
+		if parent != "" && underDomain(parent, c.config.Domain) {
+			parents[parent] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	var mutex sync.Mutex
+	wg := sizedwaitgroup.New(c.config.WildcardsThreads)
+	for parent := range parents {
+		wg.Add()
+		go func(parent string) {
+			defer wg.Done()
+
+			if isWildcard, ips := wildcards.ProbeLevel(c.wildcardResolver, parent, wildcards.DefaultProbes); isWildcard {
+				mutex.Lock()
+				c.wildcardParents[parent] = struct{}{}
+				wildcards.MergeWildcard(c.probedWildcards, &wildcards.Wildcard{
+					Root:       "*." + parent,
+					Type:       "A",
+					IPs:        ips,
+					Strategy:   "probe",
+					Evidence:   wildcards.Evidence{ProbeIPs: ips},
+					DetectedAt: time.Now(),
+				})
+				mutex.Unlock()
+			}
+		}(parent)
+	}
+	wg.Wait()
+	return nil
+}
+
+// addWildcardRoots adds the wildcard roots found while filtering the
+// results to the wildcard parents, so that the next rounds of names
+// skip them.
+func (c *Client) addWildcardRoots() {
+	roots := c.wildcardResolver.Roots()
+	for _, resolver := range c.domainResolvers {
+		roots = append(roots, resolver.Roots()...)
+	}
+	for _, root := range roots {
+		c.wildcardParents[strings.TrimPrefix(root, "*.")] = struct{}{}
+	}
+}
+
+// filterPrunedInput writes a copy of the input file without the names
+// below the wildcard parents, returning the path of the new input file
+// and the number of names dropped.
+func (c *Client) filterPrunedInput(inputFile string) (string, int, error) {
+	prunedFile := filepath.Join(c.config.TempDir, xid.New().String())
+
+	output, err := c.config.TempKey.Create(prunedFile)
+	if err != nil {
+		return "", 0, err
+	}
+	defer output.Close()
+
+	input, err := c.config.TempKey.Open(inputFile)
+	if err != nil {
+		return "", 0, err
+	}
+	defer input.Close()
+
+	dropped := 0
+	w := bufio.NewWriter(output)
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" {
+			continue
+		}
+		if c.prunedName(name) {
+			dropped++
+			continue
+		}
+		_, _ = w.WriteString(name + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", 0, err
+	}
+	return prunedFile, dropped, w.Flush()
+}
+
+// sortedWildcardParents returns the wildcard parents sorted by name
+func (c *Client) sortedWildcardParents() []string {
+	parents := make([]string, 0, len(c.wildcardParents))
+	for parent := range c.wildcardParents {
+		parents = append(parents, parent)
+	}
+	sort.Strings(parents)
+	return parents
+}
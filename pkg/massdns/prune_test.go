@@ -0,0 +1,32 @@
+package massdns
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterPrunedInput(t *testing.T) {
+	dir := t.TempDir()
+	c := &Client{
+		config:          Config{TempDir: dir},
+		wildcardParents: map[string]struct{}{"dev.example.com": {}},
+	}
+
+	require.True(t, c.prunedName("a.dev.example.com"), "Could not prune a name below a wildcard parent")
+	require.True(t, c.prunedName("x.a.dev.example.com"), "Could not prune a deeper name")
+	require.False(t, c.prunedName("dev.example.com"), "Could not keep the wildcard parent")
+	require.False(t, c.prunedName("a.example.com"), "Could not keep a name outside of the wildcard parents")
+
+	input := filepath.Join(dir, "input")
+	require.Nil(t, os.WriteFile(input, []byte("a.dev.example.com\nwww.example.com\n\nb.dev.example.com\ndev.example.com\n"), 0644))
+
+	pruned, dropped, err := c.filterPrunedInput(input)
+	require.Nil(t, err, "Could not filter the input")
+	require.Equal(t, 2, dropped, "Could not count the pruned names")
+	data, err := os.ReadFile(pruned)
+	require.Nil(t, err, "Could not read the filtered input")
+	require.Equal(t, "www.example.com\ndev.example.com\n", string(data), "Could not keep the other names")
+}
@@ -0,0 +1,49 @@
+package massdns
+
+import (
+	"sync"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// enrichPTR performs reverse lookups for all the unique ips in the
+// store, remembering the reverse names to include them in the output.
+func (c *Client) enrichPTR(st *store.Store) {
+	var mutex sync.Mutex
+	wg := sizedwaitgroup.New(c.config.WildcardsThreads)
+
+	for ip := range st.IP {
+		wg.Add()
+		go func(ip string) {
+			defer wg.Done()
+
+			names, err := c.wildcardResolver.LookupPTR(ip)
+			if err != nil || len(names) == 0 {
+				return
+			}
+			mutex.Lock()
+			c.ptrNames[ip] = names
+			mutex.Unlock()
+		}(ip)
+	}
+	wg.Wait()
+
+	c.log().Info().Msgf("Found reverse names for %d/%d ips\n", len(c.ptrNames), len(st.IP))
+}
+
+// ptrRecords returns the unique reverse names of a list of ips
+func (c *Client) ptrRecords(ips []string) []string {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, ip := range ips {
+		for _, name := range c.ptrNames[ip] {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	return names
+}
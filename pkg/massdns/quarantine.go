@@ -0,0 +1,125 @@
+package massdns
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+)
+
+// Reasons for which a result is quarantined
+const (
+	reasonLyingResolver  = "lying-resolver"
+	reasonCanaryResolver = "canary-resolver"
+	reasonSuspiciousIP   = "suspicious-ip"
+)
+
+// ReadIPList reads a list of ips from a file, one per line
+func ReadIPList(file string) (map[string]struct{}, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ips := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		ip := strings.TrimSpace(scanner.Text())
+		if ip == "" || strings.HasPrefix(ip, "#") {
+			continue
+		}
+		ips[ip] = struct{}{}
+	}
+	return ips, scanner.Err()
+}
+
+// needsResolver returns true if the resolver that answered each
+// query is needed to decide whether results are suspicious.
+func (c *Client) needsResolver() bool {
+	return c.knownAnswersEnabled() || c.config.Canaries > 0 || c.config.ResolverStatsFile != "" || c.config.ResolverAgreement > 1
+}
+
+// quarantineResults collects the results which look suspicious along
+// with the reason they were quarantined for. Results are suspicious if
+// they were answered by a resolver caught lying on a known answer check
+// or resolving a canary, or if they resolved to a suspicious ip. The
+// results already verified are skipped.
+func (c *Client) quarantineResults(st *store.Store, verified map[string]struct{}) map[string]string {
+	quarantined := make(map[string]string)
+
+	suspiciousResolvers := make(map[string]string)
+	for resolver := range c.lyingResolvers() {
+		suspiciousResolvers[resolver] = reasonLyingResolver
+	}
+	for canary := range c.canaries {
+		if meta := st.GetHost(canary); meta != nil && meta.Resolver != "" {
+			if _, ok := suspiciousResolvers[meta.Resolver]; !ok {
+				suspiciousResolvers[meta.Resolver] = reasonCanaryResolver
+			}
+		}
+	}
+	if len(suspiciousResolvers) > 0 {
+		st.RangeHosts(func(hostname string, meta *store.HostMeta) {
+			// Canaries are accounted for and removed separately
+			if _, ok := c.canaries[hostname]; ok {
+				return
+			}
+			if _, ok := verified[hostname]; ok {
+				return
+			}
+			if reason, ok := suspiciousResolvers[meta.Resolver]; ok {
+				quarantined[hostname] = reason
+			}
+		})
+	}
+
+	for ip := range c.config.SuspiciousIPs {
+		record := st.Get(ip)
+		if record == nil {
+			continue
+		}
+		for _, hostname := range record.Hostnames.List() {
+			if _, ok := c.canaries[hostname]; ok {
+				continue
+			}
+			if _, ok := verified[hostname]; ok {
+				continue
+			}
+			if _, ok := quarantined[hostname]; !ok {
+				quarantined[hostname] = reasonSuspiciousIP
+			}
+		}
+	}
+	return quarantined
+}
+
+// processQuarantine re-verifies the suspicious results against the
+// trusted resolvers before they can enter the final output, except the
+// results already verified.
+func (c *Client) processQuarantine(st *store.Store, verified map[string]struct{}) {
+	quarantined := c.quarantineResults(st, verified)
+	if len(quarantined) == 0 {
+		return
+	}
+
+	hostnames := make([]string, 0, len(quarantined))
+	reasons := make(map[string]int)
+	for hostname, reason := range quarantined {
+		hostnames = append(hostnames, hostname)
+		reasons[reason]++
+	}
+	sort.Strings(hostnames)
+
+	var summary []string
+	for reason, count := range reasons {
+		summary = append(summary, reason+": "+strconv.Itoa(count))
+	}
+	sort.Strings(summary)
+
+	rejected := c.reverify(st, hostnames)
+	c.log().Info().Msgf("Quarantined %d suspicious results (%s), %d rejected after re-verification\n", len(hostnames), strings.Join(summary, ", "), rejected)
+}
@@ -0,0 +1,21 @@
+package massdns
+
+import (
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuarantineResultsVerified(t *testing.T) {
+	st := store.New()
+	defer st.Close()
+	st.New("192.0.2.66", "www.example.com")
+	st.Get("192.0.2.66").Hostnames.Add("tls.example.com")
+
+	c := &Client{config: Config{SuspiciousIPs: map[string]struct{}{"192.0.2.66": {}}}, knownAnswerStats: make(map[string]*knownAnswerStats)}
+	require.Equal(t, map[string]string{"www.example.com": reasonSuspiciousIP, "tls.example.com": reasonSuspiciousIP}, c.quarantineResults(st, nil), "Could not quarantine results")
+
+	verified := map[string]struct{}{"www.example.com": {}}
+	require.Equal(t, map[string]string{"tls.example.com": reasonSuspiciousIP}, c.quarantineResults(st, verified), "Could not skip verified results")
+}
@@ -0,0 +1,187 @@
+package massdns
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/tmpcrypt"
+)
+
+const (
+	// defaultRateLimitWindow is the default interval between two checks
+	// of the responses for rate limiting
+	defaultRateLimitWindow = 5 * time.Second
+	// rateLimitMinNames is the minimum number of names fed during a
+	// window to check the responses for rate limiting
+	rateLimitMinNames = 100
+	// rateLimitRefused is the share of REFUSED responses in a window
+	// above which the resolvers are considered rate limiting
+	rateLimitRefused = 0.2
+	// rateLimitMaxInterval is the interval between two names the input
+	// is never slowed down beyond
+	rateLimitMaxInterval = time.Second
+)
+
+// rateLimitStats summarizes the rate limiting detected during a run
+type rateLimitStats struct {
+	// SlowDowns is the number of times the input was slowed down
+	SlowDowns int
+	// Fed is the number of names fed to massdns while monitored
+	Fed int64
+	// Responses is the number of responses written by massdns
+	Responses int64
+	// Refused is the number of REFUSED responses
+	Refused int64
+	// QPS is the final rate of the names fed, 0 if never slowed down
+	QPS int
+}
+
+// rateWindow counts the responses written by massdns in its json output
+// file as it grows.
+type rateWindow struct {
+	key     *tmpcrypt.Key
+	file    string
+	offset  int64
+	partial []byte
+}
+
+// count returns the responses and the REFUSED responses written since
+// the previous call.
+func (w *rateWindow) count() (responses, refused int64) {
+	f, err := w.key.Open(w.file)
+	if err != nil {
+		return 0, 0
+	}
+	defer f.Close()
+	if _, err := f.Seek(w.offset, io.SeekStart); err != nil {
+		return 0, 0
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return 0, 0
+	}
+	w.offset += int64(len(data))
+
+	data = append(w.partial, data...)
+	for {
+		index := bytes.IndexByte(data, '\n')
+		if index < 0 {
+			break
+		}
+		if line := data[:index]; len(bytes.TrimSpace(line)) > 0 {
+			responses++
+			if bytes.Contains(line, []byte(`"status":"REFUSED"`)) {
+				refused++
+			}
+		}
+		data = data[index+1:]
+	}
+	w.partial = append(w.partial[:0], data...)
+	return responses, refused
+}
+
+// monitorRateLimits watches the responses written to the output for rate
+// limiting if enabled, returning the function stopping it.
+func (c *Client) monitorRateLimits(output string, t *throttle) func() {
+	if !c.config.AdaptiveRate {
+		return func() {}
+	}
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		c.watchRateLimits(output, t, done)
+		close(stopped)
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// watchRateLimits slows down the names fed to massdns when the share of
+// REFUSED responses or of names left unanswered spikes, until done is
+// closed. Unanswered names are the ones which timed out, the json output
+// containing a line for every other response.
+func (c *Client) watchRateLimits(output string, t *throttle, done chan struct{}) {
+	window := c.config.RateLimitWindow
+	if window <= 0 {
+		window = defaultRateLimitWindow
+	}
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+
+	counter := &rateWindow{key: c.config.TempKey, file: output}
+	lastFed := t.Fed()
+	// baseline is the best share of names answered in a window, to
+	// which the following windows are compared
+	var baseline float64
+	var settling bool
+	for {
+		select {
+		case <-done:
+			responses, refused := counter.count()
+			c.rateLimits.Fed += t.Fed() - lastFed
+			c.rateLimits.Responses += responses
+			c.rateLimits.Refused += refused
+			return
+		case <-ticker.C:
+		}
+
+		responses, refused := counter.count()
+		fed := t.Fed()
+		names := fed - lastFed
+		lastFed = fed
+		c.rateLimits.Fed += names
+		c.rateLimits.Responses += responses
+		c.rateLimits.Refused += refused
+
+		// The window following a slow down still contains the
+		// responses to the names sent before it
+		if settling {
+			settling = false
+			continue
+		}
+		if names < rateLimitMinNames || responses == 0 {
+			continue
+		}
+
+		answered := float64(responses) / float64(names)
+		if answered > 1 {
+			answered = 1
+		}
+		refusedShare := float64(refused) / float64(responses)
+		if answered > baseline {
+			baseline = answered
+		}
+		if refusedShare < rateLimitRefused && answered >= baseline/2 {
+			continue
+		}
+
+		// Halve the rate at which the names were fed in the window
+		interval := 2 * window / time.Duration(names)
+		if current := t.Interval(); 2*current > interval {
+			interval = 2 * current
+		}
+		if interval > rateLimitMaxInterval {
+			interval = rateLimitMaxInterval
+		}
+		if interval == t.Interval() {
+			continue
+		}
+		t.SetInterval(interval)
+		settling = true
+		c.rateLimits.SlowDowns++
+		c.rateLimits.QPS = int(time.Second / interval)
+		c.log().Info().Msgf("Rate limiting detected (%.0f%% refused, %.0f%% unanswered), slowing down to %d queries/sec\n", refusedShare*100, (1-answered)*100, c.rateLimits.QPS)
+	}
+}
+
+// reportRateLimits logs the summary of the rate limiting detected
+func (c *Client) reportRateLimits() {
+	stats := c.rateLimits
+	if stats.SlowDowns == 0 {
+		c.log().Info().Msgf("Rate limiting: none detected (%d names, %d responses, %d refused)\n", stats.Fed, stats.Responses, stats.Refused)
+		return
+	}
+	c.log().Info().Msgf("Rate limiting: slowed down %d times to %d queries/sec (%d names, %d responses, %d refused)\n", stats.SlowDowns, stats.QPS, stats.Fed, stats.Responses, stats.Refused)
+}
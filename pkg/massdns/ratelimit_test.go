@@ -0,0 +1,68 @@
+package massdns
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/mockdns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateWindowCount(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "output.json")
+	require.Nil(t, os.WriteFile(file, []byte(`{"name":"a.example.com.","status":"NOERROR"}
+{"name":"b.example.com.","status":"REFUSED"}
+{"name":"c.exa`), 0644), "Could not write output")
+
+	window := &rateWindow{file: file}
+	responses, refused := window.count()
+	require.Equal(t, int64(2), responses, "Could not count responses")
+	require.Equal(t, int64(1), refused, "Could not count refused responses")
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0)
+	require.Nil(t, err, "Could not open output")
+	_, err = f.WriteString(`mple.com.","status":"REFUSED"}` + "\n")
+	require.Nil(t, err, "Could not append output")
+	f.Close()
+
+	responses, refused = window.count()
+	require.Equal(t, int64(1), responses, "Could not count partial response")
+	require.Equal(t, int64(1), refused, "Could not count partial refused response")
+}
+
+func TestRateLimitSlowDown(t *testing.T) {
+	var fixture, names strings.Builder
+	for i := 0; i < 600; i++ {
+		fmt.Fprintf(&fixture, "host%d.example.com REFUSED\n", i)
+		fmt.Fprintf(&names, "host%d.example.com\n", i)
+	}
+	zone, err := mockdns.Parse(strings.NewReader(fixture.String()))
+	require.Nil(t, err, "Could not parse fixture")
+	server, err := mockdns.Start(zone)
+	require.Nil(t, err, "Could not start mock dns")
+	defer server.Close()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	require.Nil(t, os.WriteFile(input, []byte(names.String()), 0644), "Could not write input")
+
+	client, err := New(Config{
+		Domain:          "example.com",
+		MockDNS:         server,
+		InputFile:       input,
+		TempDir:         dir,
+		AdaptiveRate:    true,
+		RateLimitWindow: 100 * time.Millisecond,
+	})
+	require.Nil(t, err, "Could not create client")
+
+	throttle := newThrottle(time.Millisecond)
+	require.Nil(t, client.execMock(filepath.Join(dir, "output.json"), "J", throttle), "Could not resolve names")
+	require.Greater(t, client.rateLimits.SlowDowns, 0, "Could not detect rate limiting")
+	require.Greater(t, throttle.Interval(), time.Millisecond, "Could not slow down input")
+	require.Equal(t, int64(600), client.rateLimits.Refused, "Could not count refused responses")
+}
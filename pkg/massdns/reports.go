@@ -0,0 +1,125 @@
+package massdns
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/cdn"
+	"github.com/mohammadanaraki/shuffledns/pkg/history"
+	"github.com/mohammadanaraki/shuffledns/pkg/netblocks"
+	"github.com/mohammadanaraki/shuffledns/pkg/report"
+)
+
+// buildReport gathers the results and the wildcards found for the report
+func (c *Client) buildReport(st *store.Store, hostnames []string, hostIPs map[string][]string, statuses map[string]history.Status) *report.Report {
+	r := &report.Report{
+		Domain:    c.config.Domain,
+		RunID:     c.config.RunID,
+		Generated: time.Now(),
+	}
+
+	for _, hostname := range hostnames {
+		host := report.Host{
+			Name:    hostname,
+			IPs:     hostIPs[hostname],
+			Status:  string(statuses[hostname]),
+			Private: hasPrivateIP(hostIPs[hostname]),
+		}
+		if meta := st.GetHost(hostname); meta != nil {
+			host.CNAME = meta.CNAME
+		}
+		if provider := c.cdnProvider(host.IPs); provider != cdn.None {
+			host.Provider = provider
+		}
+		r.Hosts = append(r.Hosts, host)
+	}
+
+	// The roots of the additional domains enumerated are included
+	roots := make(map[string]struct{})
+	for _, root := range c.wildcardResolver.Roots() {
+		roots[root] = struct{}{}
+	}
+	for _, resolver := range c.domainResolvers {
+		for _, root := range resolver.Roots() {
+			roots[root] = struct{}{}
+		}
+	}
+	for root := range roots {
+		r.WildcardRoots = append(r.WildcardRoots, root)
+	}
+	sort.Strings(r.WildcardRoots)
+
+	c.wildcardIPMutex.RLock()
+	r.WildcardIPs = len(c.wildcardIPMap)
+	c.wildcardIPMutex.RUnlock()
+
+	if c.config.ClusterASN {
+		c.addReportASNs(r, hostIPs)
+	}
+
+	r.EmailPostures = c.emailPostures
+	return r
+}
+
+// addReportASNs looks up the asns of the netblocks of the hosts, the
+// ones already looked up for the ip clusters being reused, and sets
+// the asns of each host of the report.
+func (c *Client) addReportASNs(r *report.Report, hostIPs map[string][]string) {
+	asns, names := c.lookupASNs(netblocks.Group(hostIPs, netblocks.Prefix))
+	r.ASNNames = names
+	for i := range r.Hosts {
+		seen := make(map[string]struct{})
+		for _, ip := range r.Hosts[i].IPs {
+			asn := asns[netblocks.Prefix(ip)]
+			if _, ok := seen[asn]; ok || asn == "" {
+				continue
+			}
+			seen[asn] = struct{}{}
+			r.Hosts[i].ASNs = append(r.Hosts[i].ASNs, asn)
+		}
+		sort.Strings(r.Hosts[i].ASNs)
+	}
+}
+
+// writeReport writes the html report and the markdown summary of the
+// results if asked by the user
+func (c *Client) writeReport(st *store.Store, hostnames []string, hostIPs map[string][]string, statuses map[string]history.Status) error {
+	if c.config.ReportFile == "" && c.config.ReportMarkdownFile == "" {
+		return nil
+	}
+
+	r := c.buildReport(st, hostnames, hostIPs, statuses)
+	reports := []struct {
+		file  string
+		write func(io.Writer) error
+	}{
+		{c.config.ReportFile, r.WriteHTML},
+		{c.config.ReportMarkdownFile, r.WriteMarkdown},
+	}
+	for _, report := range reports {
+		if report.file == "" {
+			continue
+		}
+		if err := writeReportFile(report.file, report.write); err != nil {
+			return fmt.Errorf("could not write report %s: %w", report.file, err)
+		}
+	}
+	return nil
+}
+
+// writeReportFile creates a file and writes a report to it
+func writeReportFile(file string, write func(io.Writer) error) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,92 @@
+package massdns
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/parser"
+)
+
+// ResolverStats contains the outcomes of the queries sent to a resolver.
+// Timeouts are not part of the massdns output, so they can't be
+// attributed to a resolver and are counted for the whole run.
+type ResolverStats struct {
+	Resolver string `json:"resolver"`
+	// Answers is the number of successful responses with records
+	Answers int `json:"answers"`
+	// NoData is the number of successful responses without records
+	NoData   int `json:"nodata"`
+	NXDomain int `json:"nxdomain"`
+	ServFail int `json:"servfail"`
+	Refused  int `json:"refused"`
+	Other    int `json:"other"`
+}
+
+// resolverStatsFile is the content of the resolver statistics file
+type resolverStatsFile struct {
+	// Timeouts is the number of names without any response once all
+	// their attempts, sent to random resolvers by massdns, timed out
+	Timeouts  int              `json:"timeouts"`
+	Resolvers []*ResolverStats `json:"resolvers"`
+}
+
+// collectResolverStats counts the responses per resolver in a massdns
+// json output file, and the names fed to massdns without a response.
+func (c *Client) collectResolverStats(output string) error {
+	remaining, count, err := c.remainingNames(output)
+	if err != nil {
+		return err
+	}
+	_ = os.Remove(remaining)
+	c.resolverTimeouts += count
+
+	file, err := c.config.TempKey.Open(output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return parser.ParseJSONResponses(file, func(response *parser.Response) {
+		stats, ok := c.resolverStats[response.Resolver]
+		if !ok {
+			stats = &ResolverStats{Resolver: response.Resolver}
+			c.resolverStats[response.Resolver] = stats
+		}
+		switch response.Status {
+		case "NOERROR":
+			if response.Answers > 0 {
+				stats.Answers++
+			} else {
+				stats.NoData++
+			}
+		case "NXDOMAIN":
+			stats.NXDomain++
+		case "SERVFAIL":
+			stats.ServFail++
+		case "REFUSED":
+			stats.Refused++
+		default:
+			stats.Other++
+		}
+	})
+}
+
+// writeResolverStats writes the resolver statistics sorted by resolver
+// to the stats file.
+func (c *Client) writeResolverStats() error {
+	stats := make([]*ResolverStats, 0, len(c.resolverStats))
+	for _, resolverStats := range c.resolverStats {
+		stats = append(stats, resolverStats)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Resolver < stats[j].Resolver
+	})
+
+	data, err := json.MarshalIndent(&resolverStatsFile{Timeouts: c.resolverTimeouts, Resolvers: stats}, "", "  ")
+	if err != nil {
+		return err
+	}
+	c.log().Info().Msgf("Writing statistics of %d resolvers to %s\n", len(stats), c.config.ResolverStatsFile)
+	return os.WriteFile(c.config.ResolverStatsFile, append(data, '\n'), 0644)
+}
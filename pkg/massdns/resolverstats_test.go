@@ -0,0 +1,35 @@
+package massdns
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolverStats(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	output := filepath.Join(dir, "output")
+	require.Nil(t, os.WriteFile(input, []byte("a.example.com\nb.example.com\nc.example.com\n"), 0644))
+	data := `{"name":"a.example.com.","status":"NOERROR","resolver":"1.1.1.1:53","data":{"answers":[{"name":"a.example.com.","type":"A","data":"10.0.0.1"}]}}
+{"name":"b.example.com.","status":"NXDOMAIN","resolver":"8.8.8.8:53"}
+`
+	require.Nil(t, os.WriteFile(output, []byte(data), 0644))
+
+	c := &Client{config: Config{TempDir: dir, InputFile: input, ResolverStatsFile: filepath.Join(dir, "stats.json")}, resolverStats: make(map[string]*ResolverStats)}
+	require.Nil(t, c.collectResolverStats(output), "Could not collect resolver statistics")
+	require.Nil(t, c.writeResolverStats(), "Could not write resolver statistics")
+
+	content, err := os.ReadFile(c.config.ResolverStatsFile)
+	require.Nil(t, err, "Could not read resolver statistics")
+	var stats resolverStatsFile
+	require.Nil(t, json.Unmarshal(content, &stats), "Could not parse resolver statistics")
+	require.Equal(t, 1, stats.Timeouts, "Could not count the names without response")
+	require.Equal(t, []*ResolverStats{
+		{Resolver: "1.1.1.1:53", Answers: 1},
+		{Resolver: "8.8.8.8:53", NXDomain: 1},
+	}, stats.Resolvers, "Could not count the responses per resolver")
+}
@@ -0,0 +1,74 @@
+package massdns
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// verifySample re-resolves a random sample of the results with the
+// trusted resolvers and reports the rate of results they disagree
+// with, which don't resolve or share no ip with the result.
+func (c *Client) verifySample(st *store.Store) {
+	hostIPs := make(map[string]map[string]struct{})
+	for ip, record := range st.IP {
+		for _, hostname := range record.Hostnames.List() {
+			if _, ok := hostIPs[hostname]; !ok {
+				hostIPs[hostname] = make(map[string]struct{})
+			}
+			hostIPs[hostname][ip] = struct{}{}
+		}
+	}
+	if len(hostIPs) == 0 {
+		return
+	}
+
+	hostnames := make([]string, 0, len(hostIPs))
+	for hostname := range hostIPs {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	random.Shuffle(len(hostnames), func(i, j int) {
+		hostnames[i], hostnames[j] = hostnames[j], hostnames[i]
+	})
+	size := int(math.Ceil(float64(len(hostnames)) * c.config.VerifySample))
+	if size > len(hostnames) {
+		size = len(hostnames)
+	}
+	sample := hostnames[:size]
+
+	var disagreements, unreachable int64
+	wg := sizedwaitgroup.New(c.config.WildcardsThreads)
+	for _, hostname := range sample {
+		wg.Add()
+		go func(hostname string) {
+			defer wg.Done()
+
+			ips, err := c.wildcardResolver.Resolve(hostname)
+			if err != nil {
+				atomic.AddInt64(&unreachable, 1)
+				return
+			}
+			for _, ip := range ips {
+				if _, ok := hostIPs[hostname][ip]; ok {
+					return
+				}
+			}
+			atomic.AddInt64(&disagreements, 1)
+		}(hostname)
+	}
+	wg.Wait()
+
+	verified := int64(len(sample)) - unreachable
+	if verified == 0 {
+		c.log().Info().Msgf("Verification sample: the trusted resolvers could not be reached for the %d sampled results\n", len(sample))
+		return
+	}
+	c.log().Info().Msgf("Verification sample: %d/%d results disagree with the trusted resolvers (%.2f%%)\n", disagreements, verified, float64(disagreements)*100/float64(verified))
+}
@@ -0,0 +1,72 @@
+package massdns
+
+import (
+	"bufio"
+	"path/filepath"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/rs/xid"
+)
+
+// filterScopeInput writes a copy of the input file without the
+// out-of-scope names, returning the path of the new input file and
+// the number of names dropped.
+func (c *Client) filterScopeInput(inputFile string) (string, int, error) {
+	scopeFile := filepath.Join(c.config.TempDir, xid.New().String())
+
+	output, err := c.config.TempKey.Create(scopeFile)
+	if err != nil {
+		return "", 0, err
+	}
+	defer output.Close()
+
+	input, err := c.config.TempKey.Open(inputFile)
+	if err != nil {
+		return "", 0, err
+	}
+	defer input.Close()
+
+	dropped := 0
+	w := bufio.NewWriter(output)
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" {
+			continue
+		}
+		if !c.config.Scope.InScope(name) {
+			dropped++
+			continue
+		}
+		_, _ = w.WriteString(name + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", 0, err
+	}
+	return scopeFile, dropped, w.Flush()
+}
+
+// filterScopeResults removes the out-of-scope hostnames and ips from
+// the store, returning the number of hostnames dropped.
+func (c *Client) filterScopeResults(st *store.Store) int {
+	dropped := make(map[string]struct{})
+	for ip, record := range st.IP {
+		ipInScope := c.config.Scope.IPInScope(ip)
+		for _, hostname := range record.Hostnames.List() {
+			if !ipInScope || !c.config.Scope.InScope(hostname) {
+				record.Hostnames.Remove(hostname)
+				dropped[hostname] = struct{}{}
+			}
+		}
+		if record.Hostnames.Len() == 0 {
+			st.Delete(ip)
+		}
+	}
+
+	// Hostnames with other in-scope ips are not dropped
+	for hostname := range knownHostnames(st) {
+		delete(dropped, hostname)
+	}
+	return len(dropped)
+}
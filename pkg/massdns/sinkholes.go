@@ -0,0 +1,121 @@
+package massdns
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+)
+
+//go:embed sinkholes.txt
+var builtinSinkholes string
+
+// Sinkholes matches ips used by dns hijacking, ad/search redirection
+// and sinkholing instead of the real answers.
+type Sinkholes struct {
+	ips      map[string]struct{}
+	networks []*net.IPNet
+}
+
+// loopbackNetwork is the loopback range, used by blocking resolvers
+var loopbackNetwork = &net.IPNet{IP: net.IPv4(127, 0, 0, 0), Mask: net.CIDRMask(8, 32)}
+
+// LoadSinkholes loads the built-in sinkhole list extended with the
+// ips and cidrs contained in the optional extra file, and with the
+// loopback range if asked.
+func LoadSinkholes(extraFile string, loopback bool) (*Sinkholes, error) {
+	sinkholes := &Sinkholes{ips: make(map[string]struct{})}
+	if err := sinkholes.read(strings.NewReader(builtinSinkholes)); err != nil {
+		return nil, err
+	}
+	if loopback {
+		sinkholes.networks = append(sinkholes.networks, loopbackNetwork)
+	}
+
+	if extraFile != "" {
+		f, err := os.Open(extraFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		if err := sinkholes.read(f); err != nil {
+			return nil, err
+		}
+	}
+	return sinkholes, nil
+}
+
+// read reads a list of ips and cidrs, one per line
+func (s *Sinkholes) read(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, "/") {
+			_, network, err := net.ParseCIDR(line)
+			if err != nil {
+				return fmt.Errorf("invalid sinkhole cidr: %s", line)
+			}
+			s.networks = append(s.networks, network)
+			continue
+		}
+		if net.ParseIP(line) == nil {
+			return fmt.Errorf("invalid sinkhole ip: %s", line)
+		}
+		s.ips[line] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Contains returns true if an ip is a known sinkhole
+func (s *Sinkholes) Contains(ip string) bool {
+	if _, ok := s.ips[ip]; ok {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range s.networks {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSinkholes drops the sinkhole ips from the store or, if the
+// user asked to only flag them, remembers them to tag the output.
+func (c *Client) filterSinkholes(st *store.Store) {
+	var found, hostnames int
+	for ip, record := range st.IP {
+		if !c.config.Sinkholes.Contains(ip) {
+			continue
+		}
+		found++
+		hostnames += record.Hostnames.Len()
+
+		if c.config.FlagSinkholes {
+			c.sinkholeIPs[ip] = struct{}{}
+			continue
+		}
+		st.Delete(ip)
+	}
+	if found == 0 {
+		return
+	}
+
+	if c.config.FlagSinkholes {
+		c.log().Info().Msgf("Flagged %d sinkhole ips resolved by %d results\n", found, hostnames)
+	} else {
+		c.log().Info().Msgf("Dropped %d sinkhole ips resolved by %d results\n", found, hostnames)
+	}
+}
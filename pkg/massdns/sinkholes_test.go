@@ -0,0 +1,22 @@
+package massdns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSinkholesContains(t *testing.T) {
+	sinkholes, err := LoadSinkholes("", false)
+	require.Nil(t, err, "Could not load built-in sinkholes")
+
+	require.True(t, sinkholes.Contains("0.0.0.0"), "Could not match sinkhole ip")
+	require.True(t, sinkholes.Contains("127.0.53.53"), "Could not match sinkhole ip")
+	require.False(t, sinkholes.Contains("127.1.2.3"), "Could not keep loopback ip")
+	require.False(t, sinkholes.Contains("185.199.111.153"), "Could not skip regular ip")
+	require.False(t, sinkholes.Contains("not-an-ip"), "Could not skip invalid ip")
+
+	sinkholes, err = LoadSinkholes("", true)
+	require.Nil(t, err, "Could not load built-in sinkholes")
+	require.True(t, sinkholes.Contains("127.1.2.3"), "Could not match loopback ip")
+}
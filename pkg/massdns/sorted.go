@@ -0,0 +1,21 @@
+package massdns
+
+import "github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+
+// Orders of the sorted output
+const (
+	// SortAlphabetical orders the output by hostname
+	SortAlphabetical = "alpha"
+	// SortReversed orders the output by the reversed labels of the
+	// hostnames (com.example.api), grouping the hosts of each domain
+	SortReversed = "reverse"
+)
+
+// sortKey returns the key ordering an output line in the sorted output
+func (c *Client) sortKey(line string) string {
+	hostname := lineHostname(line)
+	if c.config.Sorted == SortReversed {
+		return dnsname.Reverse(hostname)
+	}
+	return hostname
+}
@@ -0,0 +1,49 @@
+package massdns
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrStopped is returned by the processing of a client stopped with Stop
+var ErrStopped = errors.New("run stopped")
+
+// stopper stops the massdns execution running, if any
+type stopper struct {
+	stopped int32
+	mutex   sync.Mutex
+	// cancel interrupts the massdns execution running
+	cancel func()
+}
+
+// Stop stops the processing of the client: the massdns execution
+// running is interrupted and the processing returns ErrStopped without
+// writing the results, once the stage running returns. It can be called
+// from any goroutine, more than once.
+func (c *Client) Stop() {
+	atomic.StoreInt32(&c.stop.stopped, 1)
+
+	c.stop.mutex.Lock()
+	defer c.stop.mutex.Unlock()
+	if c.stop.cancel != nil {
+		c.stop.cancel()
+	}
+}
+
+// stopped returns true if the client was stopped
+func (c *Client) stopped() bool {
+	return atomic.LoadInt32(&c.stop.stopped) == 1
+}
+
+// setCancel sets the function interrupting the massdns execution
+// running, nil once it's done. It's called right away if the client was
+// already stopped.
+func (c *Client) setCancel(cancel func()) {
+	c.stop.mutex.Lock()
+	defer c.stop.mutex.Unlock()
+	c.stop.cancel = cancel
+	if cancel != nil && c.stopped() {
+		cancel()
+	}
+}
@@ -0,0 +1,81 @@
+package massdns
+
+import (
+	"os"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/history"
+)
+
+// streamResults contains the results written with the chunks of a
+// stream, which are summarized once the stream is over. Only the
+// validated results are kept, the answers of each chunk being released
+// once its results are written.
+type streamResults struct {
+	// store contains the validated results of the chunks
+	store *store.Store
+	// statuses contains the history statuses of the results
+	statuses map[string]history.Status
+	// written contains the hostnames already written
+	written hostnameSet
+	// existing contains the hostnames present in the output file before
+	// the run, when appending unique hostnames to it
+	existing hostnameSet
+	// chunks is the number of chunks whose results were written
+	chunks int
+}
+
+// newStreamResults returns the results of a stream
+func newStreamResults() *streamResults {
+	return &streamResults{
+		store:    store.New(),
+		statuses: make(map[string]history.Status),
+		written:  make(hostnameSet),
+	}
+}
+
+// unwritten returns the hostnames not written with the previous chunks,
+// recording them as written
+func (s *streamResults) unwritten(hostnames []string) []string {
+	fresh := hostnames[:0]
+	for _, hostname := range hostnames {
+		if s.written.Add(hostname) {
+			fresh = append(fresh, hostname)
+		}
+	}
+	return fresh
+}
+
+// add keeps the results written for a chunk along with their metadata
+func (s *streamResults) add(chunkStore *store.Store, hostnames []string, hostIPs map[string][]string, statuses map[string]history.Status) {
+	for _, hostname := range hostnames {
+		for _, ip := range hostIPs[hostname] {
+			if record := s.store.Get(ip); record != nil {
+				record.Hostnames.Add(hostname)
+				record.Counter++
+			} else {
+				s.store.New(ip, hostname)
+			}
+		}
+		if meta := chunkStore.GetHost(hostname); meta != nil {
+			s.store.SetHost(hostname, meta)
+		}
+		if status, ok := statuses[hostname]; ok {
+			s.statuses[hostname] = status
+		}
+	}
+}
+
+// removeTemp removes temporary files once they're no longer needed,
+// with the RemoveTemp function of the config if any
+func (c *Client) removeTemp(files ...string) {
+	remove := c.config.RemoveTemp
+	if remove == nil {
+		remove = os.Remove
+	}
+	for _, file := range files {
+		if err := remove(file); err != nil && !os.IsNotExist(err) {
+			c.log().Warning().Msgf("Could not remove temporary file %s: %s\n", file, err)
+		}
+	}
+}
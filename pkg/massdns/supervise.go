@@ -0,0 +1,329 @@
+package massdns
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/mohammadanaraki/shuffledns/pkg/tmpcrypt"
+	"github.com/rs/xid"
+)
+
+// execMassDNS runs massdns once on the input file. The process is
+// killed if it makes no progress, neither reading input nor writing
+// output, for the hang timeout.
+func (c *Client) execMassDNS(output, outputFormat string, t *throttle) error {
+	if c.config.MockDNS != nil {
+		return c.execMock(output, outputFormat, t)
+	}
+
+	// With encrypted temporary files, massdns writes its output to
+	// stdout, encrypted before reaching the disk
+	encrypted := c.config.TempKey.Encrypts(output)
+	massdnsOutput := output
+	if encrypted {
+		massdnsOutput = "/dev/stdout"
+	}
+	args := []string{"-r", c.config.ResolversFile, "-o", outputFormat, "-t", "A", "-w", massdnsOutput, "-s", strconv.Itoa(c.config.Threads)}
+	// When throttled, the names are fed to massdns through stdin at
+	// the maximum rate instead of letting it read the whole file. The
+	// mutations of the names are generated while feeding them too, so
+	// that the expanded names are never written to disk, and so is the
+	// input slowed down when rate limiting is detected or spaced per
+	// nameserver set. An encrypted input is decrypted while fed, and
+	// so is the input when the progress is written, to count the names
+	// sent whatever their answer.
+	interval := t.Interval()
+	feed := interval > 0 || c.config.Mutator != nil || c.config.AdaptiveRate || t.limiter != nil || c.config.TempKey.Encrypts(c.config.InputFile) || c.config.Progress != nil
+	if feed {
+		args = append(args, "-")
+	} else {
+		args = append(args, c.config.InputFile)
+	}
+	cmd := exec.Command(c.config.MassdnsPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if encrypted {
+		file, err := c.config.TempKey.Create(output)
+		if err != nil {
+			return fmt.Errorf("could not create massdns output: %w", err)
+		}
+		defer file.Close()
+		cmd.Stdout = file
+	}
+
+	var fed int64
+	var throttleErr chan error
+	if feed {
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return fmt.Errorf("could not create massdns input pipe: %w", err)
+		}
+		if interval > 0 {
+			c.log().Info().Msgf("Throttling massdns input to one query every %s\n", interval)
+		}
+		throttleErr = make(chan error, 1)
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("could not execute massdns: %w", err)
+		}
+		go func() {
+			writer := &countingWriter{WriteCloser: stdin, count: &fed}
+			throttleErr <- throttleInput(c.config.TempKey, c.config.InputFile, writer, t, c.config.Jitter, c.expandUnique)
+		}()
+	} else if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not execute massdns: %w", err)
+	}
+	c.setCancel(func() { _ = cmd.Process.Kill() })
+	defer c.setCancel(nil)
+
+	// Report the names fed, or else the names answered as the output
+	// grows, the names without answer being missing from it
+	if feed {
+		c.countProgress(fedCounter(t))
+	} else {
+		counter := &namesCounter{key: c.config.TempKey, file: output}
+		c.countProgress(counter.count)
+	}
+	stopRateLimits := c.monitorRateLimits(output, t)
+
+	done := make(chan struct{})
+	var hung int32
+	if c.config.HangTimeout > 0 {
+		go c.watchProgress(cmd, output, &fed, &hung, done)
+	}
+	err := cmd.Wait()
+	close(done)
+	stopRateLimits()
+	c.writeProgress("")
+	c.classifyDiagnostics(stderr.String())
+
+	if atomic.LoadInt32(&hung) == 1 {
+		return fmt.Errorf("massdns made no progress for %s\ndetailed error: %s", c.config.HangTimeout, stderr.String())
+	}
+	if err != nil {
+		return fmt.Errorf("could not execute massdns: %w\ndetailed error: %s", err, stderr.String())
+	}
+	if throttleErr != nil {
+		if err := <-throttleErr; err != nil {
+			return fmt.Errorf("could not write massdns input: %w", err)
+		}
+	}
+	if stderr.Len() > 0 {
+		c.log().Debug().Msgf("Massdns diagnostics: %s\n", stderr.String())
+	}
+	return nil
+}
+
+// watchProgress kills massdns when neither the input fed to it nor its
+// output grew for the hang timeout, until done is closed.
+func (c *Client) watchProgress(cmd *exec.Cmd, output string, fed *int64, hung *int32, done chan struct{}) {
+	check := c.config.HangTimeout / 10
+	if check > 10*time.Second {
+		check = 10 * time.Second
+	}
+	ticker := time.NewTicker(check)
+	defer ticker.Stop()
+
+	var last int64 = -1
+	lastProgress, lastCheck := time.Now(), time.Now()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		// A long gap between checks means the process was suspended,
+		// e.g. by pausing its daemon job, which is not a hang
+		if time.Since(lastCheck) > 2*check {
+			lastProgress = time.Now()
+		}
+		lastCheck = time.Now()
+
+		progress := atomic.LoadInt64(fed)
+		if info, err := os.Stat(output); err == nil {
+			progress += info.Size()
+		}
+		if progress != last {
+			last, lastProgress = progress, time.Now()
+			continue
+		}
+		if time.Since(lastProgress) >= c.config.HangTimeout {
+			atomic.StoreInt32(hung, 1)
+			_ = cmd.Process.Kill()
+			return
+		}
+	}
+}
+
+// countingWriter counts the bytes written to a writer
+type countingWriter struct {
+	io.WriteCloser
+	count *int64
+}
+
+// Write implements io.Writer
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	atomic.AddInt64(w.count, int64(n))
+	return n, err
+}
+
+// remainingNames writes the names of the input which are not answered
+// in the massdns output, returning the path of the file and the number
+// of names.
+func (c *Client) remainingNames(output string) (string, int, error) {
+	answered, err := answeredNames(c.config.TempKey, output)
+	if err != nil {
+		return "", 0, err
+	}
+
+	input, err := c.config.TempKey.Open(c.config.InputFile)
+	if err != nil {
+		return "", 0, err
+	}
+	defer input.Close()
+
+	remainingFile := filepath.Join(c.config.TempDir, xid.New().String())
+	file, err := c.config.TempKey.Create(remainingFile)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	// The names dropped by the scope, the depth, the plugins, invalid or
+	// duplicate were already counted
+	scopeDropped, depthDropped, pluginDropped, duplicateNames := c.scopeDropped, c.depthDropped, c.pluginDropped, c.duplicateNames
+	invalidNames := make(map[string]int, len(c.invalidNames))
+	for reason, count := range c.invalidNames {
+		invalidNames[reason] = count
+	}
+	defer func() {
+		c.scopeDropped, c.depthDropped, c.pluginDropped, c.duplicateNames, c.invalidNames = scopeDropped, depthDropped, pluginDropped, duplicateNames, invalidNames
+	}()
+
+	// The variations skipped as duplicates are skipped again
+	seen := make(hostnameSet)
+	var count int
+	w := bufio.NewWriter(file)
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		names := c.expandName(scanner.Text())
+		if c.config.Mutator != nil && c.dedupEnabled() {
+			names = c.uniqueNames(names, seen)
+		}
+		for _, name := range names {
+			if _, ok := answered[dnsname.Normalize(name)]; ok || name == "" {
+				continue
+			}
+			_, _ = w.WriteString(name + "\n")
+			count++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", 0, err
+	}
+	return remainingFile, count, w.Flush()
+}
+
+// answeredNames returns the names answered in a massdns output file,
+// either in simple or json format.
+func answeredNames(key *tmpcrypt.Key, output string) (map[string]struct{}, error) {
+	file, err := key.Open(output)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	names := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var name string
+		if strings.HasPrefix(line, "{") {
+			var record struct {
+				Name string `json:"name"`
+			}
+			// A truncated last line is simply not answered
+			if json.Unmarshal([]byte(line), &record) != nil {
+				continue
+			}
+			name = record.Name
+		} else {
+			name = strings.Fields(line)[0]
+		}
+		names[dnsname.Normalize(name)] = struct{}{}
+	}
+	return names, scanner.Err()
+}
+
+// trimPartialLine removes the last line of a file if it's not complete
+func trimPartialLine(key *tmpcrypt.Key, file string) error {
+	f, err := key.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	size, err := f.Size()
+	if err != nil {
+		return err
+	}
+
+	// Read the file backwards until the end of the last complete line
+	buffer := make([]byte, 4096)
+	for end := size; end > 0; {
+		start := end - int64(len(buffer))
+		if start < 0 {
+			start = 0
+		}
+		chunk := buffer[:end-start]
+		if _, err := f.ReadAt(chunk, start); err != nil {
+			return err
+		}
+		if index := bytes.LastIndexByte(chunk, '\n'); index >= 0 {
+			if start+int64(index)+1 == size {
+				return nil
+			}
+			return key.Truncate(file, start+int64(index)+1)
+		}
+		end = start
+	}
+	return key.Truncate(file, 0)
+}
+
+// appendFile appends the content of a file to another one
+func appendFile(key *tmpcrypt.Key, dst, src string) error {
+	in, err := key.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := key.Append(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
@@ -0,0 +1,34 @@
+package massdns
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrimPartialLine(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "output")
+	require.Nil(t, os.WriteFile(file, []byte("a.example.com. A 10.0.0.1\n\nb.example.com. A 10.0"), 0644))
+
+	require.Nil(t, trimPartialLine(nil, file), "Could not trim partial line")
+	data, err := os.ReadFile(file)
+	require.Nil(t, err)
+	require.Equal(t, "a.example.com. A 10.0.0.1\n\n", string(data), "Could not remove partial line")
+
+	require.Nil(t, trimPartialLine(nil, file), "Could not trim complete file")
+	data, err = os.ReadFile(file)
+	require.Nil(t, err)
+	require.Equal(t, "a.example.com. A 10.0.0.1\n\n", string(data), "Could not keep complete lines")
+}
+
+func TestAnsweredNames(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "output")
+	data := "A.example.com. A 10.0.0.1\n\n{\"name\":\"b.example.com.\",\"status\":\"NOERROR\"}\n{\"name\":\"c.exa"
+	require.Nil(t, os.WriteFile(file, []byte(data), 0644))
+
+	names, err := answeredNames(nil, file)
+	require.Nil(t, err, "Could not read answered names")
+	require.Equal(t, map[string]struct{}{"a.example.com": {}, "b.example.com": {}}, names, "Could not get answered names")
+}
@@ -0,0 +1,166 @@
+package massdns
+
+import (
+	"bufio"
+	"io"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/tmpcrypt"
+)
+
+// AverageQuerySize is the average number of bytes transferred for a
+// single query, counting the query and the response along with their
+// IP and UDP headers. It's used to convert a bandwidth to queries/sec.
+const AverageQuerySize = 200
+
+// QPSFromBandwidth returns the queries per second sustainable
+// with a bandwidth expressed in bits per second.
+func QPSFromBandwidth(bitsPerSecond int64) int {
+	qps := int(bitsPerSecond / 8 / AverageQuerySize)
+	if qps < 1 {
+		qps = 1
+	}
+	return qps
+}
+
+// queryInterval returns the interval between two names sent to
+// massdns or 0 if the input doesn't have to be throttled.
+func (c *Client) queryInterval() (time.Duration, error) {
+	var interval time.Duration
+	if c.config.MaxQPS > 0 {
+		interval = time.Second / time.Duration(c.config.MaxQPS)
+	}
+
+	// Spread the names evenly over the requested duration
+	if c.config.SpreadDuration > 0 {
+		lines, err := countLines(c.config.TempKey, c.config.InputFile)
+		if err != nil {
+			return 0, err
+		}
+		if c.config.Mutator != nil {
+			lines *= c.config.Mutator.Variations()
+		}
+		if lines > 0 {
+			if spread := c.config.SpreadDuration / time.Duration(lines); spread > interval {
+				interval = spread
+			}
+		}
+	}
+	return interval, nil
+}
+
+// countLines counts the non blank lines of a file
+func countLines(key *tmpcrypt.Key, file string) (int, error) {
+	f, err := key.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			lines++
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// throttle is the interval between the names fed to massdns, which can
+// be raised while they are fed, along with the number of names fed.
+type throttle struct {
+	interval int64
+	fed      int64
+	// limiter enforces the ceiling per nameserver set if set
+	limiter *nsLimiter
+}
+
+// newThrottle creates a throttle feeding one name every interval
+func newThrottle(interval time.Duration) *throttle {
+	return &throttle{interval: int64(interval)}
+}
+
+// Interval returns the current interval between two names
+func (t *throttle) Interval() time.Duration {
+	return time.Duration(atomic.LoadInt64(&t.interval))
+}
+
+// SetInterval changes the interval between two names
+func (t *throttle) SetInterval(interval time.Duration) {
+	atomic.StoreInt64(&t.interval, int64(interval))
+}
+
+// Fed returns the number of names fed so far
+func (t *throttle) Fed() int64 {
+	return atomic.LoadInt64(&t.fed)
+}
+
+// throttleInput writes the names expanded from the lines of the input
+// file to the writer one every interval of the throttle, closing the
+// writer once the whole file has been written. With jitter, each delay
+// is randomized between half and one and a half times the interval.
+func throttleInput(key *tmpcrypt.Key, inputFile string, writer io.WriteCloser, t *throttle, jitter bool, expand func(string) []string) error {
+	defer writer.Close()
+
+	file, err := key.Open(inputFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(writer)
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	next := time.Now()
+	interval := t.Interval()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		for _, name := range expand(scanner.Text()) {
+			// The names aren't sent in a burst to catch up when the
+			// interval changes
+			if current := t.Interval(); current != interval {
+				interval, next = current, time.Now().Add(current)
+			}
+			// Sleep until the time the next name is due, flushing what's
+			// buffered before so that massdns can already resolve it,
+			// then until its nameservers can receive it.
+			if err := sleepFlushed(w, time.Until(next)); err != nil {
+				return err
+			}
+			if t.limiter != nil {
+				if err := sleepFlushed(w, t.limiter.reserve(name)); err != nil {
+					return err
+				}
+			}
+			if _, err := w.WriteString(name + "\n"); err != nil {
+				return err
+			}
+			atomic.AddInt64(&t.fed, 1)
+
+			delay := interval
+			if jitter {
+				delay = interval/2 + time.Duration(random.Int63n(int64(interval)+1))
+			}
+			next = next.Add(delay)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// sleepFlushed flushes the writer before sleeping if there's a wait
+func sleepFlushed(w *bufio.Writer, wait time.Duration) error {
+	if wait <= 0 {
+		return nil
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	time.Sleep(wait)
+	return nil
+}
@@ -0,0 +1,117 @@
+package massdns
+
+import (
+	"crypto/tls"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// tlsTimeout is the timeout for the tls connections to the hosts
+const tlsTimeout = 5 * time.Second
+
+// harvestTLSNames connects to port 443 of the found hosts, extracts
+// the names in their certificates and resolves the in-scope ones not
+// found yet, for at most TLSIterations rounds.
+func (c *Client) harvestTLSNames(st *store.Store) error {
+	probed := make(map[string]struct{})
+
+	for i := 0; i < c.config.TLSIterations; i++ {
+		known := knownHostnames(st)
+
+		var targets []string
+		for hostname := range known {
+			if _, ok := probed[hostname]; !ok {
+				probed[hostname] = struct{}{}
+				targets = append(targets, hostname)
+			}
+		}
+
+		var names []string
+		for name := range c.certificateNames(targets) {
+			if _, ok := known[name]; ok || !c.inScope(name) {
+				continue
+			}
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			break
+		}
+		sort.Strings(names)
+
+		c.log().Info().Msgf("Resolving %d new names found in tls certificates\n", len(names))
+		if err := c.resolveAdditional(names, st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// certificateNames returns the names found in the certificates of
+// the hosts, without the wildcard prefix of wildcard names.
+func (c *Client) certificateNames(hosts []string) map[string]struct{} {
+	names := make(map[string]struct{})
+	var mutex sync.Mutex
+
+	wg := sizedwaitgroup.New(c.config.WildcardsThreads)
+	for _, host := range hosts {
+		wg.Add()
+		go func(host string) {
+			defer wg.Done()
+
+			found := certificateNames(host)
+			mutex.Lock()
+			for _, name := range found {
+				names[name] = struct{}{}
+			}
+			mutex.Unlock()
+		}(host)
+	}
+	wg.Wait()
+	return names
+}
+
+// certificateNames returns the names in the certificate of a host
+func certificateNames(host string) []string {
+	dialer := &net.Dialer{Timeout: tlsTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, "443"), &tls.Config{
+		ServerName: host,
+		// The certificate is only inspected, never trusted
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	var names []string
+	for _, certificate := range conn.ConnectionState().PeerCertificates {
+		for _, name := range append(certificate.DNSNames, certificate.Subject.CommonName) {
+			name = strings.TrimPrefix(dnsname.Normalize(name), "*.")
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+		// Only the leaf certificate contains the host names
+		break
+	}
+	return names
+}
+
+// inScope returns true if a name is a subdomain of the target domain,
+// or belongs to the scope if one has been specified.
+func (c *Client) inScope(name string) bool {
+	if c.config.Scope != nil {
+		return c.config.Scope.InScope(name)
+	}
+	if c.config.Domain == "" {
+		return false
+	}
+	return strings.HasSuffix(name, "."+c.config.Domain)
+}
@@ -2,17 +2,27 @@ package massdns
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
+	"io"
 	"os"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/tmpcrypt"
+	"github.com/mohammadanaraki/shuffledns/pkg/wildcards"
 )
 
 // IsBlankFile checks if a file is blank
 func IsBlankFile(file string) (bool, error) {
-	stat, err := os.Stat(file)
+	return isBlankFile(nil, file)
+}
+
+// isBlankFile checks if a file, encrypted or not, is blank
+func isBlankFile(key *tmpcrypt.Key, file string) (bool, error) {
+	size, err := key.Size(file)
 	if err != nil {
 		return true, err
 	}
-	if stat.Size() <= 1 {
+	if size <= 1 {
 		return true, nil
 	}
 	return false, nil
@@ -29,10 +39,51 @@ func (c *Client) DumpWildcardsToFile(filename string) error {
 	}
 	defer f.Close()
 
-	bw := bufio.NewWriter(f)
+	return c.DumpWildcards(f)
+}
+
+// DumpWildcardsJSONToFile dumps the wildcards found to file as json lines
+func (c *Client) DumpWildcardsJSONToFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.DumpWildcardsJSON(f)
+}
+
+// DumpWildcardsJSON writes the wildcards found to a writer as json lines
+// with their root, type, ips and the evidence of their detection.
+func (c *Client) DumpWildcardsJSON(w io.Writer) error {
+	found := make(map[string]*wildcards.Wildcard)
+	for _, wildcard := range c.wildcardResolver.Wildcards() {
+		wildcards.MergeWildcard(found, wildcard)
+	}
+	for _, resolver := range c.domainResolvers {
+		for _, wildcard := range resolver.Wildcards() {
+			wildcards.MergeWildcard(found, wildcard)
+		}
+	}
+	for _, wildcard := range c.probedWildcards {
+		wildcards.MergeWildcard(found, wildcard)
+	}
+
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	for _, wildcard := range wildcards.SortedWildcards(found) {
+		if err := encoder.Encode(wildcard); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// DumpWildcards writes the wildcard ips list to a writer
+func (c *Client) DumpWildcards(w io.Writer) error {
+	bw := bufio.NewWriter(w)
 	for k := range c.wildcardIPMap {
 		_, _ = bw.WriteString(k + "\n")
 	}
-	defer bw.Flush()
-	return nil
+	return bw.Flush()
 }
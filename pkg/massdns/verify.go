@@ -0,0 +1,67 @@
+package massdns
+
+import (
+	"github.com/mohammadanaraki/shuffledns/internal/store"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// reverify re-resolves hostnames with the trusted resolvers, replacing
+// the ips found by massdns with the trusted answers. Hostnames which
+// don't resolve anymore are removed from the store. It returns the
+// number of rejected hostnames.
+func (c *Client) reverify(st *store.Store, hostnames []string) int {
+	type verified struct {
+		hostname string
+		ips      []string
+		err      error
+	}
+
+	results := make(chan verified, len(hostnames))
+	wg := sizedwaitgroup.New(c.config.WildcardsThreads)
+	for _, hostname := range hostnames {
+		wg.Add()
+		go func(hostname string) {
+			defer wg.Done()
+			ips, err := c.wildcardResolver.Resolve(hostname)
+			results <- verified{hostname: hostname, ips: ips, err: err}
+		}(hostname)
+	}
+	wg.Wait()
+	close(results)
+
+	var rejected int
+	for result := range results {
+		// Keep the original answers if the trusted resolvers can't be reached
+		if result.err != nil {
+			continue
+		}
+		removeHostname(st, result.hostname)
+		if len(result.ips) == 0 {
+			rejected++
+			continue
+		}
+		for _, ip := range result.ips {
+			if !st.Exists(ip) {
+				st.New(ip, result.hostname)
+				continue
+			}
+			record := st.Get(ip)
+			record.Hostnames.Add(result.hostname)
+			record.Counter++
+		}
+	}
+	return rejected
+}
+
+// removeHostname removes a hostname from all the ip records
+func removeHostname(st *store.Store, hostname string) {
+	for ip, record := range st.IP {
+		if !record.Hostnames.Has(hostname) {
+			continue
+		}
+		record.Hostnames.Remove(hostname)
+		if record.Hostnames.Len() == 0 {
+			st.Delete(ip)
+		}
+	}
+}
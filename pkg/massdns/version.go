@@ -0,0 +1,97 @@
+package massdns
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// versionRegex matches the version in the massdns help
+var versionRegex = regexp.MustCompile(`(?i)massdns\s+v?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// compat describes how a massdns release is driven
+type compat struct {
+	// release is the major and minor version of the release
+	release string
+	// textFormat is the -o value of the simple text output
+	textFormat string
+	// json is true if the release can write ndjson output
+	json bool
+	// separated is true if the text output separates the replies with
+	// empty lines
+	separated bool
+}
+
+// compats are the supported massdns releases, the latest one last
+var compats = []compat{
+	// 0.2 has no output flags nor json output, writing one record
+	// per line without separating the replies
+	{release: "0.2", textFormat: "S"},
+	{release: "0.3", textFormat: "Snl", json: true, separated: true},
+	{release: "1.0", textFormat: "Snl", json: true, separated: true},
+}
+
+// latestCompat is used when the version of massdns can't be detected
+var latestCompat = compats[len(compats)-1]
+
+// DetectVersion returns the version of the massdns binary shown in its
+// help, empty if it doesn't show one.
+func DetectVersion(path string) (string, error) {
+	// Massdns exits with an error status after showing its help
+	output, err := exec.Command(path, "--help").CombinedOutput()
+	if len(output) == 0 && err != nil {
+		return "", err
+	}
+	return ParseVersion(string(output)), nil
+}
+
+// ParseVersion returns the massdns version found in a text, empty if
+// there is none.
+func ParseVersion(text string) string {
+	match := versionRegex.FindStringSubmatch(text)
+	if match == nil {
+		return ""
+	}
+	if match[3] == "" {
+		return match[1] + "." + match[2]
+	}
+	return match[1] + "." + match[2] + "." + match[3]
+}
+
+// CheckVersion returns an error listing the supported versions if a
+// massdns version is not supported. An empty version, not shown by the
+// binary, is assumed to be the latest one.
+func CheckVersion(version string) error {
+	_, err := compatFor(version)
+	return err
+}
+
+// compatFor returns how a massdns version is driven. The later 1.x
+// releases are driven like 1.0.
+func compatFor(version string) (compat, error) {
+	if version == "" {
+		return latestCompat, nil
+	}
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) >= 2 {
+		if parts[0] == "1" {
+			return latestCompat, nil
+		}
+		for _, compat := range compats {
+			if compat.release == parts[0]+"."+parts[1] {
+				return compat, nil
+			}
+		}
+	}
+	return compat{}, fmt.Errorf("massdns %s is not supported, supported versions are %s", version, supportedVersions())
+}
+
+// supportedVersions returns the list of the supported massdns releases
+func supportedVersions() string {
+	releases := make([]string, 0, len(compats))
+	for _, compat := range compats[:len(compats)-1] {
+		releases = append(releases, compat.release+".x")
+	}
+	return strings.Join(append(releases, "1.x"), ", ")
+}
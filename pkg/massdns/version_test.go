@@ -0,0 +1,33 @@
+package massdns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	require.Equal(t, "1.0.0", ParseVersion("massdns v1.0.0\nUsage: massdns [options] [domainlist]"), "Could not parse version")
+	require.Equal(t, "0.2", ParseVersion("MassDNS 0.2 Usage: ./massdns"), "Could not parse short version")
+	require.Equal(t, "", ParseVersion("Usage: massdns [options] [domainlist]"), "Could parse missing version")
+}
+
+func TestCompatFor(t *testing.T) {
+	compat, err := compatFor("0.2.1")
+	require.Nil(t, err, "Could not get compat of 0.2")
+	require.Equal(t, "S", compat.textFormat, "Could not get text format of 0.2")
+	require.False(t, compat.json, "Could get json output of 0.2")
+
+	compat, err = compatFor("1.1.0")
+	require.Nil(t, err, "Could not get compat of 1.1")
+	require.Equal(t, latestCompat, compat, "Could not drive 1.1 like the latest release")
+
+	compat, err = compatFor("")
+	require.Nil(t, err, "Could not get compat of unknown version")
+	require.Equal(t, latestCompat, compat, "Could not drive unknown version like the latest release")
+
+	err = CheckVersion("0.1")
+	require.NotNil(t, err, "Could not reject old version")
+	require.Contains(t, err.Error(), "0.2.x, 0.3.x, 1.x", "Could not list supported versions")
+	require.NotNil(t, CheckVersion("2.0.0"), "Could not reject unknown major version")
+}
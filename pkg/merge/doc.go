@@ -0,0 +1,9 @@
+// Package merge combines the outputs of multiple shuffledns runs
+// into a single deduplicated output.
+//
+// The plain text, ndjson and csv output formats are supported, gzip
+// compressed or not, the records of the domains grouped with
+// -group-by-domain being expanded to the records of their hosts.
+// Records are deduplicated by hostname, with the freshest record
+// taking precedence over older ones.
+package merge
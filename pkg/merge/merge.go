@@ -0,0 +1,325 @@
+package merge
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+// maxLineSize is the maximum size of a line of the inputs, large enough
+// for the json records of the domains grouped with -group-by-domain
+const maxLineSize = 64 * 1024 * 1024
+
+// gzipMagic are the first bytes of the outputs written with -output-compress
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ErrMixedConfigs is returned when the inputs come from runs with
+// different configurations and merging them wasn't allowed
+var ErrMixedConfigs = errors.New("outputs come from different configurations")
+
+// Options contains the configuration options for merging outputs
+type Options struct {
+	// Inputs contains the output files of previous runs
+	Inputs []string
+	// WildcardIPs contains the ips known to be wildcards. Records
+	// resolving to any of them are dropped from the merged output.
+	WildcardIPs map[string]struct{}
+	// AllowMixedConfigs merges the records of runs with different
+	// config hashes instead of failing with ErrMixedConfigs
+	AllowMixedConfigs bool
+}
+
+// Stats contains statistics about a merge operation
+type Stats struct {
+	// Records is the number of records read from all the inputs
+	Records int
+	// Unique is the number of unique hostnames written
+	Unique int
+	// Wildcards is the number of hostnames dropped as wildcards
+	Wildcards int
+	// Unchecked is the number of hostnames kept without checking them
+	// for wildcards as their records have no ips
+	Unchecked int
+	// ConfigHashes contains the distinct config hashes found in the inputs
+	ConfigHashes []string
+}
+
+// record is a single output record found in an input file
+type record struct {
+	Hostname   string   `json:"hostname"`
+	IP         []string `json:"ip"`
+	ConfigHash string   `json:"config_hash"`
+	Timestamp  string   `json:"timestamp"`
+
+	// raw is the line as found in the input
+	raw string
+	// seen is the time of the record, from its timestamp or its input
+	seen time.Time
+}
+
+// groupRecord is a json record of a domain written with -group-by-domain
+type groupRecord struct {
+	Domain     string            `json:"domain"`
+	ConfigHash string            `json:"config_hash"`
+	Hosts      []json.RawMessage `json:"hosts"`
+}
+
+// Merge merges the inputs deduplicating them by hostname and
+// writes the merged records to the writer sorted by hostname. The
+// freshest record of a hostname wins, by its timestamp or, for the
+// records without one, by the modification time of its input.
+func Merge(options *Options, writer io.Writer) (*Stats, error) {
+	inputs, modTimes, err := sortByModTime(options.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{}
+	records := make(map[string]*record)
+	hashes := make(map[string]struct{})
+
+	// The csv inputs must share their columns, the header being
+	// written once before the merged lines
+	var csvHeader string
+	var csvInputs int
+	for _, input := range inputs {
+		header, err := readRecords(input, func(r *record) {
+			stats.Records++
+			if r.ConfigHash != "" {
+				hashes[r.ConfigHash] = struct{}{}
+			}
+			seen, err := time.Parse(time.RFC3339Nano, r.Timestamp)
+			if err != nil {
+				seen = modTimes[input]
+			}
+			r.seen = seen
+			// Inputs are sorted from the oldest to the newest, so that
+			// the later record wins between records of the same time.
+			if previous, ok := records[r.Hostname]; !ok || !r.seen.Before(previous.seen) {
+				records[r.Hostname] = r
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", input, err)
+		}
+		if header == "" {
+			continue
+		}
+		if csvHeader != "" && header != csvHeader {
+			return nil, fmt.Errorf("could not read %s: csv columns differ from the other inputs", input)
+		}
+		csvHeader = header
+		csvInputs++
+	}
+	if csvInputs > 0 && csvInputs < len(inputs) {
+		return nil, errors.New("could not merge csv outputs with outputs of other formats")
+	}
+
+	for hash := range hashes {
+		stats.ConfigHashes = append(stats.ConfigHashes, hash)
+	}
+	sort.Strings(stats.ConfigHashes)
+	if len(stats.ConfigHashes) > 1 && !options.AllowMixedConfigs {
+		return stats, fmt.Errorf("%w: %s", ErrMixedConfigs, strings.Join(stats.ConfigHashes, ", "))
+	}
+
+	hostnames := make([]string, 0, len(records))
+	for hostname, r := range records {
+		if len(options.WildcardIPs) > 0 && len(r.IP) == 0 {
+			stats.Unchecked++
+		}
+		if isWildcard(r, options.WildcardIPs) {
+			stats.Wildcards++
+			continue
+		}
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+
+	w := bufio.NewWriter(writer)
+	if csvHeader != "" {
+		_, _ = w.WriteString(csvHeader)
+		_, _ = w.WriteString("\n")
+	}
+	for _, hostname := range hostnames {
+		_, _ = w.WriteString(records[hostname].raw)
+		_, _ = w.WriteString("\n")
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	stats.Unique = len(hostnames)
+	return stats, nil
+}
+
+// sortByModTime sorts the input files from the oldest to the newest,
+// returning their modification times too
+func sortByModTime(inputs []string) ([]string, map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time, len(inputs))
+	for _, input := range inputs {
+		stat, err := os.Stat(input)
+		if err != nil {
+			return nil, nil, err
+		}
+		modTimes[input] = stat.ModTime()
+	}
+
+	sorted := make([]string, len(inputs))
+	copy(sorted, inputs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return modTimes[sorted[i]].Before(modTimes[sorted[j]])
+	})
+	return sorted, modTimes, nil
+}
+
+// readRecords reads the records from an output file, gzip-compressed
+// or not, returning the csv header of csv outputs. Lines starting with
+// `{` are decoded as json records, the grouped records of a domain
+// being expanded to the records of its hosts, and other lines as plain
+// hostnames, or csv lines if the first line is a csv header.
+func readRecords(input string, callback func(r *record)) (string, error) {
+	file, err := os.Open(input)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var reader io.Reader = bufio.NewReader(file)
+	if magic, _ := reader.(*bufio.Reader).Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return "", err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	var columns map[string]int
+	var header string
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for first := true; scanner.Scan(); first = false {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if first {
+			if columns = csvColumns(line); columns != nil {
+				header = line
+				continue
+			}
+		}
+
+		switch {
+		case columns != nil:
+			r, err := csvRecord(line, columns)
+			if err != nil {
+				return "", err
+			}
+			emit(r, callback)
+		case strings.HasPrefix(line, "{"):
+			if err := jsonRecords(line, callback); err != nil {
+				return "", err
+			}
+		default:
+			r := &record{raw: dnsname.Normalize(line)}
+			r.Hostname = r.raw
+			emit(r, callback)
+		}
+	}
+	return header, scanner.Err()
+}
+
+// emit passes a record to the callback, deduplicated by the canonical
+// form of its hostname, skipping the records without one
+func emit(r *record, callback func(r *record)) {
+	r.Hostname = dnsname.Normalize(r.Hostname)
+	if r.Hostname != "" {
+		callback(r)
+	}
+}
+
+// jsonRecords decodes a json line, either the record of a host or the
+// grouped record of a domain whose hosts are passed one by one
+func jsonRecords(line string, callback func(r *record)) error {
+	r := &record{raw: line}
+	if err := json.Unmarshal([]byte(line), r); err != nil {
+		return nil
+	}
+	if r.Hostname != "" {
+		emit(r, callback)
+		return nil
+	}
+
+	var group groupRecord
+	if err := json.Unmarshal([]byte(line), &group); err != nil || group.Domain == "" {
+		return nil
+	}
+	for _, host := range group.Hosts {
+		r := &record{raw: string(host), ConfigHash: group.ConfigHash}
+		if err := json.Unmarshal(host, r); err != nil {
+			return fmt.Errorf("could not decode the hosts of domain %s: %w", group.Domain, err)
+		}
+		emit(r, callback)
+	}
+	return nil
+}
+
+// csvColumns returns the index of the columns of a csv header written
+// with -csv, whose first column is always the host, or nil if the line
+// isn't one.
+func csvColumns(line string) map[string]int {
+	fields, err := csv.NewReader(strings.NewReader(line)).Read()
+	if err != nil || len(fields) == 0 || fields[0] != "host" {
+		return nil
+	}
+	columns := make(map[string]int, len(fields))
+	for i, field := range fields {
+		columns[field] = i
+	}
+	return columns
+}
+
+// csvRecord decodes a csv line with the columns of its header, the ips
+// being separated by a space within their column
+func csvRecord(line string, columns map[string]int) (*record, error) {
+	fields, err := csv.NewReader(strings.NewReader(line)).Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not decode csv line: %w", err)
+	}
+	value := func(column string) string {
+		if index, ok := columns[column]; ok && index < len(fields) {
+			return fields[index]
+		}
+		return ""
+	}
+	r := &record{
+		raw:        line,
+		Hostname:   value("host"),
+		IP:         strings.Fields(value("ip")),
+		ConfigHash: value("config_hash"),
+		Timestamp:  value("timestamp"),
+	}
+	return r, nil
+}
+
+// isWildcard returns true if a record resolves to a wildcard ip
+func isWildcard(r *record, wildcardIPs map[string]struct{}) bool {
+	for _, ip := range r.IP {
+		if _, ok := wildcardIPs[ip]; ok {
+			return true
+		}
+	}
+	return false
+}
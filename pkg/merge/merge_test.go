@@ -0,0 +1,156 @@
+package merge
+
+import (
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDeduplicatesAndFilters(t *testing.T) {
+	dir := t.TempDir()
+
+	older := filepath.Join(dir, "older.ndjson")
+	newer := filepath.Join(dir, "newer.ndjson")
+	require.Nil(t, os.WriteFile(older, []byte(`{"hostname":"a.example.com","ip":["1.1.1.1"]}
+{"hostname":"b.example.com","ip":["2.2.2.2"]}
+`), 0644))
+	require.Nil(t, os.WriteFile(newer, []byte(`{"hostname":"a.example.com","ip":["3.3.3.3"]}
+c.example.com
+`), 0644))
+	past := time.Now().Add(-time.Hour)
+	require.Nil(t, os.Chtimes(older, past, past))
+
+	output := &strings.Builder{}
+	stats, err := Merge(&Options{
+		Inputs:      []string{newer, older},
+		WildcardIPs: map[string]struct{}{"2.2.2.2": {}},
+	}, output)
+	require.Nil(t, err, "Could not merge outputs")
+	require.Equal(t, `{"hostname":"a.example.com","ip":["3.3.3.3"]}
+c.example.com
+`, output.String(), "Could not get merged output")
+	require.Equal(t, 4, stats.Records, "Could not count records")
+	require.Equal(t, 2, stats.Unique, "Could not count unique records")
+	require.Equal(t, 1, stats.Wildcards, "Could not count wildcards")
+}
+
+func TestMergeRecordTimestamps(t *testing.T) {
+	dir := t.TempDir()
+
+	fresh := filepath.Join(dir, "fresh.ndjson")
+	touched := filepath.Join(dir, "touched.ndjson")
+	require.Nil(t, os.WriteFile(fresh, []byte(`{"hostname":"a.example.com","ip":["3.3.3.3"],"timestamp":"2022-06-01T10:00:00Z"}
+`), 0644))
+	require.Nil(t, os.WriteFile(touched, []byte(`{"hostname":"a.example.com","ip":["1.1.1.1"],"timestamp":"2022-05-01T10:00:00Z"}
+{"hostname":"b.example.com","ip":["2.2.2.2"]}
+`), 0644))
+	past := time.Now().Add(-time.Hour)
+	require.Nil(t, os.Chtimes(fresh, past, past))
+
+	output := &strings.Builder{}
+	_, err := Merge(&Options{Inputs: []string{fresh, touched}}, output)
+	require.Nil(t, err, "Could not merge outputs")
+	require.Equal(t, `{"hostname":"a.example.com","ip":["3.3.3.3"],"timestamp":"2022-06-01T10:00:00Z"}
+{"hostname":"b.example.com","ip":["2.2.2.2"]}
+`, output.String(), "Could not keep the freshest record by timestamp")
+}
+
+func TestMergeUncheckedWildcards(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "hosts.txt")
+	require.Nil(t, os.WriteFile(input, []byte("a.example.com\nb.example.com\n"), 0644))
+
+	stats, err := Merge(&Options{Inputs: []string{input}, WildcardIPs: map[string]struct{}{"2.2.2.2": {}}}, &strings.Builder{})
+	require.Nil(t, err, "Could not merge outputs")
+	require.Equal(t, 2, stats.Unchecked, "Could not count hostnames without ips")
+	require.Equal(t, 0, stats.Wildcards, "Could not count wildcards")
+}
+
+func TestMergeMixedConfigs(t *testing.T) {
+	dir := t.TempDir()
+
+	first := filepath.Join(dir, "first.ndjson")
+	second := filepath.Join(dir, "second.ndjson")
+	require.Nil(t, os.WriteFile(first, []byte(`{"hostname":"a.example.com","config_hash":"aaaa"}
+`), 0644))
+	require.Nil(t, os.WriteFile(second, []byte(`{"hostname":"b.example.com","config_hash":"bbbb"}
+`), 0644))
+
+	output := &strings.Builder{}
+	stats, err := Merge(&Options{Inputs: []string{first, second}}, output)
+	require.True(t, errors.Is(err, ErrMixedConfigs), "Could not refuse outputs of different configurations")
+	require.Equal(t, []string{"aaaa", "bbbb"}, stats.ConfigHashes, "Could not get config hashes")
+	require.Empty(t, output.String(), "Could not refuse to write the merged output")
+
+	_, err = Merge(&Options{Inputs: []string{first, second}, AllowMixedConfigs: true}, output)
+	require.Nil(t, err, "Could not merge outputs of different configurations")
+	require.Equal(t, `{"hostname":"a.example.com","config_hash":"aaaa"}
+{"hostname":"b.example.com","config_hash":"bbbb"}
+`, output.String(), "Could not get merged output")
+}
+
+func TestMergeCompressedGroupedOutput(t *testing.T) {
+	dir := t.TempDir()
+
+	grouped := filepath.Join(dir, "grouped.ndjson.gz")
+	file, err := os.Create(grouped)
+	require.Nil(t, err, "Could not create compressed output")
+	gzipWriter := gzip.NewWriter(file)
+	_, err = gzipWriter.Write([]byte(`{"domain":"example.com","hosts":[{"hostname":"a.example.com","ip":["1.1.1.1"]},{"hostname":"b.example.com","ip":["2.2.2.2"]}]}
+`))
+	require.Nil(t, err, "Could not write compressed output")
+	require.Nil(t, gzipWriter.Close(), "Could not write compressed output")
+	require.Nil(t, file.Close(), "Could not write compressed output")
+
+	// A grouped line over the default scanner buffer
+	long := filepath.Join(dir, "long.ndjson")
+	hosts := make([]string, 3000)
+	for i := range hosts {
+		hosts[i] = `{"hostname":"host-` + strings.Repeat("x", 20) + `-` + strings.Repeat("0", i%5) + `.example.org"}`
+	}
+	require.Nil(t, os.WriteFile(long, []byte(`{"domain":"example.org","hosts":[`+strings.Join(hosts, ",")+`]}`+"\n"), 0644))
+
+	output := &strings.Builder{}
+	stats, err := Merge(&Options{Inputs: []string{grouped, long}}, output)
+	require.Nil(t, err, "Could not merge outputs")
+	require.Equal(t, 3002, stats.Records, "Could not count records")
+	require.Equal(t, 7, stats.Unique, "Could not count unique records")
+	require.True(t, strings.HasPrefix(output.String(), `{"hostname":"a.example.com","ip":["1.1.1.1"]}
+{"hostname":"b.example.com","ip":["2.2.2.2"]}
+`), "Could not expand grouped records")
+}
+
+func TestMergeCSVOutput(t *testing.T) {
+	dir := t.TempDir()
+
+	older := filepath.Join(dir, "older.csv")
+	newer := filepath.Join(dir, "newer.csv")
+	require.Nil(t, os.WriteFile(older, []byte("host,ip\na.example.com,1.1.1.1\nb.example.com,2.2.2.2 4.4.4.4\n"), 0644))
+	require.Nil(t, os.WriteFile(newer, []byte("host,ip\na.example.com,3.3.3.3\n"), 0644))
+	past := time.Now().Add(-time.Hour)
+	require.Nil(t, os.Chtimes(older, past, past))
+
+	output := &strings.Builder{}
+	stats, err := Merge(&Options{
+		Inputs:      []string{older, newer},
+		WildcardIPs: map[string]struct{}{"4.4.4.4": {}},
+	}, output)
+	require.Nil(t, err, "Could not merge csv outputs")
+	require.Equal(t, "host,ip\na.example.com,3.3.3.3\n", output.String(), "Could not get merged csv output")
+	require.Equal(t, 1, stats.Wildcards, "Could not count wildcards")
+
+	plain := filepath.Join(dir, "plain.txt")
+	require.Nil(t, os.WriteFile(plain, []byte("c.example.com\n"), 0644))
+	_, err = Merge(&Options{Inputs: []string{older, plain}}, &strings.Builder{})
+	require.NotNil(t, err, "Could not refuse csv outputs mixed with other formats")
+
+	other := filepath.Join(dir, "other.csv")
+	require.Nil(t, os.WriteFile(other, []byte("host,cname\nc.example.com,d.example.com\n"), 0644))
+	_, err = Merge(&Options{Inputs: []string{older, other}}, &strings.Builder{})
+	require.NotNil(t, err, "Could not refuse csv outputs with different columns")
+}
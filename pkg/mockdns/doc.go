@@ -0,0 +1,21 @@
+// Package mockdns is a fake dns backend answering from a fixture file,
+// so that the whole enumeration, wildcard detection included, can run
+// deterministically without network access nor massdns binary.
+//
+// The fixture contains one record per line as `name type value`, the
+// types being A, AAAA, CNAME, NS, TXT and PTR (whose name is the ip). Names
+// starting with `*.` are wildcards answering for all the names below
+// them, and `name NXDOMAIN`, `name SERVFAIL` or `name REFUSED` lines
+// force the status of the responses for a name. Blank lines and lines
+// starting with # are ignored, every other name doesn't exist. ANY queries
+// are answered with all the records of a name.
+//
+//	www.example.com A 192.0.2.1
+//	api.example.com CNAME edge.cdn.example.net
+//	edge.cdn.example.net A 192.0.2.2
+//	*.dev.example.com A 192.0.2.3
+//	example.com NS ns1.example.net
+//	example.com TXT v=spf1 -all
+//	192.0.2.1 PTR www.example.com
+//	flaky.example.com SERVFAIL
+package mockdns
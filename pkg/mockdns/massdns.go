@@ -0,0 +1,105 @@
+package mockdns
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// jsonAnswer is an answer of a massdns ndjson line
+type jsonAnswer struct {
+	TTL   uint32 `json:"ttl"`
+	Type  string `json:"type"`
+	Class string `json:"class"`
+	Name  string `json:"name"`
+	Data  string `json:"data"`
+}
+
+// jsonResponse is a line of massdns ndjson output (`-o J`)
+type jsonResponse struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Class  string `json:"class"`
+	Status string `json:"status"`
+	RxTs   int64  `json:"rx_ts"`
+	Data   struct {
+		Answers []jsonAnswer `json:"answers,omitempty"`
+	} `json:"data"`
+	Flags    []string `json:"flags"`
+	Resolver string   `json:"resolver"`
+}
+
+// Massdns answers the names of the input, one per line, with their
+// A records writing the responses in the massdns output format:
+// simple text (`Snl`) or ndjson (`J`).
+func (s *Server) Massdns(input io.Reader, output io.Writer, format string) error {
+	if format != "Snl" && format != "J" {
+		return fmt.Errorf("unsupported massdns output format %s", format)
+	}
+
+	writer := bufio.NewWriter(output)
+	encoder := json.NewEncoder(writer)
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" {
+			continue
+		}
+		answers, rcode := s.zone.Lookup(name, dns.TypeA)
+
+		if format == "Snl" {
+			if rcode != dns.RcodeSuccess || len(answers) == 0 {
+				continue
+			}
+			for _, answer := range answers {
+				header := answer.Header()
+				fmt.Fprintf(writer, "%s %s %s\n", header.Name, dns.TypeToString[header.Rrtype], rdata(answer))
+			}
+			writer.WriteString("\n")
+			continue
+		}
+
+		response := jsonResponse{
+			Name:     dns.Fqdn(name),
+			Type:     "A",
+			Class:    "IN",
+			Status:   dns.RcodeToString[rcode],
+			RxTs:     time.Now().UnixNano(),
+			Flags:    []string{"rd", "ra"},
+			Resolver: s.Addr(),
+		}
+		for _, answer := range answers {
+			header := answer.Header()
+			response.Data.Answers = append(response.Data.Answers, jsonAnswer{
+				TTL:   header.Ttl,
+				Type:  dns.TypeToString[header.Rrtype],
+				Class: "IN",
+				Name:  header.Name,
+				Data:  rdata(answer),
+			})
+		}
+		if err := encoder.Encode(response); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// rdata returns the data of an answer as written by massdns
+func rdata(answer dns.RR) string {
+	switch t := answer.(type) {
+	case *dns.A:
+		return t.A.String()
+	case *dns.CNAME:
+		return t.Target
+	}
+	return strings.TrimPrefix(answer.String(), answer.Header().String())
+}
@@ -0,0 +1,105 @@
+package mockdns
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/mohammadanaraki/shuffledns/pkg/parser"
+	"github.com/stretchr/testify/require"
+)
+
+const fixture = `# test zone
+www.example.com A 192.0.2.1
+api.example.com. CNAME edge.cdn.example.net.
+edge.cdn.example.net A 192.0.2.2
+*.dev.example.com A 192.0.2.3
+example.com TXT v=spf1 -all
+example.com NS ns1.example.net
+192.0.2.1 PTR www.example.com
+flaky.example.com SERVFAIL
+`
+
+func TestZoneLookup(t *testing.T) {
+	zone, err := Parse(strings.NewReader(fixture))
+	require.Nil(t, err, "Could not parse fixture")
+
+	answers, rcode := zone.Lookup("WWW.example.com.", dns.TypeA)
+	require.Equal(t, dns.RcodeSuccess, rcode, "Could not resolve a name")
+	require.Len(t, answers, 1, "Could not get the answers")
+	require.Equal(t, "192.0.2.1", answers[0].(*dns.A).A.String(), "Could not get the ip")
+
+	answers, _ = zone.Lookup("api.example.com", dns.TypeA)
+	require.Len(t, answers, 2, "Could not follow the cname chain")
+	require.Equal(t, "edge.cdn.example.net.", answers[0].(*dns.CNAME).Target, "Could not get the cname")
+
+	answers, _ = zone.Lookup("x1.dev.example.com", dns.TypeA)
+	require.Len(t, answers, 1, "Could not resolve a wildcard")
+	require.Equal(t, "x1.dev.example.com.", answers[0].Header().Name, "Could not answer for the name")
+
+	_, rcode = zone.Lookup("dev.example.com", dns.TypeA)
+	require.Equal(t, dns.RcodeNameError, rcode, "Could not answer nxdomain")
+	_, rcode = zone.Lookup("flaky.example.com", dns.TypeA)
+	require.Equal(t, dns.RcodeServerFailure, rcode, "Could not force the status")
+	answers, rcode = zone.Lookup("example.com", dns.TypeA)
+	require.Equal(t, dns.RcodeSuccess, rcode, "Could not answer nodata")
+	require.Empty(t, answers, "Could not answer nodata")
+
+	_, err = Parse(strings.NewReader("www.example.com A not-an-ip"))
+	require.NotNil(t, err, "Could not reject an invalid record")
+}
+
+func TestServer(t *testing.T) {
+	zone, err := Parse(strings.NewReader(fixture))
+	require.Nil(t, err, "Could not parse fixture")
+	server, err := Start(zone)
+	require.Nil(t, err, "Could not start server")
+	defer server.Close()
+
+	query := new(dns.Msg)
+	query.SetQuestion("1.2.0.192.in-addr.arpa.", dns.TypePTR)
+	response, err := dns.Exchange(query, server.Addr())
+	require.Nil(t, err, "Could not query server")
+	require.Len(t, response.Answer, 1, "Could not get ptr answer")
+	require.Equal(t, "www.example.com.", response.Answer[0].(*dns.PTR).Ptr, "Could not get ptr")
+
+	query.SetQuestion("example.com.", dns.TypeTXT)
+	response, err = dns.Exchange(query, server.Addr())
+	require.Nil(t, err, "Could not query server")
+	require.Equal(t, []string{"v=spf1 -all"}, response.Answer[0].(*dns.TXT).Txt, "Could not get txt")
+
+	query.SetQuestion("example.com.", dns.TypeNS)
+	response, err = dns.Exchange(query, server.Addr())
+	require.Nil(t, err, "Could not query server")
+	require.Equal(t, "ns1.example.net.", response.Answer[0].(*dns.NS).Ns, "Could not get ns")
+}
+
+func TestServerMassdns(t *testing.T) {
+	zone, err := Parse(strings.NewReader(fixture))
+	require.Nil(t, err, "Could not parse fixture")
+	server, err := Start(zone)
+	require.Nil(t, err, "Could not start server")
+	defer server.Close()
+
+	input := "www.example.com\napi.example.com\nnope.example.com\na.dev.example.com\n"
+	for _, format := range []string{"Snl", "J"} {
+		var output bytes.Buffer
+		require.Nil(t, server.Massdns(strings.NewReader(input), &output, format), "Could not emulate massdns")
+
+		parse := parser.ParseResults
+		if format == "J" {
+			parse = parser.ParseJSON
+		}
+		results := make(map[string][]string)
+		err := parse(&output, func(result *parser.Result) {
+			results[result.Domain] = result.IP
+		})
+		require.Nil(t, err, "Could not parse %s output", format)
+		require.Equal(t, map[string][]string{
+			"www.example.com":   {"192.0.2.1"},
+			"api.example.com":   {"192.0.2.2"},
+			"a.dev.example.com": {"192.0.2.3"},
+		}, results, "Could not get %s results", format)
+	}
+}
@@ -0,0 +1,66 @@
+package mockdns
+
+import (
+	"net"
+
+	"github.com/miekg/dns"
+)
+
+// Server is a dns server answering from a zone on the loopback interface
+type Server struct {
+	zone   *Zone
+	server *dns.Server
+}
+
+// Start starts a server answering from a zone on a random udp port
+// of the loopback interface.
+func Start(zone *Zone) (*Server, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{zone: zone}
+	started := make(chan struct{})
+	s.server = &dns.Server{PacketConn: conn, Handler: s, NotifyStartedFunc: func() { close(started) }}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.server.ActivateAndServe()
+	}()
+	select {
+	case <-started:
+	case err := <-errs:
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Addr returns the ip:port address of the server
+func (s *Server) Addr() string {
+	return s.server.PacketConn.LocalAddr().String()
+}
+
+// Zone returns the zone the server answers from
+func (s *Server) Zone() *Zone {
+	return s.zone
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	return s.server.Shutdown()
+}
+
+// ServeDNS answers a query from the zone
+func (s *Server) ServeDNS(w dns.ResponseWriter, query *dns.Msg) {
+	response := new(dns.Msg)
+	response.SetReply(query)
+	response.RecursionAvailable = true
+	if len(query.Question) == 1 {
+		question := query.Question[0]
+		response.Answer, response.Rcode = s.zone.Lookup(question.Name, question.Qtype)
+	} else {
+		response.Rcode = dns.RcodeFormatError
+	}
+	_ = w.WriteMsg(response)
+}
@@ -0,0 +1,177 @@
+package mockdns
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+// ttl is the ttl of all the records answered
+const ttl = 300
+
+// maxCNAMEHops is the length after which a CNAME chain is a loop
+const maxCNAMEHops = 8
+
+// statuses are the response statuses which can be forced for a name
+var statuses = map[string]int{
+	"NXDOMAIN": dns.RcodeNameError,
+	"SERVFAIL": dns.RcodeServerFailure,
+	"REFUSED":  dns.RcodeRefused,
+}
+
+// record is a record of a name in the zone
+type record struct {
+	qtype uint16
+	value string
+}
+
+// Zone contains the records answered by the mock backend
+type Zone struct {
+	records  map[string][]record
+	statuses map[string]int
+}
+
+// Load reads a zone from a fixture file
+func Load(file string) (*Zone, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zone, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", file, err)
+	}
+	return zone, nil
+}
+
+// Parse reads a zone from the lines of a fixture
+func Parse(reader io.Reader) (*Zone, error) {
+	zone := &Zone{
+		records:  make(map[string][]record),
+		statuses: make(map[string]int),
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: expected name and type", line)
+		}
+		kind := strings.ToUpper(fields[1])
+		if rcode, ok := statuses[kind]; ok {
+			zone.statuses[dnsname.Normalize(fields[0])] = rcode
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected name, type and value", line)
+		}
+
+		name, value := dnsname.Normalize(fields[0]), strings.Join(fields[2:], " ")
+		qtype := dns.StringToType[kind]
+		switch qtype {
+		case dns.TypeA:
+			if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+				return nil, fmt.Errorf("line %d: invalid ipv4 address %s", line, value)
+			}
+		case dns.TypeAAAA:
+			if net.ParseIP(value) == nil {
+				return nil, fmt.Errorf("line %d: invalid ipv6 address %s", line, value)
+			}
+		case dns.TypeCNAME, dns.TypeNS:
+			value = dnsname.Normalize(value)
+		case dns.TypePTR:
+			reverse, err := dns.ReverseAddr(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid ip address %s", line, fields[0])
+			}
+			name, value = dnsname.Normalize(reverse), dnsname.Normalize(value)
+		case dns.TypeTXT:
+		default:
+			return nil, fmt.Errorf("line %d: unsupported type %s", line, fields[1])
+		}
+		zone.records[name] = append(zone.records[name], record{qtype: qtype, value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return zone, nil
+}
+
+// Lookup returns the answers to a query and the status of the response,
+// following the CNAME chain of the name.
+func (z *Zone) Lookup(name string, qtype uint16) ([]dns.RR, int) {
+	name = dnsname.Normalize(name)
+	if rcode, ok := z.statuses[name]; ok {
+		return nil, rcode
+	}
+
+	var answers []dns.RR
+	for hops := 0; hops < maxCNAMEHops; hops++ {
+		records, ok := z.find(name)
+		if !ok {
+			return answers, dns.RcodeNameError
+		}
+
+		var target string
+		for _, record := range records {
+			switch {
+			case record.qtype == qtype || qtype == dns.TypeANY:
+				answers = append(answers, newRR(name, record))
+			case record.qtype == dns.TypeCNAME:
+				target = record.value
+			}
+		}
+		if target == "" || qtype == dns.TypeCNAME {
+			return answers, dns.RcodeSuccess
+		}
+		answers = append(answers, newRR(name, record{qtype: dns.TypeCNAME, value: target}))
+		name = target
+	}
+	return nil, dns.RcodeServerFailure
+}
+
+// find returns the records of a name, or of the closest wildcard
+// above it if the name has no records.
+func (z *Zone) find(name string) ([]record, bool) {
+	if records, ok := z.records[name]; ok {
+		return records, true
+	}
+	for parent := name; strings.Contains(parent, "."); {
+		parent = parent[strings.IndexByte(parent, '.')+1:]
+		if records, ok := z.records["*."+parent]; ok {
+			return records, true
+		}
+	}
+	return nil, false
+}
+
+// newRR returns the resource record of a record of a name
+func newRR(name string, record record) dns.RR {
+	header := dns.RR_Header{Name: dns.Fqdn(name), Rrtype: record.qtype, Class: dns.ClassINET, Ttl: ttl}
+	switch record.qtype {
+	case dns.TypeA:
+		return &dns.A{Hdr: header, A: net.ParseIP(record.value).To4()}
+	case dns.TypeAAAA:
+		return &dns.AAAA{Hdr: header, AAAA: net.ParseIP(record.value)}
+	case dns.TypeCNAME:
+		return &dns.CNAME{Hdr: header, Target: dns.Fqdn(record.value)}
+	case dns.TypePTR:
+		return &dns.PTR{Hdr: header, Ptr: dns.Fqdn(record.value)}
+	case dns.TypeNS:
+		return &dns.NS{Hdr: header, Ns: dns.Fqdn(record.value)}
+	default:
+		return &dns.TXT{Hdr: header, Txt: []string{record.value}}
+	}
+}
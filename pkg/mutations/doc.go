@@ -0,0 +1,3 @@
+// Package mutations generates variations of the words of a bruteforce
+// wordlist by joining them with prefixes and suffixes.
+package mutations
@@ -0,0 +1,63 @@
+package mutations
+
+import "strings"
+
+// DefaultSeparators are the separators used when none are specified,
+// an empty separator joining the words and the affixes directly.
+var DefaultSeparators = []string{"-", ""}
+
+// Mutator generates the variations of words
+type Mutator struct {
+	// Prefixes are the affixes placed before the words
+	Prefixes []string
+	// Suffixes are the affixes placed after the words
+	Suffixes []string
+	// Separators are placed between the words and the affixes
+	Separators []string
+}
+
+// New creates a mutator from comma separated lists of prefixes,
+// suffixes and separators.
+func New(prefixes, suffixes, separators string) *Mutator {
+	mutator := &Mutator{
+		Prefixes:   split(prefixes),
+		Suffixes:   split(suffixes),
+		Separators: DefaultSeparators,
+	}
+	if separators != "" {
+		// Separators are kept as is, so an empty one can be given
+		mutator.Separators = strings.Split(separators, ",")
+	}
+	return mutator
+}
+
+// Mutate returns the word followed by its variations
+func (m *Mutator) Mutate(word string) []string {
+	variations := make([]string, 0, m.Variations())
+	variations = append(variations, word)
+	for _, separator := range m.Separators {
+		for _, prefix := range m.Prefixes {
+			variations = append(variations, prefix+separator+word)
+		}
+		for _, suffix := range m.Suffixes {
+			variations = append(variations, word+separator+suffix)
+		}
+	}
+	return variations
+}
+
+// Variations returns the number of names returned by Mutate per word
+func (m *Mutator) Variations() int {
+	return 1 + (len(m.Prefixes)+len(m.Suffixes))*len(m.Separators)
+}
+
+// split splits a comma separated list dropping the empty items
+func split(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
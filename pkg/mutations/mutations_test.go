@@ -0,0 +1,33 @@
+package mutations
+
+import (
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/internal/benchdata"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutate(t *testing.T) {
+	mutator := New("dev", "dev,01", "")
+
+	variations := mutator.Mutate("api")
+	require.Equal(t, []string{"api", "dev-api", "api-dev", "api-01", "devapi", "apidev", "api01"}, variations, "Could not mutate word")
+	require.Equal(t, len(variations), mutator.Variations(), "Could not count variations")
+}
+
+func TestMutateSeparators(t *testing.T) {
+	mutator := New("", "stg", "_,.")
+
+	require.Equal(t, []string{"api", "api_stg", "api.stg"}, mutator.Mutate("api"), "Could not use custom separators")
+}
+
+func BenchmarkMutate(b *testing.B) {
+	mutator := New("dev,stg,prod", "01,02,v2", "")
+	words := benchdata.Words(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, word := range words {
+			mutator.Mutate(word)
+		}
+	}
+}
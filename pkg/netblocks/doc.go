@@ -0,0 +1,8 @@
+// Package netblocks clusters the ips of the resolved hosts by netblock
+// and by autonomous system, showing which ranges concentrate the hosts
+// of a target, which are usually its own hosting ranges.
+//
+// The autonomous systems of the ips are found with the dns interface
+// of the Team Cymru ip to asn mapping service, so that the clusters
+// can be built from dns data alone.
+package netblocks
@@ -0,0 +1,128 @@
+package netblocks
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// Cluster is a group of ips with the hosts resolving to them
+type Cluster struct {
+	// Key is the netblock (e.g. 192.0.2.0/24) or the asn (e.g. AS64500)
+	Key string `json:"key"`
+	// Name is the name of the asn, if known
+	Name string `json:"name,omitempty"`
+	// HostCount is the number of hosts resolving into the cluster
+	HostCount int `json:"host_count"`
+	// IPCount is the number of ips of the cluster
+	IPCount int `json:"ip_count"`
+	// Hosts are the sorted hosts resolving into the cluster
+	Hosts []string `json:"hosts"`
+	// IPs are the sorted ips of the cluster
+	IPs []string `json:"ips"`
+}
+
+// Prefix returns the netblock of an ip, the /24 for ipv4 and the /48
+// for ipv6, or an empty string if the ip is invalid.
+func Prefix(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
+
+// Group clusters the ips of the hosts by the key returned for each ip,
+// the ips without key being left out. The clusters concentrating the
+// most hosts come first.
+func Group(hostIPs map[string][]string, key func(ip string) string) []*Cluster {
+	hosts := make(map[string]map[string]struct{})
+	ips := make(map[string]map[string]struct{})
+	for host, hostIPs := range hostIPs {
+		for _, ip := range hostIPs {
+			k := key(ip)
+			if k == "" {
+				continue
+			}
+			if _, ok := hosts[k]; !ok {
+				hosts[k] = make(map[string]struct{})
+				ips[k] = make(map[string]struct{})
+			}
+			hosts[k][host] = struct{}{}
+			ips[k][ip] = struct{}{}
+		}
+	}
+
+	clusters := make([]*Cluster, 0, len(hosts))
+	for k := range hosts {
+		cluster := &Cluster{Key: k, Hosts: sortedKeys(hosts[k]), IPs: sortedKeys(ips[k])}
+		cluster.HostCount, cluster.IPCount = len(cluster.Hosts), len(cluster.IPs)
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].HostCount != clusters[j].HostCount {
+			return clusters[i].HostCount > clusters[j].HostCount
+		}
+		return clusters[i].Key < clusters[j].Key
+	})
+	return clusters
+}
+
+// OriginName returns the name queried for the origin asn of an ip in
+// the Team Cymru dns service (e.g. 1.2.0.192.origin.asn.cymru.com).
+func OriginName(ip string) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", fmt.Errorf("invalid ip %s", ip)
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.origin.asn.cymru.com", v4[3], v4[2], v4[1], v4[0]), nil
+	}
+	const hex = "0123456789abcdef"
+	nibbles := make([]string, 0, 32)
+	for i := len(parsed) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, string(hex[parsed[i]&0xf]), string(hex[parsed[i]>>4]))
+	}
+	return strings.Join(nibbles, ".") + ".origin6.asn.cymru.com", nil
+}
+
+// ParseOrigin returns the first asn of an origin record of the Team
+// Cymru dns service (e.g. "64500 | 192.0.2.0/24 | US | arin | 2010-01-01").
+func ParseOrigin(txt string) string {
+	fields := strings.Split(txt, "|")
+	asns := strings.Fields(fields[0])
+	if len(fields) < 2 || len(asns) == 0 {
+		return ""
+	}
+	return "AS" + asns[0]
+}
+
+// ASNName returns the name queried for the description of an asn in
+// the Team Cymru dns service (e.g. AS64500.asn.cymru.com).
+func ASNName(asn string) string {
+	return asn + ".asn.cymru.com"
+}
+
+// ParseASN returns the name of an asn from its description record of
+// the Team Cymru dns service (e.g. "64500 | US | arin | 2010-01-01 | EXAMPLE - Example Inc, US").
+func ParseASN(txt string) string {
+	fields := strings.Split(txt, "|")
+	if len(fields) < 5 {
+		return ""
+	}
+	return strings.TrimSpace(fields[4])
+}
+
+// sortedKeys returns the sorted keys of a set
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
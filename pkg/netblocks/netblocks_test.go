@@ -0,0 +1,46 @@
+package netblocks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefix(t *testing.T) {
+	require.Equal(t, "192.0.2.0/24", Prefix("192.0.2.17"), "Could not get ipv4 netblock")
+	require.Equal(t, "2001:db8:1::/48", Prefix("2001:db8:1:2::1"), "Could not get ipv6 netblock")
+	require.Equal(t, "", Prefix("invalid"), "Could not reject invalid ip")
+}
+
+func TestGroup(t *testing.T) {
+	hostIPs := map[string][]string{
+		"a.example.com": {"192.0.2.1"},
+		"b.example.com": {"192.0.2.2", "198.51.100.1"},
+		"c.example.com": {"192.0.2.1"},
+	}
+
+	clusters := Group(hostIPs, Prefix)
+	require.Len(t, clusters, 2, "Could not cluster ips")
+	require.Equal(t, &Cluster{
+		Key:       "192.0.2.0/24",
+		HostCount: 3,
+		IPCount:   2,
+		Hosts:     []string{"a.example.com", "b.example.com", "c.example.com"},
+		IPs:       []string{"192.0.2.1", "192.0.2.2"},
+	}, clusters[0], "Could not get the largest cluster first")
+	require.Equal(t, "198.51.100.0/24", clusters[1].Key, "Could not get smaller cluster")
+}
+
+func TestCymru(t *testing.T) {
+	name, err := OriginName("192.0.2.1")
+	require.Nil(t, err, "Could not get origin name")
+	require.Equal(t, "1.2.0.192.origin.asn.cymru.com", name, "Could not get ipv4 origin name")
+	name, err = OriginName("2001:db8::1")
+	require.Nil(t, err, "Could not get origin name")
+	require.Equal(t, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.origin6.asn.cymru.com", name, "Could not get ipv6 origin name")
+
+	require.Equal(t, "AS64500", ParseOrigin("64500 64501 | 192.0.2.0/24 | US | arin | 2010-01-01"), "Could not parse origin")
+	require.Equal(t, "", ParseOrigin("invalid"), "Could not reject invalid origin")
+	require.Equal(t, "AS64500.asn.cymru.com", ASNName("AS64500"), "Could not get asn name")
+	require.Equal(t, "EXAMPLE - Example Inc, US", ParseASN("64500 | US | arin | 2010-01-01 | EXAMPLE - Example Inc, US"), "Could not parse asn")
+}
@@ -0,0 +1,4 @@
+// Package parking detects the hostnames served by domain-parking
+// providers from their ips, CNAME targets and nameservers using a
+// built-in fingerprint table.
+package parking
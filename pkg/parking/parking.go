@@ -0,0 +1,162 @@
+package parking
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+//go:embed fingerprints.txt
+var builtinFingerprints string
+
+// Kinds of fingerprints
+const (
+	KindIP    = "ip"
+	KindCNAME = "cname"
+	KindNS    = "ns"
+)
+
+// network is a cidr of the servers of a parking provider
+type network struct {
+	network  *net.IPNet
+	provider string
+}
+
+// suffix is a name whose subdomains belong to a parking provider
+type suffix struct {
+	name     string
+	provider string
+}
+
+// matches returns true if a normalized name is the suffix or one of
+// its subdomains
+func (s suffix) matches(name string) bool {
+	return name == s.name || strings.HasSuffix(name, "."+s.name)
+}
+
+// Fingerprints detects the parking providers of ips, CNAME targets
+// and nameservers
+type Fingerprints struct {
+	ips         map[string]string
+	networks    []network
+	cnames      []suffix
+	nameservers []suffix
+}
+
+// New creates fingerprints with the built-in ones extended with the
+// ones contained in the optional extra file.
+func New(extraFile string) (*Fingerprints, error) {
+	f := &Fingerprints{ips: make(map[string]string)}
+	if err := f.read(strings.NewReader(builtinFingerprints)); err != nil {
+		return nil, err
+	}
+
+	if extraFile != "" {
+		file, err := os.Open(extraFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		if err := f.read(file); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// read reads fingerprints in the "kind value provider" per line
+// format, the provider name being the rest of the line.
+func (f *Fingerprints) read(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) < 3 {
+			return fmt.Errorf("invalid parking fingerprint line: %s", line)
+		}
+		provider := strings.Join(parts[2:], " ")
+
+		switch kind, value := strings.ToLower(parts[0]), parts[1]; kind {
+		case KindIP:
+			if strings.Contains(value, "/") {
+				_, cidr, err := net.ParseCIDR(value)
+				if err != nil {
+					return fmt.Errorf("invalid parking fingerprint cidr: %s", value)
+				}
+				f.networks = append(f.networks, network{network: cidr, provider: provider})
+				continue
+			}
+			if net.ParseIP(value) == nil {
+				return fmt.Errorf("invalid parking fingerprint ip: %s", value)
+			}
+			f.ips[value] = provider
+		case KindCNAME:
+			f.cnames = append(f.cnames, suffix{name: dnsname.Normalize(value), provider: provider})
+		case KindNS:
+			f.nameservers = append(f.nameservers, suffix{name: dnsname.Normalize(value), provider: provider})
+		default:
+			return fmt.Errorf("invalid parking fingerprint kind: %s", parts[0])
+		}
+	}
+	return scanner.Err()
+}
+
+// MatchIP returns the parking provider serving an ip, or an empty
+// string if it is unknown.
+func (f *Fingerprints) MatchIP(ip string) string {
+	if provider, ok := f.ips[ip]; ok {
+		return provider
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	for _, n := range f.networks {
+		if n.network.Contains(parsed) {
+			return n.provider
+		}
+	}
+	return ""
+}
+
+// MatchCNAME returns the parking provider of the first target of a
+// CNAME chain belonging to one, or an empty string if there is none.
+func (f *Fingerprints) MatchCNAME(chain []string) string {
+	return matchSuffixes(f.cnames, chain)
+}
+
+// MatchNameservers returns the parking provider of the first
+// nameserver belonging to one, or an empty string if there is none.
+func (f *Fingerprints) MatchNameservers(nameservers []string) string {
+	return matchSuffixes(f.nameservers, nameservers)
+}
+
+// HasNameservers returns true if there are nameserver fingerprints,
+// which are worth looking the nameservers up for.
+func (f *Fingerprints) HasNameservers() bool {
+	return len(f.nameservers) > 0
+}
+
+// matchSuffixes returns the provider of the first name matching one
+// of the suffixes
+func matchSuffixes(suffixes []suffix, names []string) string {
+	for _, name := range names {
+		name = dnsname.Normalize(name)
+		for _, s := range suffixes {
+			if s.matches(name) {
+				return s.provider
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,41 @@
+package parking
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprintsMatch(t *testing.T) {
+	f, err := New("")
+	require.Nil(t, err, "Could not load built-in fingerprints")
+
+	require.Equal(t, "Sedo", f.MatchIP("91.195.240.94"), "Could not match a parking cidr")
+	require.Equal(t, "", f.MatchIP("93.184.216.34"), "Could not ignore an unknown ip")
+	require.Equal(t, "", f.MatchIP("invalid"), "Could not ignore an invalid ip")
+
+	require.Equal(t, "ParkingCrew", f.MatchCNAME([]string{"www.example.org", "park.parkingcrew.net."}), "Could not match a cname chain")
+	require.Equal(t, "", f.MatchCNAME([]string{"notbodis.com"}), "Could not match on label boundaries")
+
+	require.True(t, f.HasNameservers(), "Could not find nameserver fingerprints")
+	require.Equal(t, "Sedo", f.MatchNameservers([]string{"NS1.SEDOPARKING.COM"}), "Could not match a nameserver")
+	require.Equal(t, "", f.MatchNameservers([]string{"ns1.example.com"}), "Could not ignore an unknown nameserver")
+}
+
+func TestFingerprintsExtraFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "parking.txt")
+	require.Nil(t, os.WriteFile(file, []byte("# custom\nip 198.51.100.7 Example Parking\nns parked.example Example Parking\n"), 0644))
+
+	f, err := New(file)
+	require.Nil(t, err, "Could not load extra fingerprints")
+	require.Equal(t, "Example Parking", f.MatchIP("198.51.100.7"), "Could not match an extra ip")
+	require.Equal(t, "Example Parking", f.MatchNameservers([]string{"ns1.parked.example"}), "Could not match an extra nameserver")
+
+	for _, line := range []string{"ip 198.51.100.7", "ip invalid Provider", "ip 198.51.100.0/99 Provider", "mx mail.example Provider"} {
+		require.Nil(t, os.WriteFile(file, []byte(line+"\n"), 0644))
+		_, err = New(file)
+		require.NotNil(t, err, "Could not detect invalid line %s", line)
+	}
+}
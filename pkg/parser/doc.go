@@ -6,4 +6,7 @@
 // IP address is parsed from the output. It correctly handles
 // CNAME record entries outputting the first name and the subsequent
 // A records. NS records are ignored in the current implementation.
+//
+// The names are returned in their canonical form, lowercase and
+// without trailing dot.
 package parser
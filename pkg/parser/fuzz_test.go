@@ -0,0 +1,53 @@
+//go:build go1.18
+// +build go1.18
+
+package parser
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+// checkResult fails if a result doesn't hold valid names and ipv4 addresses
+func checkResult(t *testing.T, result *Result) {
+	if result.Domain == "" || dnsname.Validate(result.Domain) != "" {
+		t.Fatalf("invalid domain %q", result.Domain)
+	}
+	for _, target := range result.CNAME {
+		if target == "" || dnsname.Validate(target) != "" {
+			t.Fatalf("invalid cname %q for %s", target, result.Domain)
+		}
+	}
+	for _, ip := range result.IP {
+		if parsed := net.ParseIP(ip); parsed == nil || parsed.To4() == nil {
+			t.Fatalf("invalid ip %q for %s", ip, result.Domain)
+		}
+	}
+}
+
+func FuzzParseResults(f *testing.F) {
+	f.Add("docs.bugbounty.com. A 185.199.111.153\n\n")
+	f.Add("docs.hackerone.com. CNAME hacker0x01.github.io.\nhacker0x01.github.io. A 185.199.111.153\n\nexample.com. NS ns1.example.com.\n")
+	f.Add("Processed queries: 1000\nexample.com. SOA ns1.example.com. admin.example.com. 1 2 3 4 5\n\ndocs.bugbounty.com. A 185.19")
+	f.Fuzz(func(t *testing.T, data string) {
+		stats, err := ParseResultsStats(strings.NewReader(data), func(result *Result) {
+			checkResult(t, result)
+		})
+		if err == nil && stats.Truncated != (data != "" && !strings.HasSuffix(data, "\n")) {
+			t.Fatalf("wrong truncation %v", stats.Truncated)
+		}
+	})
+}
+
+func FuzzParseJSON(f *testing.F) {
+	f.Add(`{"name":"docs.hackerone.com.","status":"NOERROR","data":{"answers":[{"type":"CNAME","data":"hacker0x01.github.io."},{"type":"A","data":"185.199.111.153"}]},"resolver":"8.8.8.8:53"}` + "\n")
+	f.Add(`{"name":"a.example.com.","status":"NXDOMAIN","data":{}}` + "\n[WARNING] garbage\n{\"name\":")
+	f.Fuzz(func(t *testing.T, data string) {
+		_, _ = ParseJSONStats(strings.NewReader(data), func(result *Result) {
+			checkResult(t, result)
+		})
+	})
+}
@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+// jsonRecord is a single line of massdns ndjson output (`-o J`)
+type jsonRecord struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Resolver string `json:"resolver"`
+	Data     struct {
+		Answers []struct {
+			Type string `json:"type"`
+			Data string `json:"data"`
+		} `json:"answers"`
+	} `json:"data"`
+}
+
+// ParseJSON parses the massdns ndjson output returning the found
+// results to a callback function. Unlike the simple text output,
+// the json output also contains the resolver that answered.
+func ParseJSON(reader io.Reader, callback ResultCallback) error {
+	_, err := ParseJSONStats(reader, callback)
+	return err
+}
+
+// ParseJSONStats parses the massdns ndjson output returning the found
+// results to a callback function, and the statistics of the lines and
+// answers skipped.
+//
+// Lines that can't be decoded and responses without any A or
+// CNAME answers are skipped.
+func ParseJSONStats(reader io.Reader, callback ResultCallback) (*Stats, error) {
+	stats := &Stats{}
+	tail := &tailReader{reader: reader}
+	scanner := newScanner(tail)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var record jsonRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			stats.Malformed++
+			continue
+		}
+		if record.Status != "NOERROR" {
+			continue
+		}
+
+		result := &Result{
+			Domain:   validName(record.Name),
+			Resolver: record.Resolver,
+		}
+		if result.Domain == "" {
+			stats.Malformed++
+			continue
+		}
+		for _, answer := range record.Data.Answers {
+			switch answer.Type {
+			case "CNAME":
+				target := validName(answer.Data)
+				if target == "" {
+					stats.Malformed++
+					continue
+				}
+				result.CNAME = append(result.CNAME, target)
+			case "A":
+				if !isIPv4(answer.Data) {
+					stats.Malformed++
+					continue
+				}
+				result.IP = append(result.IP, answer.Data)
+			default:
+				stats.UnexpectedTypes++
+			}
+		}
+		if len(result.IP) == 0 && len(result.CNAME) == 0 {
+			continue
+		}
+		callback(result)
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, err
+	}
+	stats.Truncated = tail.truncated()
+	return stats, nil
+}
+
+// Response is the outcome of a single massdns query
+type Response struct {
+	Name     string
+	Status   string
+	Resolver string
+	// Answers is the number of records in the answer section
+	Answers int
+}
+
+// ParseJSONResponses parses the massdns ndjson output returning every
+// response, whatever its status, to a callback function.
+func ParseJSONResponses(reader io.Reader, callback func(*Response)) error {
+	scanner := newScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var record jsonRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			continue
+		}
+		callback(&Response{
+			Name:     dnsname.Normalize(record.Name),
+			Status:   record.Status,
+			Resolver: record.Resolver,
+			Answers:  len(record.Data.Answers),
+		})
+	}
+	return scanner.Err()
+}
+
+// IsJSON reports whether the massdns output in the reader is in
+// the ndjson format by looking at the first non-blank character.
+func IsJSON(reader io.Reader) (bool, error) {
+	buffered := bufio.NewReader(reader)
+	for {
+		char, _, err := buffered.ReadRune()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if char == ' ' || char == '\n' || char == '\r' || char == '\t' {
+			continue
+		}
+		return char == '{', nil
+	}
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/internal/benchdata"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserParseJSON(t *testing.T) {
+	sampleData := `{"name":"docs.hackerone.com.","type":"A","class":"IN","status":"NOERROR","data":{"answers":[{"ttl":300,"type":"CNAME","class":"IN","name":"docs.hackerone.com.","data":"hacker0x01.github.io."},{"ttl":300,"type":"A","class":"IN","name":"hacker0x01.github.io.","data":"185.199.111.153"}]},"resolver":"8.8.8.8:53"}
+{"name":"missing.hackerone.com.","type":"A","class":"IN","status":"NXDOMAIN","data":{},"resolver":"1.1.1.1:53"}`
+
+	var results []*Result
+	err := ParseJSON(strings.NewReader(sampleData), func(result *Result) {
+		results = append(results, result)
+	})
+	require.Nil(t, err, "Could not parse sample data")
+	require.Len(t, results, 1, "Could not skip failed response")
+	require.Equal(t, "docs.hackerone.com", results[0].Domain, "Could not get domain")
+	require.Equal(t, []string{"hacker0x01.github.io"}, results[0].CNAME, "Could not get cname")
+	require.Equal(t, []string{"185.199.111.153"}, results[0].IP, "Could not get ip")
+	require.Equal(t, "8.8.8.8:53", results[0].Resolver, "Could not get resolver")
+}
+
+func TestParserParseJSONResponses(t *testing.T) {
+	sampleData := `{"name":"docs.hackerone.com.","type":"A","class":"IN","status":"NOERROR","data":{"answers":[{"ttl":300,"type":"A","class":"IN","name":"docs.hackerone.com.","data":"185.199.111.153"}]},"resolver":"8.8.8.8:53"}
+{"name":"missing.hackerone.com.","type":"A","class":"IN","status":"NXDOMAIN","data":{},"resolver":"1.1.1.1:53"}`
+
+	var responses []*Response
+	err := ParseJSONResponses(strings.NewReader(sampleData), func(response *Response) {
+		responses = append(responses, response)
+	})
+	require.Nil(t, err, "Could not parse sample data")
+	require.Equal(t, []*Response{
+		{Name: "docs.hackerone.com", Status: "NOERROR", Resolver: "8.8.8.8:53", Answers: 1},
+		{Name: "missing.hackerone.com", Status: "NXDOMAIN", Resolver: "1.1.1.1:53"},
+	}, responses, "Could not get responses")
+}
+
+func TestParserIsJSON(t *testing.T) {
+	isJSON, err := IsJSON(strings.NewReader("\n{\"name\":\"a.com.\"}"))
+	require.Nil(t, err)
+	require.True(t, isJSON, "Could not detect json output")
+
+	isJSON, err = IsJSON(strings.NewReader("\na.com. A 1.1.1.1"))
+	require.Nil(t, err)
+	require.False(t, isJSON, "Could not detect text output")
+}
+
+func BenchmarkParseJSON(b *testing.B) {
+	output := benchdata.MassdnsJSON(100000)
+	b.SetBytes(int64(len(output)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := ParseJSON(strings.NewReader(output), func(*Result) {}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"bufio"
 	"io"
 	"strings"
 )
@@ -12,12 +11,47 @@ import (
 // and should be used as such.
 type Callback func(domain string, ip []string)
 
+// Result is a single resolved name found in the massdns output
+type Result struct {
+	// Domain is the name that was queried
+	Domain string
+	// IP contains the A records found for the name
+	IP []string
+	// CNAME contains the CNAME chain followed for the name, if any
+	CNAME []string
+	// Resolver is the resolver which answered the query. It's only
+	// available when parsing massdns json output.
+	Resolver string
+}
+
+// ResultCallback is a callback function that is called by
+// the parser returning the complete result found.
+// NOTE: Same as Callback, it's not thread safe and is blocking.
+type ResultCallback func(result *Result)
+
 // Parse parses the massdns output returning the found
 // domain and ip pair to a callback function.
+func Parse(reader io.Reader, callback Callback) error {
+	return ParseResults(reader, func(result *Result) {
+		callback(result.Domain, result.IP)
+	})
+}
+
+// ParseResults parses the massdns output returning the found
+// results to a callback function.
+func ParseResults(reader io.Reader, callback ResultCallback) error {
+	_, err := ParseResultsStats(reader, callback)
+	return err
+}
+
+// ParseResultsStats parses the massdns output returning the found
+// results to a callback function, and the statistics of the lines
+// skipped. Malformed lines, like stderr messages interleaved with the
+// output, and records of unexpected types are skipped.
 //
 // It's a pretty hacky solution. In future, it can and should
 // be rewritten to handle more edge cases and stuff.
-func Parse(reader io.Reader, callback Callback) error {
+func ParseResultsStats(reader io.Reader, callback ResultCallback) (*Stats, error) {
 	var (
 		// Some boolean various needed for state management
 		cnameStart bool
@@ -26,10 +60,14 @@ func Parse(reader io.Reader, callback Callback) error {
 		// Result variables to store the results
 		domain string
 		ip     []string
+		cname  []string
+
+		stats = &Stats{}
 	)
 
 	// Parse the input line by line and act on what the line means
-	scanner := bufio.NewScanner(reader)
+	tail := &tailReader{reader: reader}
+	scanner := newScanner(tail)
 	for scanner.Scan() {
 		text := scanner.Text()
 
@@ -43,63 +81,107 @@ func Parse(reader io.Reader, callback Callback) error {
 		if text == "" {
 			if domain != "" {
 				cnameStart, nsStart = false, false
-				callback(domain, ip)
-				domain, ip = "", nil
+				callback(&Result{Domain: domain, IP: ip, CNAME: cname})
+				domain, ip, cname = "", nil, nil
 			}
 			continue
-		} else {
-			// Non empty line represents DNS answer section, we split on space,
-			// iterate over all the parts, and write the answer to the struct.
-			parts := strings.Split(text, " ")
+		}
 
-			if len(parts) != 3 {
-				continue
+		// Non empty line represents DNS answer section, we split on space,
+		// iterate over all the parts, and write the answer to the struct.
+		parts, ok := splitRecord(text)
+		if !ok {
+			// Records with spaces in their data, like SOA or TXT records,
+			// are of types not parsed anyway
+			if fields := strings.Fields(text); len(fields) > 3 && isRecordType(fields[1]) {
+				stats.UnexpectedTypes++
+			} else {
+				stats.Malformed++
 			}
+			continue
+		}
+		name := validName(parts[0])
+		if name == "" || !isRecordType(parts[1]) {
+			stats.Malformed++
+			continue
+		}
 
-			// Switch on the record type, deciding what to do with
-			// a record based on the type of record.
-			switch parts[1] {
-			case "NS":
-				// If we have a NS record, then set nsStart
-				// which will ignore all the next records
-				nsStart = true
-			case "CNAME":
-				// If we have a CNAME record, then the next record should be
-				// the values for the CNAME record, so set the cnameStart value.
-				//
-				// Use the domain in the first cname field since the next fields for
-				// A record may contain domain for secondary CNAME which messes
-				// up recursive CNAME records.
-				if !cnameStart {
-					nsStart = false
-					domain = strings.TrimSuffix(parts[0], ".")
-					cnameStart = true
-				}
-			case "A":
-				// If we have an A record, check if it's not after
-				// an NS record. If not, append it to the ips.
-				//
-				// Also if we aren't inside a CNAME block, set the domain too.
-				if !nsStart {
-					if !cnameStart && domain == "" {
-						domain = strings.TrimSuffix(parts[0], ".")
-					}
-					ip = append(ip, parts[2])
+		// Switch on the record type, deciding what to do with
+		// a record based on the type of record.
+		switch parts[1] {
+		case "NS":
+			// If we have a NS record, then set nsStart
+			// which will ignore all the next records
+			nsStart = true
+		case "CNAME":
+			target := validName(parts[2])
+			if target == "" {
+				stats.Malformed++
+				continue
+			}
+			// If we have a CNAME record, then the next record should be
+			// the values for the CNAME record, so set the cnameStart value.
+			//
+			// Use the domain in the first cname field since the next fields for
+			// A record may contain domain for secondary CNAME which messes
+			// up recursive CNAME records.
+			if !cnameStart {
+				nsStart = false
+				domain = name
+				cnameStart = true
+			}
+			cname = append(cname, target)
+		case "A":
+			if !isIPv4(parts[2]) {
+				stats.Malformed++
+				continue
+			}
+			// If we have an A record, check if it's not after
+			// an NS record. If not, append it to the ips.
+			//
+			// Also if we aren't inside a CNAME block, set the domain too.
+			if !nsStart {
+				if !cnameStart && domain == "" {
+					domain = name
 				}
+				ip = append(ip, parts[2])
 			}
+		default:
+			stats.UnexpectedTypes++
 		}
-		continue
 	}
 
 	// Return error if there was any.
 	if err := scanner.Err(); err != nil {
-		return err
+		return stats, err
 	}
+	stats.Truncated = tail.truncated()
 
 	// Final callback to deliver the last piece of result
 	// if there's any.
 	if domain != "" {
-		callback(domain, ip)
+		callback(&Result{Domain: domain, IP: ip, CNAME: cname})
+	}
+	return stats, nil
+}
+
+// splitRecord splits an answer line on spaces into its name, type and
+// data, false if it doesn't have exactly three parts. Unlike strings.Split
+// it doesn't allocate, which matters on outputs with billions of lines.
+func splitRecord(text string) ([3]string, bool) {
+	var parts [3]string
+	first := strings.IndexByte(text, ' ')
+	if first < 0 {
+		return parts, false
+	}
+	second := strings.IndexByte(text[first+1:], ' ')
+	if second < 0 {
+		return parts, false
+	}
+	second += first + 1
+	if strings.IndexByte(text[second+1:], ' ') >= 0 {
+		return parts, false
 	}
-	return nil
+	parts[0], parts[1], parts[2] = text[:first], text[first+1:second], text[second+1:]
+	return parts, true
 }
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/mohammadanaraki/shuffledns/internal/benchdata"
 	"github.com/stretchr/testify/require"
 )
 
@@ -21,6 +22,17 @@ func TestParserParseSingleIP(t *testing.T) {
 	require.Equal(t, []string{"185.199.111.153"}, ip, "Could not get ip")
 }
 
+func TestParserParseNormalizesDomain(t *testing.T) {
+	sampleData := `Docs.BugBounty.COM. A 185.199.111.153`
+
+	var domain string
+	err := Parse(strings.NewReader(sampleData), func(Domain string, IP []string) {
+		domain = Domain
+	})
+	require.Nil(t, err, "Could not parse sample data")
+	require.Equal(t, "docs.bugbounty.com", domain, "Could not normalize domain")
+}
+
 func TestParserParseMultipleDomains(t *testing.T) {
 	sampleData := `
 docs.bugbounty.com. A 185.199.111.153
@@ -73,3 +85,30 @@ bugbounty-local.herokudns.io. A 185.199.111.153`
 	require.Equal(t, "docs.bugbounty.com", domain, "Could not get domain")
 	require.Equal(t, []string{"185.199.111.153"}, ip, "Could not get ip")
 }
+
+func TestParserParseResultsCNAMEChain(t *testing.T) {
+	sampleData := `
+docs.bugbounty.com. CNAME bugbounty.github.io.
+bugbounty.github.io. CNAME bugbounty-local.herokudns.io.
+bugbounty-local.herokudns.io. A 185.199.111.153`
+
+	var result *Result
+	err := ParseResults(strings.NewReader(sampleData), func(Result *Result) {
+		result = Result
+	})
+	require.Nil(t, err, "Could not parse sample data")
+	require.Equal(t, "docs.bugbounty.com", result.Domain, "Could not get domain")
+	require.Equal(t, []string{"bugbounty.github.io", "bugbounty-local.herokudns.io"}, result.CNAME, "Could not get cname")
+	require.Equal(t, []string{"185.199.111.153"}, result.IP, "Could not get ip")
+}
+
+func BenchmarkParseResults(b *testing.B) {
+	output := benchdata.MassdnsOutput(100000)
+	b.SetBytes(int64(len(output)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := ParseResults(strings.NewReader(output), func(*Result) {}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
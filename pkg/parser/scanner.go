@@ -0,0 +1,18 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+)
+
+// scanBufferSize is the size of the buffer the output is scanned with,
+// large enough to read raw files of tens of GB in few read calls and to
+// hold the longest ndjson lines. The buffer is reused for all the lines.
+const scanBufferSize = 1024 * 1024
+
+// newScanner returns a line scanner of the output with a large buffer
+func newScanner(reader io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, scanBufferSize), scanBufferSize)
+	return scanner
+}
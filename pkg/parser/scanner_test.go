@@ -0,0 +1,31 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRecord(t *testing.T) {
+	parts, ok := splitRecord("docs.bugbounty.com. A 185.199.111.153")
+	require.True(t, ok, "Could not split a record")
+	require.Equal(t, [3]string{"docs.bugbounty.com.", "A", "185.199.111.153"}, parts, "Could not get record parts")
+
+	_, ok = splitRecord("docs.bugbounty.com. 300 A 185.199.111.153")
+	require.False(t, ok, "Could not reject a line with too many parts")
+	_, ok = splitRecord("docs.bugbounty.com. A")
+	require.False(t, ok, "Could not reject a line with too few parts")
+}
+
+func TestParserParseLongLine(t *testing.T) {
+	// Lines longer than the default scanner buffer don't stop the parsing
+	sampleData := strings.Repeat("x", 100*1024) + "\n\ndocs.bugbounty.com. A 185.199.111.153"
+
+	var domain string
+	err := Parse(strings.NewReader(sampleData), func(Domain string, IP []string) {
+		domain = Domain
+	})
+	require.Nil(t, err, "Could not parse sample data")
+	require.Equal(t, "docs.bugbounty.com", domain, "Could not get domain")
+}
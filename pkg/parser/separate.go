@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// SeparateReplies returns a reader of a simple text output whose
+// replies aren't separated, like the one of massdns 0.2, adding the
+// empty line expected by the parser between the replies. A record
+// starts a new reply when its name is neither the name of the current
+// reply nor the target of one of its CNAME records.
+func SeparateReplies(reader io.Reader) io.Reader {
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		writer := bufio.NewWriter(pipeWriter)
+		names := make(map[string]struct{})
+		scanner := newScanner(reader)
+		for scanner.Scan() {
+			text := scanner.Text()
+			if text == "" {
+				names = make(map[string]struct{})
+			} else if parts, ok := splitRecord(text); ok {
+				name := strings.ToLower(parts[0])
+				if _, ok := names[name]; !ok && len(names) > 0 {
+					_ = writer.WriteByte('\n')
+					names = make(map[string]struct{})
+				}
+				names[name] = struct{}{}
+				if parts[1] == "CNAME" {
+					names[strings.ToLower(parts[2])] = struct{}{}
+				}
+			}
+			_, _ = writer.WriteString(text)
+			_ = writer.WriteByte('\n')
+		}
+		err := scanner.Err()
+		if flushErr := writer.Flush(); err == nil {
+			err = flushErr
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+	return pipeReader
+}
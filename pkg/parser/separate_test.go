@@ -0,0 +1,27 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeparateReplies(t *testing.T) {
+	sampleData := `docs.bugbounty.com. A 185.199.111.153
+docs.bugbounty.com. A 185.199.111.154
+api.bugbounty.com. CNAME bugbounty.github.io.
+bugbounty.github.io. A 185.199.108.153
+docs.hackerone.com. A 185.199.111.152
+`
+
+	var results []*Result
+	err := ParseResults(SeparateReplies(strings.NewReader(sampleData)), func(result *Result) {
+		results = append(results, result)
+	})
+	require.Nil(t, err, "Could not parse sample data")
+	require.Len(t, results, 3, "Could not separate replies")
+	require.Equal(t, &Result{Domain: "docs.bugbounty.com", IP: []string{"185.199.111.153", "185.199.111.154"}}, results[0], "Could not get first reply")
+	require.Equal(t, &Result{Domain: "api.bugbounty.com", IP: []string{"185.199.108.153"}, CNAME: []string{"bugbounty.github.io"}}, results[1], "Could not get cname reply")
+	require.Equal(t, "docs.hackerone.com", results[2].Domain, "Could not get last reply")
+}
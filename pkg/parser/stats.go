@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"io"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+// Stats counts the content skipped while parsing massdns output,
+// like stderr messages interleaved with the output or lines cut by
+// a massdns crash, instead of reporting it as results.
+type Stats struct {
+	// Malformed is the number of lines which aren't valid records
+	Malformed int
+	// UnexpectedTypes is the number of records of types not parsed,
+	// like AAAA or SOA records
+	UnexpectedTypes int
+	// Truncated is true if the output doesn't end with a newline,
+	// meaning that its last line may have been cut
+	Truncated bool
+}
+
+// Skipped returns the number of lines and records skipped
+func (s *Stats) Skipped() int {
+	return s.Malformed + s.UnexpectedTypes
+}
+
+// tailReader is a reader remembering the last byte read
+type tailReader struct {
+	reader io.Reader
+	last   byte
+	read   bool
+}
+
+func (t *tailReader) Read(p []byte) (int, error) {
+	n, err := t.reader.Read(p)
+	if n > 0 {
+		t.last, t.read = p[n-1], true
+	}
+	return n, err
+}
+
+// truncated returns true if some content was read without final newline
+func (t *tailReader) truncated() bool {
+	return t.read && t.last != '\n'
+}
+
+// isRecordType returns true if a value looks like a record type, like
+// A or TYPE65534, rather than some other word in a malformed line.
+func isRecordType(value string) bool {
+	if value == "" || len(value) > 10 || value[0] < 'A' || value[0] > 'Z' {
+		return false
+	}
+	for i := 1; i < len(value); i++ {
+		if !(value[i] >= 'A' && value[i] <= 'Z' || value[i] >= '0' && value[i] <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isIPv4 returns true if a value is an ipv4 address in dotted decimal
+// form. Unlike net.ParseIP it doesn't allocate.
+func isIPv4(value string) bool {
+	for part := 0; part < 4; part++ {
+		if part > 0 {
+			if value == "" || value[0] != '.' {
+				return false
+			}
+			value = value[1:]
+		}
+		digits, number := 0, 0
+		for digits < len(value) && value[digits] >= '0' && value[digits] <= '9' {
+			number = number*10 + int(value[digits]-'0')
+			digits++
+			if digits > 3 || number > 255 {
+				return false
+			}
+		}
+		// Leading zeros are rejected as by net.ParseIP
+		if digits == 0 || (digits > 1 && value[0] == '0') {
+			return false
+		}
+		value = value[digits:]
+	}
+	return value == ""
+}
+
+// validName returns the canonical form of a name of a record, empty if
+// it isn't a fully qualified name which could have been resolved.
+func validName(name string) string {
+	if !strings.HasSuffix(name, ".") || dnsname.Validate(name) != "" {
+		return ""
+	}
+	return dnsname.Normalize(name)
+}
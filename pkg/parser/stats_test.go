@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResultsStatsSkipsGarbage(t *testing.T) {
+	sampleData := `docs.bugbounty.com. A 185.199.111.153
+Processed queries: 1000
+docs.bugbounty.com. AAAA 2606:50c0:8000::153
+docs.bugbounty.com. A 185.199.111
+docs.bugbounty.com. A 2606:50c0:8000::153
+example.com. SOA ns1.example.com. admin.example.com. 1 7200 900 1209600 86400
+bad..name. A 1.2.3.4
+
+docs.hackerone.com. CNAME hacker0x01.github.io.
+hacker0x01.github.io. A 185.199.111.153
+`
+	var results []*Result
+	stats, err := ParseResultsStats(strings.NewReader(sampleData), func(result *Result) {
+		results = append(results, result)
+	})
+	require.Nil(t, err, "Could not parse sample data")
+	require.Len(t, results, 2, "Could not get results")
+	require.Equal(t, []string{"185.199.111.153"}, results[0].IP, "Could not skip the malformed answers")
+	require.Equal(t, "docs.hackerone.com", results[1].Domain, "Could not keep parsing after malformed lines")
+	require.Equal(t, 4, stats.Malformed, "Could not count malformed lines")
+	require.Equal(t, 2, stats.UnexpectedTypes, "Could not count unexpected types")
+	require.False(t, stats.Truncated, "Could not detect a complete output")
+}
+
+func TestParseResultsStatsTruncated(t *testing.T) {
+	stats, err := ParseResultsStats(strings.NewReader("docs.bugbounty.com. A 185.199.111.153\n\ndocs.hackerone.com. A 185.19"), func(*Result) {})
+	require.Nil(t, err, "Could not parse sample data")
+	require.True(t, stats.Truncated, "Could not detect a truncated output")
+	require.Equal(t, 1, stats.Malformed, "Could not count the truncated line")
+}
+
+func TestParseJSONStatsSkipsGarbage(t *testing.T) {
+	sampleData := `{"name":"docs.hackerone.com.","status":"NOERROR","data":{"answers":[{"type":"A","data":"185.199.111.153"},{"type":"AAAA","data":"::1"},{"type":"A","data":"not-an-ip"}]}}
+[WARNING] Could not resolve
+{"name":"docs.bugbounty.com.","status":"NOERROR","data":{"ans`
+	var results []*Result
+	stats, err := ParseJSONStats(strings.NewReader(sampleData), func(result *Result) {
+		results = append(results, result)
+	})
+	require.Nil(t, err, "Could not parse sample data")
+	require.Len(t, results, 1, "Could not get results")
+	require.Equal(t, []string{"185.199.111.153"}, results[0].IP, "Could not skip the malformed answers")
+	require.Equal(t, 3, stats.Malformed, "Could not count malformed lines and answers")
+	require.Equal(t, 1, stats.UnexpectedTypes, "Could not count unexpected types")
+	require.True(t, stats.Truncated, "Could not detect a truncated output")
+}
+
+func TestIsIPv4(t *testing.T) {
+	for _, value := range []string{"1.2.3.4", "0.0.0.0", "255.255.255.255", "10.0.100.1"} {
+		require.True(t, isIPv4(value), "Could not accept ip %s", value)
+	}
+	for _, value := range []string{"", "1.2.3", "1.2.3.4.", "1.2.3.256", "01.2.3.4", "1.2.3.4x", "::1", "1..2.3", "1234.1.1.1"} {
+		require.False(t, isIPv4(value), "Could not reject ip %s", value)
+	}
+}
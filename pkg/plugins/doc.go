@@ -0,0 +1,26 @@
+// Package plugins extends the enumeration pipeline with Go plugins, so
+// that candidates can be filtered, results enriched and output lines
+// transformed without forking the runner.
+//
+// A plugin is a main package built with `go build -buildmode=plugin`
+// exporting a variable named Plugin whose type implements one or more of
+// the Filter, Enricher and Transformer interfaces. The plugin has to be
+// built with the same Go version and module versions as shuffledns, and
+// Go plugins are only supported on linux, freebsd and macos.
+//
+//	package main
+//
+//	import "strings"
+//
+//	type internalOnly struct{}
+//
+//	func (internalOnly) FilterCandidate(name string) bool {
+//		return !strings.HasPrefix(name, "www.")
+//	}
+//
+//	func (internalOnly) EnrichResult(host string, ips []string, record map[string]interface{}) {
+//		record["team"] = "infra"
+//	}
+//
+//	var Plugin internalOnly
+package plugins
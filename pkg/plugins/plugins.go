@@ -0,0 +1,135 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"plugin"
+)
+
+// Symbol is the name of the variable exported by the plugins
+const Symbol = "Plugin"
+
+// Filter decides whether the candidate names are resolved
+type Filter interface {
+	// FilterCandidate returns false if a name must not be resolved
+	FilterCandidate(name string) bool
+}
+
+// Enricher adds fields to the json records of the results
+type Enricher interface {
+	// EnrichResult adds fields to the json record of a hostname
+	EnrichResult(host string, ips []string, record map[string]interface{})
+}
+
+// Transformer rewrites the lines written to the output
+type Transformer interface {
+	// TransformOutput returns the line to write instead of an output
+	// line, or false if the line must be dropped
+	TransformOutput(line string) (string, bool)
+}
+
+// Set is the list of plugins hooked in the pipeline, run in the order
+// they were added. A nil set has no plugins.
+type Set struct {
+	names        []string
+	filters      []Filter
+	enrichers    []Enricher
+	transformers []Transformer
+}
+
+// New creates an empty set of plugins
+func New() *Set {
+	return &Set{}
+}
+
+// Load opens the plugin files and adds them to a new set
+func Load(files []string) (*Set, error) {
+	set := New()
+	for _, file := range files {
+		p, err := plugin.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not open plugin %s: %w", file, err)
+		}
+		symbol, err := p.Lookup(Symbol)
+		if err != nil {
+			return nil, fmt.Errorf("could not find %s in plugin %s: %w", Symbol, file, err)
+		}
+		if err := set.Add(file, symbol); err != nil {
+			return nil, fmt.Errorf("could not add plugin %s: %w", file, err)
+		}
+	}
+	return set, nil
+}
+
+// Add adds a plugin implementing one or more of the hook interfaces
+func (s *Set) Add(name string, p interface{}) error {
+	var hooked bool
+	if filter, ok := p.(Filter); ok {
+		s.filters = append(s.filters, filter)
+		hooked = true
+	}
+	if enricher, ok := p.(Enricher); ok {
+		s.enrichers = append(s.enrichers, enricher)
+		hooked = true
+	}
+	if transformer, ok := p.(Transformer); ok {
+		s.transformers = append(s.transformers, transformer)
+		hooked = true
+	}
+	if !hooked {
+		return errors.New("no hook implemented")
+	}
+	s.names = append(s.names, name)
+	return nil
+}
+
+// Names returns the names of the plugins in the set
+func (s *Set) Names() []string {
+	if s == nil {
+		return nil
+	}
+	return s.names
+}
+
+// FilterCandidate returns true if all the filters accept a name
+func (s *Set) FilterCandidate(name string) bool {
+	if s == nil {
+		return true
+	}
+	for _, filter := range s.filters {
+		if !filter.FilterCandidate(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// EnrichResult runs all the enrichers on the json record of a hostname
+func (s *Set) EnrichResult(host string, ips []string, record map[string]interface{}) {
+	if s == nil {
+		return
+	}
+	for _, enricher := range s.enrichers {
+		enricher.EnrichResult(host, ips, record)
+	}
+}
+
+// HasTransformers returns true if the set contains transformers
+func (s *Set) HasTransformers() bool {
+	return s != nil && len(s.transformers) > 0
+}
+
+// TransformOutput runs all the transformers on an output line, returning
+// false as soon as one of them drops it.
+func (s *Set) TransformOutput(line string) (string, bool) {
+	if s == nil {
+		return line, true
+	}
+	for _, transformer := range s.transformers {
+		var ok bool
+		if line, ok = transformer.TransformOutput(line); !ok {
+			return "", false
+		}
+	}
+	return line, true
+}
@@ -0,0 +1,56 @@
+package plugins
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type skipWWW struct{}
+
+func (skipWWW) FilterCandidate(name string) bool {
+	return !strings.HasPrefix(name, "www.")
+}
+
+type tagger struct{}
+
+func (tagger) EnrichResult(host string, ips []string, record map[string]interface{}) {
+	record["ips"] = len(ips)
+}
+
+func (tagger) TransformOutput(line string) (string, bool) {
+	if strings.HasPrefix(line, "drop.") {
+		return "", false
+	}
+	return strings.ToUpper(line), true
+}
+
+func TestSetHooks(t *testing.T) {
+	set := New()
+	require.Nil(t, set.Add("skip", skipWWW{}), "Could not add filter")
+	require.Nil(t, set.Add("tagger", tagger{}), "Could not add enricher")
+	require.NotNil(t, set.Add("empty", struct{}{}), "Could not reject plugin without hooks")
+	require.Equal(t, []string{"skip", "tagger"}, set.Names(), "Could not list plugins")
+
+	require.False(t, set.FilterCandidate("www.example.com"), "Could not filter candidate")
+	require.True(t, set.FilterCandidate("api.example.com"), "Could not keep candidate")
+
+	record := map[string]interface{}{"hostname": "api.example.com"}
+	set.EnrichResult("api.example.com", []string{"192.0.2.1"}, record)
+	require.Equal(t, 1, record["ips"], "Could not enrich result")
+
+	line, ok := set.TransformOutput("api.example.com")
+	require.True(t, ok, "Could not keep line")
+	require.Equal(t, "API.EXAMPLE.COM", line, "Could not transform line")
+	_, ok = set.TransformOutput("drop.example.com")
+	require.False(t, ok, "Could not drop line")
+}
+
+func TestNilSet(t *testing.T) {
+	var set *Set
+	require.True(t, set.FilterCandidate("www.example.com"), "Could not keep candidate without plugins")
+	line, ok := set.TransformOutput("www.example.com")
+	require.True(t, ok && line == "www.example.com", "Could not keep line without plugins")
+	require.False(t, set.HasTransformers(), "Could not report no transformers")
+}
@@ -0,0 +1,5 @@
+// Package report renders the results of an enumeration for people who
+// don't use the command line: the counts, the hosts new or changed
+// since the previous runs, the wildcard roots, the most shared ips and
+// providers, the services targeted by CNAMEs and the full host table.
+package report
@@ -0,0 +1,45 @@
+package report
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"strings"
+)
+
+//go:embed report.html
+var htmlTemplate string
+
+// topLimit is the number of entries of the top ips, providers, asns
+// and services
+const topLimit = 10
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(htmlTemplate))
+
+// WriteHTML writes the report as a self-contained html page, the host
+// table being filtered in the browser without any external resource.
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlReport.Execute(w, struct {
+		*Report
+		Summary   Summary
+		New       []Host
+		Changed   []Host
+		TopIPs    []Count
+		Providers []Count
+		ASNs      []Count
+		Services  []Count
+		Sorted    []Host
+	}{
+		Report:    r,
+		Summary:   r.Summary(),
+		New:       r.HostsWithStatus(StatusNew),
+		Changed:   r.HostsWithStatus(StatusChanged),
+		TopIPs:    r.TopIPs(topLimit),
+		Providers: r.TopProviders(topLimit),
+		ASNs:      r.TopASNs(topLimit),
+		Services:  r.Services(topLimit),
+		Sorted:    r.SortedHosts(),
+	})
+}
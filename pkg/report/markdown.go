@@ -0,0 +1,123 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// markdownHosts is the maximum number of hosts listed per section of
+// the markdown summary, to keep it short enough for tickets.
+const markdownHosts = 25
+
+// WriteMarkdown writes a concise markdown summary of the report, with
+// the counts, the notable findings and the new hosts, suitable for
+// issue trackers and engagement notes.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	summary := r.Summary()
+
+	title := "shuffledns summary"
+	if r.Domain != "" {
+		title += ": " + r.Domain
+	}
+	fmt.Fprintf(bw, "## %s\n\n", title)
+	fmt.Fprintf(bw, "_Generated %s", r.Generated.Format("2006-01-02 15:04:05 MST"))
+	if r.RunID != "" {
+		fmt.Fprintf(bw, ", run `%s`", r.RunID)
+	}
+	fmt.Fprintf(bw, "_\n\n")
+
+	fmt.Fprintf(bw, "| Hosts | Unique ips |")
+	if summary.History {
+		fmt.Fprintf(bw, " New | Changed |")
+	}
+	fmt.Fprintf(bw, " Private | Wildcard roots |\n|---|---|")
+	if summary.History {
+		fmt.Fprintf(bw, "---|---|")
+	}
+	fmt.Fprintf(bw, "---|---|\n| %d | %d |", summary.Hosts, summary.IPs)
+	if summary.History {
+		fmt.Fprintf(bw, " %d | %d |", summary.New, summary.Changed)
+	}
+	fmt.Fprintf(bw, " %d | %d |\n", summary.Private, len(r.WildcardRoots))
+
+	// Notable findings are the ones worth a look during triage
+	var findings []string
+	if summary.Private > 0 {
+		findings = append(findings, fmt.Sprintf("%d hosts resolve to private addresses", summary.Private))
+	}
+	if summary.Changed > 0 {
+		findings = append(findings, fmt.Sprintf("%d hosts changed answers since the previous runs", summary.Changed))
+	}
+	if len(r.WildcardRoots) > 0 {
+		findings = append(findings, fmt.Sprintf("Wildcard roots: %s", codeList(r.WildcardRoots)))
+	}
+	if services := r.Services(5); len(services) > 0 {
+		var names []string
+		for _, service := range services {
+			names = append(names, fmt.Sprintf("%s (%d)", service.Name, service.Count))
+		}
+		findings = append(findings, "CNAME services: "+strings.Join(names, ", "))
+	}
+	if providers := r.TopProviders(5); len(providers) > 0 {
+		var names []string
+		for _, provider := range providers {
+			names = append(names, fmt.Sprintf("%s (%d)", provider.Name, provider.Count))
+		}
+		findings = append(findings, "Providers: "+strings.Join(names, ", "))
+	}
+	if asns := r.TopASNs(5); len(asns) > 0 {
+		var names []string
+		for _, asn := range asns {
+			names = append(names, fmt.Sprintf("%s (%d)", asn.Name, asn.Count))
+		}
+		findings = append(findings, "ASNs: "+strings.Join(names, ", "))
+	}
+	for _, posture := range r.EmailPostures {
+		if len(posture.Issues) > 0 {
+			findings = append(findings, fmt.Sprintf("Email security of `%s`: %s", posture.Domain, strings.Join(posture.Issues, ", ")))
+		}
+	}
+	if len(findings) > 0 {
+		fmt.Fprintf(bw, "\n### Notable findings\n\n")
+		for _, finding := range findings {
+			fmt.Fprintf(bw, "- %s\n", finding)
+		}
+	}
+
+	if summary.History {
+		writeMarkdownHosts(bw, "New hosts", r.HostsWithStatus(StatusNew))
+		writeMarkdownHosts(bw, "Changed hosts", r.HostsWithStatus(StatusChanged))
+	}
+	return bw.Flush()
+}
+
+// writeMarkdownHosts writes a section listing the hosts and their ips
+func writeMarkdownHosts(w io.Writer, title string, hosts []Host) {
+	if len(hosts) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n### %s (%d)\n\n", title, len(hosts))
+	for i, host := range hosts {
+		if i == markdownHosts {
+			fmt.Fprintf(w, "- and %d more\n", len(hosts)-markdownHosts)
+			break
+		}
+		fmt.Fprintf(w, "- `%s`", host.Name)
+		if len(host.IPs) > 0 {
+			fmt.Fprintf(w, " %s", strings.Join(host.IPs, ", "))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+// codeList formats names as a list of inline code spans
+func codeList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,186 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/mohammadanaraki/shuffledns/pkg/emailsec"
+	"github.com/mohammadanaraki/shuffledns/pkg/vendors"
+)
+
+// Statuses of the hosts compared with the previous runs
+const (
+	StatusNew       = "new"
+	StatusRecurring = "recurring"
+	StatusChanged   = "changed"
+)
+
+// Host is a validated host of the report
+type Host struct {
+	Name     string
+	IPs      []string
+	CNAME    []string
+	Status   string
+	Provider string
+	Private  bool
+	// ASNs are the asns of the ips of the host, if looked up
+	ASNs []string
+}
+
+// Report contains the results of an enumeration
+type Report struct {
+	Domain        string
+	RunID         string
+	Generated     time.Time
+	Hosts         []Host
+	WildcardRoots []string
+	WildcardIPs   int
+	// ASNNames are the names of the asns of the hosts, if known
+	ASNNames map[string]string
+	// EmailPostures are the email security postures of the apex
+	// domains, if collected
+	EmailPostures []*emailsec.Posture
+}
+
+// Count is a name with the number of hosts it applies to
+type Count struct {
+	Name  string
+	Count int
+}
+
+// Summary contains the counts of the report
+type Summary struct {
+	Hosts     int
+	IPs       int
+	New       int
+	Changed   int
+	Recurring int
+	Private   int
+	// History is true if the hosts were compared with previous runs
+	History bool
+}
+
+// Summary returns the counts of the report
+func (r *Report) Summary() Summary {
+	summary := Summary{Hosts: len(r.Hosts)}
+	ips := make(map[string]struct{})
+	for _, host := range r.Hosts {
+		for _, ip := range host.IPs {
+			ips[ip] = struct{}{}
+		}
+		switch host.Status {
+		case StatusNew:
+			summary.New++
+		case StatusChanged:
+			summary.Changed++
+		case StatusRecurring:
+			summary.Recurring++
+		}
+		if host.Status != "" {
+			summary.History = true
+		}
+		if host.Private {
+			summary.Private++
+		}
+	}
+	summary.IPs = len(ips)
+	return summary
+}
+
+// HostsWithStatus returns the hosts with a status, sorted by name
+func (r *Report) HostsWithStatus(status string) []Host {
+	var hosts []Host
+	for _, host := range r.Hosts {
+		if host.Status == status {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Name < hosts[j].Name })
+	return hosts
+}
+
+// SortedHosts returns all the hosts sorted by name
+func (r *Report) SortedHosts() []Host {
+	hosts := append([]Host(nil), r.Hosts...)
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Name < hosts[j].Name })
+	return hosts
+}
+
+// TopIPs returns the n ips shared by the most hosts
+func (r *Report) TopIPs(n int) []Count {
+	counts := make(map[string]int)
+	for _, host := range r.Hosts {
+		for _, ip := range host.IPs {
+			counts[ip]++
+		}
+	}
+	return top(counts, n)
+}
+
+// TopProviders returns the n cdn or cloud providers hosting the most
+// hosts, the hosts outside of known ranges being left out.
+func (r *Report) TopProviders(n int) []Count {
+	counts := make(map[string]int)
+	for _, host := range r.Hosts {
+		if host.Provider != "" {
+			counts[host.Provider]++
+		}
+	}
+	return top(counts, n)
+}
+
+// TopASNs returns the n asns hosting the most hosts, named after the
+// asn and its name if known, the hosts without asn being left out.
+func (r *Report) TopASNs(n int) []Count {
+	counts := make(map[string]int)
+	for _, host := range r.Hosts {
+		for _, asn := range host.ASNs {
+			if name := r.ASNNames[asn]; name != "" {
+				asn += " " + name
+			}
+			counts[asn]++
+		}
+	}
+	return top(counts, n)
+}
+
+// Services returns the n services targeted by the most CNAMEs, named
+// after the known SaaS providers or the registered domain of the
+// final CNAME target otherwise.
+func (r *Report) Services(n int) []Count {
+	counts := make(map[string]int)
+	for _, host := range r.Hosts {
+		if len(host.CNAME) > 0 {
+			counts[Service(host.CNAME[len(host.CNAME)-1])]++
+		}
+	}
+	return top(counts, n)
+}
+
+// Service returns the service of a CNAME target: the known SaaS
+// provider it belongs to or its registered domain.
+func Service(target string) string {
+	if vendor := vendors.Match(target); vendor != "" {
+		return vendor
+	}
+	return dnsname.RegisteredDomain(target)
+}
+
+// top returns the n names with the highest counts, by name on ties
+func top(counts map[string]int, n int) []Count {
+	sorted := make([]Count, 0, len(counts))
+	for name, count := range counts {
+		sorted = append(sorted, Count{Name: name, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	if n > 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/emailsec"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport() *Report {
+	return &Report{
+		Domain:    "example.com",
+		RunID:     "run-1",
+		Generated: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Hosts: []Host{
+			{Name: "www.example.com", IPs: []string{"1.1.1.1"}, CNAME: []string{"d111.cloudfront.net"}, Status: StatusRecurring, Provider: "cloudfront"},
+			{Name: "api.example.com", IPs: []string{"1.1.1.1", "2.2.2.2"}, Status: StatusNew},
+			{Name: "docs.example.com", IPs: []string{"3.3.3.3"}, CNAME: []string{"docs.example.org", "lb.hosting.example.net"}, Status: StatusChanged},
+			{Name: "vpn.example.com", IPs: []string{"10.0.0.1"}, Status: StatusNew, Private: true},
+		},
+		WildcardRoots: []string{"*.dev.example.com"},
+		WildcardIPs:   3,
+	}
+}
+
+func TestSummary(t *testing.T) {
+	summary := testReport().Summary()
+	require.Equal(t, Summary{Hosts: 4, IPs: 4, New: 2, Changed: 1, Recurring: 1, Private: 1, History: true}, summary, "Could not count the hosts")
+}
+
+func TestTop(t *testing.T) {
+	r := testReport()
+	require.Equal(t, []Count{{"1.1.1.1", 2}, {"10.0.0.1", 1}}, r.TopIPs(2), "Could not get the top ips")
+	require.Equal(t, []Count{{"cloudfront", 1}}, r.TopProviders(10), "Could not get the providers")
+	require.Equal(t, []Count{{"Amazon CloudFront", 1}, {"example.net", 1}}, r.Services(10), "Could not get the services")
+	require.Equal(t, []string{"api.example.com", "vpn.example.com"}, names(r.HostsWithStatus(StatusNew)), "Could not get the new hosts")
+}
+
+func TestTopASNs(t *testing.T) {
+	r := testReport()
+	r.Hosts[0].ASNs = []string{"AS64500"}
+	r.Hosts[1].ASNs = []string{"AS64500", "AS64501"}
+	r.ASNNames = map[string]string{"AS64500": "EXAMPLE - Example Inc, US"}
+	require.Equal(t, []Count{{"AS64500 EXAMPLE - Example Inc, US", 2}, {"AS64501", 1}}, r.TopASNs(10), "Could not get the asns")
+
+	var builder strings.Builder
+	require.Nil(t, r.WriteMarkdown(&builder), "Could not write the markdown summary")
+	require.Contains(t, builder.String(), "- ASNs: AS64500 EXAMPLE - Example Inc, US (2), AS64501 (1)", "Could not write the asns")
+
+	builder.Reset()
+	require.Nil(t, r.WriteHTML(&builder), "Could not write the html report")
+	require.Contains(t, builder.String(), "<td>AS64501</td>", "Could not write the asns")
+	require.Empty(t, testReport().TopASNs(10), "Could not leave out the hosts without asn")
+}
+
+func TestService(t *testing.T) {
+	require.Equal(t, "Fastly", Service("example.global.ssl.fastly.net."), "Could not match the service")
+	require.Equal(t, "Azure Blob Storage", Service("acct.blob.core.windows.net"), "Could not match the longest suffix")
+	require.Equal(t, "example.org", Service("a.b.example.org"), "Could not fall back to the registered domain")
+	require.Equal(t, "example.co.uk", Service("cdn.example.co.uk"), "Could not get the registered domain under a public suffix")
+}
+
+func TestWriteHTML(t *testing.T) {
+	r := testReport()
+	r.Hosts = append(r.Hosts, Host{Name: "<script>.example.com"})
+
+	var builder strings.Builder
+	require.Nil(t, r.WriteHTML(&builder), "Could not write the html report")
+	html := builder.String()
+	require.Contains(t, html, "shuffledns report: example.com", "Could not write the title")
+	require.Contains(t, html, "*.dev.example.com", "Could not write the wildcard roots")
+	require.Contains(t, html, "<td>www.example.com</td>", "Could not write the host table")
+	require.Contains(t, html, "&lt;script&gt;.example.com", "Could not escape the hostnames")
+	require.NotContains(t, html, "src=", "Could not keep the report self-contained")
+}
+
+func names(hosts []Host) []string {
+	var result []string
+	for _, host := range hosts {
+		result = append(result, host.Name)
+	}
+	return result
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	r := testReport()
+	for i := 0; i < markdownHosts+2; i++ {
+		r.Hosts = append(r.Hosts, Host{Name: fmt.Sprintf("host%02d.example.com", i), Status: StatusNew})
+	}
+
+	var builder strings.Builder
+	require.Nil(t, r.WriteMarkdown(&builder), "Could not write the markdown summary")
+	markdown := builder.String()
+	require.Contains(t, markdown, "## shuffledns summary: example.com", "Could not write the title")
+	require.Contains(t, markdown, "| 31 | 4 | 29 | 1 | 1 | 1 |", "Could not write the counts")
+	require.Contains(t, markdown, "- 1 hosts resolve to private addresses", "Could not write the findings")
+	require.Contains(t, markdown, "- Wildcard roots: `*.dev.example.com`", "Could not write the wildcard roots")
+	require.Contains(t, markdown, "### New hosts (29)", "Could not write the new hosts")
+	require.Contains(t, markdown, "- `api.example.com` 1.1.1.1, 2.2.2.2", "Could not write the host ips")
+	require.Contains(t, markdown, "- and 4 more", "Could not truncate the new hosts")
+}
+
+func TestEmailPostures(t *testing.T) {
+	r := testReport()
+	r.EmailPostures = []*emailsec.Posture{
+		{Domain: "example.com", SPF: "v=spf1 -all", DMARCPolicy: "none", Issues: []string{"DMARC policy none only monitors spoofed messages"}},
+	}
+
+	var builder strings.Builder
+	require.Nil(t, r.WriteHTML(&builder), "Could not write the html report")
+	require.Contains(t, builder.String(), "<td>example.com</td><td>v=spf1 -all</td><td>p=none</td>", "Could not write the email postures")
+
+	builder.Reset()
+	require.Nil(t, r.WriteMarkdown(&builder), "Could not write the markdown summary")
+	require.Contains(t, builder.String(), "- Email security of `example.com`: DMARC policy none only monitors spoofed messages", "Could not write the email issues")
+}
@@ -0,0 +1,6 @@
+// Package resolvers parses the entries of resolver lists, which are
+// ipv4 or ipv6 addresses optionally followed by a port, written as
+// 1.1.1.1, 1.1.1.1:53, 2606:4700:4700::1111 or [2606:4700:4700::1111]:53.
+// The lists are files with an entry per line or inline comma separated
+// entries such as 10.0.0.1:5353,10.0.0.2:5353.
+package resolvers
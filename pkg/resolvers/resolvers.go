@@ -0,0 +1,207 @@
+package resolvers
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultPort is the port of the resolvers without an explicit one
+const DefaultPort = "53"
+
+// Family is the address family of the resolvers to use
+type Family int
+
+const (
+	// AnyFamily uses both ipv4 and ipv6 resolvers
+	AnyFamily Family = iota
+	// IPv4 uses only ipv4 resolvers
+	IPv4
+	// IPv6 uses only ipv6 resolvers
+	IPv6
+)
+
+// String returns the name of the family
+func (f Family) String() string {
+	switch f {
+	case IPv4:
+		return "ipv4"
+	case IPv6:
+		return "ipv6"
+	default:
+		return "any"
+	}
+}
+
+// Parse returns the ip:port address of a resolver entry, with the
+// ipv6 addresses enclosed in brackets.
+func Parse(entry string) (string, error) {
+	entry = strings.TrimSpace(entry)
+
+	// A bare ipv6 address contains colons without being host:port
+	if ip := net.ParseIP(strings.Trim(entry, "[]")); ip != nil {
+		return net.JoinHostPort(ip.String(), DefaultPort), nil
+	}
+
+	host, port, err := net.SplitHostPort(entry)
+	if err != nil {
+		return "", fmt.Errorf("invalid resolver %q: %w", entry, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", fmt.Errorf("invalid resolver %q: not an ip address", entry)
+	}
+	if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+		return "", fmt.Errorf("invalid resolver %q: invalid port", entry)
+	}
+	return net.JoinHostPort(ip.String(), port), nil
+}
+
+// FamilyOf returns the family of a resolver address
+func FamilyOf(address string) Family {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return AnyFamily
+	}
+	if ip.To4() != nil {
+		return IPv4
+	}
+	return IPv6
+}
+
+// Filter returns the addresses of a family
+func Filter(addresses []string, family Family) []string {
+	if family == AnyFamily {
+		return addresses
+	}
+	var filtered []string
+	for _, address := range addresses {
+		if FamilyOf(address) == family {
+			filtered = append(filtered, address)
+		}
+	}
+	return filtered
+}
+
+// ParseList returns the addresses of a list of resolver entries,
+// skipping the blank ones.
+func ParseList(entries []string) ([]string, error) {
+	var addresses []string
+	for _, entry := range entries {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		address, err := Parse(entry)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+// ReadFile returns the addresses of the resolvers of a file
+func ReadFile(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		entries = append(entries, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ParseList(entries)
+}
+
+// Load returns the addresses of the resolvers of a file, or of a comma
+// separated list of resolver entries if there is no such file.
+func Load(value string) ([]string, error) {
+	if _, err := os.Stat(value); err == nil || !IsList(value) {
+		return ReadFile(value)
+	}
+	return ParseList(strings.Split(value, ","))
+}
+
+// IsList returns true if a value is a comma separated list of
+// valid resolver entries rather than a file.
+func IsList(value string) bool {
+	if strings.TrimSpace(value) == "" {
+		return false
+	}
+	_, err := ParseList(strings.Split(value, ","))
+	return err == nil
+}
+
+// WriteFile writes resolver addresses to a file, one per line, in
+// the format understood by massdns.
+func WriteFile(file string, addresses []string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, address := range addresses {
+		_, _ = w.WriteString(address + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Exclude returns the addresses which aren't in the excluded ones
+func Exclude(addresses, excluded []string) []string {
+	skip := make(map[string]struct{}, len(excluded))
+	for _, address := range excluded {
+		skip[address] = struct{}{}
+	}
+	var kept []string
+	for _, address := range addresses {
+		if _, ok := skip[address]; !ok {
+			kept = append(kept, address)
+		}
+	}
+	return kept
+}
+
+// Split partitions addresses in two disjoint pools, the second one
+// holding the given fraction of them, rounded up, picked evenly across
+// the list. The second pool is empty if there are fewer than two
+// addresses, and the first one always keeps at least one.
+func Split(addresses []string, fraction float64) ([]string, []string) {
+	if len(addresses) < 2 || fraction <= 0 {
+		return addresses, nil
+	}
+	reserved := int(math.Ceil(float64(len(addresses)) * fraction))
+	if reserved >= len(addresses) {
+		reserved = len(addresses) - 1
+	}
+
+	var first, second []string
+	step := float64(len(addresses)) / float64(reserved)
+	next := 0.0
+	for i, address := range addresses {
+		if len(second) < reserved && float64(i) >= next {
+			second = append(second, address)
+			next += step
+			continue
+		}
+		first = append(first, address)
+	}
+	return first, second
+}
@@ -0,0 +1,92 @@
+package resolvers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]string{
+		"1.1.1.1":                     "1.1.1.1:53",
+		" 1.1.1.1:5353 ":              "1.1.1.1:5353",
+		"2606:4700:4700::1111":        "[2606:4700:4700::1111]:53",
+		"[2606:4700:4700::1111]":      "[2606:4700:4700::1111]:53",
+		"[2606:4700:4700::1111]:5353": "[2606:4700:4700::1111]:5353",
+		"2606:4700:4700:0:0:0:0:1111": "[2606:4700:4700::1111]:53",
+	}
+	for entry, expected := range tests {
+		address, err := Parse(entry)
+		require.Nil(t, err, "Could not parse %s", entry)
+		require.Equal(t, expected, address, "Could not get address of %s", entry)
+	}
+
+	for _, entry := range []string{"resolver.example.com", "1.1.1.1:dns-port", "1.1.1.1:domain", "1.1.1.1:0", "1.1.1.1:99999", "1.1.1"} {
+		_, err := Parse(entry)
+		require.NotNil(t, err, "Could not detect invalid entry %s", entry)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	addresses := []string{"1.1.1.1:53", "[2606:4700:4700::1111]:53"}
+	require.Equal(t, addresses, Filter(addresses, AnyFamily), "Could not keep all families")
+	require.Equal(t, []string{"1.1.1.1:53"}, Filter(addresses, IPv4), "Could not filter ipv4")
+	require.Equal(t, []string{"[2606:4700:4700::1111]:53"}, Filter(addresses, IPv6), "Could not filter ipv6")
+}
+
+func TestReadWriteFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "resolvers.txt")
+	require.Nil(t, os.WriteFile(file, []byte("1.1.1.1\n\n::1\n"), 0644))
+
+	addresses, err := ReadFile(file)
+	require.Nil(t, err, "Could not read resolvers")
+	require.Equal(t, []string{"1.1.1.1:53", "[::1]:53"}, addresses, "Could not get addresses")
+
+	require.Nil(t, WriteFile(file, addresses), "Could not write resolvers")
+	written, err := ReadFile(file)
+	require.Nil(t, err, "Could not read written resolvers")
+	require.Equal(t, addresses, written, "Could not read back addresses")
+}
+
+func TestLoad(t *testing.T) {
+	addresses, err := Load("10.0.0.1:5353,[::1]:5353")
+	require.Nil(t, err, "Could not load inline resolvers")
+	require.Equal(t, []string{"10.0.0.1:5353", "[::1]:5353"}, addresses, "Could not get inline addresses")
+
+	file := filepath.Join(t.TempDir(), "resolvers.txt")
+	require.Nil(t, os.WriteFile(file, []byte("10.0.0.1:5353\n"), 0644))
+	addresses, err = Load(file)
+	require.Nil(t, err, "Could not load resolvers file")
+	require.Equal(t, []string{"10.0.0.1:5353"}, addresses, "Could not get file addresses")
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.txt"))
+	require.NotNil(t, err, "Could not detect missing file")
+}
+
+func TestExclude(t *testing.T) {
+	addresses := []string{"1.1.1.1:53", "8.8.8.8:53", "9.9.9.9:53"}
+	require.Equal(t, []string{"1.1.1.1:53", "9.9.9.9:53"}, Exclude(addresses, []string{"8.8.8.8:53"}), "Could not exclude addresses")
+	require.Nil(t, Exclude(addresses, addresses), "Could not exclude all addresses")
+}
+
+func TestSplit(t *testing.T) {
+	var addresses []string
+	for i := 1; i <= 10; i++ {
+		addresses = append(addresses, fmt.Sprintf("10.0.0.%d:53", i))
+	}
+	first, second := Split(addresses, 0.2)
+	require.Equal(t, []string{"10.0.0.1:53", "10.0.0.6:53"}, second, "Could not reserve addresses evenly")
+	require.Len(t, first, 8, "Could not keep the other addresses")
+	require.Equal(t, first, Exclude(first, second), "Could not get disjoint pools")
+
+	first, second = Split(addresses, 1)
+	require.Len(t, first, 1, "Could not keep an address in the first pool")
+	require.Len(t, second, 9, "Could not reserve the other addresses")
+
+	first, second = Split(addresses[:1], 0.5)
+	require.Equal(t, addresses[:1], first, "Could not keep a single address")
+	require.Nil(t, second, "Could not skip splitting a single address")
+}
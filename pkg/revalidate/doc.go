@@ -0,0 +1,10 @@
+// Package revalidate re-resolves the results of a previous shuffledns
+// run to find the hosts which are still alive.
+//
+// Both the plain text and the ndjson output formats are supported as
+// inputs. Each hostname is resolved again and compared with its previous
+// records: hosts resolving to the same records are alive, hosts resolving
+// to other records are changed and hosts which don't resolve anymore are
+// gone. Hosts which couldn't be resolved because of resolver errors are
+// kept with their previous records as unverified.
+package revalidate
@@ -0,0 +1,204 @@
+package revalidate
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// The statuses of a revalidated host
+const (
+	StatusAlive      = "alive"
+	StatusChanged    = "changed"
+	StatusGone       = "gone"
+	StatusUnverified = "unverified"
+)
+
+// ResolveFunc returns the A records and the CNAME chain of a host.
+// A host which doesn't exist returns no records and no error, and an
+// answer which doesn't tell whether it exists returns an error.
+type ResolveFunc func(host string) ([]string, []string, error)
+
+// Options contains the configuration options for revalidating an output
+type Options struct {
+	// Input is the output file of the previous run
+	Input string
+	// Threads is the number of hosts resolved concurrently
+	Threads int
+	// Resolve resolves the hosts with the trusted resolvers
+	Resolve ResolveFunc
+}
+
+// Result is the revalidated record of a host
+type Result struct {
+	Hostname   string   `json:"hostname"`
+	IP         []string `json:"ip,omitempty"`
+	CNAME      []string `json:"cname,omitempty"`
+	Status     string   `json:"status"`
+	PreviousIP []string `json:"previous_ip,omitempty"`
+}
+
+// Stats contains statistics about a revalidation
+type Stats struct {
+	// Hosts is the number of unique hostnames read from the input
+	Hosts int
+	// Alive is the number of hosts resolving to the same records
+	Alive int
+	// Changed is the number of hosts resolving to other records
+	Changed int
+	// Unverified is the number of hosts which couldn't be resolved
+	Unverified int
+	// Gone contains the hostnames which don't resolve anymore
+	Gone []string
+}
+
+// record is a single output record found in the input file
+type record struct {
+	Hostname string   `json:"hostname"`
+	IP       []string `json:"ip"`
+	CNAME    []string `json:"cname"`
+}
+
+// Revalidate re-resolves the hosts of the input and writes the records
+// of the hosts which didn't disappear to the writer as ndjson, sorted
+// by hostname.
+func Revalidate(options *Options, writer io.Writer) (*Stats, error) {
+	records, err := readRecords(options.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	threads := options.Threads
+	if threads <= 0 {
+		threads = 1
+	}
+	results := make([]*Result, len(records))
+	wg := sizedwaitgroup.New(threads)
+	var mutex sync.Mutex
+	for i, r := range records {
+		wg.Add()
+		go func(i int, r *record) {
+			defer wg.Done()
+			result := check(r, options.Resolve)
+			mutex.Lock()
+			results[i] = result
+			mutex.Unlock()
+		}(i, r)
+	}
+	wg.Wait()
+
+	stats := &Stats{Hosts: len(results)}
+	w := bufio.NewWriter(writer)
+	encoder := json.NewEncoder(w)
+	for _, result := range results {
+		switch result.Status {
+		case StatusGone:
+			stats.Gone = append(stats.Gone, result.Hostname)
+			continue
+		case StatusAlive:
+			stats.Alive++
+		case StatusChanged:
+			stats.Changed++
+		case StatusUnverified:
+			stats.Unverified++
+		}
+		if err := encoder.Encode(result); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// check resolves a host again and compares it with its previous records
+func check(r *record, resolve ResolveFunc) *Result {
+	result := &Result{Hostname: r.Hostname}
+	ips, chain, err := resolve(r.Hostname)
+	switch {
+	case err != nil:
+		result.IP, result.CNAME = r.IP, r.CNAME
+		result.Status = StatusUnverified
+	case len(ips) == 0 && len(chain) == 0:
+		result.Status = StatusGone
+	default:
+		result.IP, result.CNAME = ips, chain
+		result.Status = StatusAlive
+		// Plain text inputs have no records to compare with
+		if len(r.IP) > 0 && !sameSet(r.IP, ips) {
+			result.Status = StatusChanged
+			result.PreviousIP = r.IP
+		}
+	}
+	return result
+}
+
+// sameSet returns true if two lists contain the same values
+func sameSet(a, b []string) bool {
+	set := make(map[string]struct{}, len(a))
+	for _, value := range a {
+		set[value] = struct{}{}
+	}
+	other := make(map[string]struct{}, len(b))
+	for _, value := range b {
+		if _, ok := set[value]; !ok {
+			return false
+		}
+		other[value] = struct{}{}
+	}
+	return len(set) == len(other)
+}
+
+// readRecords reads the records of an output file deduplicated by
+// hostname and sorted. Lines starting with `{` are decoded as json
+// records, other lines as plain hostnames.
+func readRecords(input string) ([]*record, error) {
+	file, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	byHostname := make(map[string]*record)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		r := &record{}
+		if strings.HasPrefix(line, "{") {
+			if err := json.Unmarshal([]byte(line), r); err != nil {
+				continue
+			}
+		} else {
+			r.Hostname = line
+		}
+		r.Hostname = dnsname.Normalize(r.Hostname)
+		if r.Hostname == "" {
+			continue
+		}
+		byHostname[r.Hostname] = r
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	records := make([]*record, 0, len(byHostname))
+	for _, r := range byHostname {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Hostname < records[j].Hostname
+	})
+	return records, nil
+}
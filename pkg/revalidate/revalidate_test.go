@@ -0,0 +1,64 @@
+package revalidate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevalidate(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "previous.ndjson")
+	require.Nil(t, os.WriteFile(input, []byte(`{"hostname":"a.example.com","ip":["1.1.1.1"]}
+{"hostname":"b.example.com","ip":["2.2.2.2"]}
+{"hostname":"c.example.com","ip":["3.3.3.3"]}
+{"hostname":"d.example.com","ip":["4.4.4.4"]}
+E.example.com
+`), 0644))
+
+	records := map[string][]string{
+		"a.example.com": {"1.1.1.1"},
+		"b.example.com": {"5.5.5.5"},
+		"e.example.com": {"6.6.6.6"},
+	}
+	resolve := func(host string) ([]string, []string, error) {
+		if host == "d.example.com" {
+			return nil, nil, errors.New("timeout")
+		}
+		return records[host], nil, nil
+	}
+
+	output := &strings.Builder{}
+	stats, err := Revalidate(&Options{Input: input, Threads: 2, Resolve: resolve}, output)
+	require.Nil(t, err, "Could not revalidate output")
+	require.Equal(t, `{"hostname":"a.example.com","ip":["1.1.1.1"],"status":"alive"}
+{"hostname":"b.example.com","ip":["5.5.5.5"],"status":"changed","previous_ip":["2.2.2.2"]}
+{"hostname":"d.example.com","ip":["4.4.4.4"],"status":"unverified"}
+{"hostname":"e.example.com","ip":["6.6.6.6"],"status":"alive"}
+`, output.String(), "Could not get revalidated output")
+	require.Equal(t, 5, stats.Hosts, "Could not count hosts")
+	require.Equal(t, 2, stats.Alive, "Could not count alive hosts")
+	require.Equal(t, 1, stats.Changed, "Could not count changed hosts")
+	require.Equal(t, 1, stats.Unverified, "Could not count unverified hosts")
+	require.Equal(t, []string{"c.example.com"}, stats.Gone, "Could not get gone hosts")
+}
+
+func TestRevalidateCNAMEOnly(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "previous.ndjson")
+	require.Nil(t, os.WriteFile(input, []byte(`{"hostname":"alias.example.com","cname":["app.example.net"]}
+`), 0644))
+
+	resolve := func(host string) ([]string, []string, error) {
+		return nil, []string{"app.example.net"}, nil
+	}
+	output := &strings.Builder{}
+	stats, err := Revalidate(&Options{Input: input, Threads: 1, Resolve: resolve}, output)
+	require.Nil(t, err, "Could not revalidate output")
+	require.Equal(t, `{"hostname":"alias.example.com","cname":["app.example.net"],"status":"alive"}
+`, output.String(), "Could not keep CNAME-only host")
+	require.Equal(t, 1, stats.Alive, "Could not count CNAME-only host as alive")
+	require.Empty(t, stats.Gone, "Could not keep CNAME-only host from gone hosts")
+}
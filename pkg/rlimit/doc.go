@@ -0,0 +1,3 @@
+// Package rlimit reads and raises the limit of open file descriptors
+// of the process, which bounds the number of concurrent sockets.
+package rlimit
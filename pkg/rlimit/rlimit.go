@@ -0,0 +1,25 @@
+package rlimit
+
+import "errors"
+
+// ErrUnsupported is returned on platforms without file descriptor limits
+var ErrUnsupported = errors.New("file descriptor limits not supported on this platform")
+
+// Raise raises the soft limit of open files to want, capped by the hard
+// limit, returning the resulting soft limit. The limit is never lowered.
+func Raise(want uint64) (uint64, error) {
+	soft, hard, err := Files()
+	if err != nil {
+		return 0, err
+	}
+	if want > hard {
+		want = hard
+	}
+	if want <= soft {
+		return soft, nil
+	}
+	if err := setFiles(want, hard); err != nil {
+		return soft, err
+	}
+	return want, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package rlimit
+
+// Files returns the soft and hard limits of open files of the process
+func Files() (uint64, uint64, error) {
+	return 0, 0, ErrUnsupported
+}
+
+// setFiles sets the soft and hard limits of open files of the process
+func setFiles(soft, hard uint64) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,24 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package rlimit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRaise(t *testing.T) {
+	soft, hard, err := Files()
+	require.Nil(t, err, "Could not get limits")
+	require.True(t, soft > 0 && soft <= hard, "Could not get valid limits")
+
+	raised, err := Raise(soft - 1)
+	require.Nil(t, err, "Could not raise limit")
+	require.Equal(t, soft, raised, "Could not keep higher limit")
+
+	raised, err = Raise(hard)
+	require.Nil(t, err, "Could not raise limit")
+	require.Equal(t, hard, raised, "Could not raise limit to hard limit")
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package rlimit
+
+import "syscall"
+
+// Files returns the soft and hard limits of open files of the process
+func Files() (uint64, uint64, error) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0, 0, err
+	}
+	return uint64(limit.Cur), uint64(limit.Max), nil
+}
+
+// setFiles sets the soft and hard limits of open files of the process
+func setFiles(soft, hard uint64) error {
+	limit := syscall.Rlimit{}
+	limit.Cur, limit.Max = soft, hard
+	return syscall.Setrlimit(syscall.RLIMIT_NOFILE, &limit)
+}
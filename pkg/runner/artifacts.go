@@ -0,0 +1,224 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/scheduler"
+	"github.com/projectdiscovery/gologger"
+)
+
+// artifactsTimeFormat is the format of the names of the directories of
+// the runs of a job in the artifacts directory
+const artifactsTimeFormat = "20060102T150405Z"
+
+// RunSummary is the summary of a run of a job stored with its artifacts
+type RunSummary struct {
+	Job      string    `json:"job"`
+	Tenant   string    `json:"tenant,omitempty"`
+	Args     []string  `json:"args"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Duration string    `json:"duration"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// artifacts contains the files of a run of a job kept by the daemon
+type artifacts struct {
+	dir    string
+	output *os.File
+}
+
+// newArtifacts creates the artifacts directory of a run of a job with
+// the file receiving its output, which is ndjson for json jobs. The job
+// name has to be a single path segment so that the directory stays
+// under the artifacts root, jobs files edited by hand included.
+func newArtifacts(root, job string, start time.Time, args []string) (*artifacts, error) {
+	jobDir := filepath.Join(root, job)
+	if job == "" || job == "." || job == ".." || filepath.Dir(jobDir) != filepath.Clean(root) {
+		return nil, fmt.Errorf("invalid job name %s", job)
+	}
+	dir := filepath.Join(jobDir, start.UTC().Format(artifactsTimeFormat))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	name := "output.txt"
+	if hasFlag(args, "json") {
+		name = "output.ndjson"
+	}
+	output, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	return &artifacts{dir: dir, output: output}, nil
+}
+
+// args returns the arguments of a job writing its wildcards and progress
+// events to the artifacts, unless the job writes them elsewhere.
+func (a *artifacts) args(args []string) []string {
+	extended := append([]string{}, args...)
+	if !hasFlag(args, "wildcard-output-file") {
+		extended = append(extended, "-wildcard-output-file", filepath.Join(a.dir, "wildcards.txt"))
+	}
+	if !hasFlag(args, "progress-json") {
+		extended = append(extended, "-progress-json", filepath.Join(a.dir, "progress.ndjson"))
+	}
+	return extended
+}
+
+// stdout returns the writer receiving the output of the job, which is
+// still written to the output of the daemon too.
+func (a *artifacts) stdout() io.Writer {
+	return io.MultiWriter(os.Stdout, a.output)
+}
+
+// close writes the summary of the run and closes the output file
+func (a *artifacts) close(summary *RunSummary) error {
+	a.output.Close()
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(a.dir, "summary.json"), data, 0600)
+}
+
+// hasFlag returns true if a flag is set in command line arguments,
+// either alone, with its value as next argument or after an equal sign.
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		arg = strings.TrimLeft(arg, "-")
+		if arg == name || strings.HasPrefix(arg, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneArtifacts removes the artifacts of the runs started before the
+// retention period, and the directories of the jobs left empty.
+func pruneArtifacts(root string, retention time.Duration, now time.Time) {
+	jobs, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, job := range jobs {
+		if !job.IsDir() {
+			continue
+		}
+		jobDir := filepath.Join(root, job.Name())
+		runs, err := os.ReadDir(jobDir)
+		if err != nil {
+			continue
+		}
+		kept := 0
+		for _, run := range runs {
+			start, err := time.Parse(artifactsTimeFormat, run.Name())
+			if err != nil || !run.IsDir() || now.Sub(start) <= retention {
+				kept++
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(jobDir, run.Name())); err != nil {
+				gologger.Error().Msgf("Could not remove artifacts of job %s: %s\n", job.Name(), err)
+				kept++
+			}
+		}
+		if kept == 0 {
+			_ = os.Remove(jobDir)
+		}
+	}
+}
+
+// serveArtifacts serves the artifacts of the runs of the jobs read-only
+// over http under /artifacts/<job>/<run>/<file>, the directories being
+// listed too.
+func serveArtifacts(addr, root, jobsFile string) {
+	mux := http.NewServeMux()
+	mux.Handle("/artifacts/", artifactsHandler(root, jobsFile))
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	gologger.Info().Msgf("Serving job artifacts on http://%s/artifacts/\n", addr)
+	if err := server.ListenAndServe(); err != nil {
+		gologger.Error().Msgf("Could not serve artifacts: %s\n", err)
+	}
+}
+
+// artifactsHandler returns the handler of the artifacts, which requires
+// the api key of a tenant as bearer token and serves only the artifacts
+// of the jobs of this tenant. The key of the empty tenant, created by
+// "daemon key" without -tenant, serves the jobs without tenant. The
+// tenants and the jobs are reloaded on each request so that the keys
+// created, rotated or revoked apply immediately.
+func artifactsHandler(root, jobsFile string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		tenants, err := scheduler.LoadTenants(scheduler.TenantsFile(jobsFile))
+		if err != nil {
+			gologger.Error().Msgf("Could not load tenants: %s\n", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		tenant := tenants.Authenticate(key)
+		if tenant == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="artifacts"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		jobs, err := scheduler.LoadJobs(jobsFile)
+		if err != nil {
+			gologger.Error().Msgf("Could not load jobs: %s\n", err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/artifacts/")
+		if path == "" {
+			listTenantArtifacts(w, root, jobs.Tenant(tenant.Name))
+			return
+		}
+		name := path
+		if index := strings.Index(path, "/"); index != -1 {
+			name = path[:index]
+		}
+		job := jobs.Get(name)
+		if name == "." || name == ".." || job == nil || job.Tenant != tenant.Name {
+			http.NotFound(w, r)
+			return
+		}
+		prefix := "/artifacts/" + name
+		if path == name {
+			http.Redirect(w, r, prefix+"/", http.StatusMovedPermanently)
+			return
+		}
+		http.StripPrefix(prefix, http.FileServer(http.Dir(filepath.Join(root, name)))).ServeHTTP(w, r)
+	})
+}
+
+// listTenantArtifacts lists the jobs of a tenant having artifacts like
+// the directory listings of the other artifacts
+func listTenantArtifacts(w http.ResponseWriter, root string, jobs []*scheduler.Job) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<pre>\n")
+	for _, job := range jobs {
+		if info, err := os.Stat(filepath.Join(root, job.Name)); err != nil || !info.IsDir() {
+			continue
+		}
+		link := url.URL{Path: job.Name + "/"}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", link.String(), html.EscapeString(job.Name+"/"))
+	}
+	fmt.Fprintf(w, "</pre>\n")
+}
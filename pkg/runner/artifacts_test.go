@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/scheduler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifactsHandlerTenants(t *testing.T) {
+	dir := t.TempDir()
+	jobsFile := filepath.Join(dir, "jobs.json")
+	root := filepath.Join(dir, "artifacts")
+
+	require.Nil(t, scheduler.UpdateJobs(jobsFile, func(jobs *scheduler.Jobs) error {
+		if err := jobs.Add(&scheduler.Job{Name: "red", Schedule: "* * * * *", Tenant: "red-team"}); err != nil {
+			return err
+		}
+		if err := jobs.Add(&scheduler.Job{Name: "blue", Schedule: "* * * * *", Tenant: "blue-team"}); err != nil {
+			return err
+		}
+		return jobs.Add(&scheduler.Job{Name: "shared", Schedule: "* * * * *"})
+	}), "Could not add jobs")
+	var key, sharedKey string
+	require.Nil(t, scheduler.UpdateTenants(scheduler.TenantsFile(jobsFile), func(tenants *scheduler.Tenants) error {
+		var err error
+		if key, err = tenants.NewKey("red-team", 0, 0); err != nil {
+			return err
+		}
+		sharedKey, err = tenants.NewKey("", 0, 0)
+		return err
+	}), "Could not create key")
+	for _, job := range []string{"red", "blue", "shared"} {
+		run := filepath.Join(root, job, "20220530T153000Z")
+		require.Nil(t, os.MkdirAll(run, 0700), "Could not create artifacts")
+		require.Nil(t, os.WriteFile(filepath.Join(run, "output.txt"), []byte(job+".example.com\n"), 0600), "Could not write artifacts")
+	}
+	server := httptest.NewServer(artifactsHandler(root, jobsFile))
+	defer server.Close()
+
+	get := func(path, key string) (int, string) {
+		request, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		require.Nil(t, err, "Could not create request")
+		if key != "" {
+			request.Header.Set("Authorization", "Bearer "+key)
+		}
+		response, err := http.DefaultClient.Do(request)
+		require.Nil(t, err, "Could not get %s", path)
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		require.Nil(t, err, "Could not read %s", path)
+		return response.StatusCode, string(body)
+	}
+
+	status, _ := get("/artifacts/red/20220530T153000Z/output.txt", "")
+	require.Equal(t, http.StatusUnauthorized, status, "Could not require a key")
+	status, _ = get("/artifacts/red/20220530T153000Z/output.txt", "sdns_wrong")
+	require.Equal(t, http.StatusUnauthorized, status, "Could not reject unknown key")
+
+	status, body := get("/artifacts/red/20220530T153000Z/output.txt", key)
+	require.Equal(t, http.StatusOK, status, "Could not get artifacts of tenant")
+	require.Equal(t, "red.example.com\n", body, "Could not get artifacts content")
+
+	status, body = get("/artifacts/", key)
+	require.Equal(t, http.StatusOK, status, "Could not list artifacts")
+	require.Contains(t, body, "red/", "Could not list jobs of tenant")
+	require.NotContains(t, body, "blue/", "Could not hide jobs of other tenants")
+
+	status, _ = get("/artifacts/blue/20220530T153000Z/output.txt", key)
+	require.Equal(t, http.StatusNotFound, status, "Could not hide artifacts of other tenants")
+	status, _ = get("/artifacts/red/../blue/20220530T153000Z/output.txt", key)
+	require.NotEqual(t, http.StatusOK, status, "Could not prevent escaping the job artifacts")
+
+	status, _ = get("/artifacts/shared/20220530T153000Z/output.txt", key)
+	require.Equal(t, http.StatusNotFound, status, "Could not hide artifacts of jobs without tenant")
+	status, body = get("/artifacts/shared/20220530T153000Z/output.txt", sharedKey)
+	require.Equal(t, http.StatusOK, status, "Could not get artifacts of jobs without tenant")
+	require.Equal(t, "shared.example.com\n", body, "Could not get artifacts content")
+	status, _ = get("/artifacts/red/20220530T153000Z/output.txt", sharedKey)
+	require.Equal(t, http.StatusNotFound, status, "Could not hide artifacts of tenants from the key without tenant")
+}
+
+func TestNewArtifactsInvalidJob(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "artifacts")
+	for _, job := range []string{"../../x", "../x", "a/b", "..", ""} {
+		_, err := newArtifacts(root, job, time.Now(), nil)
+		require.NotNil(t, err, "Could not reject job name %s", job)
+	}
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err, "Could not read directory")
+	require.Empty(t, entries, "Could not keep the artifacts under the root")
+
+	run, err := newArtifacts(root, "hourly", time.Now(), nil)
+	require.Nil(t, err, "Could not create artifacts")
+	require.Nil(t, run.close(&RunSummary{Job: "hourly"}), "Could not close artifacts")
+}
@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// bandwidthUnits contains the multipliers of the bandwidth units
+var bandwidthUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"gbps", 1000 * 1000 * 1000},
+	{"mbps", 1000 * 1000},
+	{"kbps", 1000},
+	{"bps", 1},
+}
+
+// parseBandwidth parses a bandwidth like 10mbps into bits per second
+func parseBandwidth(value string) (int64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	for _, unit := range bandwidthUnits {
+		if !strings.HasSuffix(value, unit.suffix) {
+			continue
+		}
+		number, err := strconv.ParseFloat(strings.TrimSuffix(value, unit.suffix), 64)
+		if err != nil || number <= 0 {
+			return 0, fmt.Errorf("invalid bandwidth: %s", value)
+		}
+		return int64(number * float64(unit.multiplier)), nil
+	}
+	return 0, fmt.Errorf("invalid bandwidth unit in %s (use bps, kbps, mbps or gbps)", value)
+}
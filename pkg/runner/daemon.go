@@ -0,0 +1,641 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/scheduler"
+	"github.com/projectdiscovery/gologger"
+)
+
+// DaemonOptions contains the configuration options for the daemon subcommand
+type DaemonOptions struct {
+	Command    string        // Command is the daemon action to perform (run, add, list, remove, pause, resume, key, revoke)
+	JobsFile   string        // JobsFile is the file where the recurring jobs are persisted
+	Name       string        // Name is the name of the job to add, remove, pause or resume
+	Schedule   string        // Schedule is the cron expression of the job to add
+	Priority   int           // Priority is the priority of the job to add, the highest running first
+	Tenant     string        // Tenant is the tenant owning the job to add, or whose jobs are listed
+	Parallel   int           // Parallel is the maximum number of jobs running at the same time
+	MaxQPS     int           // MaxQPS is the query budget per second shared by the running jobs (0 for unlimited)
+	TenantJobs int           // TenantJobs is the maximum number of jobs of a tenant running at the same time (0 for unlimited), or of the tenant of a key
+	TenantQPS  int           // TenantQPS is the query budget per second shared by the running jobs of a tenant (0 for unlimited), or of the tenant of a key
+	Artifacts  string        // Artifacts is the directory where the artifacts of the runs of the jobs are kept
+	Retention  time.Duration // Retention is the duration the artifacts of a run are kept (0 to keep them forever)
+	Listen     string        // Listen is the address serving the artifacts over http
+	Args       []string      // Args are the shuffledns arguments of the job to add
+	Silent     bool          // Silent suppresses any extra text
+	NoColor    bool          // NoColor disables the colored output
+}
+
+// ParseDaemonOptions parses the command line flags for the daemon subcommand
+func ParseDaemonOptions(args []string) (*DaemonOptions, error) {
+	options := &DaemonOptions{}
+
+	flagSet := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		gologger.Print().Msgf("Usage: shuffledns daemon [run|add|list|remove|pause|resume|key|revoke] -jobs jobs.json [flags] [-- shuffledns args]\n")
+		flagSet.PrintDefaults()
+	}
+	flagSet.StringVar(&options.JobsFile, "jobs", "", "File where recurring jobs are persisted")
+	flagSet.StringVar(&options.Name, "name", "", "Name of the job to add, remove, pause or resume")
+	flagSet.StringVar(&options.Schedule, "cron", "", "Cron expression of the job to add (e.g. \"0 */6 * * *\")")
+	flagSet.IntVar(&options.Priority, "priority", 0, "Priority of the job to add, the jobs due at the same time running highest first")
+	flagSet.StringVar(&options.Tenant, "tenant", "", "Tenant owning the job to add, whose jobs are listed, or whose key is created or revoked")
+	flagSet.IntVar(&options.Parallel, "parallel", 1, "Maximum number of jobs running at the same time")
+	flagSet.IntVar(&options.MaxQPS, "max-qps", 0, "Dns queries per second shared fairly by the running jobs (0 for unlimited)")
+	flagSet.IntVar(&options.TenantJobs, "tenant-jobs", 0, "Maximum number of jobs of a tenant running at the same time (0 for unlimited), or of the tenant of a key")
+	flagSet.IntVar(&options.TenantQPS, "tenant-qps", 0, "Dns queries per second shared fairly by the running jobs of a tenant (0 for unlimited), or by the ones of the tenant of a key")
+	flagSet.StringVar(&options.Artifacts, "artifacts", "", "Directory where the output, summary, wildcards and progress of each run are kept")
+	flagSet.DurationVar(&options.Retention, "retention", 0, "Duration the artifacts of a run are kept (0 to keep them forever)")
+	flagSet.StringVar(&options.Listen, "listen", "", "Address serving the artifacts for download over http (e.g. 127.0.0.1:8080)")
+	flagSet.BoolVar(&options.Silent, "silent", false, "Show only job output")
+	flagSet.BoolVar(&options.NoColor, "nC", false, "Don't Use colors in output")
+
+	// Everything after -- is the command line of the job
+	for i, arg := range args {
+		if arg == "--" {
+			options.Args = args[i+1:]
+			args = args[:i]
+			break
+		}
+	}
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return nil, err
+	}
+
+	(&Options{Silent: options.Silent, NoColor: options.NoColor}).configureOutput()
+
+	options.Command = "run"
+	if len(positional) > 0 {
+		options.Command = positional[0]
+	}
+	if options.JobsFile == "" {
+		flagSet.Usage()
+		return nil, fmt.Errorf("%w: no jobs file provided", ErrUsage)
+	}
+	if options.Parallel < 1 {
+		return nil, invalidOption("invalid number of parallel jobs")
+	}
+	if options.MaxQPS < 0 {
+		return nil, invalidOption("invalid maximum queries per second")
+	}
+	if options.TenantJobs < 0 || options.TenantQPS < 0 {
+		return nil, invalidOption("invalid tenant quota")
+	}
+	if options.Retention < 0 {
+		return nil, invalidOption("invalid artifacts retention")
+	}
+	if (options.Retention > 0 || options.Listen != "") && options.Artifacts == "" {
+		return nil, invalidOption("artifacts options specified without artifacts directory")
+	}
+	// The jobs of the tenants run in their own directories
+	if options.Artifacts != "" {
+		artifacts, err := filepath.Abs(options.Artifacts)
+		if err != nil {
+			return nil, err
+		}
+		options.Artifacts = artifacts
+	}
+	return options, nil
+}
+
+// RunDaemon performs the daemon action requested by the user
+func RunDaemon(options *DaemonOptions) error {
+	switch options.Command {
+	case "add":
+		if len(options.Args) == 0 {
+			return fmt.Errorf("no shuffledns arguments given for the job")
+		}
+		if options.Tenant != "" {
+			if err := checkTenantArgs(options.Args); err != nil {
+				return err
+			}
+		}
+		err := scheduler.UpdateJobs(options.JobsFile, func(jobs *scheduler.Jobs) error {
+			return jobs.Add(&scheduler.Job{Name: options.Name, Schedule: options.Schedule, Priority: options.Priority, Tenant: options.Tenant, Args: options.Args})
+		})
+		if err != nil {
+			return err
+		}
+		gologger.Info().Msgf("Added job %s (%s)\n", options.Name, options.Schedule)
+		return nil
+	case "remove":
+		err := scheduler.UpdateJobs(options.JobsFile, func(jobs *scheduler.Jobs) error {
+			if !jobs.Remove(options.Name) {
+				return fmt.Errorf("no job named %s", options.Name)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		gologger.Info().Msgf("Removed job %s\n", options.Name)
+		return nil
+	case "pause", "resume":
+		paused := options.Command == "pause"
+		err := scheduler.UpdateJobs(options.JobsFile, func(jobs *scheduler.Jobs) error {
+			job := jobs.Get(options.Name)
+			if job == nil {
+				return fmt.Errorf("no job named %s", options.Name)
+			}
+			job.Paused = paused
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if paused {
+			gologger.Info().Msgf("Paused job %s\n", options.Name)
+		} else {
+			gologger.Info().Msgf("Resumed job %s\n", options.Name)
+		}
+		return nil
+	case "list":
+		jobs, err := scheduler.LoadJobs(options.JobsFile)
+		if err != nil {
+			return err
+		}
+		list := jobs.List()
+		if options.Tenant != "" {
+			list = jobs.Tenant(options.Tenant)
+		}
+		for _, job := range list {
+			lastRun := "never"
+			if !job.LastRun.IsZero() {
+				lastRun = job.LastRun.Format(time.RFC3339)
+			}
+			state := "active"
+			if job.Paused {
+				state = "paused"
+			}
+			gologger.Silent().Msgf("%s\t%s\t%s\t%s\t%s\t%s\n", job.Name, tenantName(job.Tenant), job.Schedule, state, lastRun, strings.Join(job.Args, " "))
+		}
+		return nil
+	case "key":
+		var key string
+		err := scheduler.UpdateTenants(scheduler.TenantsFile(options.JobsFile), func(tenants *scheduler.Tenants) error {
+			var err error
+			key, err = tenants.NewKey(options.Tenant, options.TenantJobs, options.TenantQPS)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		// The key is only stored hashed, so it can't be shown again
+		gologger.Info().Msgf("Created key of tenant %s, store it as it won't be shown again\n", tenantName(options.Tenant))
+		gologger.Silent().Msgf("%s\n", key)
+		return nil
+	case "revoke":
+		err := scheduler.UpdateTenants(scheduler.TenantsFile(options.JobsFile), func(tenants *scheduler.Tenants) error {
+			if !tenants.Remove(options.Tenant) {
+				return fmt.Errorf("no key for tenant %s", tenantName(options.Tenant))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		gologger.Info().Msgf("Revoked key of tenant %s\n", tenantName(options.Tenant))
+		return nil
+	case "run":
+		return runDaemon(options)
+	default:
+		return fmt.Errorf("unknown daemon command %s", options.Command)
+	}
+}
+
+// jobScheduler tracks the jobs started by the daemon across the checks
+type jobScheduler struct {
+	executable string
+	options    *DaemonOptions
+	// slots are held by the running jobs which aren't paused
+	slots chan struct{}
+
+	mutex sync.Mutex
+	// running contains the names of the running jobs
+	running map[string]struct{}
+	// tenants counts the running jobs per tenant
+	tenants map[string]int
+}
+
+// runDaemon runs the scheduled jobs forever, checking every minute
+// for due jobs. The jobs file is reloaded on each check so that jobs
+// can be added or removed while the daemon is running.
+func runDaemon(options *DaemonOptions) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	jobsFile := options.JobsFile
+	gologger.Info().Msgf("Started daemon with jobs from %s\n", jobsFile)
+	if share := jobQPS(options); share > 0 {
+		gologger.Info().Msgf("Sharing %d queries per second between %d parallel jobs (%d each)\n", options.MaxQPS, options.Parallel, share)
+	}
+	if options.TenantJobs > 0 || options.TenantQPS > 0 {
+		gologger.Info().Msgf("Limiting each tenant to %d parallel jobs and %d queries per second (0 for unlimited)\n", options.TenantJobs, options.TenantQPS)
+	}
+	if options.Listen != "" {
+		go serveArtifacts(options.Listen, options.Artifacts, jobsFile)
+	}
+
+	s := &jobScheduler{
+		executable: executable,
+		options:    options,
+		slots:      make(chan struct{}, options.Parallel),
+		running:    make(map[string]struct{}),
+		tenants:    make(map[string]int),
+	}
+	for {
+		now := time.Now().Truncate(time.Minute)
+
+		// The tenants are reloaded with the jobs for the changes of
+		// their quotas to apply to the next jobs started
+		jobs, err := scheduler.LoadJobs(jobsFile)
+		if err != nil {
+			gologger.Error().Msgf("Could not load jobs: %s\n", err)
+		} else if tenants, err := scheduler.LoadTenants(scheduler.TenantsFile(jobsFile)); err != nil {
+			gologger.Error().Msgf("Could not load tenants: %s\n", err)
+		} else {
+			s.runDueJobs(jobs, tenants, now)
+		}
+		if options.Artifacts != "" && options.Retention > 0 {
+			pruneArtifacts(options.Artifacts, options.Retention, time.Now())
+		}
+
+		time.Sleep(time.Until(now.Add(time.Minute)))
+	}
+}
+
+// runDueJobs starts the jobs due at a time, the ones with the highest
+// priority first and at most the parallel jobs at the same time,
+// without waiting for them so that the next checks aren't delayed by
+// long or paused jobs. The jobs still running from a previous check are
+// skipped, and the jobs over the parallel jobs or over the quota of
+// their tenant are left due for the next check. The quotas of a tenant
+// set with its key take precedence over the ones of the daemon.
+func (s *jobScheduler) runDueJobs(jobs *scheduler.Jobs, tenants *scheduler.Tenants, now time.Time) {
+	for _, job := range jobs.Queue() {
+		due, err := job.Due(now)
+		if err != nil {
+			gologger.Error().Msgf("Could not check job %s: %s\n", job.Name, err)
+			continue
+		}
+		if !due {
+			continue
+		}
+		// The arguments are checked again for the jobs added before the
+		// check or by editing the jobs file
+		if job.Tenant != "" {
+			if err := checkTenantArgs(job.Args); err != nil {
+				gologger.Error().Msgf("Could not run job %s: %s\n", job.Name, err)
+				continue
+			}
+		}
+		tenantJobs, tenantQPS := tenantQuotas(s.options, tenants.Get(job.Tenant))
+
+		s.mutex.Lock()
+		if _, ok := s.running[job.Name]; ok {
+			s.mutex.Unlock()
+			gologger.Info().Msgf("Skipped job %s as its previous run is still running\n", job.Name)
+			continue
+		}
+		if job.Tenant != "" && tenantJobs > 0 && s.tenants[job.Tenant] >= tenantJobs {
+			s.mutex.Unlock()
+			gologger.Info().Msgf("Deferred job %s as tenant %s has %d running jobs\n", job.Name, job.Tenant, tenantJobs)
+			continue
+		}
+		select {
+		case s.slots <- struct{}{}:
+		default:
+			s.mutex.Unlock()
+			gologger.Info().Msgf("Deferred job %s as %d jobs are running\n", job.Name, s.options.Parallel)
+			continue
+		}
+		s.running[job.Name] = struct{}{}
+		s.tenants[job.Tenant]++
+		s.mutex.Unlock()
+
+		// Persist the run time before starting so that a restart in
+		// the middle of the job doesn't run it twice. The jobs are
+		// reloaded to not revert the changes made since this check,
+		// and the job isn't started if it was paused or removed since.
+		start := false
+		err = scheduler.UpdateJobs(s.options.JobsFile, func(current *scheduler.Jobs) error {
+			stored := current.Get(job.Name)
+			if stored == nil || stored.Paused {
+				return nil
+			}
+			stored.LastRun = now
+			job, start = stored, true
+			return nil
+		})
+		if err != nil {
+			gologger.Error().Msgf("Could not save jobs: %s\n", err)
+		}
+		if !start {
+			s.finish(job, true)
+			continue
+		}
+
+		args := job.Args
+		share := jobQPS(s.options)
+		if tenantShare := tenantShare(s.options.Parallel, tenantJobs, tenantQPS); job.Tenant != "" && tenantShare > 0 && (share == 0 || tenantShare < share) {
+			share = tenantShare
+		}
+		if share > 0 {
+			args = limitQPS(args, share)
+		}
+		gologger.Info().Msgf("Running job %s\n", job.Name)
+		go s.run(job, args)
+	}
+}
+
+// finish releases the resources of a job, its slot included if held
+func (s *jobScheduler) finish(job *scheduler.Job, holdsSlot bool) {
+	if holdsSlot {
+		<-s.slots
+	}
+	s.mutex.Lock()
+	delete(s.running, job.Name)
+	s.tenants[job.Tenant]--
+	s.mutex.Unlock()
+}
+
+// run runs a job with its artifacts until it exits
+func (s *jobScheduler) run(job *scheduler.Job, args []string) {
+	holdsSlot := true
+	defer func() { s.finish(job, holdsSlot) }()
+
+	dir, err := tenantDir(s.options.JobsFile, job.Tenant)
+	if err != nil {
+		gologger.Error().Msgf("Could not create directory of tenant %s: %s\n", job.Tenant, err)
+		return
+	}
+	start := time.Now()
+	var stdout io.Writer = os.Stdout
+	var runArtifacts *artifacts
+	if s.options.Artifacts != "" {
+		runArtifacts, err = newArtifacts(s.options.Artifacts, job.Name, start, args)
+		if err != nil {
+			gologger.Error().Msgf("Could not create artifacts of job %s: %s\n", job.Name, err)
+			return
+		}
+		args, stdout = runArtifacts.args(args), runArtifacts.stdout()
+	}
+	holdsSlot, err = runJob(s.executable, s.options.JobsFile, job.Name, dir, args, stdout, s.slots)
+	if runArtifacts != nil {
+		summary := &RunSummary{Job: job.Name, Tenant: job.Tenant, Args: args, Start: start, End: time.Now(), Duration: time.Since(start).String()}
+		if err != nil {
+			summary.Error = err.Error()
+		}
+		if err := runArtifacts.close(summary); err != nil {
+			gologger.Error().Msgf("Could not write summary of job %s: %s\n", job.Name, err)
+		}
+	}
+	if err != nil {
+		gologger.Error().Msgf("Job %s failed: %s\n", job.Name, err)
+		return
+	}
+	gologger.Info().Msgf("Finished job %s in %s\n", job.Name, time.Since(start))
+}
+
+// jobQPS returns the fair share of the query budget of each job, or 0
+// if the budget is unlimited. The rate of a job is fixed when it
+// starts, so the budget is divided by the maximum number of parallel
+// jobs for their total to never exceed it.
+func jobQPS(options *DaemonOptions) int {
+	if options.MaxQPS == 0 {
+		return 0
+	}
+	share := options.MaxQPS / options.Parallel
+	if share < 1 {
+		share = 1
+	}
+	return share
+}
+
+// tenantQuotas returns the maximum number of running jobs and the query
+// budget of a tenant, the ones set with its key if any and the ones of
+// the daemon otherwise.
+func tenantQuotas(options *DaemonOptions, tenant *scheduler.Tenant) (int, int) {
+	jobs, qps := options.TenantJobs, options.TenantQPS
+	if tenant != nil && tenant.Jobs > 0 {
+		jobs = tenant.Jobs
+	}
+	if tenant != nil && tenant.QPS > 0 {
+		qps = tenant.QPS
+	}
+	return jobs, qps
+}
+
+// tenantShare returns the fair share of the query budget of a tenant
+// for each of its jobs, or 0 if the budget is unlimited. Like the
+// global budget, it's divided by the maximum number of parallel jobs of
+// the tenant.
+func tenantShare(parallel, jobs, qps int) int {
+	if qps == 0 {
+		return 0
+	}
+	if jobs > 0 && jobs < parallel {
+		parallel = jobs
+	}
+	share := qps / parallel
+	if share < 1 {
+		share = 1
+	}
+	return share
+}
+
+// tenantDir returns the working directory of the jobs of a tenant next
+// to the jobs file, creating it if needed, so that the relative output
+// paths of the jobs of different tenants don't collide. The jobs
+// without tenant run in the working directory of the daemon.
+func tenantDir(jobsFile, tenant string) (string, error) {
+	if tenant == "" {
+		return "", nil
+	}
+	dir := filepath.Join(filepath.Dir(jobsFile), "tenants", tenant)
+	return dir, os.MkdirAll(dir, 0700)
+}
+
+// tenantName returns the name of a tenant for the messages, "-" for the
+// jobs without tenant
+func tenantName(tenant string) string {
+	if tenant == "" {
+		return "-"
+	}
+	return tenant
+}
+
+// tenantPathFlags are the shuffledns flags whose value is a file or a
+// directory read or written by the run
+var tenantPathFlags = map[string]struct{}{
+	// Files written
+	"o": {}, "o-hosts": {}, "o-urls": {}, "o-burp": {}, "o-targets": {}, "ip-clusters": {}, "o-ipmap": {},
+	"report": {}, "report-md": {}, "progress-json": {}, "wildcard-output-file": {}, "wildcard-output-json": {},
+	"manifest": {}, "resolver-stats": {}, "store": {}, "changes-output": {}, "directory": {},
+	// Files read
+	"list": {}, "w": {}, "r": {}, "wr": {}, "raw-input": {}, "scope": {}, "dnsgen": {},
+	"known-answers-file": {}, "suspicious-ips": {}, "sinkholes-file": {}, "parking-file": {},
+	"cdn-ranges": {}, "vendor-fingerprints": {}, "sign-key": {}, "sign-password-file": {},
+}
+
+// tenantForbiddenFlags are the shuffledns flags running commands or
+// loading code and configuration chosen by the job, which would let a
+// tenant execute anything as the daemon, or sending requests to a url
+// chosen by the job, which would let a tenant reach the hosts of the
+// network of the daemon.
+var tenantForbiddenFlags = map[string]struct{}{
+	"on-result": {}, "on-complete": {}, "plugins": {}, "massdns": {}, "config": {}, "webhook": {},
+}
+
+// checkTenantArgs returns an error if the arguments of a tenant job
+// run commands, load code or send requests with tenantForbiddenFlags,
+// or read or write outside of the directory of the tenant, with an
+// absolute path or one going up with "..", so that a tenant can't
+// escape its directory nor read or overwrite the files of the other
+// tenants or of the daemon. The resolvers given inline to -r and -wr
+// are neither absolute nor going up, so they pass the check.
+func checkTenantArgs(args []string) error {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" {
+			break
+		}
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		name := strings.TrimLeft(args[i], "-")
+		value, hasValue := "", false
+		if index := strings.Index(name, "="); index != -1 {
+			name, value, hasValue = name[:index], name[index+1:], true
+		}
+		if _, ok := tenantForbiddenFlags[name]; ok {
+			return fmt.Errorf("-%s is not allowed in the jobs of a tenant", name)
+		}
+		if _, ok := tenantPathFlags[name]; !ok {
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				continue
+			}
+			i++
+			value = args[i]
+		}
+		clean := filepath.Clean(value)
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("path %s of -%s is outside of the tenant directory", value, name)
+		}
+	}
+	return nil
+}
+
+// limitQPS returns the arguments of a job with its maximum queries
+// per second capped to a rate, keeping its own one if lower.
+func limitQPS(args []string, qps int) []string {
+	limited := make([]string, 0, len(args)+2)
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		value := ""
+		switch {
+		case strings.HasPrefix(name, "max-qps="):
+			value = strings.TrimPrefix(name, "max-qps=")
+		case name == "max-qps" && i+1 < len(args):
+			i++
+			value = args[i]
+		default:
+			limited = append(limited, args[i])
+			continue
+		}
+		if own, err := strconv.Atoi(value); err == nil && own > 0 && own < qps {
+			qps = own
+		}
+	}
+	return append(limited, "-max-qps", strconv.Itoa(qps))
+}
+
+// pauseCheckInterval is the interval between the checks of the jobs
+// file for a running job being paused or resumed
+const pauseCheckInterval = 5 * time.Second
+
+// runJob runs a job in a directory until it exits, writing its output
+// to stdout. The job and its massdns process are suspended while the
+// job is paused in the jobs file and resumed afterwards, keeping the
+// progress of the run in memory. A paused job gives its slot back to
+// the other jobs and takes one again before being resumed. It returns
+// whether the job still holds a slot.
+func runJob(executable, jobsFile, name, dir string, args []string, stdout io.Writer, slots chan struct{}) (bool, error) {
+	cmd := exec.Command(executable, args...)
+	cmd.Dir = dir
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return true, err
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	ticker := time.NewTicker(pauseCheckInterval)
+	defer ticker.Stop()
+
+	var paused bool
+	for {
+		select {
+		case err := <-exited:
+			return !paused, err
+		case <-ticker.C:
+		}
+
+		jobs, err := scheduler.LoadJobs(jobsFile)
+		if err != nil {
+			gologger.Error().Msgf("Could not load jobs: %s\n", err)
+			continue
+		}
+		// Removed jobs are resumed to let them finish
+		current := jobs.Get(name)
+		pause := current != nil && current.Paused
+		if pause == paused {
+			continue
+		}
+		if pause {
+			err = suspendProcessGroup(cmd)
+		} else {
+			// Wait for a slot, unless the job was killed meanwhile
+			select {
+			case slots <- struct{}{}:
+			case err := <-exited:
+				return false, err
+			}
+			err = resumeProcessGroup(cmd)
+		}
+		if err != nil {
+			if !pause {
+				<-slots
+			}
+			gologger.Error().Msgf("Could not pause or resume job %s: %s\n", name, err)
+			continue
+		}
+		if pause {
+			<-slots
+		}
+		paused = pause
+		if paused {
+			gologger.Info().Msgf("Suspended job %s\n", name)
+		} else {
+			gologger.Info().Msgf("Resumed job %s\n", name)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package runner
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// errSuspendUnsupported is returned when processes can't be suspended
+var errSuspendUnsupported = errors.New("suspending jobs is not supported on this platform")
+
+// setProcessGroup does nothing as process groups are not available
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// suspendProcessGroup returns an error as processes can't be suspended
+func suspendProcessGroup(cmd *exec.Cmd) error {
+	return errSuspendUnsupported
+}
+
+// resumeProcessGroup returns an error as processes can't be suspended
+func resumeProcessGroup(cmd *exec.Cmd) error {
+	return errSuspendUnsupported
+}
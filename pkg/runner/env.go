@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix is the prefix of the environment variables for options
+const envPrefix = "SHUFFLEDNS_"
+
+// envNames contains descriptive environment variable names for
+// the flags whose name is too short to be meaningful on its own.
+var envNames = map[string]string{
+	"d":  "DOMAIN",
+	"r":  "RESOLVERS",
+	"w":  "WORDLIST",
+	"o":  "OUTPUT",
+	"v":  "VERBOSE",
+	"nC": "NO_COLOR",
+	"t":  "THREADS",
+	"wt": "WILDCARD_THREADS",
+	"wr": "WILDCARD_RESOLVERS",
+}
+
+// envName returns the environment variable name for a flag
+func envName(flagName string) string {
+	if name, ok := envNames[flagName]; ok {
+		return envPrefix + name
+	}
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnvironment sets the flags from their environment variables.
+// It must be called before parsing the command line so that flags
+// given on the command line take precedence over the environment.
+func applyEnvironment(flagSet *flag.FlagSet) error {
+	var err error
+	flagSet.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || err != nil {
+			return
+		}
+		if setErr := f.Value.Set(value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for %s: %w", value, envName(f.Name), setErr)
+		}
+	})
+	return err
+}
+
+// isExplicit returns true if a flag was set on the command line
+// or through its environment variable.
+func isExplicit(flagSet *flag.FlagSet, flagName string) bool {
+	if _, ok := os.LookupEnv(envName(flagName)); ok {
+		return true
+	}
+	var set bool
+	flagSet.Visit(func(f *flag.Flag) {
+		if f.Name == flagName {
+			set = true
+		}
+	})
+	return set
+}
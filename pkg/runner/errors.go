@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+// The errors returned by the option parsing, the validation and the
+// runner, allowing library users to handle failures with errors.Is.
+var (
+	// ErrMissingResolvers is returned when no resolver list is provided
+	ErrMissingResolvers = errors.New("no resolver list provided")
+	// ErrResolversNotFound is returned when the resolver list doesn't exist
+	ErrResolversNotFound = errors.New("resolver file doesn't exists")
+	// ErrBlankResolvers is returned when the resolver list is blank
+	ErrBlankResolvers = errors.New("blank resolver list specified")
+	// ErrMissingDomain is returned when a domain is required but not provided
+	ErrMissingDomain = errors.New("no domain provided")
+	// ErrMissingInput is returned when there is nothing to enumerate
+	ErrMissingInput = errors.New("no wordlist or subdomains given as input")
+	// ErrConflictingInput is returned when both bruteforce and resolving are requested
+	ErrConflictingInput = errors.New("both bruteforce and resolving options specified")
+	// ErrMassdnsNotFound is returned when the massdns binary can't be found
+	ErrMassdnsNotFound = errors.New("could not find massdns binary")
+	// ErrInvalidOption is returned when an option has an invalid value
+	// or can't be used along with the other options
+	ErrInvalidOption = errors.New("invalid option")
+	// ErrUsage is returned when a subcommand is invoked incorrectly
+	ErrUsage = errors.New("invalid usage")
+)
+
+// parseError returns the error of the parsing of the flags, the flag
+// package having already shown it along with the usage. The help
+// requested with -h is returned as flag.ErrHelp.
+func parseError(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return flag.ErrHelp
+	}
+	return fmt.Errorf("%w: %s", ErrUsage, err)
+}
+
+// OptionError is the error returned for an option with an invalid
+// value, matching ErrInvalidOption with errors.Is.
+type OptionError struct {
+	err error
+}
+
+// invalidOption returns an OptionError formatted with fmt.Errorf
+func invalidOption(format string, args ...interface{}) error {
+	return &OptionError{err: fmt.Errorf(format, args...)}
+}
+
+// Error returns the description of the invalid option
+func (e *OptionError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error wrapped by the description, if any
+func (e *OptionError) Unwrap() error {
+	return errors.Unwrap(e.err)
+}
+
+// Is reports whether the target is ErrInvalidOption
+func (e *OptionError) Is(target error) bool {
+	return target == ErrInvalidOption
+}
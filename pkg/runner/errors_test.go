@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubcommandParseErrors(t *testing.T) {
+	parsers := map[string]func(args []string) error{
+		"merge":       func(args []string) error { _, err := ParseMergeOptions(args); return err },
+		"daemon":      func(args []string) error { _, err := ParseDaemonOptions(args); return err },
+		"split":       func(args []string) error { _, err := ParseSplitOptions(args); return err },
+		"wordlist":    func(args []string) error { _, err := ParseWordlistOptions(args); return err },
+		"healthcheck": func(args []string) error { _, err := ParseHealthcheckOptions(args); return err },
+		"verify":      func(args []string) error { _, err := ParseVerifyOptions(args); return err },
+		"store":       func(args []string) error { _, err := ParseStoreOptions(args); return err },
+	}
+	for name, parse := range parsers {
+		err := parse([]string{"-not-a-flag"})
+		require.True(t, errors.Is(err, ErrUsage), "Could not return usage error for invalid flag of %s: %v", name, err)
+
+		err = parse([]string{"-h"})
+		require.True(t, errors.Is(err, flag.ErrHelp), "Could not return help error of %s: %v", name, err)
+	}
+
+	_, err := ParseDaemonOptions([]string{"-jobs", "jobs.json", "-parallel", "0"})
+	require.True(t, errors.Is(err, ErrInvalidOption), "Could not return invalid option error: %v", err)
+	_, err = ParseDaemonOptions([]string{"-parallel", "2"})
+	require.True(t, errors.Is(err, ErrUsage), "Could not return usage error for missing jobs file: %v", err)
+}
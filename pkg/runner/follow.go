@@ -0,0 +1,75 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// followPollInterval is the interval between the checks for new lines
+// appended to a followed list
+const followPollInterval = 200 * time.Millisecond
+
+// followLines returns the source of the lines of a file another process
+// is still appending to. The lines are read as they are appended until
+// the end marker line, if any, or until nothing was appended for the
+// timeout.
+func followLines(file, marker string, timeout time.Duration) lineSource {
+	return func(lines chan<- string) error {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		reader := bufio.NewReader(f)
+		var partial strings.Builder
+		lastData := time.Now()
+		for {
+			data, err := reader.ReadString('\n')
+			if data != "" {
+				lastData = time.Now()
+			}
+			if err == io.EOF {
+				// The writer may not have completed the last line yet
+				partial.WriteString(data)
+				if timeout > 0 && time.Since(lastData) >= timeout {
+					if partial.Len() > 0 {
+						lines <- strings.TrimRight(partial.String(), "\r")
+					}
+					return nil
+				}
+				time.Sleep(followPollInterval)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			partial.WriteString(data)
+			line := strings.TrimRight(partial.String(), "\r\n")
+			partial.Reset()
+			if marker != "" && line == marker {
+				return nil
+			}
+			lines <- line
+		}
+	}
+}
+
+// followList resolves the names of the list while another process is
+// still appending to it.
+func (r *Runner) followList() error {
+	if r.options.FollowMarker != "" {
+		r.log().Info().Msgf("Following %s until the %q line\n", r.options.SubdomainsList, r.options.FollowMarker)
+	} else {
+		r.log().Info().Msgf("Following %s until nothing is appended for %s\n", r.options.SubdomainsList, r.options.FollowTimeout)
+	}
+	source := followLines(r.options.SubdomainsList, r.options.FollowMarker, r.options.FollowTimeout)
+	if err := r.streamNames(source); err != nil {
+		return fmt.Errorf("could not follow %s: %w", r.options.SubdomainsList, err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowListChunks(t *testing.T) {
+	options, dir := mockOptions(t, `www.example.com A 192.0.2.1
+api.example.com A 192.0.2.2
+`)
+	options.SubdomainsList = filepath.Join(dir, "list.txt")
+	options.Follow = true
+	options.FollowMarker = "DONE"
+	options.StdinChunk = 1
+	options.OutputHosts = filepath.Join(dir, "hosts.txt")
+	require.Nil(t, os.WriteFile(options.SubdomainsList, []byte("www.example.com\napi.example.com\nDONE\n"), 0644), "Could not write list")
+
+	runner, err := New(options)
+	require.Nil(t, err, "Could not create runner")
+	defer runner.Close()
+	require.Nil(t, runner.followList(), "Could not resolve followed list")
+
+	hosts, err := os.ReadFile(options.OutputHosts)
+	require.Nil(t, err, "Could not read hosts export")
+	require.Contains(t, string(hosts), "192.0.2.1 www.example.com", "Could not export the first chunk")
+	require.Contains(t, string(hosts), "192.0.2.2 api.example.com", "Could not export the second chunk")
+}
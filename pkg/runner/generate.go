@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"bufio"
+	"io"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsgen"
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/mohammadanaraki/shuffledns/pkg/history"
+	"github.com/mohammadanaraki/shuffledns/pkg/markov"
+	"github.com/mohammadanaraki/shuffledns/pkg/tmpcrypt"
+	"github.com/rs/xid"
+)
+
+// markovOrder is the number of previous characters the generated
+// characters depend on.
+const markovOrder = 3
+
+// knownSubdomains returns the subdomain parts of the known hostnames
+// of the domain, read from the input list if asked and from the
+// history datastore if one was given.
+func (r *Runner) knownSubdomains(inputFile string) ([]string, error) {
+	var hostnames []string
+	if inputFile != "" {
+		lines, err := readLines(r.tempKey, inputFile)
+		if err != nil {
+			return nil, err
+		}
+		hostnames = append(hostnames, lines...)
+	}
+	if r.options.StoreFile != "" {
+		db, err := history.Open(r.options.StoreFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, asset := range db.Assets() {
+			hostnames = append(hostnames, asset.Hostname)
+		}
+	}
+
+	suffix := "." + r.options.Domain
+	var subdomains []string
+	for _, hostname := range hostnames {
+		hostname = dnsname.Normalize(hostname)
+		if strings.HasSuffix(hostname, suffix) {
+			subdomains = append(subdomains, strings.TrimSuffix(hostname, suffix))
+		}
+	}
+	return subdomains, nil
+}
+
+// addGeneratedCandidates returns a copy of the resolution list with
+// the candidates generated from the known subdomains appended to it,
+// or prepended if they are resolved first. The input list is used as
+// known subdomains only if trainOnInput.
+func (r *Runner) addGeneratedCandidates(resolveFile string, trainOnInput bool) (string, error) {
+	trainingFile := ""
+	if trainOnInput {
+		trainingFile = resolveFile
+	}
+	subdomains, err := r.knownSubdomains(trainingFile)
+	if err != nil {
+		return "", err
+	}
+
+	var generated []string
+	if r.options.GenerateMarkov > 0 {
+		chain := markov.New(markovOrder)
+		for _, subdomain := range subdomains {
+			chain.Train(subdomain)
+		}
+		candidates := chain.Generate(r.options.GenerateMarkov, rand.New(rand.NewSource(time.Now().UnixNano())))
+		r.log().Info().Msgf("Generated %d markov candidates from %d known subdomains\n", len(candidates), len(subdomains))
+		generated = append(generated, candidates...)
+	}
+	if r.options.Dnsgen != "" {
+		words, err := readLines(nil, r.options.Dnsgen)
+		if err != nil {
+			return "", err
+		}
+		candidates := dnsgen.Generate(subdomains, words)
+		r.log().Info().Msgf("Generated %d dnsgen candidates from %d known subdomains\n", len(candidates), len(subdomains))
+		generated = append(generated, candidates...)
+	}
+	return r.appendCandidates(resolveFile, generated, r.options.GeneratedFirst)
+}
+
+// readLines returns the non blank lines of a file
+func readLines(key *tmpcrypt.Key, file string) ([]string, error) {
+	f, err := key.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// appendCandidates writes a copy of the resolution list with the
+// subdomains of the domain appended to it, or prepended if first,
+// returning its path.
+func (r *Runner) appendCandidates(resolveFile string, subdomains []string, first bool) (string, error) {
+	outputFile := filepath.Join(r.tempDir, xid.New().String())
+	output, err := r.tempKey.Create(outputFile)
+	if err != nil {
+		return "", err
+	}
+	defer output.Close()
+
+	input, err := r.tempKey.Open(resolveFile)
+	if err != nil {
+		return "", err
+	}
+	defer input.Close()
+
+	writer := bufio.NewWriter(output)
+	if !first {
+		if _, err := io.Copy(writer, input); err != nil {
+			return "", err
+		}
+		// Make sure the candidates start on their own line
+		_, _ = writer.WriteString("\n")
+	}
+	for _, subdomain := range subdomains {
+		_, _ = writer.WriteString(subdomain + "." + r.options.Domain + "\n")
+	}
+	if first {
+		if _, err := io.Copy(writer, input); err != nil {
+			return "", err
+		}
+	}
+	return outputFile, writer.Flush()
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/healthcheck"
+	"github.com/projectdiscovery/gologger"
+)
+
+const (
+	// healthcheckServer is the dns server queried to check outbound connectivity
+	healthcheckServer = "1.1.1.1"
+	// healthcheckMinFree is the free space recommended in the temporary directory
+	healthcheckMinFree = 1 << 30
+	// healthcheckMinFiles is the open files limit recommended
+	healthcheckMinFiles = 1024
+)
+
+// HealthcheckOptions contains the configuration options for the healthcheck subcommand
+type HealthcheckOptions struct {
+	MassdnsPath   string        // MassdnsPath contains the path to massdns binary
+	ResolversFile string        // ResolversFile is the file containing resolvers to check
+	Directory     string        // Directory is the directory for temporary data
+	Timeout       time.Duration // Timeout is the timeout of the dns queries
+	Threads       int           // Threads is the number of resolvers checked in parallel
+	NoColor       bool          // NoColor disables the colored output
+}
+
+// ParseHealthcheckOptions parses the command line flags for the healthcheck subcommand
+func ParseHealthcheckOptions(args []string) (*HealthcheckOptions, error) {
+	options := &HealthcheckOptions{}
+
+	flagSet := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		gologger.Print().Msgf("Usage: shuffledns healthcheck -r resolvers.txt [flags]\n")
+		flagSet.PrintDefaults()
+	}
+	flagSet.StringVar(&options.MassdnsPath, "massdns", "", "Path to the massdns binary")
+	flagSet.StringVar(&options.ResolversFile, "r", "", "File or comma separated list of resolvers to check")
+	flagSet.StringVar(&options.Directory, "directory", "", "Temporary directory for enumeration")
+	flagSet.DurationVar(&options.Timeout, "timeout", 2*time.Second, "Timeout of the dns queries")
+	flagSet.IntVar(&options.Threads, "t", 50, "Number of resolvers checked in parallel")
+	flagSet.BoolVar(&options.NoColor, "nC", false, "Don't Use colors in output")
+
+	if _, err := parseInterspersed(flagSet, args); err != nil {
+		return nil, err
+	}
+
+	(&Options{NoColor: options.NoColor}).configureOutput()
+
+	if options.ResolversFile == "" {
+		flagSet.Usage()
+		return nil, fmt.Errorf("%w: %s", ErrUsage, ErrMissingResolvers)
+	}
+	if options.Threads < 1 {
+		return nil, invalidOption("invalid number of threads")
+	}
+	return options, nil
+}
+
+// RunHealthcheck checks the environment shuffledns runs in and prints
+// a pass/fail report, returning an error if any check failed.
+func RunHealthcheck(options *HealthcheckOptions) error {
+	massdnsPath := options.MassdnsPath
+	if massdnsPath == "" {
+		massdnsPath = (&Runner{}).findBinary()
+	}
+
+	results := []healthcheck.Result{
+		healthcheck.MassdnsBinary(massdnsPath),
+		healthcheck.Resolvers(options.ResolversFile, options.Timeout, options.Threads),
+		healthcheck.Outbound("udp", healthcheckServer, options.Timeout),
+		healthcheck.Outbound("tcp", healthcheckServer, options.Timeout),
+		healthcheck.TempDir(options.Directory, healthcheckMinFree),
+		healthcheck.FileLimit(healthcheckMinFiles),
+	}
+
+	var failed int
+	for _, result := range results {
+		status := "PASS"
+		if !result.OK {
+			status = "FAIL"
+			failed++
+		}
+		gologger.Print().Msgf("[%s] %s: %s\n", status, result.Name, result.Detail)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d checks failed", failed, len(results))
+	}
+	return nil
+}
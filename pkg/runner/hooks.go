@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/massdns"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+const (
+	// hookConcurrency is the maximum number of result hooks running at once
+	hookConcurrency = 10
+	// hookTimeout is the time after which a hook command is killed
+	hookTimeout = time.Minute
+)
+
+// completion is the summary of a run passed to the completion hook
+type completion struct {
+	RunID   string `json:"run_id"`
+	Domain  string `json:"domain"`
+	Results int    `json:"results"`
+	Output  string `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// resultHook returns the callback running the result hook of the user
+// for each validated result, along with a function waiting for the
+// commands started. The callback is nil if no result hook is set.
+func (r *Runner) resultHook() (func(result *massdns.Result), func()) {
+	if r.options.OnResult == "" {
+		return nil, func() {}
+	}
+	wg := sizedwaitgroup.New(hookConcurrency)
+
+	onResult := func(result *massdns.Result) {
+		data, err := json.Marshal(result)
+		if err != nil {
+			r.log().Error().Msgf("Could not marshal result for hook: %s\n", err)
+			return
+		}
+		env := []string{
+			"SHUFFLEDNS_HOST=" + result.Host,
+			"SHUFFLEDNS_IP=" + strings.Join(result.IP, ","),
+			"SHUFFLEDNS_CNAME=" + strings.Join(result.CNAME, ","),
+			"SHUFFLEDNS_RUN_ID=" + r.runID,
+		}
+		wg.Add()
+		go func() {
+			defer wg.Done()
+			if err := runHook(r.options.OnResult, env, data); err != nil {
+				r.log().Error().Msgf("Could not run result hook for %s: %s\n", result.Host, err)
+			}
+		}()
+	}
+	return onResult, wg.Wait
+}
+
+// completeHook runs the completion hook of the user once the run is done
+func (r *Runner) completeHook(results int, processErr error) {
+	if r.options.OnComplete == "" {
+		return
+	}
+	summary := completion{
+		RunID:   r.runID,
+		Domain:  r.options.Domain,
+		Results: results,
+		Output:  r.options.Output,
+	}
+	if processErr != nil {
+		summary.Error = processErr.Error()
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		r.log().Error().Msgf("Could not marshal summary for hook: %s\n", err)
+		return
+	}
+	env := []string{
+		"SHUFFLEDNS_RUN_ID=" + summary.RunID,
+		"SHUFFLEDNS_DOMAIN=" + summary.Domain,
+		"SHUFFLEDNS_RESULTS=" + strconv.Itoa(summary.Results),
+		"SHUFFLEDNS_OUTPUT=" + summary.Output,
+		"SHUFFLEDNS_ERROR=" + summary.Error,
+	}
+	if err := runHook(r.options.OnComplete, env, data); err != nil {
+		r.log().Error().Msgf("Could not run completion hook: %s\n", err)
+	}
+}
+
+// runHook runs a hook command through the shell with the given extra
+// environment and json data on its standard input. The output of the
+// command goes to stderr to keep stdout for the results.
+func runHook(command string, env []string, data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,32 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package runner
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompleteHookEarlyFailure(t *testing.T) {
+	options, dir := mockOptions(t, "www.example.com A 192.0.2.1\n")
+	summary := filepath.Join(dir, "summary.json")
+	options.Wordlist = filepath.Join(dir, "missing.txt")
+	options.OnComplete = "cat > " + summary
+
+	runner, err := New(options)
+	require.Nil(t, err, "Could not create runner")
+	defer runner.Close()
+	require.NotNil(t, runner.RunEnumeration(), "Could not fail on missing wordlist")
+
+	data, err := os.ReadFile(summary)
+	require.Nil(t, err, "Could not run completion hook")
+	var got completion
+	require.Nil(t, json.Unmarshal(data, &got), "Could not decode completion summary")
+	require.Equal(t, 0, got.Results, "Could not get results of failed run")
+	require.Contains(t, got.Error, "could not read bruteforce wordlist", "Could not get error of failed run")
+}
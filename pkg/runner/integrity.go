@@ -0,0 +1,196 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/integrity"
+	"github.com/projectdiscovery/gologger"
+)
+
+// signTools are the tools the manifest can be signed with
+var signTools = map[string]struct{}{
+	"minisign": {},
+	"cosign":   {},
+}
+
+// writeManifest writes the manifest of the output files of the run,
+// signed if asked.
+func (r *Runner) writeManifest() error {
+	manifest := &integrity.Manifest{
+		RunID:      r.runID,
+		ConfigHash: r.configHash,
+		Domain:     r.options.Domain,
+		CreatedAt:  time.Now().UTC(),
+	}
+	seen := make(map[string]struct{})
+	for _, file := range r.options.outputFiles() {
+		if *file == "" || *file == r.options.Manifest {
+			continue
+		}
+		if _, ok := seen[*file]; ok {
+			continue
+		}
+		seen[*file] = struct{}{}
+		// The files of the features which found nothing aren't written
+		if info, err := os.Stat(*file); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if err := manifest.Add(r.options.Manifest, *file); err != nil {
+			return err
+		}
+	}
+	if err := manifest.Write(r.options.Manifest); err != nil {
+		return err
+	}
+	r.log().Info().Msgf("Wrote manifest of %d output files to %s\n", len(manifest.Files), r.options.Manifest)
+
+	if r.options.Sign == "" {
+		return nil
+	}
+	signature, err := r.signManifest()
+	if err != nil {
+		return fmt.Errorf("could not sign manifest: %w", err)
+	}
+	r.log().Info().Msgf("Signed manifest with %s to %s\n", r.options.Sign, signature)
+	return nil
+}
+
+// signPasswordEnv is the environment variable containing the password of
+// the signing key, kept out of the flags to not show it in the process
+// list
+const signPasswordEnv = envPrefix + "SIGN_PASSWORD"
+
+// signManifest signs the manifest with the tool of the user, returning
+// the signature file. The password of the key is given to the tool if
+// set, and prompted on the terminal otherwise, as stdin may be the
+// input of the run.
+func (r *Runner) signManifest() (string, error) {
+	password, hasPassword, err := r.signPassword()
+	if err != nil {
+		return "", err
+	}
+
+	var cmd *exec.Cmd
+	var signature string
+	switch r.options.Sign {
+	case "minisign":
+		signature = r.options.Manifest + ".minisig"
+		cmd = exec.Command("minisign", "-S", "-s", r.options.SignKey, "-m", r.options.Manifest, "-x", signature)
+	case "cosign":
+		// The manifest names the target and its files, so it's not
+		// uploaded to the public transparency log
+		signature = r.options.Manifest + ".sig"
+		cmd = exec.Command("cosign", "sign-blob", "--yes", "--tlog-upload=false", "--key", r.options.SignKey, "--output-signature", signature, r.options.Manifest)
+	default:
+		return "", fmt.Errorf("unknown signing tool %s", r.options.Sign)
+	}
+	switch {
+	case hasPassword && r.options.Sign == "cosign":
+		cmd.Env = append(os.Environ(), "COSIGN_PASSWORD="+password)
+	case hasPassword:
+		// minisign reads the password from stdin when it's not a terminal
+		cmd.Stdin = strings.NewReader(password + "\n")
+	default:
+		// Without terminal the tool fails on keys with a password
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			defer tty.Close()
+			cmd.Stdin = tty
+		}
+	}
+	// The output of the tool goes to stderr to keep stdout for the results
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return signature, nil
+}
+
+// signPassword returns the password of the signing key from the password
+// file or the environment, and whether one is set
+func (r *Runner) signPassword() (string, bool, error) {
+	if r.options.SignPasswordFile != "" {
+		data, err := os.ReadFile(r.options.SignPasswordFile)
+		if err != nil {
+			return "", false, fmt.Errorf("could not read signing password: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+	password, ok := os.LookupEnv(signPasswordEnv)
+	return password, ok, nil
+}
+
+// VerifyOptions contains the configuration options for the verify subcommand
+type VerifyOptions struct {
+	Manifest        string // Manifest is the manifest of the output files to verify
+	Input           string // Input is the output of a previous run to revalidate
+	TrustedResolver string // TrustedResolver is the file or list of resolvers revalidating the input
+	Output          string // Output is the file to write the hosts still alive to
+	Threads         int    // Threads is the number of hosts revalidated concurrently
+	Retries         int    // Retries is the number of retries of the dns queries
+	NoColor         bool   // NoColor disables the colored output
+}
+
+// ParseVerifyOptions parses the command line flags for the verify subcommand
+func ParseVerifyOptions(args []string) (*VerifyOptions, error) {
+	options := &VerifyOptions{}
+
+	flagSet := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		gologger.Print().Msgf("Usage: shuffledns verify -manifest manifest.json [flags]\n")
+		gologger.Print().Msgf("       shuffledns verify -i previous.ndjson -tr trusted.txt [flags]\n")
+		flagSet.PrintDefaults()
+	}
+	flagSet.StringVar(&options.Manifest, "manifest", "", "Manifest of the output files to verify")
+	flagSet.StringVar(&options.Input, "i", "", "Output of a previous run to revalidate")
+	flagSet.StringVar(&options.TrustedResolver, "tr", "", "File or comma separated list of trusted resolvers revalidating the input")
+	flagSet.StringVar(&options.Output, "o", "", "File to write the hosts still alive to (optional)")
+	flagSet.IntVar(&options.Threads, "t", 100, "Number of hosts revalidated concurrently")
+	flagSet.IntVar(&options.Retries, "retries", 5, "Number of retries of the dns queries")
+	flagSet.BoolVar(&options.NoColor, "nC", false, "Don't Use colors in output")
+
+	if _, err := parseInterspersed(flagSet, args); err != nil {
+		return nil, err
+	}
+
+	(&Options{NoColor: options.NoColor}).configureOutput()
+
+	switch {
+	case options.Manifest == "" && options.Input == "":
+		flagSet.Usage()
+		return nil, fmt.Errorf("%w: no manifest or input provided", ErrUsage)
+	case options.Manifest != "" && options.Input != "":
+		return nil, fmt.Errorf("%w: a manifest and an input can't be verified together", ErrUsage)
+	case options.Input != "" && options.TrustedResolver == "":
+		flagSet.Usage()
+		return nil, fmt.Errorf("%w: no trusted resolvers provided to revalidate the input", ErrUsage)
+	}
+	return options, nil
+}
+
+// RunVerify checks the output files listed in a manifest, returning an
+// error if any is missing or changed. The signature of the manifest
+// isn't checked, which is left to the tool which signed it. With an
+// input, it revalidates the results of a previous run instead.
+func RunVerify(options *VerifyOptions) error {
+	if options.Input != "" {
+		return runRevalidate(options)
+	}
+	manifest, mismatches, err := integrity.Verify(options.Manifest)
+	if err != nil {
+		return err
+	}
+	for _, mismatch := range mismatches {
+		gologger.Print().Msgf("[FAIL] %s: %s\n", mismatch.Path, mismatch.Reason)
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d/%d files don't match the manifest", len(mismatches), len(manifest.Files))
+	}
+	gologger.Print().Msgf("[PASS] %d files of run %s match the manifest\n", len(manifest.Files), manifest.RunID)
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignManifestPassword(t *testing.T) {
+	dir := t.TempDir()
+	// The fake minisign writes the password read on stdin as signature
+	script := "#!/bin/sh\nread password\nfor last; do :; done\necho \"$password\" > \"$last\"\n"
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "minisign"), []byte(script), 0755), "Could not write fake minisign")
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	passwordFile := filepath.Join(dir, "key.pass")
+	require.Nil(t, os.WriteFile(passwordFile, []byte("from-file\n"), 0600), "Could not write password file")
+	r := &Runner{options: &Options{Sign: "minisign", SignKey: "key", Manifest: filepath.Join(dir, "manifest.json"), SignPasswordFile: passwordFile}}
+
+	signature, err := r.signManifest()
+	require.Nil(t, err, "Could not sign manifest")
+	data, err := os.ReadFile(signature)
+	require.Nil(t, err, "Could not read signature")
+	require.Equal(t, "from-file\n", string(data), "Could not pass password from file")
+
+	r.options.SignPasswordFile = ""
+	t.Setenv(signPasswordEnv, "from-env")
+	_, err = r.signManifest()
+	require.Nil(t, err, "Could not sign manifest")
+	data, err = os.ReadFile(signature)
+	require.Nil(t, err, "Could not read signature")
+	require.Equal(t, "from-env\n", string(data), "Could not pass password from environment")
+}
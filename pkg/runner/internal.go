@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"bufio"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/xid"
+)
+
+// resolvConf is the system resolver configuration with the search domains
+const resolvConf = "/etc/resolv.conf"
+
+// readSearchDomains returns the search domains of a resolv.conf file,
+// from its last search or domain line like the system resolver.
+func readSearchDomains(file string) ([]string, error) {
+	lines, err := readLines(nil, file)
+	if err != nil {
+		return nil, err
+	}
+
+	var domains []string
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || (fields[0] != "search" && fields[0] != "domain") {
+			continue
+		}
+		domains = nil
+		for _, domain := range fields[1:] {
+			if domain = strings.Trim(strings.ToLower(domain), "."); domain != "" {
+				domains = append(domains, domain)
+			}
+		}
+	}
+	return domains, nil
+}
+
+// searchDomains returns the domains qualifying the single label names
+// in internal mode: the ones provided by the user, the enumerated
+// domain or the system search domains.
+func (r *Runner) searchDomains() []string {
+	if r.options.SearchDomains != "" {
+		var domains []string
+		for _, domain := range strings.Split(r.options.SearchDomains, ",") {
+			if domain = strings.Trim(strings.TrimSpace(strings.ToLower(domain)), "."); domain != "" {
+				domains = append(domains, domain)
+			}
+		}
+		return domains
+	}
+	if r.options.Domain != "" {
+		return []string{r.options.Domain}
+	}
+	domains, err := readSearchDomains(resolvConf)
+	if err != nil {
+		r.log().Debug().Msgf("Could not read search domains: %s\n", err)
+	}
+	return domains
+}
+
+// qualifyNames writes a copy of the resolution list where the single
+// label names are qualified with every search domain, returning its
+// path. Names already containing a dot are written unchanged.
+func (r *Runner) qualifyNames(resolveFile string, domains []string) (string, error) {
+	input, err := r.tempKey.Open(resolveFile)
+	if err != nil {
+		return "", err
+	}
+	defer input.Close()
+
+	outputFile := filepath.Join(r.tempDir, xid.New().String())
+	output, err := r.tempKey.Create(outputFile)
+	if err != nil {
+		return "", err
+	}
+	defer output.Close()
+
+	var qualified int
+	w := bufio.NewWriter(output)
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		name := strings.Trim(strings.TrimSpace(scanner.Text()), ".")
+		if name == "" {
+			continue
+		}
+		if strings.Contains(name, ".") {
+			_, _ = w.WriteString(name + "\n")
+			continue
+		}
+		for _, domain := range domains {
+			_, _ = w.WriteString(name + "." + domain + "\n")
+		}
+		qualified++
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if qualified > 0 {
+		r.log().Info().Msgf("Qualified %d single label names with the search domains %s\n", qualified, strings.Join(domains, ", "))
+	}
+	return outputFile, w.Flush()
+}
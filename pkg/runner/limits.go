@@ -0,0 +1,42 @@
+package runner
+
+import (
+	"github.com/mohammadanaraki/shuffledns/pkg/rlimit"
+)
+
+// reservedDescriptors is the number of file descriptors kept for
+// massdns, its pipes and sockets, and the input and output files.
+const reservedDescriptors = 64
+
+// fileLimitNeeded returns the open files limit needed for the
+// concurrent massdns resolves, which inherit the limit, and the
+// concurrent wildcard and verification queries, each holding a socket.
+func fileLimitNeeded(options *Options) uint64 {
+	return uint64(options.Threads + options.WildcardThreads + reservedDescriptors)
+}
+
+// checkFileLimit warns when the open files limit can't sustain the
+// concurrent resolves, raising the soft limit first if asked by the user.
+func (r *Runner) checkFileLimit() {
+	needed := fileLimitNeeded(r.options)
+
+	soft, hard, err := rlimit.Files()
+	if err != nil {
+		r.log().Debug().Msgf("Could not get open files limit: %s\n", err)
+		return
+	}
+	if r.options.RaiseFDLimit && soft < hard {
+		raised, err := rlimit.Raise(hard)
+		if err != nil {
+			r.log().Error().Msgf("Could not raise open files limit: %s\n", err)
+		} else {
+			r.log().Debug().Msgf("Raised open files limit from %d to %d\n", soft, raised)
+			soft = raised
+		}
+	}
+	r.log().Debug().Msgf("Open files limit is %d (hard limit %d)\n", soft, hard)
+
+	if soft < needed {
+		r.log().Info().Msgf("Open files limit %d is lower than the %d needed with -t %d and -wt %d, lower them or raise it (ulimit -n %d or -raise-fd-limit)\n", soft, needed, r.options.Threads, r.options.WildcardThreads, needed)
+	}
+}
@@ -0,0 +1,33 @@
+package runner
+
+import "runtime/debug"
+
+const (
+	// lowResourceSortChunkSize is the size in bytes of the lines sorted
+	// in memory by the external sorts in low resource mode
+	lowResourceSortChunkSize = 8 * 1024 * 1024
+	// lowResourceGCPercent is the heap growth triggering a garbage
+	// collection in low resource mode, half of the default one
+	lowResourceGCPercent = 50
+)
+
+// applyLowResource lowers the memory used by the run on devices with
+// little memory, where the default buffers and heap growth get the
+// process killed. The concurrency is lowered by the low-resource
+// profile, and the duplicate names are skipped on disk.
+func (r *Runner) applyLowResource() {
+	if !r.options.LowResource {
+		return
+	}
+	debug.SetGCPercent(lowResourceGCPercent)
+	r.log().Info().Msgf("Low resource mode: %d concurrent resolves, %d wildcard checks, duplicates skipped on disk\n", r.options.Threads, r.options.WildcardThreads)
+}
+
+// sortChunkSize returns the size in bytes of the lines sorted in memory
+// by the external sorts, 0 for the default one
+func (r *Runner) sortChunkSize() int {
+	if r.options.LowResource {
+		return lowResourceSortChunkSize
+	}
+	return 0
+}
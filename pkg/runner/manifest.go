@@ -0,0 +1,134 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/resolvers"
+)
+
+// configHash returns a short hash of the options that affect the
+// results of an enumeration. Runs with the same hash were executed
+// with an equivalent configuration and their results can be mixed.
+// The options only changing the format of the output, like the fields
+// or the order of the results, are left out. The input files are
+// identified by their path, size and modification time rather than
+// read, so that computing the hash doesn't consume a pipe given as
+// input nor read large files twice.
+func (options *Options) configHash() string {
+	values := []string{
+		// Names resolved
+		"domain=" + options.Domain,
+		"list=" + fileStamp(options.SubdomainsList),
+		"wordlist=" + fileStamp(options.Wordlist),
+		"raw-input=" + fileStamp(options.MassdnsRaw),
+		"prefixes=" + options.Prefixes,
+		"suffixes=" + options.Suffixes,
+		"separators=" + options.Separators,
+		fmt.Sprintf("markov=%d", options.GenerateMarkov),
+		"dnsgen=" + fileStamp(options.Dnsgen),
+		fmt.Sprintf("cname-depth=%d", options.CNAMEDepth),
+		fmt.Sprintf("tls-sans=%d", options.TLSSans),
+		fmt.Sprintf("internal=%t", options.Internal),
+		"search-domains=" + options.SearchDomains,
+		"record-types=" + options.RecordTypes,
+		"min-hit-rate=" + options.MinHitRate,
+		fmt.Sprintf("hit-rate-window=%d", options.HitRateWindow),
+		"plugins=" + fileStamps(options.Plugins),
+
+		// Resolution
+		"resolvers=" + resolversHash(options.ResolversFile),
+		"wildcard-resolvers=" + resolversHash(options.WildcardResolvers),
+		"resolver-split=" + options.ResolverSplit,
+		fmt.Sprintf("ipv4=%t", options.IPv4),
+		fmt.Sprintf("ipv6=%t", options.IPv6),
+		fmt.Sprintf("retries=%d", options.Retries),
+		fmt.Sprintf("resolver-agreement=%d", options.ResolverAgreement),
+		"verify-sample=" + options.VerifySample,
+		"suspicious-ips=" + fileStamp(options.SuspiciousIPs),
+
+		// Wildcard filtering
+		fmt.Sprintf("strict-wildcard=%t", options.StrictWildcard),
+		"wildcard-mode=" + options.WildcardMode,
+		fmt.Sprintf("prune-wildcards=%t", options.PruneWildcards),
+		fmt.Sprintf("no-wildcard-precheck=%t", options.NoWildcardPrecheck),
+		fmt.Sprintf("precheck-parents=%t", options.PrecheckParents),
+
+		// Result filters
+		"scope=" + fileStamp(options.ScopeFile),
+		"match-regex=" + options.MatchRegex,
+		"filter-regex=" + options.FilterRegex,
+		fmt.Sprintf("min-depth=%d", options.MinDepth),
+		fmt.Sprintf("max-depth=%d", options.MaxDepth),
+		fmt.Sprintf("exclude-private=%t", options.ExcludePrivate),
+		fmt.Sprintf("only-private=%t", options.OnlyPrivate),
+		fmt.Sprintf("no-sinkhole-filter=%t", options.NoSinkholeFilter),
+		fmt.Sprintf("flag-sinkholes=%t", options.FlagSinkholes),
+		"sinkholes-file=" + fileStamp(options.SinkholesFile),
+		fmt.Sprintf("sinkhole-loopback=%t", options.SinkholeLoopback),
+		fmt.Sprintf("exclude-parked=%t", options.ExcludeParked),
+		"parking-file=" + fileStamp(options.ParkingFile),
+		fmt.Sprintf("collapse-cdn=%t", options.CollapseCDN),
+	}
+	// The cdn ranges only change the results when collapsing them
+	if options.CollapseCDN {
+		values = append(values, "cdn-ranges="+fileStamp(options.CDNRanges))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(values, "\n")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// fileStamps returns the stamps of a comma separated list of files
+func fileStamps(files string) string {
+	if files == "" {
+		return ""
+	}
+	var stamps []string
+	for _, file := range strings.Split(files, ",") {
+		stamps = append(stamps, fileStamp(strings.TrimSpace(file)))
+	}
+	return strings.Join(stamps, ",")
+}
+
+// fileStamp identifies a regular file by its absolute path, size and
+// modification time. Other files, like pipes and devices, are only
+// identified by their path since they can't be inspected without
+// consuming them.
+func fileStamp(file string) string {
+	if file == "" {
+		return ""
+	}
+	path, err := filepath.Abs(file)
+	if err != nil {
+		path = file
+	}
+	info, err := os.Stat(file)
+	if err != nil || !info.Mode().IsRegular() {
+		return path
+	}
+	return fmt.Sprintf("%s:%d:%d", path, info.Size(), info.ModTime().UnixNano())
+}
+
+// resolversHash returns the hash of the sorted addresses of a file or
+// inline list of resolvers, so that the order of the list doesn't
+// change it. The resolvers file is small and read again to load the
+// resolvers, so it is hashed by content unless it is a pipe.
+func resolversHash(list string) string {
+	if info, err := os.Stat(list); err == nil && !info.Mode().IsRegular() {
+		return fileStamp(list)
+	}
+	addresses, err := resolvers.Load(list)
+	if err != nil {
+		return fileStamp(list)
+	}
+	sorted := append([]string(nil), addresses...)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,86 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		file := filepath.Join(dir, name)
+		require.Nil(t, os.WriteFile(file, []byte(data), 0644), "Could not write file")
+		return file
+	}
+	options := &Options{Domain: "example.com", ResolversFile: write("r1.txt", "1.1.1.1\n8.8.8.8\n"), Wordlist: write("w1.txt", "www\napi\n")}
+	hash := options.configHash()
+
+	reordered := &Options{Domain: "example.com", ResolversFile: write("r2.txt", "8.8.8.8\n1.1.1.1\n"), Wordlist: options.Wordlist}
+	require.Equal(t, hash, reordered.configHash(), "Could not hash the resolvers regardless of their order")
+
+	write("w1.txt", "www\nmail\nftp\n")
+	require.NotEqual(t, hash, options.configHash(), "Could not change the hash with the file")
+}
+
+func TestConfigHashOptions(t *testing.T) {
+	base := func() *Options {
+		return &Options{Domain: "example.com", ResolversFile: "1.1.1.1", WildcardMode: "ip", Retries: 5}
+	}
+	hash := base().configHash()
+
+	changes := map[string]func(*Options){
+		"scope":              func(o *Options) { o.ScopeFile = "scope.yaml" },
+		"match-regex":        func(o *Options) { o.MatchRegex = "^api" },
+		"filter-regex":       func(o *Options) { o.FilterRegex = "^dev" },
+		"prefixes":           func(o *Options) { o.Prefixes = "dev" },
+		"suffixes":           func(o *Options) { o.Suffixes = "prod" },
+		"separators":         func(o *Options) { o.Separators = "-" },
+		"wildcard-resolvers": func(o *Options) { o.WildcardResolvers = "8.8.8.8" },
+		"min-depth":          func(o *Options) { o.MinDepth = 2 },
+		"max-depth":          func(o *Options) { o.MaxDepth = 2 },
+		"exclude-private":    func(o *Options) { o.ExcludePrivate = true },
+		"only-private":       func(o *Options) { o.OnlyPrivate = true },
+		"no-sinkhole-filter": func(o *Options) { o.NoSinkholeFilter = true },
+		"sinkholes-file":     func(o *Options) { o.SinkholesFile = "sinkholes.txt" },
+		"record-types":       func(o *Options) { o.RecordTypes = "AAAA" },
+		"internal":           func(o *Options) { o.Internal = true },
+		"markov":             func(o *Options) { o.GenerateMarkov = 100 },
+		"dnsgen":             func(o *Options) { o.Dnsgen = "words.txt" },
+		"exclude-parked":     func(o *Options) { o.ExcludeParked = true },
+	}
+	for name, change := range changes {
+		options := base()
+		change(options)
+		require.NotEqual(t, hash, options.configHash(), "Could not change the hash with %s", name)
+	}
+
+	formats := map[string]func(*Options){
+		"fields":          func(o *Options) { o.Fields = "host,ip,cname" },
+		"json":            func(o *Options) { o.Json = true },
+		"csv":             func(o *Options) { o.CSV = true },
+		"sorted":          func(o *Options) { o.Sorted = "alpha" },
+		"group-by-domain": func(o *Options) { o.GroupByDomain = true },
+		"output":          func(o *Options) { o.Output = "out.txt" },
+		"cdn-ranges":      func(o *Options) { o.CDNRanges = "ranges.txt" },
+	}
+	for name, change := range formats {
+		options := base()
+		change(options)
+		require.Equal(t, hash, options.configHash(), "Could not keep the hash with %s", name)
+	}
+}
+
+func TestFileStamp(t *testing.T) {
+	dir := t.TempDir()
+	require.Equal(t, dir, fileStamp(dir), "Could not identify a non-regular file by its path")
+
+	file := filepath.Join(dir, "list.txt")
+	require.Nil(t, os.WriteFile(file, []byte("www.example.com\n"), 0644), "Could not write file")
+	stamp := fileStamp(file)
+	require.Nil(t, os.Chtimes(file, time.Now(), time.Now().Add(time.Hour)), "Could not touch file")
+	require.NotEqual(t, stamp, fileStamp(file), "Could not change the stamp with the modification time")
+}
@@ -0,0 +1,157 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/merge"
+	"github.com/mohammadanaraki/shuffledns/pkg/safefile"
+	"github.com/mohammadanaraki/shuffledns/pkg/wildcards"
+	"github.com/projectdiscovery/gologger"
+)
+
+// MergeOptions contains the configuration options for the merge subcommand
+type MergeOptions struct {
+	Inputs        []string // Inputs are the output files of previous runs
+	Output        string   // Output is the file to write merged results to
+	WildcardCache string   // WildcardCache is a file with known wildcard ips to filter
+	Silent        bool     // Silent suppresses any extra text and only writes merged results
+	NoColor       bool     // NoColor disables the colored output
+	// AllowMixedConfigs merges the outputs of runs with different config hashes
+	AllowMixedConfigs bool
+}
+
+// ParseMergeOptions parses the command line flags for the merge subcommand
+func ParseMergeOptions(args []string) (*MergeOptions, error) {
+	options := &MergeOptions{}
+
+	flagSet := flag.NewFlagSet("merge", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		gologger.Print().Msgf("Usage: shuffledns merge [flags] output1 output2 ...\n")
+		flagSet.PrintDefaults()
+	}
+	flagSet.StringVar(&options.Output, "o", "", "File to write merged output to (optional)")
+	flagSet.StringVar(&options.WildcardCache, "wildcard-cache", "", "File containing wildcard ips or json wildcards to filter from merged output")
+	flagSet.BoolVar(&options.Silent, "silent", false, "Show only merged results in output")
+	flagSet.BoolVar(&options.NoColor, "nC", false, "Don't Use colors in output")
+	flagSet.BoolVar(&options.AllowMixedConfigs, "allow-mixed-configs", false, "Merge outputs of runs with different config hashes")
+
+	inputs, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return nil, err
+	}
+	options.Inputs = inputs
+
+	(&Options{Silent: options.Silent, NoColor: options.NoColor}).configureOutput()
+
+	if len(options.Inputs) < 1 {
+		flagSet.Usage()
+		return nil, fmt.Errorf("%w: no output files to merge", ErrUsage)
+	}
+	return options, nil
+}
+
+// parseInterspersed parses the flags allowing them to appear
+// after positional arguments, returning the positional arguments. The
+// invalid flags return ErrUsage, and -h returns flag.ErrHelp.
+func parseInterspersed(flagSet *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flagSet.Parse(args); err != nil {
+			return nil, parseError(err)
+		}
+		args = flagSet.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	return positional, nil
+}
+
+// RunMerge merges the outputs of multiple runs into one output
+func RunMerge(options *MergeOptions) error {
+	wildcardIPs, err := readWildcardCache(options.WildcardCache)
+	if err != nil {
+		return err
+	}
+
+	var writer io.Writer = os.Stdout
+	var output *safefile.File
+	if options.Output != "" {
+		output, err = safefile.Create(options.Output)
+		if err != nil {
+			return err
+		}
+		defer output.Abort()
+		writer = output
+	}
+
+	stats, err := merge.Merge(&merge.Options{
+		Inputs:            options.Inputs,
+		WildcardIPs:       wildcardIPs,
+		AllowMixedConfigs: options.AllowMixedConfigs,
+	}, writer)
+	if errors.Is(err, merge.ErrMixedConfigs) {
+		gologger.Warning().Msgf("Merged outputs come from different configurations: %s\n", strings.Join(stats.ConfigHashes, ", "))
+		return errors.New("refusing to merge outputs of different configurations, use -allow-mixed-configs to merge them anyway")
+	}
+	if err != nil {
+		return err
+	}
+	if output != nil {
+		if err := output.Commit(); err != nil {
+			return err
+		}
+	}
+
+	if len(stats.ConfigHashes) > 1 {
+		gologger.Warning().Msgf("Merged outputs come from different configurations: %s\n", strings.Join(stats.ConfigHashes, ", "))
+	}
+	if stats.Unchecked > 0 {
+		gologger.Warning().Msgf("Could not check %d hostnames for wildcards as their records have no ips (merge json outputs with the ip field)\n", stats.Unchecked)
+	}
+	gologger.Info().Msgf("Merged %d records into %d unique hostnames (%d wildcards removed)\n", stats.Records, stats.Unique, stats.Wildcards)
+	return nil
+}
+
+// readWildcardCache reads a list of wildcard ips from a file, either one
+// ip per line or the json lines written by -wildcard-output-json
+func readWildcardCache(file string) (map[string]struct{}, error) {
+	wildcardIPs := make(map[string]struct{})
+	if file == "" {
+		return wildcardIPs, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.New("could not read wildcard cache: " + err.Error())
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			if line != "" {
+				wildcardIPs[line] = struct{}{}
+			}
+			continue
+		}
+		var wildcard wildcards.Wildcard
+		if err := json.Unmarshal([]byte(line), &wildcard); err != nil {
+			return nil, errors.New("could not read wildcard cache: " + err.Error())
+		}
+		for _, ip := range wildcard.IPs {
+			wildcardIPs[ip] = struct{}{}
+		}
+	}
+	return wildcardIPs, scanner.Err()
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/history"
+)
+
+// notifyChanges delivers the change events for hostnames whose
+// answers changed to the sinks configured by the user.
+func (r *Runner) notifyChanges(changes []*history.Change) {
+	if len(changes) == 0 {
+		return
+	}
+	r.log().Info().Msgf("Found %d hostnames with changed answers\n", len(changes))
+
+	if r.options.ChangesOutput != "" {
+		if err := writeChanges(r.options.ChangesOutput, changes); err != nil {
+			r.log().Error().Msgf("Could not write changes output: %s\n", err)
+		}
+	}
+	if r.options.Webhook != "" {
+		batch := &changesBatch{RunID: r.runID, Domain: r.options.Domain, Count: len(changes), Changes: changes}
+		if err := postChanges(r.options.Webhook, batch); err != nil {
+			r.log().Error().Msgf("Could not send changes to webhook: %s\n", err)
+		}
+	}
+}
+
+// writeChanges writes the change events to a file as ndjson
+func writeChanges(file string, changes []*history.Change) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+	for _, change := range changes {
+		if err := encoder.Encode(change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// changesBatch is the body sent to the webhook with the changes of a run
+type changesBatch struct {
+	RunID   string            `json:"run_id"`
+	Domain  string            `json:"domain,omitempty"`
+	Count   int               `json:"count"`
+	Changes []*history.Change `json:"changes"`
+}
+
+// postChanges sends the change events of a run to a webhook in a
+// single json body, so that large diffs don't flood the receiver.
+func postChanges(url string, batch *changesBatch) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package runner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/history"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyChangesWebhook(t *testing.T) {
+	var batches []changesBatch
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var batch changesBatch
+		require.Nil(t, json.NewDecoder(req.Body).Decode(&batch), "Could not decode webhook body")
+		batches = append(batches, batch)
+	}))
+	defer server.Close()
+
+	r := &Runner{runID: "run", options: &Options{Domain: "example.com", Webhook: server.URL}}
+	r.notifyChanges([]*history.Change{
+		{Hostname: "www.example.com", Status: history.StatusChanged, IPs: []string{"192.0.2.2"}},
+		{Hostname: "api.example.com", Status: history.StatusChanged, IPs: []string{"192.0.2.3"}},
+		{Hostname: "dev.example.com", Status: history.StatusChanged, IPs: []string{"192.0.2.4"}},
+	})
+
+	require.Len(t, batches, 1, "Could not send the changes in a single request")
+	require.Equal(t, "run", batches[0].RunID, "Could not send the run id")
+	require.Equal(t, 3, batches[0].Count, "Could not send the number of changes")
+	require.Len(t, batches[0].Changes, 3, "Could not send the changes")
+}
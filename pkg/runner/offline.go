@@ -0,0 +1,37 @@
+package runner
+
+import "strings"
+
+// onlineFeatures returns the options set which reach the network other
+// than through dns queries, or run code of the user which may.
+func (options *Options) onlineFeatures() []string {
+	var features []string
+	if options.Webhook != "" {
+		features = append(features, "-webhook (http requests)")
+	}
+	if options.TLSSans > 0 {
+		features = append(features, "-tls-sans (tls connections to the found hosts)")
+	}
+	if options.OnResult != "" {
+		features = append(features, "-on-result (user command)")
+	}
+	if options.OnComplete != "" {
+		features = append(features, "-on-complete (user command)")
+	}
+	if options.Plugins != "" {
+		features = append(features, "-plugins (user code)")
+	}
+	if options.Sign == "cosign" {
+		features = append(features, "-sign cosign (sigstore services)")
+	}
+	return features
+}
+
+// validateOffline returns an error listing the options which can't be
+// used in strict offline mode.
+func (options *Options) validateOffline() error {
+	if features := options.onlineFeatures(); len(features) > 0 {
+		return invalidOption("strict offline mode forbids %s", strings.Join(features, ", "))
+	}
+	return nil
+}
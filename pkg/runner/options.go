@@ -6,7 +6,11 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/mohammadanaraki/shuffledns/pkg/backoff"
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/mohammadanaraki/shuffledns/pkg/massdns"
 	"github.com/projectdiscovery/fileutil"
 	"github.com/projectdiscovery/gologger"
 )
@@ -15,51 +19,283 @@ import (
 // the active dns resolving process.
 type Options struct {
 	Directory          string // Directory is a directory for temporary data
+	EncryptTmp         bool   // EncryptTmp encrypts the candidate lists and raw outputs of the temporary directory
+	ShredTmp           bool   // ShredTmp overwrites the temporary files before removing them, even on interrupts
 	Domain             string // Domain is the domain to find subdomains
 	SubdomainsList     string // SubdomainsList is the file containing list of hosts to resolve
-	ResolversFile      string // ResolversFile is the file containing resolvers to use for enumeration
+	ResolversFile      string // ResolversFile is the file or comma separated list of resolvers to use for enumeration
+	WildcardResolvers  string // WildcardResolvers is the file or comma separated list of resolvers to use for wildcard probes and verification
+	ResolverSplit      string // ResolverSplit is the percentage of the resolvers reserved for verification when they come from a single list
+	IPv4               bool   // IPv4 uses only the ipv4 resolvers
+	IPv6               bool   // IPv6 uses only the ipv6 resolvers
 	Wordlist           string // Wordlist is a wordlist to use for enumeration
 	MassdnsPath        string // MassdnsPath contains the path to massdns binary
+	UseBundledMassdns  bool   // UseBundledMassdns uses the massdns binary embedded in the release builds
+	MockDNS            string // MockDNS is a fixture answering the queries instead of massdns and the resolvers (SHUFFLEDNS_MOCK_DNS only)
 	Output             string // Output is the file to write found subdomains to.
+	OutputCompress     bool   // OutputCompress writes the output file gzip-compressed
+	OutputAppendUnique bool   // OutputAppendUnique appends the hostnames not already present to the output file
+	Sorted             string // Sorted is the order of the output (alpha or reverse), unordered if empty
+	GroupByDomain      bool   // GroupByDomain writes one json record per registered domain with its hosts
+	OutputHosts        string // OutputHosts is the file to write the results to in hosts file format
+	OutputURLs         string // OutputURLs is the file to write the urls of the likely web services of the results to
+	OutputBurp         string // OutputBurp is the file to write a burp target scope with the results to
+	OutputTargets      string // OutputTargets is the file to write the unique ips of the results to for port scanners
+	OutputIPMap        string // OutputIPMap is the file to write the unique ips with their hostnames to (json or csv)
+	IPClusters         string // IPClusters is the file to write the clusters of the ips per netblock and asn to
+	ClusterASN         bool   // ClusterASN looks up the asn of the netblocks to cluster the ips per asn
+	EmailPosture       bool   // EmailPosture collects the spf, dkim and dmarc records of the apex domains
+	DKIMSelectors      string // DKIMSelectors is the comma separated list of dkim selectors to guess
+	TargetsHostnames   bool   // TargetsHostnames comments each ip of the targets file with its hostnames
+	Report             string // Report is the file to write the html report of the results to
+	ReportMarkdown     string // ReportMarkdown is the file to write the markdown summary of the results to
+	ProgressJSON       string // ProgressJSON is the file to write the progress events to as json lines (- for stderr)
 	Json               bool   // Json is the format for making output as ndjson
+	CSV                bool   // CSV writes the output as csv with the selected fields as columns
+	Fields             string // Fields is the comma separated list of fields to write in json output
+	CDNRanges          string // CDNRanges is a file with additional cdn ip ranges
+	CollapseCDN        bool   // CollapseCDN collapses hostnames fronted by the same cdn configuration
+	VendorFingerprints string // VendorFingerprints is a file with additional vendor cname patterns
+	PTREnrich          bool   // PTREnrich adds the reverse names of the resolved ips to json output
+	AnyRecords         bool   // AnyRecords adds the records of all types of the hosts to json output
+	RecordTypes        string // RecordTypes is the comma separated list of record types of the hosts to resolve
+	ScopeFile          string // ScopeFile is the yaml file with the rules for the names in scope
+	GenerateMarkov     int    // GenerateMarkov is the number of candidates generated from the known subdomains
+	Dnsgen             string // Dnsgen is the word file combined with the labels of the known subdomains
+	OrderWords         bool   // OrderWords resolves the candidates most likely found first
+	GeneratedFirst     bool   // GeneratedFirst resolves the candidates generated from the known subdomains before the bruteforce words
+	Prefixes           string // Prefixes is the comma separated list of prefixes to join to the words
+	Suffixes           string // Suffixes is the comma separated list of suffixes to join to the words
+	Separators         string // Separators is the comma separated list of separators between words and affixes
+	MatchRegex         string // MatchRegex is the regex candidates and results have to match
+	FilterRegex        string // FilterRegex is the regex of the candidates and results to drop
+	CNAMEDepth         int    // CNAMEDepth is the maximum levels of in-scope cname target domains to enumerate
+	TLSSans            int    // TLSSans is the maximum rounds resolving names found in tls certificates
 	Silent             bool   // Silent suppresses any extra text and only writes found host:port to screen
 	Version            bool   // Version specifies if we should just show version and exit
 	Retries            int    // Retries is the number of retries for dns enumeration
 	Verbose            bool   // Verbose flag indicates whether to show verbose output or not
 	NoColor            bool   // No-Color disables the colored output
 	Threads            int    // Thread controls the number of parallel host to enumerate
+	RaiseFDLimit       bool   // RaiseFDLimit raises the soft limit of open files to the hard limit
+	MaxBandwidth       string // MaxBandwidth caps the bandwidth used by dns queries (e.g. 10mbps)
+	MaxQPS             int    // MaxQPS caps the number of dns queries per second (0 for unlimited)
 	MassdnsRaw         string // MassdnsRaw perform wildcards filtering from an existing massdns output file
 	WildcardThreads    int    // WildcardsThreads controls the number of parallel host to check for wildcard
 	StrictWildcard     bool   // StrictWildcard flag indicates whether wildcard check has to be performed on each found subdomains
+	NoDedup            bool   // NoDedup resolves the duplicate names instead of skipping them
+	PruneWildcards     bool   // PruneWildcards skips the names below wildcard parents instead of resolving them
+	MinHitRate         string // MinHitRate is the percentage of names found under which a domain stops being bruteforced
+	HitRateWindow      int    // HitRateWindow is the number of recent names of a domain the hit rate is computed over
+	NoWildcardPrecheck bool   // NoWildcardPrecheck disables the wildcard probes of the domain before resolving
+	PrecheckParents    bool   // PrecheckParents probes the common second-level parents in the wildcard pre-check too
 	WildcardOutputFile string // StrictWildcard flag indicates whether wildcard check has to be performed on each found subdomains
+	WildcardOutputJSON string // WildcardOutputJSON is the file to write the wildcards found to as json lines with their evidence
+	Manifest           string // Manifest is the file to write the sha256 manifest of the output files to
+	Sign               string // Sign is the tool signing the manifest, minisign or cosign
+	SignKey            string // SignKey is the secret key signing the manifest
+	SignPasswordFile   string // SignPasswordFile is the file containing the password of the signing key
+	ResolverAgreement  int    // ResolverAgreement is the number of distinct resolvers which must agree on an answer
+	VerifySample       string // VerifySample is the percentage of the results re-resolved with trusted resolvers
+	ResolverStats      string // ResolverStats is the file to write the statistics per resolver to
+	WildcardMode       string // WildcardMode is the strategy deciding whether hosts are answered by wildcards
+	Canaries           int    // Canaries is the number of nonexistent names added to estimate false positives
+	KnownAnswers       int    // KnownAnswers is the number of names between known answer checks
+	KnownAnswersFile   string // KnownAnswersFile is a file with custom names and their known answers
+	SuspiciousIPs      string // SuspiciousIPs is a file with ips whose results have to be re-verified
+	ExcludePrivate     bool   // ExcludePrivate drops the results resolving to private ips
+	OnlyPrivate        bool   // OnlyPrivate keeps only the results resolving to private ips
+	MinDepth           int    // MinDepth is the minimum number of labels below the registered domain
+	MaxDepth           int    // MaxDepth is the maximum number of labels below the registered domain
+	NoSinkholeFilter   bool   // NoSinkholeFilter disables the filtering of results resolving to sinkholes
+	FlagSinkholes      bool   // FlagSinkholes flags the results resolving to sinkholes instead of dropping them
+	SinkholesFile      string // SinkholesFile is a file with additional sinkhole ips and cidrs
+	SinkholeLoopback   bool   // SinkholeLoopback filters the results resolving to loopback ips as sinkholes
+	ExcludeParked      bool   // ExcludeParked drops the results served by domain-parking providers
+	ParkingFile        string // ParkingFile is a file with additional domain-parking fingerprints
+	StoreFile          string // StoreFile is the history datastore to record discovered assets to
+	ChangesOutput      string // ChangesOutput is the file to write change events for changed answers to
+	Webhook            string // Webhook is the url to send change events for changed answers to
+	OnResult           string // OnResult is the command run for each validated result
+	OnComplete         string // OnComplete is the command run once the run is complete
+	Plugins            string // Plugins is the comma separated list of plugin files extending the pipeline
 
-	Stdin bool // Stdin specifies whether stdin input was given to the process
+	Profile    string // Profile is the name of the profile with the options to use
+	ConfigFile string // ConfigFile is the config file where profiles are defined
+
+	Internal        bool          // Internal tunes the enumeration of internal zones through corporate resolvers
+	SearchDomains   string        // SearchDomains is the comma separated list of domains qualifying single label names
+	Stealth         bool          // Stealth sends queries slowly with randomized delays
+	LowResource     bool          // LowResource uses conservative defaults for devices with little memory
+	StealthDuration time.Duration // StealthDuration spreads the stealth queries over a duration
+	AdaptiveRate    bool          // AdaptiveRate slows down the queries when rate limiting is detected
+	NSMaxQPS        int           // NSMaxQPS is the maximum number of queries per second to the zones of a nameserver set
+	OfflineStrict   bool          // OfflineStrict forbids every network access other than dns queries
+
+	MassdnsHangTimeout time.Duration // MassdnsHangTimeout is the time after which massdns is restarted if it made no progress
+	MassdnsRestarts    int           // MassdnsRestarts is the maximum number of massdns restarts on the remaining names
+	ProgressInterval   time.Duration // ProgressInterval is the interval between the progress events written during a stage
+
+	RetryBackoff           time.Duration // RetryBackoff is the delay before the first retry of verification queries
+	RetryBackoffMax        time.Duration // RetryBackoffMax is the maximum delay between retries of verification queries
+	RetryBackoffMultiplier float64       // RetryBackoffMultiplier is the factor applied to the delay after each retry
+	RetryBackoffJitter     float64       // RetryBackoffJitter is the fraction of the delay randomized in both directions
+
+	Stdin      bool          // Stdin specifies whether stdin input was given to the process
+	StdinChunk int           // StdinChunk is the number of names of stdin or a followed list resolved per chunk (0 to read the whole input first)
+	StdinFlush time.Duration // StdinFlush is the time after which an incomplete chunk of stdin or a followed list is resolved
+
+	Follow        bool          // Follow resolves the names appended to the list by another process as they appear
+	FollowMarker  string        // FollowMarker is the line ending a followed list
+	FollowTimeout time.Duration // FollowTimeout is the time without new names after which a followed list ends
+
+	Logger         *gologger.Logger // Logger receives the messages of the runner (gologger.DefaultLogger if nil)
+	ResultsWriter  io.Writer        // ResultsWriter receives the found subdomains instead of stdout
+	WildcardWriter io.Writer        // WildcardWriter receives the wildcard ips found along with the wildcard output file
+	ProgressWriter io.Writer        // ProgressWriter receives the progress events as json lines along with the progress file
 }
 
-// ParseOptions parses the command line flags provided by a user
-func ParseOptions() *Options {
+// ParseOptions parses the command line flags provided by a user,
+// returning an error if they are invalid.
+func ParseOptions() (*Options, error) {
 	options := &Options{}
 
 	flag.StringVar(&options.Directory, "directory", "", "Temporary directory for enumeration")
+	flag.BoolVar(&options.EncryptTmp, "encrypt-tmp", false, "Encrypt the candidate lists and raw outputs of the temporary directory with an ephemeral key")
+	flag.BoolVar(&options.ShredTmp, "shred-tmp", false, "Overwrite the temporary files before removing them, including on interrupts and crashes")
 	flag.StringVar(&options.Domain, "d", "", "Domain to find or resolve subdomains for")
 	flag.StringVar(&options.SubdomainsList, "list", "", "File containing list of subdomains to resolve")
-	flag.StringVar(&options.ResolversFile, "r", "", "File containing list of resolvers for enumeration")
+	flag.BoolVar(&options.Follow, "follow", false, "Resolve the names appended to the list by another tool as they appear (requires -list)")
+	flag.StringVar(&options.FollowMarker, "follow-marker", "", "Line ending the followed list (optional)")
+	flag.DurationVar(&options.FollowTimeout, "follow-timeout", time.Minute, "Time without new names after which the followed list ends (0 to wait for the marker)")
+	flag.IntVar(&options.StdinChunk, "stdin-chunk", 0, "Number of names piped via stdin or followed resolved per chunk while reading them (0 to read the whole input first)")
+	flag.DurationVar(&options.StdinFlush, "stdin-flush", 30*time.Second, "Time after which an incomplete chunk of stdin or of a followed list is resolved (0 to wait for a full chunk)")
+	flag.StringVar(&options.ResolversFile, "r", "", "File or comma separated list of resolvers for enumeration (ip, ip:port or [ipv6]:port)")
+	flag.StringVar(&options.WildcardResolvers, "wr", "", "File or comma separated list of resolvers for wildcard probes and verification")
+	flag.StringVar(&options.ResolverSplit, "resolver-split", "10%", "Percentage of the resolvers reserved for verification when bulk and verification use one list (0 to share the resolvers)")
+	flag.BoolVar(&options.IPv4, "4", false, "Use only ipv4 resolvers")
+	flag.BoolVar(&options.IPv6, "6", false, "Use only ipv6 resolvers")
 	flag.StringVar(&options.Wordlist, "w", "", "File containing words to bruteforce for domain")
 	flag.StringVar(&options.MassdnsPath, "massdns", "", "Path to the massdns binary")
+	flag.BoolVar(&options.UseBundledMassdns, "use-bundled-massdns", false, "Use the massdns binary bundled in the release builds, extracted to the config directory")
 	flag.StringVar(&options.Output, "o", "", "File to write output to (optional)")
+	flag.StringVar(&options.OutputHosts, "o-hosts", "", "File to write results to in hosts file format (ip hostname per line)")
+	flag.StringVar(&options.OutputURLs, "o-urls", "", "File to write urls with guessed schemes and ports to (for aquatone or eyewitness)")
+	flag.StringVar(&options.OutputBurp, "o-burp", "", "File to write a burp suite target scope json including the results to")
+	flag.StringVar(&options.OutputTargets, "o-targets", "", "File to write the unique ips to (for nmap -iL or masscan -iL)")
+	flag.StringVar(&options.IPClusters, "ip-clusters", "", "File to write the clusters of the result ips per /24 netblock (and asn with -asn) to")
+	flag.BoolVar(&options.ClusterASN, "asn", false, "Look up the asn of the netblocks with the Team Cymru dns service to cluster the ips and break the report down per asn")
+	flag.BoolVar(&options.EmailPosture, "email-posture", false, "Collect the spf, dkim and dmarc records of the apex domains and report their email security")
+	flag.StringVar(&options.DKIMSelectors, "dkim-selectors", "", "Comma separated dkim selectors to guess with -email-posture (default common selectors)")
+	flag.StringVar(&options.OutputIPMap, "o-ipmap", "", "File to write the unique ips with the hostnames resolving to them to (csv for .csv files, json otherwise)")
+	flag.BoolVar(&options.TargetsHostnames, "targets-hostnames", false, "Comment each ip of the targets file with the hostnames resolving to it")
+	flag.StringVar(&options.Report, "report", "", "File to write a self-contained html report of the results to")
+	flag.StringVar(&options.ReportMarkdown, "report-md", "", "File to write a markdown summary of the results to (for issue trackers)")
+	flag.StringVar(&options.ProgressJSON, "progress-json", "", "File to write progress events to as json lines (- for stderr)")
+	flag.DurationVar(&options.ProgressInterval, "progress-interval", 5*time.Second, "Interval between the progress events written during a stage")
+	flag.BoolVar(&options.OutputCompress, "output-compress", false, "Write the output file gzip-compressed")
+	flag.BoolVar(&options.OutputAppendUnique, "o-append-unique", false, "Append to the output file the hosts not already present in it")
+	flag.BoolVar(&options.GroupByDomain, "group-by-domain", false, "Write one json record per registered domain with its hosts and ips (requires -json)")
+	flag.StringVar(&options.Sorted, "sorted", "", "Order the output alphabetically (alpha) or by reversed labels grouping the domains (reverse)")
 	flag.BoolVar(&options.Json, "json", false, "Make output format as ndjson")
+	flag.BoolVar(&options.CSV, "csv", false, "Make output format as csv with a header and the -fields columns")
+	flag.StringVar(&options.Fields, "fields", "", "Comma separated fields to show in json or csv output (host,ip,cname,resolver,cdn,vendor,run_id,config_hash,timestamp)")
+	flag.StringVar(&options.CDNRanges, "cdn-ranges", "", "File with additional cdn ranges (provider cidr per line)")
+	flag.StringVar(&options.VendorFingerprints, "vendor-fingerprints", "", "File with additional vendor cname patterns (pattern vendor per line)")
+	flag.BoolVar(&options.CollapseCDN, "collapse-cdn", false, "Write one representative entry for hosts with the same cdn ips and cname target")
+	flag.BoolVar(&options.PTREnrich, "ptr-enrich", false, "Add reverse names of the resolved ips to json output")
+	flag.BoolVar(&options.AnyRecords, "any", false, "Add the records of all types of the hosts to json output (ANY queries with per-type fallback)")
+	flag.StringVar(&options.RecordTypes, "record-types", "", "Comma separated record types of the hosts to resolve, the types other than A being added to json output (e.g. A,AAAA,MX)")
+	flag.StringVar(&options.ScopeFile, "scope", "", "Yaml file with the domains, name regexes and ip ranges in scope")
+	flag.IntVar(&options.GenerateMarkov, "generate-markov", 0, "Generate N candidates with a markov chain trained on the known subdomains")
+	flag.StringVar(&options.Dnsgen, "dnsgen", "", "Word file to combine with the labels of the known subdomains (dnsgen style)")
+	flag.BoolVar(&options.OrderWords, "order-words", false, "Resolve the bruteforce words most likely found first")
+	flag.BoolVar(&options.GeneratedFirst, "generated-first", false, "Resolve the candidates generated from known subdomains before the bruteforce words")
+	flag.StringVar(&options.Prefixes, "prefixes", "", "Comma separated prefixes to generate variations of the names with (e.g. dev,stg)")
+	flag.StringVar(&options.Suffixes, "suffixes", "", "Comma separated suffixes to generate variations of the names with (e.g. dev,01)")
+	flag.StringVar(&options.Separators, "separators", "", "Comma separated separators between the names and the affixes (default - and none)")
+	flag.StringVar(&options.MatchRegex, "match-regex", "", "Only resolve and output names matching the regex")
+	flag.StringVar(&options.FilterRegex, "filter-regex", "", "Don't resolve and output names matching the regex")
+	flag.IntVar(&options.CNAMEDepth, "cname-depth", 0, "Enumerate in-scope domains targeted by cnames of found hosts up to N levels")
+	flag.IntVar(&options.TLSSans, "tls-sans", 0, "Resolve in-scope names found in tls certificates of found hosts for N rounds")
 	flag.BoolVar(&options.Silent, "silent", false, "Show only subdomains in output")
 	flag.BoolVar(&options.Version, "version", false, "Show version of shuffledns")
 	flag.IntVar(&options.Retries, "retries", 5, "Number of retries for dns enumeration")
 	flag.BoolVar(&options.Verbose, "v", false, "Show Verbose output")
 	flag.BoolVar(&options.NoColor, "nC", false, "Don't Use colors in output")
 	flag.IntVar(&options.Threads, "t", 10000, "Number of concurrent massdns resolves")
+	flag.BoolVar(&options.RaiseFDLimit, "raise-fd-limit", false, "Raise the soft limit of open files to the hard limit")
+	flag.StringVar(&options.MaxBandwidth, "max-bandwidth", "", "Maximum bandwidth for dns queries (e.g. 10mbps)")
+	flag.IntVar(&options.MaxQPS, "max-qps", 0, "Maximum number of dns queries per second (0 for unlimited)")
+	flag.BoolVar(&options.AdaptiveRate, "adaptive-rate", false, "Slow down the queries when resolvers or authoritative servers rate limit them")
+	flag.IntVar(&options.NSMaxQPS, "ns-max-qps", 0, "Maximum number of dns queries per second to the zones served by the same nameservers (0 for unlimited)")
+	flag.BoolVar(&options.OfflineStrict, "offline-strict", false, "Forbid every network access other than dns queries, failing if a feature needing one is set")
+	flag.BoolVar(&options.Internal, "internal", false, "Enumerate internal zones through the corporate resolvers of -r only")
+	flag.StringVar(&options.SearchDomains, "search-domains", "", "Comma separated domains qualifying single label names in internal mode (default -d or the system search domains)")
+	flag.BoolVar(&options.Stealth, "stealth", false, "Send queries slowly with randomized delays")
+	flag.DurationVar(&options.StealthDuration, "stealth-duration", 0, "Spread stealth queries evenly over a duration (e.g. 6h)")
+	flag.BoolVar(&options.LowResource, "low-resource", false, "Use conservative defaults for arm boards and devices with 1-2 GB of memory")
+	flag.DurationVar(&options.MassdnsHangTimeout, "massdns-hang-timeout", 10*time.Minute, "Restart massdns if it made no progress for a duration (0 to disable)")
+	flag.IntVar(&options.MassdnsRestarts, "massdns-restarts", 3, "Maximum number of massdns restarts on the remaining names after a crash or hang")
+	flag.DurationVar(&options.RetryBackoff, "retry-backoff", backoff.DefaultPolicy.Initial, "Delay before the first retry of wildcard and verification queries (0 to retry immediately)")
+	flag.DurationVar(&options.RetryBackoffMax, "retry-backoff-max", backoff.DefaultPolicy.Max, "Maximum delay between retries of wildcard and verification queries")
+	flag.Float64Var(&options.RetryBackoffMultiplier, "retry-backoff-multiplier", backoff.DefaultPolicy.Multiplier, "Factor applied to the retry delay after each retry")
+	flag.Float64Var(&options.RetryBackoffJitter, "retry-backoff-jitter", backoff.DefaultPolicy.Jitter, "Fraction of the retry delay randomized in both directions (0-1)")
 	flag.StringVar(&options.MassdnsRaw, "raw-input", "", "Validate raw full massdns output")
 	flag.BoolVar(&options.StrictWildcard, "strict-wildcard", false, "Perform wildcard check on all found subdomains")
+	flag.BoolVar(&options.PruneWildcards, "prune-wildcards", false, "Skip the candidates below the wildcard parents found before and during the run instead of resolving them")
+	flag.BoolVar(&options.NoDedup, "no-dedup", false, "Resolve the duplicate names of the input, variations and additional rounds (saves memory on huge runs)")
+	flag.StringVar(&options.MinHitRate, "min-hit-rate", "", "Stop resolving the names of a domain once the percentage of them found over the recent names drops below it (e.g. 0.1%)")
+	flag.IntVar(&options.HitRateWindow, "hit-rate-window", massdns.DefaultHitRateWindow, "Number of recent names of a domain the hit rate is computed over")
+	flag.BoolVar(&options.NoWildcardPrecheck, "no-wildcard-precheck", false, "Don't probe the domain for wildcards before generating the candidates")
+	flag.BoolVar(&options.PrecheckParents, "precheck-parents", false, "Probe common second-level parents (dev, staging...) for wildcards before generating the candidates")
 	flag.IntVar(&options.WildcardThreads, "wt", 25, "Number of concurrent wildcard checks")
 	flag.StringVar(&options.WildcardOutputFile, "wildcard-output-file", "", "Dump wildcard ips to output file")
+	flag.StringVar(&options.WildcardOutputJSON, "wildcard-output-json", "", "Dump wildcard roots with their ips and detection evidence to output file as json lines")
+	flag.StringVar(&options.Manifest, "manifest", "", "File to write the sha256 manifest of the output files to")
+	flag.StringVar(&options.Sign, "sign", "", "Sign the manifest with minisign or cosign (requires -manifest and -sign-key)")
+	flag.StringVar(&options.SignKey, "sign-key", "", "Secret key signing the manifest")
+	flag.StringVar(&options.SignPasswordFile, "sign-password-file", "", "File containing the password of the signing key (or SHUFFLEDNS_SIGN_PASSWORD, prompted on the terminal otherwise)")
+	flag.IntVar(&options.ResolverAgreement, "resolver-agreement", 0, "Accept results only if N distinct resolvers agree on their answer")
+	flag.StringVar(&options.VerifySample, "verify-sample", "", "Percentage of the results re-resolved with trusted resolvers to report the disagreement rate (e.g. 10%)")
+	flag.StringVar(&options.ResolverStats, "resolver-stats", "", "File to write the answers, nxdomain and servfail counts per resolver and the timeouts to")
+	flag.StringVar(&options.WildcardMode, "wildcard-mode", "exact-ip", "Wildcard detection strategy (exact-ip, ip-set, statistical, cname)")
+	flag.IntVar(&options.Canaries, "canaries", 0, "Number of nonexistent canary names added to estimate the false-positive rate")
+	flag.IntVar(&options.KnownAnswers, "known-answers", 0, "Interleave a known answer check every N names to detect lying resolvers")
+	flag.StringVar(&options.KnownAnswersFile, "known-answers-file", "", "File with names and their known answers (name ip1,ip2 per line)")
+	flag.StringVar(&options.SuspiciousIPs, "suspicious-ips", "", "File with ips whose results are re-verified with trusted resolvers")
+	flag.BoolVar(&options.ExcludePrivate, "exclude-private", false, "Drop results resolving to private, loopback or link-local ips")
+	flag.BoolVar(&options.OnlyPrivate, "only-private", false, "Keep only results resolving to private, loopback or link-local ips")
+	flag.IntVar(&options.MinDepth, "min-depth", 0, "Minimum number of labels of the names below the registered domain (0 for no minimum)")
+	flag.IntVar(&options.MaxDepth, "max-depth", 0, "Maximum number of labels of the names below the registered domain (0 for no maximum)")
+	flag.BoolVar(&options.NoSinkholeFilter, "no-sinkhole-filter", false, "Don't filter results resolving to known sinkhole ips")
+	flag.BoolVar(&options.FlagSinkholes, "flag-sinkholes", false, "Flag results resolving to known sinkhole ips instead of dropping them")
+	flag.StringVar(&options.SinkholesFile, "sinkholes-file", "", "File with additional sinkhole ips and cidrs")
+	flag.BoolVar(&options.SinkholeLoopback, "sinkhole-loopback", false, "Filter results resolving to loopback ips (127.0.0.0/8) as sinkholes")
+	flag.BoolVar(&options.ExcludeParked, "exclude-parked", false, "Drop results served by known domain-parking providers instead of flagging them in json output")
+	flag.StringVar(&options.ParkingFile, "parking-file", "", "File with additional domain-parking fingerprints (ip|cname|ns value provider per line)")
+	flag.StringVar(&options.StoreFile, "store", "", "History datastore to record discovered assets to (optional)")
+	flag.StringVar(&options.ChangesOutput, "changes-output", "", "File to write hosts with changed answers to (requires -store)")
+	flag.StringVar(&options.Webhook, "webhook", "", "Webhook url to send hosts with changed answers to (requires -store)")
+	flag.StringVar(&options.OnResult, "on-result", "", "Command to run for each validated result (json on stdin, SHUFFLEDNS_HOST/IP/CNAME env)")
+	flag.StringVar(&options.OnComplete, "on-complete", "", "Command to run once the run is complete (json summary on stdin)")
+	flag.StringVar(&options.Plugins, "plugins", "", "Comma separated list of Go plugin files filtering candidates, enriching results or transforming output")
+	flag.StringVar(&options.Profile, "profile", "", "Profile with options to use (quick, thorough, stealth, internal, low-resource or defined in config)")
+	flag.StringVar(&options.ConfigFile, "config", "", "Config file with profile definitions (default $HOME/.config/shuffledns/config.yaml)")
+
+	// Options can also be configured through environment variables
+	if err := applyEnvironment(flag.CommandLine); err != nil {
+		return nil, invalidOption("%w", err)
+	}
+
+	// The errors are returned instead of exiting, -h included
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		return nil, parseError(err)
+	}
 
-	flag.Parse()
+	// The mock backend is meant for tests, so it has no flag
+	options.MockDNS = os.Getenv(envName("mock-dns"))
 
 	// Check if stdin pipe was given
 	options.Stdin = fileutil.HasStdin()
@@ -70,15 +306,33 @@ func ParseOptions() *Options {
 	// Show the user the banner
 	showBanner()
 
+	// The caller exits once the version is shown
 	if options.Version {
 		gologger.Info().Msgf("Current Version: %s\n", Version)
-		os.Exit(0)
+		return options, nil
 	}
+	// Stealth mode uses the stealth profile unless another one is chosen
+	if options.Stealth && options.Profile == "" {
+		options.Profile = "stealth"
+	}
+	// Internal mode uses the internal profile unless another one is chosen
+	if options.Internal && options.Profile == "" {
+		options.Profile = "internal"
+	}
+	// Low resource mode uses the low-resource profile unless another one is chosen
+	if options.LowResource && options.Profile == "" {
+		options.Profile = "low-resource"
+	}
+
+	// Apply the options of the selected profile, if any
+	if err := options.applyProfile(); err != nil {
+		return nil, invalidOption("%w", err)
+	}
+
 	// Validate the options passed by the user and if any
-	// invalid options have been used, exit.
-	err := options.validateOptions()
-	if err != nil {
-		gologger.Fatal().Msgf("Program exiting: %s\n", err)
+	// invalid options have been used, return the error.
+	if err := options.Validate(); err != nil {
+		return nil, err
 	}
 
 	// if all the flags are provided via cli we ignore stdin by draining it
@@ -94,6 +348,18 @@ func ParseOptions() *Options {
 		_, _ = io.Copy(buffer, os.Stdin)
 		options.Domain = strings.TrimRight(buffer.String(), "\r\n")
 	}
+	options.Domain = dnsname.Normalize(options.Domain)
+
+	return options, nil
+}
 
-	return options
+// retryBackoff returns the backoff policy for the retries of the
+// wildcard and verification queries.
+func (options *Options) retryBackoff() backoff.Policy {
+	return backoff.Policy{
+		Initial:    options.RetryBackoff,
+		Multiplier: options.RetryBackoffMultiplier,
+		Jitter:     options.RetryBackoffJitter,
+		Max:        options.RetryBackoffMax,
+	}
 }
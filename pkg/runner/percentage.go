@@ -0,0 +1,16 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePercentage parses a percentage like 10% or 10 into a fraction
+func parsePercentage(value string) (float64, error) {
+	number, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil || number <= 0 || number > 100 {
+		return 0, fmt.Errorf("invalid percentage: %s", value)
+	}
+	return number / 100, nil
+}
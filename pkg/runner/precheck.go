@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"bufio"
+	"sort"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/massdns"
+	"github.com/mohammadanaraki/shuffledns/pkg/tmpcrypt"
+	"github.com/mohammadanaraki/shuffledns/pkg/wildcards"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// precheckParents are the second-level parents commonly answered by
+// wildcards, probed with -precheck-parents
+var precheckParents = []string{
+	"dev", "test", "qa", "uat", "stage", "staging", "preprod", "beta",
+	"demo", "sandbox", "internal", "corp", "int", "cdn", "static",
+}
+
+// precheckWildcards probes the domain, and the common second-level
+// parents if asked, for wildcards before the candidates are generated
+// and resolved, warning about the share of the candidates which would
+// be answered by them. The candidates are the lines of a file followed
+// by a suffix.
+func (r *Runner) precheckWildcards(file, suffix string) error {
+	_, wildcardResolvers, err := r.partitionResolvers()
+	if err != nil {
+		return err
+	}
+	retryBackoff := r.options.retryBackoff()
+	resolver, err := massdns.NewWildcardResolver(massdns.Config{
+		Domain:            r.options.Domain,
+		Retries:           r.options.Retries,
+		WildcardResolvers: wildcardResolvers,
+		ResolverFamily:    r.options.resolverFamily(),
+		Backoff:           &retryBackoff,
+	})
+	if err != nil {
+		return err
+	}
+
+	levels := []string{r.options.Domain}
+	if r.options.PrecheckParents {
+		for _, parent := range precheckParents {
+			levels = append(levels, parent+"."+r.options.Domain)
+		}
+	}
+
+	found := make([]bool, len(levels))
+	wg := sizedwaitgroup.New(r.options.WildcardThreads)
+	for i, level := range levels {
+		wg.Add()
+		go func(i int, level string) {
+			defer wg.Done()
+
+			found[i], _ = wildcards.ProbeLevel(resolver, level, wildcards.DefaultProbes)
+		}(i, level)
+	}
+	wg.Wait()
+
+	var wildcardLevels []string
+	for i, level := range levels {
+		if found[i] {
+			wildcardLevels = append(wildcardLevels, level)
+		}
+	}
+	if len(wildcardLevels) == 0 {
+		r.log().Debug().Msgf("Wildcard pre-check: no wildcard found on %d levels of %s\n", len(levels), r.options.Domain)
+		return nil
+	}
+	sort.Strings(wildcardLevels)
+
+	total, wasted, err := countWildcardCandidates(r.tempKey, file, suffix, wildcardLevels)
+	if err != nil {
+		return err
+	}
+	var percentage float64
+	if total > 0 {
+		percentage = float64(wasted) * 100 / float64(total)
+	}
+	for _, level := range wildcardLevels {
+		r.log().Info().Msgf("Wildcard pre-check: random names under %s resolve\n", level)
+	}
+	r.log().Info().Msgf("Wildcard pre-check: about %.1f%% of the queries (%d of %d candidates) would be answered by wildcards, consider -prune-wildcards or another -wildcard-mode, or abort\n", percentage, wasted, total)
+	return nil
+}
+
+// countWildcardCandidates returns the number of candidates of a file,
+// each line followed by a suffix, and the number of them below one of
+// the wildcard levels.
+func countWildcardCandidates(key *tmpcrypt.Key, file, suffix string, levels []string) (int, int, error) {
+	f, err := key.Open(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	var total, wasted int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(scanner.Text())), ".")
+		if line == "" {
+			continue
+		}
+		total++
+		name := line + suffix
+		for _, level := range levels {
+			if strings.HasSuffix(name, "."+level) {
+				wasted++
+				break
+			}
+		}
+	}
+	return total, wasted, scanner.Err()
+}
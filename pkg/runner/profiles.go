@@ -0,0 +1,160 @@
+package runner
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile is a named bundle of options for common configurations
+type Profile struct {
+	Threads         int    `yaml:"threads"`
+	Retries         int    `yaml:"retries"`
+	WildcardThreads int    `yaml:"wildcard-threads"`
+	StrictWildcard  *bool  `yaml:"strict-wildcard"`
+	RecordTypes     string `yaml:"record-types"`
+}
+
+// configFile is the structure of the shuffledns config file
+type configFile struct {
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// defaultProfiles are the profiles available without a config file
+var defaultProfiles = map[string]*Profile{
+	"quick": {
+		Threads:         10000,
+		Retries:         1,
+		WildcardThreads: 50,
+		StrictWildcard:  boolPtr(false),
+		RecordTypes:     "A",
+	},
+	"thorough": {
+		Threads:         5000,
+		Retries:         10,
+		WildcardThreads: 25,
+		StrictWildcard:  boolPtr(true),
+		RecordTypes:     "A,AAAA,CNAME,MX,TXT,NS",
+	},
+	"stealth": {
+		Threads:         50,
+		Retries:         3,
+		WildcardThreads: 2,
+		StrictWildcard:  boolPtr(false),
+		RecordTypes:     "A",
+	},
+	"internal": {
+		Threads:         500,
+		Retries:         3,
+		WildcardThreads: 10,
+		StrictWildcard:  boolPtr(false),
+		RecordTypes:     "A,AAAA,CNAME,SRV",
+	},
+	"low-resource": {
+		Threads:         1000,
+		Retries:         5,
+		WildcardThreads: 5,
+		StrictWildcard:  boolPtr(false),
+		RecordTypes:     "A",
+	},
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
+
+// configDir returns the directory of the config file and of the
+// files kept between runs
+func configDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "shuffledns")
+}
+
+// defaultConfigFile returns the default location of the config file
+func defaultConfigFile() string {
+	dir := configDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "config.yaml")
+}
+
+// loadProfiles returns the default profiles merged with the ones
+// defined in the config file. A missing default config file is not
+// an error, while a missing user specified one is.
+func loadProfiles(file string, explicit bool) (map[string]*Profile, error) {
+	profiles := make(map[string]*Profile, len(defaultProfiles))
+	for name, profile := range defaultProfiles {
+		profiles[name] = profile
+	}
+	if file == "" {
+		return profiles, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return profiles, nil
+		}
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	config := &configFile{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("could not parse config file: %w", err)
+	}
+	for name, profile := range config.Profiles {
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// applyProfile applies the options of the selected profile. Options
+// explicitly set on the command line or through the environment
+// take precedence over the profile.
+func (options *Options) applyProfile() error {
+	if options.Profile == "" {
+		return nil
+	}
+
+	configFile, explicit := options.ConfigFile, options.ConfigFile != ""
+	if !explicit {
+		configFile = defaultConfigFile()
+	}
+	profiles, err := loadProfiles(configFile, explicit)
+	if err != nil {
+		return err
+	}
+	profile, ok := profiles[options.Profile]
+	if !ok {
+		return errors.New("unknown profile " + options.Profile)
+	}
+
+	isSet := func(name string) bool {
+		return isExplicit(flag.CommandLine, name)
+	}
+
+	if profile.Threads > 0 && !isSet("t") {
+		options.Threads = profile.Threads
+	}
+	if profile.Retries > 0 && !isSet("retries") {
+		options.Retries = profile.Retries
+	}
+	if profile.WildcardThreads > 0 && !isSet("wt") {
+		options.WildcardThreads = profile.WildcardThreads
+	}
+	if profile.StrictWildcard != nil && !isSet("strict-wildcard") {
+		options.StrictWildcard = *profile.StrictWildcard
+	}
+	if profile.RecordTypes != "" && !isSet("record-types") {
+		options.RecordTypes = profile.RecordTypes
+	}
+	return nil
+}
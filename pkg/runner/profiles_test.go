@@ -0,0 +1,28 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyProfileRecordTypes(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	require.Nil(t, os.WriteFile(config, []byte("profiles:\n  mail:\n    record-types: A,MX,TXT\n"), 0644), "Could not write config file")
+
+	options := &Options{Profile: "thorough", ConfigFile: config}
+	require.Nil(t, options.applyProfile(), "Could not apply profile")
+	require.Equal(t, "A,AAAA,CNAME,MX,TXT,NS", options.RecordTypes, "Could not apply record types of built-in profile")
+	require.True(t, options.StrictWildcard, "Could not apply strict wildcard of built-in profile")
+
+	options = &Options{Profile: "mail", ConfigFile: config}
+	require.Nil(t, options.applyProfile(), "Could not apply profile")
+	require.Equal(t, "A,MX,TXT", options.RecordTypes, "Could not apply record types of config profile")
+
+	t.Setenv("SHUFFLEDNS_RECORD_TYPES", "A")
+	options = &Options{Profile: "thorough", ConfigFile: config, RecordTypes: "A"}
+	require.Nil(t, options.applyProfile(), "Could not apply profile")
+	require.Equal(t, "A", options.RecordTypes, "Could not keep explicit record types")
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// progressWriter returns the writer receiving the progress events, the
+// progress file and the library writer, along with a function closing
+// the file. It returns a nil writer if no progress was asked.
+func (r *Runner) progressWriter() (io.Writer, func(), error) {
+	var writers []io.Writer
+	closeFile := func() {}
+
+	switch r.options.ProgressJSON {
+	case "":
+	case "-":
+		writers = append(writers, os.Stderr)
+	default:
+		file, err := openReopenableFile(r.options.ProgressJSON)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.progressFile = file
+		writers = append(writers, file)
+		closeFile = func() {
+			file.Close()
+			r.progressFile = nil
+		}
+	}
+	if r.options.ProgressWriter != nil {
+		writers = append(writers, r.options.ProgressWriter)
+	}
+
+	switch len(writers) {
+	case 0:
+		return nil, closeFile, nil
+	case 1:
+		return writers[0], closeFile, nil
+	}
+	return io.MultiWriter(writers...), closeFile, nil
+}
+
+// reopenableFile is a file appended to which can be reopened after
+// being rotated, e.g. by logrotate.
+type reopenableFile struct {
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+}
+
+// openReopenableFile opens a file for appending. Appending lets the
+// file be a named pipe read by a wrapper too.
+func openReopenableFile(path string) (*reopenableFile, error) {
+	f := &reopenableFile{path: path}
+	if err := f.Reopen(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write implements io.Writer
+func (f *reopenableFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.file.Write(p)
+}
+
+// Reopen closes the file and opens its path again
+func (f *reopenableFile) Reopen() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file != nil {
+		f.file.Close()
+	}
+	f.file = file
+	return nil
+}
+
+// Close closes the file
+func (f *reopenableFile) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	return f.file.Close()
+}
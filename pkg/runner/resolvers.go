@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/massdns"
+	"github.com/mohammadanaraki/shuffledns/pkg/resolvers"
+	"github.com/rs/xid"
+)
+
+// resolverFamily returns the address family of the resolvers to use
+func (options *Options) resolverFamily() resolvers.Family {
+	switch {
+	case options.IPv4:
+		return resolvers.IPv4
+	case options.IPv6:
+		return resolvers.IPv6
+	default:
+		return resolvers.AnyFamily
+	}
+}
+
+// parseResolverSplit parses the percentage of the resolvers reserved
+// for the verification into a fraction, 0 if the bulk and verification
+// traffic share them.
+func parseResolverSplit(value string) (float64, error) {
+	if value := strings.TrimSuffix(strings.TrimSpace(value), "%"); value == "" || value == "0" {
+		return 0, nil
+	}
+	return parsePercentage(value)
+}
+
+// resolverSplit returns the fraction of the resolvers reserved for the
+// verification, 0 if the bulk and verification traffic share them.
+func (options *Options) resolverSplit() float64 {
+	split, _ := parseResolverSplit(options.ResolverSplit)
+	return split
+}
+
+// loadResolvers returns the addresses of the resolvers of a file or
+// inline list matching the address family.
+func (r *Runner) loadResolvers(list string) ([]string, error) {
+	family := r.options.resolverFamily()
+
+	addresses, err := resolvers.Load(list)
+	if err != nil {
+		return nil, err
+	}
+	addresses = resolvers.Filter(addresses, family)
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no %s resolvers in %s", family, list)
+	}
+	return addresses, nil
+}
+
+// writeResolvers writes resolver addresses to a temporary file in the
+// format understood by massdns, returning its path.
+func (r *Runner) writeResolvers(addresses []string) (string, error) {
+	prepared := filepath.Join(r.tempDir, xid.New().String())
+	if err := resolvers.WriteFile(prepared, addresses); err != nil {
+		return "", err
+	}
+	return prepared, nil
+}
+
+// partitionResolvers returns the files of the resolvers for the bulk
+// massdns traffic and for the wildcard probes and verification, the
+// latter empty to use the trusted ones. Unless the split is disabled,
+// the two pools never share a resolver so that the rate limits hit by
+// the bulk traffic don't corrupt the verification: the resolvers used
+// for the verification are removed from the bulk ones and, when both
+// come from the same list, a share of it is reserved for verification.
+func (r *Runner) partitionResolvers() (string, string, error) {
+	if r.resolverPools != nil {
+		return r.resolverPools[0], r.resolverPools[1], nil
+	}
+
+	bulk, err := r.loadResolvers(r.options.ResolversFile)
+	if err != nil {
+		return "", "", err
+	}
+	// The mock backend answers on a single address
+	split := r.options.resolverSplit()
+	if r.mockDNS != nil {
+		split = 0
+	}
+
+	var verify []string
+	switch {
+	case r.options.WildcardResolvers != "":
+		if verify, err = r.loadResolvers(r.options.WildcardResolvers); err != nil {
+			return "", "", err
+		}
+	case r.options.Internal:
+		// Public resolvers can't answer for internal zones
+		if bulk, verify = resolvers.Split(bulk, split); verify == nil {
+			verify = bulk
+		}
+	}
+
+	if split > 0 {
+		used := verify
+		if used == nil {
+			used = massdns.TrustedResolvers(r.options.resolverFamily())
+		}
+		remaining := resolvers.Exclude(bulk, used)
+		switch {
+		case len(remaining) == 0:
+			r.log().Info().Msgf("Bulk and verification traffic share the resolvers, the list is too small to split\n")
+		case len(remaining) < len(bulk):
+			r.log().Debug().Msgf("Excluded %d verification resolvers from the bulk ones\n", len(bulk)-len(remaining))
+			bulk = remaining
+		}
+	}
+
+	resolversFile, err := r.writeResolvers(bulk)
+	if err != nil {
+		return "", "", err
+	}
+	var wildcardResolvers string
+	if verify != nil {
+		if wildcardResolvers, err = r.writeResolvers(verify); err != nil {
+			return "", "", err
+		}
+		r.log().Debug().Msgf("Using %d resolvers for bulk traffic and %d for verification\n", len(bulk), len(verify))
+	}
+	r.resolverPools = []string{resolversFile, wildcardResolvers}
+	return resolversFile, wildcardResolvers, nil
+}
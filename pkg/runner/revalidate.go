@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"io"
+	"os"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/resolvers"
+	"github.com/mohammadanaraki/shuffledns/pkg/revalidate"
+	"github.com/mohammadanaraki/shuffledns/pkg/wildcards"
+	"github.com/projectdiscovery/gologger"
+)
+
+// runRevalidate re-resolves the results of a previous run with the
+// trusted resolvers and writes the hosts still alive with their
+// updated records.
+func runRevalidate(options *VerifyOptions) error {
+	servers, err := resolvers.Load(options.TrustedResolver)
+	if err != nil {
+		return err
+	}
+	resolver, err := wildcards.NewResolver("", options.Retries)
+	if err != nil {
+		return err
+	}
+	if err := resolver.AddServersFromList(servers); err != nil {
+		return err
+	}
+
+	var writer io.Writer = os.Stdout
+	if options.Output != "" {
+		file, err := os.Create(options.Output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	stats, err := revalidate.Revalidate(&revalidate.Options{
+		Input:   options.Input,
+		Threads: options.Threads,
+		Resolve: resolver.ResolveRecords,
+	}, writer)
+	if err != nil {
+		return err
+	}
+
+	for _, hostname := range stats.Gone {
+		gologger.Info().Msgf("Host %s is gone\n", hostname)
+	}
+	gologger.Info().Msgf("Revalidated %d hosts: %d alive, %d changed, %d gone, %d unverified\n", stats.Hosts, stats.Alive, stats.Changed, len(stats.Gone), stats.Unverified)
+	return nil
+}
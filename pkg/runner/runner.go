@@ -3,38 +3,135 @@ package runner
 import (
 	"bufio"
 	"errors"
-	"io"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/miekg/dns"
 	"github.com/projectdiscovery/gologger"
+	"github.com/mohammadanaraki/shuffledns/pkg/bundled"
+	"github.com/mohammadanaraki/shuffledns/pkg/cdn"
+	"github.com/mohammadanaraki/shuffledns/pkg/emailsec"
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/mohammadanaraki/shuffledns/pkg/history"
 	"github.com/mohammadanaraki/shuffledns/pkg/massdns"
+	"github.com/mohammadanaraki/shuffledns/pkg/mockdns"
+	"github.com/mohammadanaraki/shuffledns/pkg/mutations"
+	"github.com/mohammadanaraki/shuffledns/pkg/parking"
+	"github.com/mohammadanaraki/shuffledns/pkg/plugins"
+	"github.com/mohammadanaraki/shuffledns/pkg/scope"
+	"github.com/mohammadanaraki/shuffledns/pkg/tmpcrypt"
+	"github.com/mohammadanaraki/shuffledns/pkg/vendors"
+	"github.com/mohammadanaraki/shuffledns/pkg/wildcards"
 	"github.com/rs/xid"
 )
 
+// stealthNSMaxQPS is the maximum queries per second sent to the zones
+// of a nameserver set in stealth mode
+const stealthNSMaxQPS = 5
+
 // Runner is a client for running the enumeration process.
 type Runner struct {
-	tempDir string
-	options *Options
+	tempDir    string
+	runID      string
+	configHash string
+	options    *Options
+
+	// progressFile is the progress file reopened on SIGHUP
+	progressFile *reopenableFile
+	// mockDNS answers the queries when the mock backend is used
+	mockDNS *mockdns.Server
+	// plugins extend the pipeline with the plugins of the user
+	plugins *plugins.Set
+	// tempKey encrypts the files of the temporary directory if asked
+	tempKey *tmpcrypt.Key
+	// massdnsVersion is the version of the massdns binary, empty if
+	// it doesn't show it
+	massdnsVersion string
+	// resolverPools are the files of the bulk and verification
+	// resolvers once partitioned
+	resolverPools []string
+	// results counts the results written by the run for the
+	// completion hook
+	results   int
+	closeOnce sync.Once
+
+	// stopMutex protects the massdns client running and whether the
+	// run was stopped
+	stopMutex sync.Mutex
+	client    *massdns.Client
+	stopped   bool
 }
 
 // New creates a new client for running enumeration process.
 func New(options *Options) (*Runner, error) {
 	runner := &Runner{
-		options: options,
+		runID:      xid.New().String(),
+		configHash: options.configHash(),
+		options:    options,
+	}
+	runner.log().Info().Msgf("Run ID %s (config hash %s)\n", runner.runID, runner.configHash)
+
+	// The options of embedding services may not have been validated
+	if options.OfflineStrict {
+		if err := options.validateOffline(); err != nil {
+			return nil, err
+		}
+		runner.log().Info().Msgf("Strict offline mode: only dns queries leave the host\n")
+	}
+	runner.checkFileLimit()
+
+	// The subdomains of a public suffix belong to unrelated owners
+	if options.Domain != "" && dnsname.IsPublicSuffix(options.Domain) {
+		runner.log().Info().Msgf("Domain %s is a public suffix, its subdomains are registered by unrelated owners\n", options.Domain)
+	}
+
+	runner.applyLowResource()
+
+	// The bundled massdns is extracted once and reused by the next runs
+	if options.UseBundledMassdns && options.MockDNS == "" {
+		dir := configDir()
+		if dir == "" {
+			return nil, errors.New("could not find the config directory to extract massdns to")
+		}
+		path, err := bundled.Extract(dir)
+		if err != nil {
+			return nil, fmt.Errorf("could not extract bundled massdns: %w", err)
+		}
+		options.MassdnsPath = path
+		runner.log().Info().Msgf("Using bundled massdns %s at %s\n", bundled.Version, path)
 	}
 
 	// Setup the massdns binary path if none was give.
 	// If no valid path found, return an error
-	if options.MassdnsPath == "" {
+	if options.MassdnsPath == "" && options.MockDNS == "" {
 		options.MassdnsPath = runner.findBinary()
 		if options.MassdnsPath == "" {
-			return nil, errors.New("could not find massdns binary")
+			return nil, ErrMassdnsNotFound
 		}
-		gologger.Debug().Msgf("Discovered massdns binary at %s\n", options.MassdnsPath)
+		runner.log().Debug().Msgf("Discovered massdns binary at %s\n", options.MassdnsPath)
+	}
+
+	// The flags and the output parsing depend on the massdns version,
+	// a mismatch giving empty results
+	if options.MockDNS == "" {
+		version, err := massdns.DetectVersion(options.MassdnsPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not execute massdns: %w", err)
+		}
+		if err := massdns.CheckVersion(version); err != nil {
+			return nil, err
+		}
+		if version == "" {
+			runner.log().Debug().Msgf("Massdns doesn't show its version, assuming the latest one\n")
+		} else {
+			runner.log().Debug().Msgf("Detected massdns version %s\n", version)
+		}
+		runner.massdnsVersion = version
 	}
 
 	// Create a temporary directory that will be removed at the end
@@ -45,12 +142,105 @@ func New(options *Options) (*Runner, error) {
 	}
 	runner.tempDir = dir
 
+	// The key only lives in memory, the files left behind can't be read
+	if options.EncryptTmp {
+		if runner.tempKey, err = tmpcrypt.New(dir); err != nil {
+			runner.Close()
+			return nil, fmt.Errorf("could not create temporary file key: %w", err)
+		}
+	}
+
+	// Expand the templates of the output file names for the run
+	if err := runner.expandOutputFiles(time.Now()); err != nil {
+		runner.Close()
+		return nil, invalidOption("%w", err)
+	}
+
+	if options.Plugins != "" {
+		if err := runner.loadPlugins(); err != nil {
+			runner.Close()
+			return nil, err
+		}
+	}
+
+	if options.MockDNS != "" {
+		if err := runner.startMockDNS(); err != nil {
+			runner.Close()
+			return nil, err
+		}
+	}
 	return runner, nil
 }
 
-// Close releases all the resources and cleans up
+// loadPlugins loads the plugins extending the pipeline
+func (r *Runner) loadPlugins() error {
+	var files []string
+	for _, file := range strings.Split(r.options.Plugins, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	var err error
+	r.plugins, err = plugins.Load(files)
+	if err != nil {
+		return err
+	}
+	r.log().Info().Msgf("Loaded plugins %s\n", strings.Join(r.plugins.Names(), ", "))
+	return nil
+}
+
+// startMockDNS starts the mock backend answering from the fixture,
+// which replaces the resolvers and the wildcard resolvers.
+func (r *Runner) startMockDNS() error {
+	zone, err := mockdns.Load(r.options.MockDNS)
+	if err != nil {
+		return fmt.Errorf("could not load mock dns fixture: %w", err)
+	}
+	r.mockDNS, err = mockdns.Start(zone)
+	if err != nil {
+		return fmt.Errorf("could not start mock dns server: %w", err)
+	}
+	r.options.ResolversFile, r.options.WildcardResolvers = r.mockDNS.Addr(), r.mockDNS.Addr()
+	r.log().Info().Msgf("Answering queries from mock dns fixture %s on %s\n", r.options.MockDNS, r.mockDNS.Addr())
+	return nil
+}
+
+// log returns the logger receiving the messages of the runner
+func (r *Runner) log() *gologger.Logger {
+	if r.options.Logger != nil {
+		return r.options.Logger
+	}
+	return gologger.DefaultLogger
+}
+
+// Stop stops the run from another goroutine, e.g. on an interrupt: the
+// massdns execution running is interrupted and RunEnumeration returns
+// once the stage running is done, without writing the results, so that
+// Close can clean up the temporary files.
+func (r *Runner) Stop() {
+	r.stopMutex.Lock()
+	defer r.stopMutex.Unlock()
+	r.stopped = true
+	if r.client != nil {
+		r.client.Stop()
+	}
+}
+
+// Close releases all the resources and cleans up, shredding the
+// temporary files if asked. It can be called more than once.
 func (r *Runner) Close() {
-	os.RemoveAll(r.tempDir)
+	r.closeOnce.Do(func() {
+		if r.mockDNS != nil {
+			r.mockDNS.Close()
+		}
+		if !r.options.ShredTmp {
+			os.RemoveAll(r.tempDir)
+			return
+		}
+		if err := shredDir(r.tempDir); err != nil {
+			r.log().Error().Msgf("Could not shred temporary files: %s\n", err)
+		}
+	})
 }
 
 // findBinary searches for massdns binary in various pre-defined paths
@@ -72,64 +262,84 @@ func (r *Runner) findBinary() string {
 
 // RunEnumeration sets up the input layer for giving input to massdns
 // binary and runs the actual enumeration
-func (r *Runner) RunEnumeration() {
+func (r *Runner) RunEnumeration() error {
+	// The temporary files are shredded before crashing
+	if r.options.ShredTmp {
+		defer func() {
+			if err := recover(); err != nil {
+				r.Close()
+				panic(err)
+			}
+		}()
+	}
+
+	// The completion hook runs for the runs failing before resolving too
+	err := r.enumerate()
+	r.completeHook(r.results, err)
+	return err
+}
+
+// enumerate runs the enumeration of the input given by the user
+func (r *Runner) enumerate() error {
 	// Handle a list of subdomains to resolve
 	if r.options.SubdomainsList != "" {
-		r.processSubdomains()
-		return
+		return r.processSubdomains()
 	}
 
 	// Handle a domain to bruteforce with wordlist
 	if r.options.Wordlist != "" {
-		r.processDomain()
-		return
+		return r.processDomain()
 	}
 
 	// Handle stdin input
 	if r.options.Stdin {
 		// Is the stdin input a domain for bruteforce
 		if r.options.Wordlist != "" {
-			r.processDomain()
-			return
+			return r.processDomain()
 		}
 		// Write the input from stdin to a file and resolve it.
-		r.processSubdomains()
-		return
+		return r.processSubdomains()
 	}
 
 	// Handle only wildcard filtering
 	if r.options.MassdnsRaw != "" {
-		r.processSubdomains()
-		return
+		return r.processSubdomains()
 	}
+	return ErrMissingInput
 }
 
 // processDomain processes the bruteforce for a domain using a wordlist
-func (r *Runner) processDomain() {
+func (r *Runner) processDomain() error {
+	// Warn about the wildcards answering the candidates before
+	// generating and resolving them
+	if !r.options.NoWildcardPrecheck {
+		if err := r.precheckWildcards(r.options.Wordlist, "."+r.options.Domain); err != nil {
+			return fmt.Errorf("could not check wildcards: %w", err)
+		}
+	}
+
 	resolveFile := filepath.Join(r.tempDir, xid.New().String())
-	file, err := os.Create(resolveFile)
+	file, err := r.tempKey.Create(resolveFile)
 	if err != nil {
-		gologger.Error().Msgf("Could not create bruteforce list (%s): %s\n", r.tempDir, err)
-		return
+		return fmt.Errorf("could not create bruteforce list (%s): %w", r.tempDir, err)
 	}
 	writer := bufio.NewWriter(file)
 
 	// Read the input wordlist for bruteforce generation
 	inputFile, err := os.Open(r.options.Wordlist)
 	if err != nil {
-		gologger.Error().Msgf("Could not read bruteforce wordlist (%s): %s\n", r.options.Wordlist, err)
 		file.Close()
-		return
+		return fmt.Errorf("could not read bruteforce wordlist (%s): %w", r.options.Wordlist, err)
 	}
 
-	gologger.Info().Msgf("Started generating bruteforce permutation\n")
+	r.log().Info().Msgf("Started generating bruteforce permutation\n")
 
 	now := time.Now()
 	// Create permutation for domain with wordlist
 	scanner := bufio.NewScanner(inputFile)
 	for scanner.Scan() {
 		// RFC4343 - case insensitive domain
-		text := strings.ToLower(scanner.Text())
+		text := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(scanner.Text())), ".")
 		if text == "" {
 			continue
 		}
@@ -139,65 +349,418 @@ func (r *Runner) processDomain() {
 	inputFile.Close()
 	file.Close()
 
-	gologger.Info().Msgf("Generating permutations took %s\n", time.Since(now))
+	r.log().Info().Msgf("Generating permutations took %s\n", time.Since(now))
+
+	// Resolve the most likely candidates first, ordering the bruteforce
+	// words only when the generated candidates are resolved before them
+	if r.options.OrderWords && r.options.GeneratedFirst {
+		if err := r.orderCandidates(resolveFile); err != nil {
+			return fmt.Errorf("could not order bruteforce list: %w", err)
+		}
+	}
+
+	// Add the candidates generated from the known subdomains
+	if r.options.GenerateMarkov > 0 || r.options.Dnsgen != "" {
+		if resolveFile, err = r.addGeneratedCandidates(resolveFile, false); err != nil {
+			return fmt.Errorf("could not generate candidates: %w", err)
+		}
+	}
+
+	if r.options.OrderWords && !r.options.GeneratedFirst {
+		if err := r.orderCandidates(resolveFile); err != nil {
+			return fmt.Errorf("could not order bruteforce list: %w", err)
+		}
+	}
 
 	// Run the actual massdns enumeration process
-	r.runMassdns(resolveFile)
+	return r.runMassdns(singleChunk(resolveFile))
 }
 
 // processSubdomain processes the resolving for a list of subdomains
-func (r *Runner) processSubdomains() {
-	var resolveFile string
-
-	// If there is stdin, write the resolution list to the file
+func (r *Runner) processSubdomains() error {
+	// The names of stdin are resolved in chunks while they are read
 	if r.options.Stdin && r.options.SubdomainsList == "" {
-		resolveFile = filepath.Join(r.tempDir, xid.New().String())
-		file, err := os.Create(resolveFile)
-		if err != nil {
-			gologger.Error().Msgf("Could not create resolution list (%s): %s\n", r.tempDir, err)
-			return
+		return r.streamStdin(os.Stdin)
+	}
+	// The names of a list still being written are resolved in chunks too
+	if r.options.Follow {
+		return r.followList()
+	}
+	// Use the file if user has provided one
+	resolveFile, err := r.prepareList(r.options.SubdomainsList, true)
+	if err != nil {
+		return err
+	}
+	return r.runMassdns(singleChunk(resolveFile))
+}
+
+// singleChunk returns the chunks of an input resolved at once
+func singleChunk(inputFile string) massdns.ChunkFunc {
+	return func() (string, bool, error) {
+		return inputFile, false, nil
+	}
+}
+
+// prepareList qualifies and extends a list of subdomains before it's
+// resolved, warning about the wildcards answering them for the first
+// chunk of a stream.
+func (r *Runner) prepareList(resolveFile string, first bool) (string, error) {
+	// Qualify the single label names of internal hosts
+	if r.options.Internal && r.options.MassdnsRaw == "" {
+		if domains := r.searchDomains(); len(domains) > 0 {
+			var err error
+			if resolveFile, err = r.qualifyNames(resolveFile, domains); err != nil {
+				return "", fmt.Errorf("could not qualify names: %w", err)
+			}
 		}
-		_, _ = io.Copy(file, os.Stdin)
-		file.Close()
-	} else {
-		// Use the file if user has provided one
-		resolveFile = r.options.SubdomainsList
 	}
 
-	// Run the actual massdns enumeration process
-	r.runMassdns(resolveFile)
+	// Warn about the wildcards answering the names of the domain
+	if first && !r.options.NoWildcardPrecheck && r.options.Domain != "" && r.options.MassdnsRaw == "" {
+		if err := r.precheckWildcards(resolveFile, ""); err != nil {
+			return "", fmt.Errorf("could not check wildcards: %w", err)
+		}
+	}
+
+	// Add the candidates generated from the known subdomains
+	if r.options.GenerateMarkov > 0 || r.options.Dnsgen != "" {
+		var err error
+		if resolveFile, err = r.addGeneratedCandidates(resolveFile, true); err != nil {
+			return "", fmt.Errorf("could not generate candidates: %w", err)
+		}
+	}
+	return resolveFile, nil
 }
 
-// runMassdns runs the massdns tool on the list of inputs
-func (r *Runner) runMassdns(inputFile string) {
+// runMassdns runs the massdns tool on the chunks of the list of inputs
+func (r *Runner) runMassdns(next massdns.ChunkFunc) error {
+	fields, err := massdns.ParseFields(r.options.Fields)
+	if err != nil {
+		return fmt.Errorf("could not parse output fields: %w", err)
+	}
+
+	// Convert the bandwidth cap to a query rate for massdns
+	var maxQPS int
+	if r.options.MaxBandwidth != "" {
+		bandwidth, err := parseBandwidth(r.options.MaxBandwidth)
+		if err != nil {
+			return fmt.Errorf("could not parse bandwidth: %w", err)
+		}
+		maxQPS = massdns.QPSFromBandwidth(bandwidth)
+	}
+	if r.options.MaxQPS > 0 && (maxQPS == 0 || r.options.MaxQPS < maxQPS) {
+		maxQPS = r.options.MaxQPS
+	}
+	// The rate per authoritative nameserver set is kept very low in
+	// stealth mode to not be noticed by the servers of the targets
+	nsMaxQPS := r.options.NSMaxQPS
+	if r.options.Stealth && (nsMaxQPS == 0 || nsMaxQPS > stealthNSMaxQPS) {
+		nsMaxQPS = stealthNSMaxQPS
+	}
+
+	// Load the known answers used to detect lying resolvers
+	knownAnswers := massdns.DefaultKnownAnswers
+	if r.options.KnownAnswersFile != "" {
+		knownAnswers, err = massdns.ReadKnownAnswers(r.options.KnownAnswersFile)
+		if err != nil {
+			return fmt.Errorf("could not read known answers: %w", err)
+		}
+	}
+
+	// Load the ips whose results have to be re-verified
+	var suspiciousIPs map[string]struct{}
+	if r.options.SuspiciousIPs != "" {
+		suspiciousIPs, err = massdns.ReadIPList(r.options.SuspiciousIPs)
+		if err != nil {
+			return fmt.Errorf("could not read suspicious ips: %w", err)
+		}
+	}
+
+	// Load the known sinkhole ips unless the user opted out
+	var sinkholes *massdns.Sinkholes
+	if !r.options.NoSinkholeFilter {
+		sinkholes, err = massdns.LoadSinkholes(r.options.SinkholesFile, r.options.SinkholeLoopback)
+		if err != nil {
+			return fmt.Errorf("could not load sinkholes: %w", err)
+		}
+	}
+
+	// Detect the parked results if they are flagged or dropped
+	var parkingFingerprints *parking.Fingerprints
+	if r.options.Json || r.options.ExcludeParked {
+		parkingFingerprints, err = parking.New(r.options.ParkingFile)
+		if err != nil {
+			return fmt.Errorf("could not load parking fingerprints: %w", err)
+		}
+	}
+
+	// Load the rules for the names in scope of the enumeration
+	var targetScope *scope.Scope
+	if r.options.ScopeFile != "" {
+		targetScope, err = scope.Load(r.options.ScopeFile)
+		if err != nil {
+			return fmt.Errorf("could not load scope: %w", err)
+		}
+	}
+
+	// The name regexes are applied as additional scope rules
+	if r.options.MatchRegex != "" || r.options.FilterRegex != "" {
+		if targetScope == nil {
+			targetScope = &scope.Scope{}
+		}
+		var include, exclude []string
+		if r.options.MatchRegex != "" {
+			include = append(include, r.options.MatchRegex)
+		}
+		if r.options.FilterRegex != "" {
+			exclude = append(exclude, r.options.FilterRegex)
+		}
+		if err := targetScope.AddRules(include, exclude); err != nil {
+			return fmt.Errorf("could not add name regexes: %w", err)
+		}
+	}
+
+	retryBackoff := r.options.retryBackoff()
+
+	// Pass the resolvers of the requested family to massdns
+	resolversFile, wildcardResolvers, err := r.partitionResolvers()
+	if err != nil {
+		return fmt.Errorf("could not prepare resolvers: %w", err)
+	}
+
+	var verifySample float64
+	if r.options.VerifySample != "" {
+		verifySample, err = parsePercentage(r.options.VerifySample)
+		if err != nil {
+			return fmt.Errorf("could not parse verification sample: %w", err)
+		}
+	}
+
+	var minHitRate float64
+	if r.options.MinHitRate != "" {
+		minHitRate, err = parsePercentage(r.options.MinHitRate)
+		if err != nil {
+			return fmt.Errorf("could not parse minimum hit rate: %w", err)
+		}
+	}
+
+	wildcardStrategy, err := wildcards.ParseStrategy(r.options.WildcardMode)
+	if err != nil {
+		return fmt.Errorf("could not parse wildcard mode: %w", err)
+	}
+
+	// The A records are resolved by massdns, the records of the other
+	// types being collected for the json output only
+	var recordTypes []uint16
+	if r.options.Json {
+		types, err := wildcards.ParseRecordTypes(r.options.RecordTypes)
+		if err != nil {
+			return fmt.Errorf("could not parse record types: %w", err)
+		}
+		for _, qtype := range types {
+			if qtype != dns.TypeA {
+				recordTypes = append(recordTypes, qtype)
+			}
+		}
+	}
+
+	// Generate the variations of the names while resolving them
+	var mutator *mutations.Mutator
+	if r.options.Prefixes != "" || r.options.Suffixes != "" {
+		mutator = mutations.New(r.options.Prefixes, r.options.Suffixes, r.options.Separators)
+	}
+
+	// Load the cdn ranges if the results have to be tagged or reported
+	var cdnChecker *cdn.Checker
+	needsCDN := r.options.CollapseCDN || r.options.Report != "" || r.options.ReportMarkdown != ""
+	needsVendors := false
+	for _, field := range fields {
+		switch field {
+		case massdns.FieldCDN:
+			needsCDN = true
+		case massdns.FieldVendor:
+			needsVendors = true
+		}
+	}
+	if needsCDN {
+		cdnChecker, err = cdn.New(r.options.CDNRanges)
+		if err != nil {
+			return fmt.Errorf("could not load cdn ranges: %w", err)
+		}
+	}
+
+	// Load the vendor fingerprints if the results have to be tagged
+	var vendorFingerprints *vendors.Fingerprints
+	if needsVendors {
+		vendorFingerprints, err = vendors.New(r.options.VendorFingerprints)
+		if err != nil {
+			return fmt.Errorf("could not load vendor fingerprints: %w", err)
+		}
+	}
+
+	// Open the progress events stream if the user asked for one
+	progress, closeProgress, err := r.progressWriter()
+	if err != nil {
+		return fmt.Errorf("could not open progress file: %w", err)
+	}
+	defer closeProgress()
+
+	// Open the history datastore if the user asked for one
+	var historyDB *history.DB
+	var changes []*history.Change
+	if r.options.StoreFile != "" {
+		historyDB, err = history.Open(r.options.StoreFile)
+		if err != nil {
+			return fmt.Errorf("could not open history store: %w", err)
+		}
+	}
+
+	// Run the hooks of the user on the validated results
+	onResult, waitHooks := r.resultHook()
+
 	massdns, err := massdns.New(massdns.Config{
 		Domain:             r.options.Domain,
 		Retries:            r.options.Retries,
 		MassdnsPath:        r.options.MassdnsPath,
+		MassdnsVersion:     r.massdnsVersion,
+		MockDNS:            r.mockDNS,
+		Plugins:            r.plugins,
 		Threads:            r.options.Threads,
+		HangTimeout:        r.options.MassdnsHangTimeout,
+		MaxRestarts:        r.options.MassdnsRestarts,
+		MaxQPS:             maxQPS,
+		SpreadDuration:     r.options.StealthDuration,
+		Jitter:             r.options.Stealth,
+		AdaptiveRate:       r.options.AdaptiveRate,
+		NSMaxQPS:           nsMaxQPS,
 		WildcardsThreads:   r.options.WildcardThreads,
-		InputFile:          inputFile,
-		ResolversFile:      r.options.ResolversFile,
+		ResolversFile:      resolversFile,
+		WildcardResolvers:  wildcardResolvers,
+		ResolverFamily:     r.options.resolverFamily(),
+		Backoff:            &retryBackoff,
+		VerifySample:       verifySample,
+		ResolverAgreement:  r.options.ResolverAgreement,
 		TempDir:            r.tempDir,
+		TempKey:            r.tempKey,
+		RemoveTemp:         r.removeTemp,
 		OutputFile:         r.options.Output,
+		OutputCompress:     r.options.OutputCompress,
+		OutputAppendUnique: r.options.OutputAppendUnique,
+		Sorted:             r.options.Sorted,
+		GroupByDomain:      r.options.GroupByDomain,
+		HostsFile:          r.options.OutputHosts,
+		URLsFile:           r.options.OutputURLs,
+		BurpScopeFile:      r.options.OutputBurp,
+		TargetsFile:        r.options.OutputTargets,
+		IPMapFile:          r.options.OutputIPMap,
+		IPClustersFile:     r.options.IPClusters,
+		ClusterASN:         r.options.ClusterASN,
+		EmailPosture:       r.options.EmailPosture,
+		DKIMSelectors:      emailsec.ParseSelectors(r.options.DKIMSelectors),
+		TargetsHostnames:   r.options.TargetsHostnames,
+		ReportFile:         r.options.Report,
+		ReportMarkdownFile: r.options.ReportMarkdown,
 		Json:               r.options.Json,
+		CSV:                r.options.CSV,
+		Fields:             fields,
+		CDN:                cdnChecker,
+		Vendors:            vendorFingerprints,
+		CollapseCDN:        r.options.CollapseCDN,
+		PTREnrich:          r.options.PTREnrich,
+		AnyRecords:         r.options.AnyRecords,
+		RecordTypes:        recordTypes,
+		Mutator:            mutator,
+		Wordlist:           r.options.Wordlist,
+		Scope:              targetScope,
+		CNAMEDepth:         r.options.CNAMEDepth,
+		TLSIterations:      r.options.TLSSans,
+		RunID:              r.runID,
+		ConfigHash:         r.configHash,
 		MassdnsRaw:         r.options.MassdnsRaw,
 		StrictWildcard:     r.options.StrictWildcard,
+		PruneWildcards:     r.options.PruneWildcards,
+		NoDedup:            r.options.NoDedup,
+		DiskDedup:          r.options.LowResource,
+		SortChunkSize:      r.sortChunkSize(),
+		MinHitRate:         minHitRate,
+		HitRateWindow:      r.options.HitRateWindow,
 		WildcardOutputFile: r.options.WildcardOutputFile,
+		WildcardStrategy:   wildcardStrategy,
+		ResolverStatsFile:  r.options.ResolverStats,
+		Canaries:           r.options.Canaries,
+		KnownAnswers:       knownAnswers,
+		KnownAnswersEvery:  r.options.KnownAnswers,
+		SuspiciousIPs:      suspiciousIPs,
+		Sinkholes:          sinkholes,
+		FlagSinkholes:      r.options.FlagSinkholes,
+		Parking:            parkingFingerprints,
+		ExcludeParked:      r.options.ExcludeParked,
+		ExcludePrivate:     r.options.ExcludePrivate,
+		OnlyPrivate:        r.options.OnlyPrivate,
+		MinDepth:           r.options.MinDepth,
+		MaxDepth:           r.options.MaxDepth,
+		History:            historyDB,
+		Logger:             r.log(),
+		ResultsWriter:      r.options.ResultsWriter,
+		Progress:           progress,
+		ProgressInterval:   r.options.ProgressInterval,
+		OnChange: func(change *history.Change) {
+			changes = append(changes, change)
+		},
+		OnResult: func(result *massdns.Result) {
+			r.results++
+			if onResult != nil {
+				onResult(result)
+			}
+		},
 	})
 	if err != nil {
-		gologger.Error().Msgf("Could not create massdns client: %s\n", err)
-		return
+		return fmt.Errorf("could not create massdns client: %w", err)
 	}
 
-	err = massdns.Process()
-	if err != nil {
-		gologger.Error().Msgf("Could not run massdns: %s\n", err)
+	// A run stopped meanwhile doesn't start resolving
+	r.stopMutex.Lock()
+	r.client = massdns
+	if r.stopped {
+		massdns.Stop()
+	}
+	r.stopMutex.Unlock()
+
+	// The results found before a failure are still recorded
+	processErr := massdns.ProcessChunks(next)
+	waitHooks()
+
+	if historyDB != nil {
+		if err := historyDB.Save(); err != nil {
+			r.log().Error().Msgf("Could not save history store: %s\n", err)
+		}
+		r.notifyChanges(changes)
 	}
 
 	if r.options.WildcardOutputFile != "" {
 		_ = massdns.DumpWildcardsToFile(r.options.WildcardOutputFile)
 	}
+	if r.options.WildcardOutputJSON != "" {
+		if err := massdns.DumpWildcardsJSONToFile(r.options.WildcardOutputJSON); err != nil {
+			r.log().Error().Msgf("Could not write wildcards json: %s\n", err)
+		}
+	}
+	if r.options.WildcardWriter != nil {
+		if err := massdns.DumpWildcards(r.options.WildcardWriter); err != nil {
+			r.log().Error().Msgf("Could not write wildcards: %s\n", err)
+		}
+	}
 
-	gologger.Info().Msgf("Finished resolving. Hack the Planet!\n")
+	// The manifest covers the files written before a failure too
+	if r.options.Manifest != "" {
+		if err := r.writeManifest(); err != nil {
+			r.log().Error().Msgf("Could not write manifest: %s\n", err)
+		}
+	}
+
+	if processErr != nil {
+		return fmt.Errorf("could not run massdns: %w", processErr)
+	}
+	r.log().Info().Msgf("Finished resolving. Hack the Planet!\n")
+	return nil
 }
@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// shredFile overwrites a file with random data before removing it
+func shredFile(file string) error {
+	f, err := os.OpenFile(file, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := io.CopyN(f, rand.Reader, info.Size()); err != nil {
+		f.Close()
+		return err
+	}
+	// The random data has to reach the disk before the file is removed
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(file)
+}
+
+// shredDir shreds the files of a directory before removing it, going
+// on with the other files when one can't be shredded.
+func shredDir(dir string) error {
+	var shredErr error
+	walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if err := shredFile(path); err != nil && !os.IsNotExist(err) && shredErr == nil {
+			shredErr = err
+		}
+		return nil
+	})
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if walkErr != nil {
+		return walkErr
+	}
+	return shredErr
+}
+
+// removeTemp removes a temporary file once it's no longer needed,
+// shredding it first if asked
+func (r *Runner) removeTemp(file string) error {
+	if r.options.ShredTmp {
+		return shredFile(file)
+	}
+	return os.Remove(file)
+}
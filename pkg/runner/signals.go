@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/massdns"
+)
+
+// DumpState logs the progress of the enumeration and the state of the
+// process, with the stacks of the goroutines in debug mode. It can be
+// called from any goroutine, e.g. when the process receives SIGUSR1.
+func (r *Runner) DumpState() {
+	r.stopMutex.Lock()
+	client := r.client
+	r.stopMutex.Unlock()
+
+	state := massdns.ProgressEvent{}
+	if client != nil {
+		state = client.State()
+	}
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+
+	stage := state.Stage
+	if stage == "" {
+		stage = "starting"
+	}
+	r.log().Info().Msgf("State: run %s, stage %s, %d/%d (%.2f%%), %d results, elapsed %s\n", r.runID, stage, state.Done, state.Total, state.Percent, state.Results, time.Duration(state.Elapsed*float64(time.Second)).Round(time.Second))
+	r.log().Info().Msgf("Process: %d goroutines, %d MB allocated, %d MB obtained from the system\n", runtime.NumGoroutine(), memory.Alloc/1024/1024, memory.Sys/1024/1024)
+
+	var stacks strings.Builder
+	if err := pprof.Lookup("goroutine").WriteTo(&stacks, 1); err == nil {
+		r.log().Debug().Msgf("Goroutines:\n%s\n", stacks.String())
+	}
+}
+
+// ReopenFiles reopens the files written during the run, for them to be
+// rotated, e.g. when the process receives SIGHUP. The output files are
+// created once the run is finished.
+func (r *Runner) ReopenFiles() {
+	if r.progressFile == nil {
+		return
+	}
+	if err := r.progressFile.Reopen(); err != nil {
+		r.log().Error().Msgf("Could not reopen progress file: %s\n", err)
+		return
+	}
+	r.log().Info().Msgf("Reopened progress file %s\n", r.options.ProgressJSON)
+}
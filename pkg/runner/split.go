@@ -0,0 +1,62 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/split"
+	"github.com/projectdiscovery/gologger"
+)
+
+// SplitOptions contains the configuration options for the split subcommand
+type SplitOptions struct {
+	Wordlist  string // Wordlist is the wordlist to split in chunks
+	Chunks    int    // Chunks is the number of chunks to create
+	Directory string // Directory is the directory to write the chunks to
+	Domain    string // Domain is the domain to bruteforce written in the chunk specs
+	NoColor   bool   // NoColor disables the colored output
+}
+
+// ParseSplitOptions parses the command line flags for the split subcommand
+func ParseSplitOptions(args []string) (*SplitOptions, error) {
+	options := &SplitOptions{}
+
+	flagSet := flag.NewFlagSet("split", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		gologger.Print().Msgf("Usage: shuffledns split -w wordlist.txt -n 20 -o chunks/ [flags]\n")
+		flagSet.PrintDefaults()
+	}
+	flagSet.StringVar(&options.Wordlist, "w", "", "File containing words to split in chunks")
+	flagSet.IntVar(&options.Chunks, "n", 10, "Number of chunks to create")
+	flagSet.StringVar(&options.Directory, "o", "", "Directory to write the chunks to")
+	flagSet.StringVar(&options.Domain, "d", "", "Domain to bruteforce written in the chunk specs (optional)")
+	flagSet.BoolVar(&options.NoColor, "nC", false, "Don't Use colors in output")
+
+	if _, err := parseInterspersed(flagSet, args); err != nil {
+		return nil, err
+	}
+
+	(&Options{NoColor: options.NoColor}).configureOutput()
+
+	if options.Wordlist == "" || options.Directory == "" {
+		flagSet.Usage()
+		return nil, fmt.Errorf("%w: no wordlist or output directory provided", ErrUsage)
+	}
+	return options, nil
+}
+
+// RunSplit splits a wordlist in chunks enumerable independently
+func RunSplit(options *SplitOptions) error {
+	manifest, err := split.Split(options.Wordlist, options.Chunks, options.Directory, options.Domain)
+	if err != nil {
+		return err
+	}
+
+	gologger.Info().Msgf("Wrote %d chunks and %s to %s\n", len(manifest.Chunks), split.ManifestFile, options.Directory)
+	gologger.Info().Msgf("Run each chunk with its args from the manifest, e.g.: shuffledns %s -r resolvers.txt\n", strings.Join(manifest.Chunks[0].Args, " "))
+	gologger.Info().Msgf("To filter wildcards consistently, dump them in every chunk with -wildcard-output-file and pass their union to merge with -wildcard-cache\n")
+	gologger.Info().Msgf("Merge the chunk outputs with: shuffledns %s -o %s\n", strings.Join(manifest.Merge, " "), filepath.Join(options.Directory, "merged.ndjson"))
+	return nil
+}
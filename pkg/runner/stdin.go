@@ -0,0 +1,139 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// stdinChunk is a chunk of the names read from stdin or a followed list
+type stdinChunk struct {
+	file  string
+	names int
+}
+
+// lineSource sends the lines of an input to a channel until the input
+// is exhausted
+type lineSource func(lines chan<- string) error
+
+// readerLines returns the source of the lines of a reader
+func readerLines(reader io.Reader) lineSource {
+	return func(lines chan<- string) error {
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		return scanner.Err()
+	}
+}
+
+// streamStdin reads the names of stdin in chunks, resolving each chunk
+// as soon as it's complete.
+func (r *Runner) streamStdin(reader io.Reader) error {
+	if err := r.streamNames(readerLines(reader)); err != nil {
+		return fmt.Errorf("could not read stdin: %w", err)
+	}
+	return nil
+}
+
+// streamNames reads the names of an input in chunks of at most
+// StdinChunk names, resolving each chunk as soon as it's full or the
+// flush interval elapsed since its first name, so that the names of an
+// unbounded pipeline are resolved while they are produced. The chunks
+// are resolved by the same massdns client, which writes the results of
+// each chunk once resolved, and the files of a chunk are removed once
+// the next one is requested. The whole input is resolved at once when
+// StdinChunk is 0.
+func (r *Runner) streamNames(source lineSource) error {
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		err := source(lines)
+		close(lines)
+		readErr <- err
+	}()
+
+	var index int
+	var chunked bool
+	var previous []string
+	next := func() (string, bool, error) {
+		// The previous chunk is resolved once the next one is requested
+		for _, file := range previous {
+			if err := r.removeTemp(file); err != nil && !os.IsNotExist(err) {
+				r.log().Warning().Msgf("Could not remove temporary file %s: %s\n", file, err)
+			}
+		}
+		previous = nil
+
+		chunk, more, err := r.readStdinChunk(lines, r.options.StdinChunk)
+		if err != nil {
+			return "", false, err
+		}
+		if !more {
+			// The error of the source is only known once it's exhausted
+			if err := <-readErr; err != nil {
+				return "", false, err
+			}
+		}
+		chunked = chunked || more
+		if chunked && chunk.names > 0 {
+			r.log().Info().Msgf("Resolving chunk %d of the input (%d names)\n", index+1, chunk.names)
+		}
+		file, err := r.prepareList(chunk.file, index == 0)
+		index++
+		previous = append(previous, chunk.file)
+		if file != chunk.file {
+			previous = append(previous, file)
+		}
+		return file, more, err
+	}
+	err := r.runMassdns(next)
+	for _, file := range previous {
+		_ = r.removeTemp(file)
+	}
+	return err
+}
+
+// readStdinChunk writes the next chunk of at most size names to a
+// temporary file, all of them if size is 0, returning false once the
+// input is exhausted.
+func (r *Runner) readStdinChunk(lines <-chan string, size int) (*stdinChunk, bool, error) {
+	chunk := &stdinChunk{file: filepath.Join(r.tempDir, xid.New().String())}
+	file, err := r.tempKey.Create(chunk.file)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not create resolution list (%s): %w", r.tempDir, err)
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+
+	// The flush timer starts with the first name of the chunk
+	var flush <-chan time.Time
+	more := true
+	for more && (size == 0 || chunk.names < size) {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				more = false
+				continue
+			}
+			if line == "" {
+				continue
+			}
+			_, _ = writer.WriteString(line + "\n")
+			chunk.names++
+			if chunk.names == 1 && size > 0 && r.options.StdinFlush > 0 {
+				timer := time.NewTimer(r.options.StdinFlush)
+				defer timer.Stop()
+				flush = timer.C
+			}
+		case <-flush:
+			return chunk, true, writer.Flush()
+		}
+	}
+	return chunk, more, writer.Flush()
+}
@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockOptions returns the options of a run answered by a mock dns
+// fixture, writing its outputs to a temporary directory.
+func mockOptions(t *testing.T, fixture string) (*Options, string) {
+	dir := t.TempDir()
+	zone := filepath.Join(dir, "zone.txt")
+	require.Nil(t, os.WriteFile(zone, []byte(fixture), 0644), "Could not write fixture")
+
+	return &Options{
+		Domain:             "example.com",
+		MockDNS:            zone,
+		Directory:          dir,
+		Retries:            1,
+		Threads:            10,
+		WildcardThreads:    5,
+		NoWildcardPrecheck: true,
+		Output:             filepath.Join(dir, "output.txt"),
+	}, dir
+}
+
+func TestStreamStdinChunks(t *testing.T) {
+	options, dir := mockOptions(t, `www.example.com A 192.0.2.1
+api.example.com A 192.0.2.2
+mail.example.com A 192.0.2.3
+`)
+	options.Stdin = true
+	options.StdinChunk = 2
+	options.Sorted = "alpha"
+	options.OutputHosts = filepath.Join(dir, "hosts.txt")
+	options.ReportMarkdown = filepath.Join(dir, "report.md")
+
+	runner, err := New(options)
+	require.Nil(t, err, "Could not create runner")
+	defer runner.Close()
+
+	// The results of each chunk are sorted and appended once resolved,
+	// the duplicate of the last chunk being skipped
+	input := "www.example.com\napi.example.com\nnope.example.com\nmail.example.com\nwww.example.com\n"
+	require.Nil(t, runner.streamStdin(strings.NewReader(input)), "Could not resolve stdin chunks")
+
+	output, err := os.ReadFile(options.Output)
+	require.Nil(t, err, "Could not read output")
+	require.Equal(t, "api.example.com\nwww.example.com\nmail.example.com\n", string(output), "Could not write the sorted results of each chunk")
+
+	// The files of the chunks are removed once resolved
+	temps, err := os.ReadDir(runner.tempDir)
+	require.Nil(t, err, "Could not read temporary directory")
+	for _, temp := range temps {
+		data, err := os.ReadFile(filepath.Join(runner.tempDir, temp.Name()))
+		require.Nil(t, err, "Could not read temporary file")
+		require.NotContains(t, string(data), "example.com", "Could not remove the files of the chunks")
+	}
+
+	hosts, err := os.ReadFile(options.OutputHosts)
+	require.Nil(t, err, "Could not read hosts export")
+	for _, line := range []string{"192.0.2.1 www.example.com", "192.0.2.2 api.example.com", "192.0.2.3 mail.example.com"} {
+		require.Contains(t, string(hosts), line, "Could not export the hosts of all the chunks")
+	}
+
+	report, err := os.ReadFile(options.ReportMarkdown)
+	require.Nil(t, err, "Could not read report")
+	require.Contains(t, string(report), "| 3 | 3 | 0 | 0 |", "Could not report the hosts of all the chunks")
+}
+
+func TestStreamStdinWritesChunks(t *testing.T) {
+	options, _ := mockOptions(t, `www.example.com A 192.0.2.1
+api.example.com A 192.0.2.2
+`)
+	options.Stdin = true
+	options.StdinChunk = 1
+
+	runner, err := New(options)
+	require.Nil(t, err, "Could not create runner")
+	defer runner.Close()
+
+	reader, writer := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- runner.streamStdin(reader)
+	}()
+
+	// The result of the first chunk is written while stdin is still open
+	_, err = writer.Write([]byte("www.example.com\n"))
+	require.Nil(t, err, "Could not write stdin")
+	require.Eventually(t, func() bool {
+		output, _ := os.ReadFile(options.Output)
+		return string(output) == "www.example.com\n"
+	}, 10*time.Second, 10*time.Millisecond, "Could not write the results of the first chunk")
+
+	_, err = writer.Write([]byte("api.example.com\n"))
+	require.Nil(t, err, "Could not write stdin")
+	require.Nil(t, writer.Close(), "Could not close stdin")
+	require.Nil(t, <-done, "Could not resolve stdin chunks")
+
+	output, err := os.ReadFile(options.Output)
+	require.Nil(t, err, "Could not read output")
+	require.Equal(t, "www.example.com\napi.example.com\n", string(output), "Could not append the results of the next chunks")
+}
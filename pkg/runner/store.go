@@ -0,0 +1,95 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/mohammadanaraki/shuffledns/pkg/history"
+	"github.com/projectdiscovery/gologger"
+)
+
+// StoreQueryOptions contains the configuration options for the store query subcommand
+type StoreQueryOptions struct {
+	StoreFile string        // StoreFile is the history datastore to query
+	Domain    string        // Domain restricts the results to the subdomains of a domain
+	Since     time.Duration // Since restricts the results to assets seen in the last duration
+	Json      bool          // Json is the format for making output as ndjson
+	NoColor   bool          // NoColor disables the colored output
+}
+
+// ParseStoreOptions parses the command line flags for the store subcommand
+func ParseStoreOptions(args []string) (*StoreQueryOptions, error) {
+	options := &StoreQueryOptions{}
+
+	flagSet := flag.NewFlagSet("store", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		gologger.Print().Msgf("Usage: shuffledns store query -store assets.db [flags]\n")
+		flagSet.PrintDefaults()
+	}
+	flagSet.StringVar(&options.StoreFile, "store", "", "History datastore to query")
+	flagSet.StringVar(&options.Domain, "d", "", "Show only the subdomains of a domain")
+	flagSet.DurationVar(&options.Since, "since", 0, "Show only assets seen in the last duration (e.g. 24h)")
+	flagSet.BoolVar(&options.Json, "json", false, "Make output format as ndjson")
+	flagSet.BoolVar(&options.NoColor, "nC", false, "Don't Use colors in output")
+
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return nil, err
+	}
+
+	(&Options{NoColor: options.NoColor}).configureOutput()
+
+	if len(positional) != 1 || positional[0] != "query" {
+		flagSet.Usage()
+		return nil, fmt.Errorf("%w: unknown store command", ErrUsage)
+	}
+	if options.StoreFile == "" {
+		return nil, fmt.Errorf("%w: no history store provided", ErrUsage)
+	}
+	return options, nil
+}
+
+// RunStoreQuery prints the assets recorded in the history datastore
+func RunStoreQuery(options *StoreQueryOptions) error {
+	if _, err := os.Stat(options.StoreFile); err != nil {
+		return errors.New("history store doesn't exist")
+	}
+	db, err := history.Open(options.StoreFile)
+	if err != nil {
+		return err
+	}
+
+	// The hostnames are stored in their canonical form
+	domain := dnsname.Normalize(options.Domain)
+	now := time.Now()
+	for _, asset := range db.Assets() {
+		if domain != "" && asset.Hostname != domain && !strings.HasSuffix(asset.Hostname, "."+domain) {
+			continue
+		}
+		if options.Since > 0 && now.Sub(asset.LastSeen) > options.Since {
+			continue
+		}
+
+		if options.Json {
+			data, err := json.Marshal(asset)
+			if err != nil {
+				return fmt.Errorf("could not marshal asset as json: %v", err)
+			}
+			gologger.Silent().Msgf("%s\n", data)
+			continue
+		}
+
+		var ips []string
+		for _, ip := range asset.IPs {
+			ips = append(ips, ip.Value)
+		}
+		gologger.Silent().Msgf("%s [%s] first-seen=%s last-seen=%s\n", asset.Hostname, strings.Join(ips, ","), asset.FirstSeen.Format(time.RFC3339), asset.LastSeen.Format(time.RFC3339))
+	}
+	return nil
+}
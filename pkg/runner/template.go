@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// templateVariable matches the variables of the output file templates
+var templateVariable = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// templateVariables are the variables expanded in the output file names
+var templateVariables = map[string]struct{}{
+	"domain":      {},
+	"date":        {},
+	"time":        {},
+	"run_id":      {},
+	"config_hash": {},
+}
+
+// outputFiles returns the options containing the output file names
+// which can be templates.
+func (options *Options) outputFiles() []*string {
+	return []*string{
+		&options.Output,
+		&options.OutputHosts,
+		&options.OutputURLs,
+		&options.OutputBurp,
+		&options.OutputTargets,
+		&options.OutputIPMap,
+		&options.IPClusters,
+		&options.Report,
+		&options.ReportMarkdown,
+		&options.WildcardOutputFile,
+		&options.WildcardOutputJSON,
+		&options.ResolverStats,
+		&options.ChangesOutput,
+		&options.Manifest,
+	}
+}
+
+// validateTemplates returns an error if an output file name contains
+// an unknown variable, or the domain variable without a domain given
+// with -d or on stdin along with a wordlist.
+func (options *Options) validateTemplates() error {
+	hasDomain := options.Domain != "" || (options.Stdin && options.Wordlist != "")
+	for _, file := range options.outputFiles() {
+		for _, match := range templateVariable.FindAllStringSubmatch(*file, -1) {
+			if _, ok := templateVariables[match[1]]; !ok {
+				return fmt.Errorf("unknown variable %s in output file %s", match[1], *file)
+			}
+			if match[1] == "domain" && !hasDomain {
+				return fmt.Errorf("variable domain in output file %s requires a domain (-d)", *file)
+			}
+		}
+	}
+	return nil
+}
+
+// expandOutputFiles replaces the variables of the output file names by
+// their values for the run, e.g. results/{{domain}}/{{date}}.ndjson,
+// creating the directories of the expanded files.
+func (r *Runner) expandOutputFiles(now time.Time) error {
+	values := map[string]string{
+		"domain":      r.options.Domain,
+		"date":        now.Format("2006-01-02"),
+		"time":        now.Format("150405"),
+		"run_id":      r.runID,
+		"config_hash": r.configHash,
+	}
+
+	for _, file := range r.options.outputFiles() {
+		if !templateVariable.MatchString(*file) {
+			continue
+		}
+		var err error
+		expanded := templateVariable.ReplaceAllStringFunc(*file, func(match string) string {
+			name := templateVariable.FindStringSubmatch(match)[1]
+			if values[name] == "" {
+				err = fmt.Errorf("no value for variable %s in output file %s", name, *file)
+			}
+			// The values never contain path separators
+			return strings.ReplaceAll(values[name], string(filepath.Separator), "_")
+		})
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(expanded), 0755); err != nil {
+			return err
+		}
+		r.log().Debug().Msgf("Expanded output file %s to %s\n", *file, expanded)
+		*file = expanded
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		valid   bool
+	}{
+		{"plain", Options{Output: "out.txt"}, true},
+		{"variables", Options{Domain: "example.com", Output: "{{domain}}/{{date}}-{{ time }}.txt", Report: "{{run_id}}-{{config_hash}}.html"}, true},
+		{"unknown variable", Options{Output: "{{host}}.txt"}, false},
+		{"domain without domain", Options{ReportMarkdown: "{{domain}}.md"}, false},
+		{"domain from stdin", Options{Stdin: true, Wordlist: "words.txt", Output: "{{domain}}.txt"}, true},
+	}
+	for _, test := range tests {
+		err := test.options.validateTemplates()
+		if test.valid {
+			require.Nil(t, err, "Could not validate %s template", test.name)
+		} else {
+			require.NotNil(t, err, "Could not reject %s template", test.name)
+		}
+	}
+}
+
+func TestExpandOutputFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 30, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		template string
+		expanded string
+	}{
+		{"out.txt", "out.txt"},
+		{"{{domain}}/{{date}}.ndjson", "example.com/2024-05-30.ndjson"},
+		{"{{ time }}-{{run_id}}.txt", "153000-run.txt"},
+		{"{{config_hash}}.json", "cff8742ecaf3.json"},
+	}
+	for _, test := range tests {
+		r := &Runner{runID: "run", configHash: "cff8742ecaf3", options: &Options{Domain: "example.com", Output: filepath.Join(dir, test.template)}}
+		require.Nil(t, r.expandOutputFiles(now), "Could not expand %s", test.template)
+		require.Equal(t, filepath.Join(dir, test.expanded), r.options.Output, "Could not expand %s", test.template)
+		require.DirExists(t, filepath.Dir(r.options.Output), "Could not create the directory of %s", test.template)
+	}
+
+	r := &Runner{options: &Options{Output: filepath.Join(dir, "{{run_id}}.txt")}}
+	require.NotNil(t, r.expandOutputFiles(now), "Could not fail on a variable without value")
+}
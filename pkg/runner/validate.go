@@ -1,45 +1,289 @@
 package runner
 
 import (
-	"errors"
 	"fmt"
 	"os"
+	"regexp"
 
 	"github.com/projectdiscovery/gologger"
 	"github.com/projectdiscovery/gologger/formatter"
 	"github.com/projectdiscovery/gologger/levels"
+	"github.com/mohammadanaraki/shuffledns/pkg/bundled"
 	"github.com/mohammadanaraki/shuffledns/pkg/massdns"
+	"github.com/mohammadanaraki/shuffledns/pkg/resolvers"
+	"github.com/mohammadanaraki/shuffledns/pkg/wildcards"
 )
 
-// validateOptions validates the configuration options passed
-func (options *Options) validateOptions() error {
+// Validate validates the configuration options passed, returning
+// the errors defined in this package for the invalid ones.
+func (options *Options) Validate() error {
 	// Both verbose and silent flags were used
 	if options.Verbose && options.Silent {
-		return errors.New("both verbose and silent mode specified")
+		return invalidOption("both verbose and silent mode specified")
 	}
 
-	// Check if a list of resolvers was provided and it exists
-	if options.ResolversFile == "" {
-		return errors.New("no resolver list provided")
+	if options.UseBundledMassdns {
+		if options.MassdnsPath != "" {
+			return invalidOption("both bundled massdns and a massdns path specified")
+		}
+		if !bundled.Available() {
+			return invalidOption("%w, use a release build for linux amd64 or arm64 or install massdns", bundled.ErrNotBundled)
+		}
+	}
+
+	if options.TargetsHostnames && options.OutputTargets == "" {
+		return invalidOption("targets hostnames require a targets file")
 	}
-	if _, err := os.Stat(options.ResolversFile); os.IsNotExist(err) {
-		return errors.New("resolver file doesn't exists")
+
+	if err := options.validateTemplates(); err != nil {
+		return invalidOption("%w", err)
 	}
 
-	// Check if resolvers are blank
-	if blank, err := massdns.IsBlankFile(options.ResolversFile); err == nil {
-		if blank {
-			return errors.New("blank resolver list specified")
+	// Compression is only applied to the output file
+	if options.OutputCompress && options.Output == "" {
+		return invalidOption("output compression requires an output file")
+	}
+	if options.OutputAppendUnique && options.Output == "" {
+		return invalidOption("appending unique hosts requires an output file")
+	}
+	if options.Sorted != "" && options.Sorted != massdns.SortAlphabetical && options.Sorted != massdns.SortReversed {
+		return invalidOption("invalid output order %s", options.Sorted)
+	}
+	if options.ClusterASN && options.IPClusters == "" && options.Report == "" && options.ReportMarkdown == "" {
+		return invalidOption("asn lookups require an ip clusters file or a report")
+	}
+	if options.PrecheckParents && options.NoWildcardPrecheck {
+		return invalidOption("both parent pre-check and no wildcard pre-check specified")
+	}
+	if options.PruneWildcards && options.Domain == "" {
+		return invalidOption("wildcard pruning requires a domain")
+	}
+	if options.DKIMSelectors != "" && !options.EmailPosture {
+		return invalidOption("dkim selectors require -email-posture")
+	}
+	if options.CSV && options.Json {
+		return invalidOption("both json and csv output specified")
+	}
+	// The header of a csv output is written once
+	if options.CSV && options.OutputAppendUnique {
+		return invalidOption("appending unique hosts to csv output is not supported")
+	}
+	if options.GroupByDomain && !options.Json {
+		return invalidOption("grouping by domain requires json output")
+	}
+	if options.GroupByDomain && options.OutputAppendUnique {
+		return invalidOption("appending unique hosts to grouped output is not supported")
+	}
+	if options.Follow && options.SubdomainsList == "" {
+		return invalidOption("following requires a list of subdomains")
+	}
+	if options.Follow && options.FollowMarker == "" && options.FollowTimeout <= 0 {
+		return invalidOption("following requires an end marker or a timeout")
+	}
+	if options.StdinChunk < 0 {
+		return invalidOption("invalid stdin chunk size %d", options.StdinChunk)
+	}
+	if options.OutputAppendUnique && options.OutputCompress {
+		return invalidOption("appending unique hosts to a compressed output file is not supported")
+	}
+
+	if options.MaxQPS < 0 {
+		return invalidOption("invalid maximum queries per second")
+	}
+	if options.NSMaxQPS < 0 {
+		return invalidOption("invalid maximum queries per second per nameserver set")
+	}
+
+	// Check if the bandwidth cap is valid
+	if options.MaxBandwidth != "" {
+		if _, err := parseBandwidth(options.MaxBandwidth); err != nil {
+			return invalidOption("%w", err)
 		}
-	} else {
-		return fmt.Errorf("could not read resolvers: %w", err)
+	}
+
+	if options.StealthDuration > 0 && !options.Stealth {
+		return invalidOption("stealth duration can only be used in stealth mode")
+	}
+
+	if options.Canaries < 0 {
+		return invalidOption("invalid number of canaries")
+	}
+	if options.Canaries > 0 && options.Domain == "" {
+		return invalidOption("canaries require a domain")
+	}
+
+	if options.KnownAnswers < 0 {
+		return invalidOption("invalid known answers interval")
+	}
+	if options.KnownAnswersFile != "" && options.KnownAnswers == 0 {
+		return invalidOption("known answers file requires known answer checks")
+	}
+	// The default known answers are public names
+	if options.Internal && options.KnownAnswers > 0 && options.KnownAnswersFile == "" {
+		return invalidOption("known answer checks in internal mode require a known answers file")
+	}
+	if options.SearchDomains != "" && !options.Internal {
+		return invalidOption("search domains can only be used in internal mode")
+	}
+
+	if options.ExcludePrivate && options.OnlyPrivate {
+		return invalidOption("both private results exclusion and selection specified")
+	}
+	if options.MinDepth < 0 || options.MaxDepth < 0 {
+		return invalidOption("negative depth limit specified")
+	}
+	if options.MaxDepth > 0 && options.MinDepth > options.MaxDepth {
+		return invalidOption("minimum depth greater than maximum depth")
+	}
+	if options.NoSinkholeFilter && (options.FlagSinkholes || options.SinkholesFile != "" || options.SinkholeLoopback) {
+		return invalidOption("sinkhole options specified with the sinkhole filter disabled")
+	}
+	if options.ParkingFile != "" && !options.Json && !options.ExcludeParked {
+		return invalidOption("parking fingerprints specified without json output nor parked results exclusion")
+	}
+
+	if options.GenerateMarkov < 0 {
+		return invalidOption("invalid number of markov candidates")
+	}
+	if options.GenerateMarkov > 0 && options.Domain == "" {
+		return invalidOption("generating markov candidates requires a domain")
+	}
+	if options.GenerateMarkov > 0 && options.MassdnsRaw != "" {
+		return invalidOption("generating markov candidates is not supported with raw massdns input")
+	}
+	if options.Dnsgen != "" && options.Domain == "" {
+		return invalidOption("dnsgen generation requires a domain")
+	}
+	if options.Dnsgen != "" && options.MassdnsRaw != "" {
+		return invalidOption("dnsgen generation is not supported with raw massdns input")
+	}
+	if options.GeneratedFirst && options.GenerateMarkov == 0 && options.Dnsgen == "" {
+		return invalidOption("resolving generated candidates first requires markov or dnsgen generation")
+	}
+	if options.OrderWords && options.Wordlist == "" {
+		return invalidOption("ordering words requires a wordlist")
+	}
+	if options.Separators != "" && options.Prefixes == "" && options.Suffixes == "" {
+		return invalidOption("separators require prefixes or suffixes")
+	}
+	if options.ResolverAgreement < 0 {
+		return invalidOption("invalid resolver agreement")
+	}
+	if options.ResolverAgreement > 1 && options.MassdnsRaw != "" {
+		return invalidOption("resolver agreement is not supported with raw massdns input")
+	}
+	if options.MinHitRate != "" {
+		if _, err := parsePercentage(options.MinHitRate); err != nil {
+			return invalidOption("%w", err)
+		}
+		if options.HitRateWindow < 0 {
+			return invalidOption("hit rate window can't be negative")
+		}
+		if options.StealthDuration > 0 {
+			return invalidOption("minimum hit rate is not supported with a stealth duration")
+		}
+	}
+	if options.VerifySample != "" {
+		if _, err := parsePercentage(options.VerifySample); err != nil {
+			return invalidOption("%w", err)
+		}
+	}
+	if options.ResolverStats != "" && options.MassdnsRaw != "" {
+		return invalidOption("resolver statistics are not supported with raw massdns input")
+	}
+	if err := options.retryBackoff().Validate(); err != nil {
+		return invalidOption("%w", err)
+	}
+	if options.MassdnsHangTimeout < 0 {
+		return invalidOption("invalid massdns hang timeout")
+	}
+	if options.ProgressInterval <= 0 && (options.ProgressJSON != "" || options.ProgressWriter != nil) {
+		return invalidOption("invalid progress interval")
+	}
+	if options.MassdnsRestarts < 0 {
+		return invalidOption("invalid number of massdns restarts")
+	}
+	if _, err := wildcards.ParseStrategy(options.WildcardMode); err != nil {
+		return invalidOption("%w", err)
+	}
+	if _, err := regexp.Compile(options.MatchRegex); err != nil {
+		return invalidOption("invalid match regex: %w", err)
+	}
+	if _, err := regexp.Compile(options.FilterRegex); err != nil {
+		return invalidOption("invalid filter regex: %w", err)
+	}
+	if options.CNAMEDepth < 0 {
+		return invalidOption("invalid cname depth")
+	}
+	if options.CNAMEDepth > 0 && (options.ScopeFile == "" || options.Wordlist == "") {
+		return invalidOption("cname target enumeration requires a scope file and a wordlist")
+	}
+	if options.TLSSans < 0 {
+		return invalidOption("invalid number of tls certificate rounds")
+	}
+	if options.TLSSans > 0 && options.Domain == "" {
+		return invalidOption("tls certificate harvesting requires a domain")
+	}
+
+	// Only the dns queries of the enumeration leave the host offline
+	if options.OfflineStrict {
+		if err := options.validateOffline(); err != nil {
+			return err
+		}
+	}
+
+	// Changes can only be detected against the history datastore
+	if (options.ChangesOutput != "" || options.Webhook != "") && options.StoreFile == "" {
+		return invalidOption("change notifications require a history store")
+	}
+
+	// The manifest is signed with the key of the user
+	if options.Sign != "" {
+		if _, ok := signTools[options.Sign]; !ok {
+			return invalidOption("invalid signing tool %s", options.Sign)
+		}
+		if options.Manifest == "" || options.SignKey == "" {
+			return invalidOption("signing requires a manifest and a key")
+		}
+	}
+	if options.SignPasswordFile != "" && options.Sign == "" {
+		return invalidOption("signing password specified without signing tool")
+	}
+
+	if options.PTREnrich && !options.Json {
+		return invalidOption("ptr enrichment can only be used with json output")
+	}
+	if options.AnyRecords && !options.Json {
+		return invalidOption("any records can only be used with json output")
+	}
+	if _, err := wildcards.ParseRecordTypes(options.RecordTypes); err != nil {
+		return invalidOption("%w", err)
+	}
+
+	// Check if the output fields are valid
+	if options.Fields != "" {
+		if !options.Json && !options.CSV {
+			return invalidOption("output fields can only be used with json or csv output")
+		}
+		if _, err := massdns.ParseFields(options.Fields); err != nil {
+			return invalidOption("%w", err)
+		}
+	}
+
+	// The mock backend answers in place of the resolvers
+	if options.MockDNS != "" {
+		if _, err := os.Stat(options.MockDNS); err != nil {
+			return invalidOption("could not read mock dns fixture: %w", err)
+		}
+	} else if err := options.validateResolverLists(); err != nil {
+		return err
 	}
 
 	// Check if the user just wants to perform wildcard filtering on an
 	// existing massdns output file.
 	if options.MassdnsRaw != "" {
 		if options.Domain == "" {
-			return errors.New("no domain supplied for massdns input")
+			return fmt.Errorf("%w for massdns input", ErrMissingDomain)
 		}
 		// Return as no more validation required
 		return nil
@@ -56,24 +300,85 @@ func (options *Options) validateOptions() error {
 
 	// If domain was not provided and stdin was not provided, error out
 	if options.Domain == "" && !options.Stdin && options.Wordlist == "" {
-		return errors.New("no domain was provided for bruteforce")
+		return fmt.Errorf("%w for bruteforce", ErrMissingDomain)
 	}
 
 	// Check if stdin was given and no
 	if options.Wordlist == "" && (options.Stdin || options.SubdomainsList != "") && options.Domain == "" {
-		return errors.New("no domain was provided for resolving subdomains")
+		return fmt.Errorf("%w for resolving subdomains", ErrMissingDomain)
 	}
 
 	// Check for either wordlist or stdin or subdomain list
 	if !options.Stdin && options.SubdomainsList == "" && options.Wordlist == "" {
-		return errors.New("no wordlist or subdomains given as input")
+		return ErrMissingInput
 	}
 
 	// Check for only bruteforce or resolving
 	if options.SubdomainsList != "" && options.Wordlist != "" {
-		return errors.New("both bruteforce and resolving options specified")
+		return ErrConflictingInput
+	}
+
+	return nil
+}
+
+// validateResolverLists checks the resolvers and wildcard resolvers
+func (options *Options) validateResolverLists() error {
+	// Check if a list of resolvers was provided and it exists,
+	// unless the resolvers are given inline
+	if options.ResolversFile == "" {
+		return ErrMissingResolvers
+	}
+	if !resolvers.IsList(options.ResolversFile) {
+		if _, err := os.Stat(options.ResolversFile); os.IsNotExist(err) {
+			return ErrResolversNotFound
+		}
+
+		// Check if resolvers are blank
+		if blank, err := massdns.IsBlankFile(options.ResolversFile); err == nil {
+			if blank {
+				return ErrBlankResolvers
+			}
+		} else {
+			return fmt.Errorf("could not read resolvers: %w", err)
+		}
+	}
+	if options.IPv4 && options.IPv6 {
+		return invalidOption("both ipv4 and ipv6 only resolvers specified")
+	}
+	if err := validateResolvers(options.ResolversFile, options.resolverFamily()); err != nil {
+		return err
+	}
+
+	if _, err := parseResolverSplit(options.ResolverSplit); err != nil {
+		return invalidOption("%w", err)
+	}
+
+	// Check the dedicated wildcard resolvers if any
+	if options.WildcardResolvers != "" {
+		if !resolvers.IsList(options.WildcardResolvers) {
+			if blank, err := massdns.IsBlankFile(options.WildcardResolvers); err != nil {
+				return invalidOption("could not read wildcard resolvers: %w", err)
+			} else if blank {
+				return invalidOption("blank wildcard resolver list specified")
+			}
+		}
+		if err := validateResolvers(options.WildcardResolvers, options.resolverFamily()); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
+// validateResolvers checks that the entries of a resolver list are
+// valid and that some of them are of the requested family.
+func validateResolvers(list string, family resolvers.Family) error {
+	addresses, err := resolvers.Load(list)
+	if err != nil {
+		return invalidOption("%w", err)
+	}
+	if len(resolvers.Filter(addresses, family)) == 0 {
+		return invalidOption("no %s resolvers in %s", family, list)
+	}
 	return nil
 }
 
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/mohammadanaraki/shuffledns/pkg/wordlist"
+	"github.com/projectdiscovery/gologger"
+)
+
+// WordlistOptions contains the configuration options for the wordlist subcommand
+type WordlistOptions struct {
+	Command string // Command is the wordlist action to perform (normalize)
+	Input   string // Input is the wordlist to process
+	Output  string // Output is the file to write the processed wordlist to (stdout if empty)
+	NoColor bool   // NoColor disables the colored output
+}
+
+// ParseWordlistOptions parses the command line flags for the wordlist subcommand
+func ParseWordlistOptions(args []string) (*WordlistOptions, error) {
+	options := &WordlistOptions{}
+
+	flagSet := flag.NewFlagSet("wordlist", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		gologger.Print().Msgf("Usage: shuffledns wordlist normalize in.txt [-o out.txt] [flags]\n")
+		flagSet.PrintDefaults()
+	}
+	flagSet.StringVar(&options.Output, "o", "", "File to write the normalized wordlist to (stdout if not given)")
+	flagSet.BoolVar(&options.NoColor, "nC", false, "Don't Use colors in output")
+
+	positional, err := parseInterspersed(flagSet, args)
+	if err != nil {
+		return nil, err
+	}
+
+	(&Options{NoColor: options.NoColor}).configureOutput()
+
+	if len(positional) != 2 {
+		flagSet.Usage()
+		return nil, fmt.Errorf("%w: no wordlist command or input provided", ErrUsage)
+	}
+	options.Command, options.Input = positional[0], positional[1]
+	return options, nil
+}
+
+// RunWordlist performs the wordlist action requested by the user
+func RunWordlist(options *WordlistOptions) error {
+	if options.Command != "normalize" {
+		return fmt.Errorf("unknown wordlist command %s", options.Command)
+	}
+
+	input, err := os.Open(options.Input)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	var output io.Writer = os.Stdout
+	if options.Output != "" {
+		file, err := os.Create(options.Output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		output = file
+	}
+
+	stats, err := wordlist.Normalize(input, output)
+	if err != nil {
+		return err
+	}
+	gologger.Info().Msgf("Read %d words, wrote %d\n", stats.Read, stats.Written)
+	gologger.Info().Msgf("Normalized %d words, dropped %d duplicates, %d without valid characters and %d with labels over %d characters\n", stats.Modified, stats.Duplicates, stats.Invalid, stats.Overlong, dnsname.MaxLabelLength)
+	return nil
+}
@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/history"
+	"github.com/mohammadanaraki/shuffledns/pkg/wordrank"
+)
+
+// orderCandidates rewrites the bruteforce list with the candidates
+// most likely found first, ranked by the built-in corpus and the
+// subdomains recorded in the history datastore.
+func (r *Runner) orderCandidates(resolveFile string) error {
+	ranker := wordrank.New()
+	if r.options.StoreFile != "" {
+		db, err := history.Open(r.options.StoreFile)
+		if err != nil {
+			return err
+		}
+		var hostnames []string
+		for _, asset := range db.Assets() {
+			hostnames = append(hostnames, asset.Hostname)
+		}
+		ranker.Learn(r.options.Domain, hostnames)
+		r.log().Info().Msgf("Learned word frequencies from %d known subdomains\n", len(hostnames))
+	}
+
+	input, err := r.tempKey.Open(resolveFile)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(input)
+	input.Close()
+	if err != nil {
+		return err
+	}
+	candidates := strings.Fields(string(data))
+
+	// The candidates are ordered by the score of their word
+	suffix := "." + r.options.Domain
+	words := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		words[i] = strings.TrimSuffix(candidate, suffix)
+	}
+	ranker.Sort(words)
+
+	file, err := r.tempKey.Create(resolveFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, word := range words {
+		_, _ = writer.WriteString(word + suffix + "\n")
+	}
+	return writer.Flush()
+}
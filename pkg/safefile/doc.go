@@ -0,0 +1,11 @@
+// Package safefile writes output files so that a crash or a kill in
+// the middle of a run never leaves a partial file behind.
+//
+// A new file is written to a temporary file in the same directory and
+// renamed over the target once complete, so the target either doesn't
+// exist, keeps its previous content or is complete. An append records
+// the size of the file in a journal next to it before writing; an
+// append which didn't complete is rolled back to that size when the
+// file is opened again, so the lines already in the file can be
+// trusted by the next run.
+package safefile
@@ -0,0 +1,211 @@
+package safefile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// JournalSuffix is the suffix of the journal of a file being appended to
+const JournalSuffix = ".journal"
+
+// File is an output file being written, which only replaces or extends
+// the target once committed.
+type File struct {
+	*os.File
+	// target is the file written to
+	target string
+	// journal is the journal of the append, empty for a new file
+	journal string
+	// size is the size of the target before the append
+	size int64
+	// mode is the mode of a new file, the one of the target it replaces
+	mode os.FileMode
+	// direct is true if the target is written directly, like a pipe
+	direct bool
+	done   bool
+}
+
+// special returns true if a file exists and is not a regular file, like
+// a named pipe or a device, which can't be replaced nor truncated
+func special(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && !info.Mode().IsRegular()
+}
+
+// Create starts writing a new file replacing the target on commit.
+// Special files like named pipes are written directly.
+func Create(name string) (*File, error) {
+	if special(name) {
+		file, err := os.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		return &File{File: file, target: name, direct: true}, nil
+	}
+	dir, base := filepath.Split(name)
+	if dir == "" {
+		dir = "."
+	}
+	// The temporary file stays private while it's written, and gets the
+	// mode of the target it replaces, or 0644 for a new one, on commit
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(name); err == nil {
+		mode = info.Mode().Perm()
+	}
+	file, err := os.CreateTemp(dir, "."+base+".*.partial")
+	if err != nil {
+		return nil, err
+	}
+	return &File{File: file, target: name, mode: mode}, nil
+}
+
+// Append starts appending to a file, creating it if needed, after
+// rolling back an append which didn't complete. Special files like
+// named pipes are written directly.
+func Append(name string) (*File, error) {
+	if special(name) {
+		file, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return &File{File: file, target: name, direct: true}, nil
+	}
+	if _, err := Recover(name); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	journal := name + JournalSuffix
+	if err := writeSynced(journal, []byte(strconv.FormatInt(info.Size(), 10))); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &File{File: file, target: name, journal: journal, size: info.Size()}, nil
+}
+
+// Recover rolls back the append to a file which didn't complete,
+// returning true if there was one.
+func Recover(name string) (bool, error) {
+	journal := name + JournalSuffix
+	data, err := os.ReadFile(journal)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		// The journal itself was cut short, the append never started
+		return false, os.Remove(journal)
+	}
+	if err := os.Truncate(name, size); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	return true, os.Remove(journal)
+}
+
+// Commit makes the written data durable and, for a new file, replaces
+// the target with it.
+func (f *File) Commit() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	if f.direct {
+		return f.File.Close()
+	}
+	if err := f.Sync(); err != nil {
+		f.File.Close()
+		return f.rollback(err)
+	}
+	if f.journal == "" {
+		if err := f.Chmod(f.mode); err != nil {
+			f.File.Close()
+			return f.rollback(err)
+		}
+	}
+	if err := f.File.Close(); err != nil {
+		return f.rollback(err)
+	}
+	if f.journal != "" {
+		return os.Remove(f.journal)
+	}
+	if err := os.Rename(f.Name(), f.target); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	syncDir(filepath.Dir(f.target))
+	return nil
+}
+
+// Abort discards the written data, leaving the target as it was. It
+// does nothing once the file is committed.
+func (f *File) Abort() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	if f.direct {
+		return f.File.Close()
+	}
+	f.File.Close()
+	return f.rollback(nil)
+}
+
+// rollback discards the written data, returning err if set
+func (f *File) rollback(err error) error {
+	var rollbackErr error
+	if f.journal != "" {
+		if rollbackErr = os.Truncate(f.target, f.size); rollbackErr == nil {
+			rollbackErr = os.Remove(f.journal)
+		}
+	} else {
+		rollbackErr = os.Remove(f.Name())
+	}
+	if err != nil {
+		return err
+	}
+	return rollbackErr
+}
+
+// writeSynced writes a file and makes it durable
+func writeSynced(name string, data []byte) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(name))
+	return nil
+}
+
+// syncDir makes a rename or a creation in a directory durable. Errors
+// are ignored since directories can't be synced on every platform.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,89 @@
+package safefile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readFile(t *testing.T, name string) string {
+	data, err := os.ReadFile(name)
+	require.Nil(t, err, "Could not read file")
+	return string(data)
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "output.txt")
+	require.Nil(t, os.WriteFile(name, []byte("old.example.com\n"), 0644))
+
+	file, err := Create(name)
+	require.Nil(t, err, "Could not create file")
+	_, err = file.WriteString("new.example.com\n")
+	require.Nil(t, err, "Could not write file")
+	require.Equal(t, "old.example.com\n", readFile(t, name), "Could not keep target until commit")
+	require.Nil(t, file.Commit(), "Could not commit file")
+	require.Equal(t, "new.example.com\n", readFile(t, name), "Could not replace target")
+
+	file, err = Create(name)
+	require.Nil(t, err, "Could not create file")
+	_, _ = file.WriteString("partial")
+	require.Nil(t, file.Abort(), "Could not abort file")
+	require.Equal(t, "new.example.com\n", readFile(t, name), "Could not keep target on abort")
+
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err, "Could not read directory")
+	require.Len(t, entries, 1, "Could not remove temporary files")
+}
+
+func TestAppendRecover(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "output.txt")
+	require.Nil(t, os.WriteFile(name, []byte("a.example.com\n"), 0644))
+
+	file, err := Append(name)
+	require.Nil(t, err, "Could not append to file")
+	_, _ = file.WriteString("b.example.com\n")
+	require.Nil(t, file.Commit(), "Could not commit append")
+	require.Equal(t, "a.example.com\nb.example.com\n", readFile(t, name), "Could not append")
+	require.NoFileExists(t, name+JournalSuffix, "Could not remove journal")
+
+	// A crash in the middle of an append leaves the journal behind
+	file, err = Append(name)
+	require.Nil(t, err, "Could not append to file")
+	_, _ = file.WriteString("c.exam")
+	file.File.Close()
+
+	recovered, err := Recover(name)
+	require.Nil(t, err, "Could not recover file")
+	require.True(t, recovered, "Could not detect incomplete append")
+	require.Equal(t, "a.example.com\nb.example.com\n", readFile(t, name), "Could not roll back incomplete append")
+
+	recovered, err = Recover(name)
+	require.Nil(t, err, "Could not recover file")
+	require.False(t, recovered, "Could detect incomplete append twice")
+}
+
+func TestCreateMode(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "output.txt")
+
+	file, err := Create(name)
+	require.Nil(t, err, "Could not create file")
+	info, err := file.Stat()
+	require.Nil(t, err, "Could not stat temporary file")
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm(), "Could not keep temporary file private")
+	require.Nil(t, file.Commit(), "Could not commit file")
+	info, err = os.Stat(name)
+	require.Nil(t, err, "Could not stat file")
+	require.Equal(t, os.FileMode(0644), info.Mode().Perm(), "Could not set mode of new file")
+
+	require.Nil(t, os.Chmod(name, 0640), "Could not change mode")
+	file, err = Create(name)
+	require.Nil(t, err, "Could not create file")
+	require.Nil(t, file.Commit(), "Could not commit file")
+	info, err = os.Stat(name)
+	require.Nil(t, err, "Could not stat file")
+	require.Equal(t, os.FileMode(0640), info.Mode().Perm(), "Could not keep mode of replaced file")
+}
@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minute     map[int]struct{}
+	hour       map[int]struct{}
+	dayOfMonth map[int]struct{}
+	month      map[int]struct{}
+	dayOfWeek  map[int]struct{}
+
+	// restricted day fields follow the cron rule where a time
+	// matches if either of the day fields matches.
+	dayOfMonthAny bool
+	dayOfWeekAny  bool
+}
+
+// field contains the bounds of a cron field
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// ParseSchedule parses a five field cron expression
+func ParseSchedule(expression string) (*Schedule, error) {
+	parts := strings.Fields(expression)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected %d fields", expression, len(fields))
+	}
+
+	values := make([]map[int]struct{}, len(fields))
+	for i, part := range parts {
+		parsed, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expression, err)
+		}
+		values[i] = parsed
+	}
+
+	return &Schedule{
+		minute:        values[0],
+		hour:          values[1],
+		dayOfMonth:    values[2],
+		month:         values[3],
+		dayOfWeek:     values[4],
+		dayOfMonthAny: parts[2] == "*",
+		dayOfWeekAny:  parts[4] == "*",
+	}, nil
+}
+
+// parseField parses a single comma separated cron field
+func parseField(value string, f field) (map[int]struct{}, error) {
+	result := make(map[int]struct{})
+
+	for _, item := range strings.Split(value, ",") {
+		step := 1
+		if idx := strings.Index(item, "/"); idx != -1 {
+			parsed, err := strconv.Atoi(item[idx+1:])
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid step in %s field: %s", f.name, item)
+			}
+			step = parsed
+			item = item[:idx]
+		}
+
+		start, end := f.min, f.max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid value in %s field: %s", f.name, item)
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid value in %s field: %s", f.name, item)
+				}
+			}
+		}
+		if start < f.min || end > f.max || start > end {
+			return nil, fmt.Errorf("out of range value in %s field: %s", f.name, item)
+		}
+
+		for i := start; i <= end; i += step {
+			result[i] = struct{}{}
+		}
+	}
+	return result, nil
+}
+
+// Next returns the first time matching the schedule after t
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	// A schedule repeats at most every four years, so give up
+	// searching after that as the schedule can never match.
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if _, ok := s.month[int(t.Month())]; !ok {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if _, ok := s.hour[t.Hour()]; !ok {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if _, ok := s.minute[t.Minute()]; !ok {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay returns true if the day of t matches the schedule
+func (s *Schedule) matchDay(t time.Time) bool {
+	_, dayOfMonth := s.dayOfMonth[t.Day()]
+	_, dayOfWeek := s.dayOfWeek[int(t.Weekday())]
+
+	switch {
+	case s.dayOfMonthAny && s.dayOfWeekAny:
+		return true
+	case s.dayOfMonthAny:
+		return dayOfWeek
+	case s.dayOfWeekAny:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
@@ -0,0 +1,36 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleNext(t *testing.T) {
+	start := time.Date(2022, 3, 14, 10, 30, 0, 0, time.UTC) // Monday
+
+	tests := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"* * * * *", time.Date(2022, 3, 14, 10, 31, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2022, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"*/15 9-17 * * *", time.Date(2022, 3, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * 0", time.Date(2022, 3, 20, 2, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 4 1,15 * 5", time.Date(2022, 3, 15, 4, 30, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		schedule, err := ParseSchedule(test.expression)
+		require.Nil(t, err, "Could not parse %s", test.expression)
+		require.Equal(t, test.expected, schedule.Next(start), "Could not get next time for %s", test.expression)
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, expression := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := ParseSchedule(expression)
+		require.NotNil(t, err, "Could not reject %s", expression)
+	}
+}
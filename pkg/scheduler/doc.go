@@ -0,0 +1,8 @@
+// Package scheduler implements recurring enumeration jobs
+// driven by cron-style schedules.
+//
+// Schedules use the standard five field cron syntax (minute,
+// hour, day of month, month and day of week) supporting
+// wildcards, ranges, lists and steps. Jobs are persisted to
+// a json file so that they survive restarts of the daemon.
+package scheduler
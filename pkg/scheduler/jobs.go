@@ -0,0 +1,211 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Job is a recurring enumeration job
+type Job struct {
+	// Name is the unique name of the job
+	Name string `json:"name"`
+	// Schedule is the cron expression of the job
+	Schedule string `json:"schedule"`
+	// Args are the shuffledns command line arguments of the job
+	Args []string `json:"args"`
+	// LastRun is the time the job was last started
+	LastRun time.Time `json:"last_run,omitempty"`
+	// Paused suspends the job while running and keeps it from starting
+	Paused bool `json:"paused,omitempty"`
+	// Priority orders the jobs due at the same time, the highest first
+	Priority int `json:"priority,omitempty"`
+	// Tenant is the team owning the job, whose jobs share its quotas
+	// and working directory
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Due returns true if the job has to be run at a time. Paused jobs
+// are never due, and run once when resumed if they missed a run.
+func (j *Job) Due(now time.Time) (bool, error) {
+	schedule, err := ParseSchedule(j.Schedule)
+	if err != nil {
+		return false, err
+	}
+	if j.Paused {
+		return false, nil
+	}
+	// Jobs that never ran are due at their first scheduled time
+	last := j.LastRun
+	if last.IsZero() {
+		last = now.Add(-time.Minute)
+	}
+	next := schedule.Next(last)
+	return !next.IsZero() && !next.After(now), nil
+}
+
+// Jobs is a list of jobs persisted to a file
+type Jobs struct {
+	path string
+	jobs map[string]*Job
+}
+
+// LoadJobs loads the jobs from a file. A missing file is
+// treated as an empty list of jobs.
+func LoadJobs(path string) (*Jobs, error) {
+	jobs := &Jobs{path: path, jobs: make(map[string]*Job)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return jobs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*Job
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("could not decode jobs file: %w", err)
+	}
+	for _, job := range list {
+		jobs.jobs[job.Name] = job
+	}
+	return jobs, nil
+}
+
+// UpdateJobs loads the jobs of a file, applies update to them and saves
+// them if it succeeds, holding an exclusive lock of the file meanwhile.
+// The commands and the daemon changing the jobs concurrently therefore
+// never save a stale copy reverting the changes of each other.
+func UpdateJobs(path string, update func(jobs *Jobs) error) error {
+	unlock, err := lockJobs(path)
+	if err != nil {
+		return fmt.Errorf("could not lock jobs file: %w", err)
+	}
+	defer unlock()
+
+	jobs, err := LoadJobs(path)
+	if err != nil {
+		return err
+	}
+	if err := update(jobs); err != nil {
+		return err
+	}
+	return jobs.Save()
+}
+
+// Add adds or replaces a job validating its name and schedule
+func (j *Jobs) Add(job *Job) error {
+	if job.Name == "" {
+		return fmt.Errorf("no job name specified")
+	}
+	if !validName(job.Name) {
+		return fmt.Errorf("invalid job name %s", job.Name)
+	}
+	if !validTenant(job.Tenant) {
+		return fmt.Errorf("invalid tenant name %s", job.Tenant)
+	}
+	if _, err := ParseSchedule(job.Schedule); err != nil {
+		return err
+	}
+	j.jobs[job.Name] = job
+	return nil
+}
+
+// validTenant returns true if a tenant name can be used as a directory
+// name, the empty name being the one of the jobs without tenant.
+func validTenant(tenant string) bool {
+	return tenant == "" || validName(tenant)
+}
+
+// validName returns true if a name is a single path segment which can
+// be used as a directory name: letters, digits, hyphens, underscores
+// and dots but not only dots.
+func validName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove removes a job returning false if it doesn't exist
+func (j *Jobs) Remove(name string) bool {
+	if _, ok := j.jobs[name]; !ok {
+		return false
+	}
+	delete(j.jobs, name)
+	return true
+}
+
+// Get returns a job by name or nil if it doesn't exist
+func (j *Jobs) Get(name string) *Job {
+	return j.jobs[name]
+}
+
+// List returns the jobs sorted by name
+func (j *Jobs) List() []*Job {
+	list := make([]*Job, 0, len(j.jobs))
+	for _, job := range j.jobs {
+		list = append(list, job)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].Name < list[b].Name
+	})
+	return list
+}
+
+// Tenant returns the jobs of a tenant sorted by name
+func (j *Jobs) Tenant(tenant string) []*Job {
+	var list []*Job
+	for _, job := range j.List() {
+		if job.Tenant == tenant {
+			list = append(list, job)
+		}
+	}
+	return list
+}
+
+// Queue returns the jobs sorted by decreasing priority, then by least
+// recent run so that the jobs deferred by a quota go first, then by name
+func (j *Jobs) Queue() []*Job {
+	queue := j.List()
+	sort.SliceStable(queue, func(a, b int) bool {
+		if queue[a].Priority != queue[b].Priority {
+			return queue[a].Priority > queue[b].Priority
+		}
+		return queue[a].LastRun.Before(queue[b].LastRun)
+	})
+	return queue
+}
+
+// Save persists the jobs to disk. Concurrent changes have to go
+// through UpdateJobs instead, which reloads the jobs under a lock.
+func (j *Jobs) Save() error {
+	data, err := json.MarshalIndent(j.List(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), j.path)
+}
@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobsPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+
+	jobs, err := LoadJobs(path)
+	require.Nil(t, err, "Could not load jobs")
+	require.NotNil(t, jobs.Add(&Job{Name: "bad", Schedule: "* *"}), "Could not reject invalid schedule")
+	for _, name := range []string{"../../x", "a/b", "..", "."} {
+		require.NotNil(t, jobs.Add(&Job{Name: name, Schedule: "0 * * * *"}), "Could not reject invalid job name %s", name)
+	}
+	require.Nil(t, jobs.Add(&Job{Name: "hourly", Schedule: "0 * * * *", Args: []string{"-d", "example.com"}}))
+	require.Nil(t, jobs.Save(), "Could not save jobs")
+
+	jobs, err = LoadJobs(path)
+	require.Nil(t, err, "Could not reload jobs")
+	require.Len(t, jobs.List(), 1, "Could not get jobs")
+	require.Equal(t, []string{"-d", "example.com"}, jobs.List()[0].Args, "Could not get job args")
+}
+
+func TestJobDue(t *testing.T) {
+	job := &Job{Name: "hourly", Schedule: "0 * * * *"}
+
+	due, err := job.Due(time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC))
+	require.Nil(t, err)
+	require.True(t, due, "Could not get due job")
+
+	job.LastRun = time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	due, err = job.Due(time.Date(2022, 1, 1, 10, 30, 0, 0, time.UTC))
+	require.Nil(t, err)
+	require.False(t, due, "Could not get job not yet due")
+}
+
+func TestPausedJob(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+
+	jobs, err := LoadJobs(path)
+	require.Nil(t, err, "Could not load jobs")
+	require.Nil(t, jobs.Add(&Job{Name: "hourly", Schedule: "0 * * * *", LastRun: time.Date(2022, 1, 1, 9, 0, 0, 0, time.UTC)}))
+	jobs.Get("hourly").Paused = true
+	require.Nil(t, jobs.Save(), "Could not save jobs")
+
+	jobs, err = LoadJobs(path)
+	require.Nil(t, err, "Could not reload jobs")
+	job := jobs.Get("hourly")
+	require.True(t, job.Paused, "Could not persist paused job")
+
+	due, err := job.Due(time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC))
+	require.Nil(t, err)
+	require.False(t, due, "Could not skip paused job")
+
+	job.Paused = false
+	due, err = job.Due(time.Date(2022, 1, 1, 11, 30, 0, 0, time.UTC))
+	require.Nil(t, err)
+	require.True(t, due, "Could not run missed job once resumed")
+	require.Nil(t, jobs.Get("missing"), "Could not get missing job")
+}
+
+func TestJobsQueue(t *testing.T) {
+	jobs, err := LoadJobs(filepath.Join(t.TempDir(), "jobs.json"))
+	require.Nil(t, err, "Could not load jobs")
+	require.Nil(t, jobs.Add(&Job{Name: "a-low", Schedule: "* * * * *"}))
+	require.Nil(t, jobs.Add(&Job{Name: "b-high", Schedule: "* * * * *", Priority: 10}))
+	require.Nil(t, jobs.Add(&Job{Name: "c-high", Schedule: "* * * * *", Priority: 10}))
+	require.Nil(t, jobs.Add(&Job{Name: "d-lowest", Schedule: "* * * * *", Priority: -1}))
+
+	var names []string
+	for _, job := range jobs.Queue() {
+		names = append(names, job.Name)
+	}
+	require.Equal(t, []string{"b-high", "c-high", "a-low", "d-lowest"}, names, "Could not order the jobs by priority")
+
+	jobs.Get("b-high").LastRun = time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	require.Equal(t, "c-high", jobs.Queue()[0].Name, "Could not order the jobs by least recent run")
+}
+
+func TestJobsTenant(t *testing.T) {
+	jobs, err := LoadJobs(filepath.Join(t.TempDir(), "jobs.json"))
+	require.Nil(t, err, "Could not load jobs")
+	require.NotNil(t, jobs.Add(&Job{Name: "bad", Schedule: "* * * * *", Tenant: "../team"}), "Could not reject invalid tenant")
+	require.Nil(t, jobs.Add(&Job{Name: "a", Schedule: "* * * * *", Tenant: "red-team"}))
+	require.Nil(t, jobs.Add(&Job{Name: "b", Schedule: "* * * * *", Tenant: "blue-team"}))
+	require.Nil(t, jobs.Add(&Job{Name: "c", Schedule: "* * * * *", Tenant: "red-team"}))
+
+	var names []string
+	for _, job := range jobs.Tenant("red-team") {
+		names = append(names, job.Name)
+	}
+	require.Equal(t, []string{"a", "c"}, names, "Could not get the jobs of a tenant")
+}
+
+func TestUpdateJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+
+	// Concurrent updates of a stale copy don't revert each other
+	stale, err := LoadJobs(path)
+	require.Nil(t, err, "Could not load jobs")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := UpdateJobs(path, func(jobs *Jobs) error {
+				return jobs.Add(&Job{Name: fmt.Sprintf("job-%d", i), Schedule: "0 * * * *"})
+			})
+			require.Nil(t, err, "Could not update jobs")
+		}(i)
+	}
+	wg.Wait()
+	require.Len(t, stale.List(), 0, "Could not keep the stale copy unchanged")
+
+	jobs, err := LoadJobs(path)
+	require.Nil(t, err, "Could not reload jobs")
+	require.Len(t, jobs.List(), 10, "Could not keep every concurrent update")
+
+	// A failed update isn't saved
+	require.NotNil(t, UpdateJobs(path, func(jobs *Jobs) error {
+		jobs.Remove("job-0")
+		return errors.New("failed")
+	}), "Could not return the update error")
+	jobs, err = LoadJobs(path)
+	require.Nil(t, err, "Could not reload jobs")
+	require.NotNil(t, jobs.Get("job-0"), "Could not discard a failed update")
+}
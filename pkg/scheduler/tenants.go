@@ -0,0 +1,169 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Tenant is a tenant of a shared daemon along with its api key and
+// its quotas
+type Tenant struct {
+	// Name is the name of the tenant, empty for the jobs without tenant
+	Name string `json:"name"`
+	// KeyHash is the sha256 hash of the api key of the tenant, the key
+	// itself being only shown when it's created
+	KeyHash string `json:"key_hash"`
+	// Jobs is the maximum number of jobs of the tenant running at the
+	// same time (0 for the default of the daemon)
+	Jobs int `json:"jobs,omitempty"`
+	// QPS is the query budget per second shared by the running jobs of
+	// the tenant (0 for the default of the daemon)
+	QPS int `json:"qps,omitempty"`
+}
+
+// Tenants is a list of tenants persisted to a file
+type Tenants struct {
+	path    string
+	tenants map[string]*Tenant
+}
+
+// TenantsFile returns the file of the tenants of a jobs file, next to
+// it (e.g. jobs.tenants.json for jobs.json)
+func TenantsFile(jobsFile string) string {
+	return strings.TrimSuffix(jobsFile, filepath.Ext(jobsFile)) + ".tenants.json"
+}
+
+// LoadTenants loads the tenants from a file. A missing file is treated
+// as an empty list of tenants.
+func LoadTenants(path string) (*Tenants, error) {
+	tenants := &Tenants{path: path, tenants: make(map[string]*Tenant)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return tenants, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []*Tenant
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("could not decode tenants file: %w", err)
+	}
+	for _, tenant := range list {
+		tenants.tenants[tenant.Name] = tenant
+	}
+	return tenants, nil
+}
+
+// UpdateTenants loads the tenants of a file, applies update to them and
+// saves them if it succeeds, holding an exclusive lock of the file.
+func UpdateTenants(path string, update func(tenants *Tenants) error) error {
+	unlock, err := lockJobs(path)
+	if err != nil {
+		return fmt.Errorf("could not lock tenants file: %w", err)
+	}
+	defer unlock()
+
+	tenants, err := LoadTenants(path)
+	if err != nil {
+		return err
+	}
+	if err := update(tenants); err != nil {
+		return err
+	}
+	return tenants.save()
+}
+
+// NewKey creates or replaces the api key of a tenant with the given
+// quotas, returning the key.
+func (t *Tenants) NewKey(name string, jobs, qps int) (string, error) {
+	if !validTenant(name) {
+		return "", fmt.Errorf("invalid tenant name %s", name)
+	}
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	key := "sdns_" + hex.EncodeToString(secret)
+	t.tenants[name] = &Tenant{Name: name, KeyHash: hashKey(key), Jobs: jobs, QPS: qps}
+	return key, nil
+}
+
+// Remove removes a tenant and its key returning false if it doesn't exist
+func (t *Tenants) Remove(name string) bool {
+	if _, ok := t.tenants[name]; !ok {
+		return false
+	}
+	delete(t.tenants, name)
+	return true
+}
+
+// Get returns a tenant by name or nil if it doesn't exist
+func (t *Tenants) Get(name string) *Tenant {
+	return t.tenants[name]
+}
+
+// Authenticate returns the tenant of an api key or nil if the key is
+// unknown
+func (t *Tenants) Authenticate(key string) *Tenant {
+	if key == "" {
+		return nil
+	}
+	hash := hashKey(key)
+	for _, tenant := range t.tenants {
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(tenant.KeyHash)) == 1 {
+			return tenant
+		}
+	}
+	return nil
+}
+
+// List returns the tenants sorted by name
+func (t *Tenants) List() []*Tenant {
+	list := make([]*Tenant, 0, len(t.tenants))
+	for _, tenant := range t.tenants {
+		list = append(list, tenant)
+	}
+	sort.Slice(list, func(a, b int) bool {
+		return list[a].Name < list[b].Name
+	})
+	return list
+}
+
+// save persists the tenants to disk, readable only by the owner
+func (t *Tenants) save() error {
+	data, err := json.MarshalIndent(t.List(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), filepath.Base(t.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), t.path)
+}
+
+// hashKey returns the hex sha256 hash of an api key
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantKeys(t *testing.T) {
+	jobsFile := filepath.Join(t.TempDir(), "jobs.json")
+	path := TenantsFile(jobsFile)
+	require.Equal(t, filepath.Join(filepath.Dir(jobsFile), "jobs.tenants.json"), path, "Could not get tenants file")
+
+	var key string
+	err := UpdateTenants(path, func(tenants *Tenants) error {
+		var err error
+		key, err = tenants.NewKey("red-team", 2, 300)
+		return err
+	})
+	require.Nil(t, err, "Could not create key")
+	require.True(t, strings.HasPrefix(key, "sdns_"), "Could not get key")
+
+	// Only the hash of the key is stored
+	data, err := os.ReadFile(path)
+	require.Nil(t, err, "Could not read tenants file")
+	require.NotContains(t, string(data), key, "Could not hash the stored key")
+
+	tenants, err := LoadTenants(path)
+	require.Nil(t, err, "Could not load tenants")
+	tenant := tenants.Authenticate(key)
+	require.NotNil(t, tenant, "Could not authenticate key")
+	require.Equal(t, "red-team", tenant.Name, "Could not get tenant of key")
+	require.Equal(t, 2, tenant.Jobs, "Could not get tenant jobs quota")
+	require.Equal(t, 300, tenant.QPS, "Could not get tenant qps quota")
+	require.Nil(t, tenants.Authenticate("sdns_wrong"), "Could not reject unknown key")
+	require.Nil(t, tenants.Authenticate(""), "Could not reject empty key")
+
+	// A new key replaces the previous one
+	var rotated string
+	require.Nil(t, UpdateTenants(path, func(tenants *Tenants) error {
+		rotated, err = tenants.NewKey("red-team", 0, 0)
+		return err
+	}), "Could not rotate key")
+	tenants, err = LoadTenants(path)
+	require.Nil(t, err, "Could not reload tenants")
+	require.Nil(t, tenants.Authenticate(key), "Could not revoke rotated key")
+	require.NotNil(t, tenants.Authenticate(rotated), "Could not authenticate rotated key")
+
+	_, err = tenants.NewKey("../escape", 0, 0)
+	require.NotNil(t, err, "Could not reject invalid tenant name")
+}
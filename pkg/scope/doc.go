@@ -0,0 +1,3 @@
+// Package scope decides which names belong to the targets of an
+// enumeration using the rules of a yaml scope file.
+package scope
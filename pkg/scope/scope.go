@@ -0,0 +1,163 @@
+package scope
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"gopkg.in/yaml.v3"
+)
+
+// Scope contains the rules for the names in scope of an enumeration
+type Scope struct {
+	// Domains are the registered domains in scope
+	Domains []string `yaml:"domains"`
+	// Include are the regexes of which names must match at least one
+	Include []string `yaml:"include"`
+	// Exclude are the regexes of the names out of scope
+	Exclude []string `yaml:"exclude"`
+	// IPs are the ips and cidrs the results must resolve into
+	IPs []string `yaml:"ips"`
+
+	include  []*regexp.Regexp
+	exclude  []*regexp.Regexp
+	networks []*net.IPNet
+}
+
+// Load reads a scope from a yaml file
+func Load(file string) (*Scope, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := &Scope{}
+	if err := yaml.Unmarshal(data, scope); err != nil {
+		return nil, err
+	}
+	for i, domain := range scope.Domains {
+		scope.Domains[i] = normalize(domain)
+		// A public suffix would put the domains of everyone in scope
+		if dnsname.IsPublicSuffix(scope.Domains[i]) {
+			return nil, fmt.Errorf("domain %s is a public suffix", scope.Domains[i])
+		}
+	}
+	if err := scope.AddRules(scope.Include, scope.Exclude); err != nil {
+		return nil, err
+	}
+	for _, value := range scope.IPs {
+		// Single ips are stored as the smallest network
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ip range %s: %w", value, err)
+		}
+		scope.networks = append(scope.networks, network)
+	}
+	return scope, nil
+}
+
+// AddRules adds regexes of which names must match at least one, and
+// regexes of the names out of scope.
+func (s *Scope) AddRules(include, exclude []string) error {
+	compiled, err := compile(include)
+	if err != nil {
+		return err
+	}
+	s.include = append(s.include, compiled...)
+
+	compiled, err = compile(exclude)
+	if err != nil {
+		return err
+	}
+	s.exclude = append(s.exclude, compiled...)
+	return nil
+}
+
+// compile compiles a list of regexes
+func compile(expressions []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, expression := range expressions {
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %s: %w", expression, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Domain returns the in-scope domain a name belongs to, or an empty
+// string if the name is not in scope.
+func (s *Scope) Domain(name string) string {
+	name = normalize(name)
+
+	var found string
+	for _, domain := range s.Domains {
+		if name != domain && !strings.HasSuffix(name, "."+domain) {
+			continue
+		}
+		// Prefer the most specific domain
+		if len(domain) > len(found) {
+			found = domain
+		}
+	}
+	return found
+}
+
+// InScope returns true if a name belongs to an in-scope domain, matches
+// the include regexes and none of the exclude ones. Each empty list of
+// rules matches all the names.
+func (s *Scope) InScope(name string) bool {
+	name = normalize(name)
+
+	if len(s.Domains) > 0 && s.Domain(name) == "" {
+		return false
+	}
+	if len(s.include) > 0 && !matchAny(s.include, name) {
+		return false
+	}
+	return !matchAny(s.exclude, name)
+}
+
+// IPInScope returns true if an ip belongs to the in-scope ranges, or
+// if no range has been specified.
+func (s *Scope) IPInScope(value string) bool {
+	if len(s.networks) == 0 {
+		return true
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny returns true if a name matches any of the regexes
+func matchAny(expressions []*regexp.Regexp, name string) bool {
+	for _, re := range expressions {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize returns the lowercase form of a name without trailing dot
+func normalize(name string) string {
+	return dnsname.Normalize(name)
+}
@@ -0,0 +1,56 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeDomain(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scope.yaml")
+	require.Nil(t, os.WriteFile(file, []byte("domains:\n  - example.com\n  - Example.NET.\n  - dev.example.com\n"), 0644))
+
+	scope, err := Load(file)
+	require.Nil(t, err, "Could not load scope")
+
+	require.Equal(t, "example.com", scope.Domain("example.com"), "Could not match apex")
+	require.Equal(t, "example.net", scope.Domain("cdn.example.net."), "Could not match normalized domain")
+	require.Equal(t, "dev.example.com", scope.Domain("a.dev.example.com"), "Could not match most specific domain")
+	require.Equal(t, "", scope.Domain("notexample.com"), "Could not reject out of scope name")
+}
+
+func TestScopeRules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scope.yaml")
+	data := "domains:\n  - example.com\ninclude:\n  - '^(dev|stg)-'\nexclude:\n  - '^stg-legacy\\.'\nips:\n  - 10.0.0.0/8\n  - 192.168.1.1\n"
+	require.Nil(t, os.WriteFile(file, []byte(data), 0644))
+
+	scope, err := Load(file)
+	require.Nil(t, err, "Could not load scope")
+
+	require.True(t, scope.InScope("dev-api.example.com"), "Could not match included name")
+	require.False(t, scope.InScope("www.example.com"), "Could not reject not included name")
+	require.False(t, scope.InScope("stg-legacy.example.com"), "Could not reject excluded name")
+	require.False(t, scope.InScope("dev-api.example.net"), "Could not reject name of other domain")
+
+	require.True(t, scope.IPInScope("10.1.2.3"), "Could not match ip in range")
+	require.True(t, scope.IPInScope("192.168.1.1"), "Could not match single ip")
+	require.False(t, scope.IPInScope("192.168.1.2"), "Could not reject ip out of range")
+}
+
+func TestScopeInvalidRegex(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scope.yaml")
+	require.Nil(t, os.WriteFile(file, []byte("include:\n  - '('\n"), 0644))
+
+	_, err := Load(file)
+	require.NotNil(t, err, "Could not reject invalid regex")
+}
+
+func TestScopePublicSuffix(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scope.yaml")
+	require.Nil(t, os.WriteFile(file, []byte("domains:\n  - example.co.uk\n  - co.uk\n"), 0644))
+
+	_, err := Load(file)
+	require.NotNil(t, err, "Could not reject public suffix")
+}
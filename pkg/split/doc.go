@@ -0,0 +1,8 @@
+// Package split divides a bruteforce wordlist into chunks that
+// can be enumerated independently, for example as separate
+// Kubernetes Jobs, and merged back together afterwards.
+//
+// Words are assigned to chunks by hashing them, so the same
+// word always ends up in the same chunk regardless of its
+// position in the wordlist.
+package split
@@ -0,0 +1,128 @@
+package split
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Chunk is a self-contained unit of work
+type Chunk struct {
+	// Index is the index of the chunk starting from 0
+	Index int `json:"index"`
+	// Wordlist is the file containing the words of the chunk
+	Wordlist string `json:"wordlist"`
+	// Words is the number of words in the chunk
+	Words int `json:"words"`
+	// Output is the suggested output file for the chunk
+	Output string `json:"output"`
+	// Args are the shuffledns arguments to enumerate the chunk
+	Args []string `json:"args"`
+}
+
+// Manifest describes all the chunks of a split wordlist
+type Manifest struct {
+	Domain string   `json:"domain,omitempty"`
+	Chunks []*Chunk `json:"chunks"`
+	// Merge are the shuffledns arguments to merge the chunk outputs
+	Merge []string `json:"merge"`
+}
+
+// ManifestFile is the name of the manifest written in the output directory
+const ManifestFile = "manifest.json"
+
+// Split splits a wordlist in n chunks writing them along with
+// a manifest to the output directory.
+func Split(wordlist string, n int, directory, domain string) (*Manifest, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of chunks: %d", n)
+	}
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, err
+	}
+
+	input, err := os.Open(wordlist)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	manifest := &Manifest{Domain: domain, Merge: []string{"merge"}}
+	files := make([]*os.File, n)
+	writers := make([]*bufio.Writer, n)
+	for i := 0; i < n; i++ {
+		chunk := &Chunk{
+			Index:    i,
+			Wordlist: filepath.Join(directory, fmt.Sprintf("chunk-%04d.txt", i)),
+			Output:   filepath.Join(directory, fmt.Sprintf("chunk-%04d.ndjson", i)),
+		}
+		// The ips are needed to filter wildcards when merging the outputs
+		chunk.Args = []string{"-w", chunk.Wordlist, "-json", "-fields", "host,ip", "-o", chunk.Output}
+		if domain != "" {
+			chunk.Args = append([]string{"-d", domain}, chunk.Args...)
+		}
+		manifest.Chunks = append(manifest.Chunks, chunk)
+		manifest.Merge = append(manifest.Merge, chunk.Output)
+
+		file, err := os.Create(chunk.Wordlist)
+		if err != nil {
+			closeAll(files)
+			return nil, err
+		}
+		files[i] = file
+		writers[i] = bufio.NewWriter(file)
+	}
+
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		// RFC4343 - case insensitive domain
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" {
+			continue
+		}
+		if _, ok := seen[word]; ok {
+			continue
+		}
+		seen[word] = struct{}{}
+
+		index := chunkIndex(word, n)
+		_, _ = writers[index].WriteString(word + "\n")
+		manifest.Chunks[index].Words++
+	}
+	for _, writer := range writers {
+		_ = writer.Flush()
+	}
+	closeAll(files)
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(directory, ManifestFile), data, 0644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// chunkIndex returns the chunk a word is assigned to
+func chunkIndex(word string, n int) int {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(word))
+	return int(hasher.Sum32() % uint32(n))
+}
+
+func closeAll(files []*os.File) {
+	for _, file := range files {
+		if file != nil {
+			file.Close()
+		}
+	}
+}
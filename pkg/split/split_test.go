@@ -0,0 +1,36 @@
+package split
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	wordlist := filepath.Join(dir, "words.txt")
+	require.Nil(t, os.WriteFile(wordlist, []byte("www\nmail\napi\nWWW\n\ndev\nstaging\n"), 0644))
+
+	manifest, err := Split(wordlist, 3, filepath.Join(dir, "chunks"), "example.com")
+	require.Nil(t, err, "Could not split wordlist")
+	require.Len(t, manifest.Chunks, 3, "Could not get chunks")
+
+	var words []string
+	var total int
+	for _, chunk := range manifest.Chunks {
+		data, err := os.ReadFile(chunk.Wordlist)
+		require.Nil(t, err, "Could not read chunk")
+		for _, word := range strings.Fields(string(data)) {
+			require.Equal(t, chunk.Index, chunkIndex(word, 3), "Could not assign word deterministically")
+			words = append(words, word)
+		}
+		total += chunk.Words
+		require.Equal(t, []string{"-d", "example.com"}, chunk.Args[:2], "Could not get chunk args")
+	}
+	require.Equal(t, 5, total, "Could not deduplicate words")
+	require.ElementsMatch(t, []string{"www", "mail", "api", "dev", "staging"}, words, "Could not get all words")
+	require.Equal(t, "merge", manifest.Merge[0], "Could not get merge args")
+}
@@ -0,0 +1,10 @@
+// Package tmpcrypt encrypts the temporary files of a run at rest.
+//
+// The files are encrypted with AES-256 in counter mode under a key
+// generated for the run and kept in memory only, so that the candidate
+// lists and the raw outputs left in the temporary directory, e.g. by a
+// killed run or on a shared host, can't be read once the run is over.
+// Each file starts with its random counter, which lets the files be
+// appended to and read from any offset. The files are not authenticated:
+// the encryption protects their content, not their integrity.
+package tmpcrypt
@@ -0,0 +1,282 @@
+package tmpcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// headerSize is the size of the counter written at the start of a file
+const headerSize = aes.BlockSize
+
+// Key encrypts the files of a directory with an ephemeral key. A nil
+// key reads and writes all the files in plaintext.
+type Key struct {
+	block cipher.Block
+	dir   string
+}
+
+// New creates a random key encrypting the files of a directory
+func New(dir string) (*Key, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{block: block, dir: filepath.Clean(dir)}, nil
+}
+
+// Encrypts returns true if a file is encrypted by the key, i.e. it's in
+// the directory of the key.
+func (k *Key) Encrypts(name string) bool {
+	if k == nil {
+		return false
+	}
+	rel, err := filepath.Rel(k.dir, filepath.Clean(name))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// stream returns the keystream of a file starting at a plaintext offset
+func (k *Key) stream(iv []byte, offset int64) cipher.Stream {
+	counter := make([]byte, headerSize)
+	copy(counter, iv)
+	// The counter is a 128-bit big endian integer incremented per block
+	low := binary.BigEndian.Uint64(counter[8:])
+	blocks := uint64(offset / headerSize)
+	high := binary.BigEndian.Uint64(counter[:8])
+	if low+blocks < low {
+		high++
+	}
+	binary.BigEndian.PutUint64(counter[:8], high)
+	binary.BigEndian.PutUint64(counter[8:], low+blocks)
+
+	stream := cipher.NewCTR(k.block, counter)
+	if skip := offset % headerSize; skip > 0 {
+		discard := make([]byte, skip)
+		stream.XORKeyStream(discard, discard)
+	}
+	return stream
+}
+
+// writer encrypts the data written to a file
+type writer struct {
+	file   *os.File
+	stream cipher.Stream
+	buffer []byte
+}
+
+// Write implements io.Writer. The data is written right away so that
+// the file can be read while it grows.
+func (w *writer) Write(p []byte) (int, error) {
+	if cap(w.buffer) < len(p) {
+		w.buffer = make([]byte, len(p))
+	}
+	buffer := w.buffer[:len(p)]
+	w.stream.XORKeyStream(buffer, p)
+	return w.file.Write(buffer)
+}
+
+// Close implements io.Closer
+func (w *writer) Close() error {
+	return w.file.Close()
+}
+
+// Create creates or truncates a file for writing
+func (k *Key) Create(name string) (io.WriteCloser, error) {
+	file, err := os.Create(name)
+	if err != nil || !k.Encrypts(name) {
+		return file, err
+	}
+	iv := make([]byte, headerSize)
+	if _, err := rand.Read(iv); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Write(iv); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &writer{file: file, stream: k.stream(iv, 0)}, nil
+}
+
+// Append opens a file for appending, creating it if needed
+func (k *Key) Append(name string) (io.WriteCloser, error) {
+	if !k.Encrypts(name) {
+		return os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	}
+	info, err := os.Stat(name)
+	if os.IsNotExist(err) || (err == nil && info.Size() == 0) {
+		return k.Create(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, headerSize)
+	if _, err := file.ReadAt(iv, 0); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &writer{file: file, stream: k.stream(iv, info.Size()-headerSize)}, nil
+}
+
+// Truncate changes the plaintext size of a file
+func (k *Key) Truncate(name string, size int64) error {
+	if k.Encrypts(name) {
+		size += headerSize
+	}
+	return os.Truncate(name, size)
+}
+
+// Size returns the plaintext size of a file
+func (k *Key) Size(name string) (int64, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0, err
+	}
+	if !k.Encrypts(name) {
+		return info.Size(), nil
+	}
+	if info.Size() < headerSize {
+		return 0, nil
+	}
+	return info.Size() - headerSize, nil
+}
+
+// File is a file opened for reading, decrypted if needed
+type File struct {
+	file *os.File
+	key  *Key
+	// iv is the counter of the file, nil if it's in plaintext
+	iv []byte
+	// empty is true for an encrypted file whose counter isn't written
+	// yet, read as an empty file
+	empty  bool
+	offset int64
+	stream cipher.Stream
+}
+
+// Open opens a file for reading
+func (k *Key) Open(name string) (*File, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{file: file, key: k}
+	if !k.Encrypts(name) {
+		return f, nil
+	}
+
+	iv := make([]byte, headerSize)
+	n, err := io.ReadFull(file, iv)
+	if n == 0 && err == io.EOF {
+		f.empty = true
+		return f, nil
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f.iv = iv
+	return f, nil
+}
+
+// Read implements io.Reader
+func (f *File) Read(p []byte) (int, error) {
+	if f.empty {
+		return 0, io.EOF
+	}
+	n, err := f.file.Read(p)
+	if f.iv != nil && n > 0 {
+		if f.stream == nil {
+			f.stream = f.key.stream(f.iv, f.offset)
+		}
+		f.stream.XORKeyStream(p[:n], p[:n])
+	}
+	f.offset += int64(n)
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	if f.empty {
+		return 0, io.EOF
+	}
+	if f.iv == nil {
+		return f.file.ReadAt(p, off)
+	}
+	n, err := f.file.ReadAt(p, off+headerSize)
+	if n > 0 {
+		f.key.stream(f.iv, off).XORKeyStream(p[:n], p[:n])
+	}
+	return n, err
+}
+
+// Seek implements io.Seeker
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.empty {
+		return 0, nil
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		size, err := f.Size()
+		if err != nil {
+			return 0, err
+		}
+		offset += size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	header := int64(0)
+	if f.iv != nil {
+		header = headerSize
+	}
+	if _, err := f.file.Seek(offset+header, io.SeekStart); err != nil {
+		return 0, err
+	}
+	f.offset, f.stream = offset, nil
+	return offset, nil
+}
+
+// Size returns the plaintext size of the file
+func (f *File) Size() (int64, error) {
+	if f.empty {
+		return 0, nil
+	}
+	info, err := f.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if f.iv == nil {
+		return info.Size(), nil
+	}
+	return info.Size() - headerSize, nil
+}
+
+// Close implements io.Closer
+func (f *File) Close() error {
+	return f.file.Close()
+}
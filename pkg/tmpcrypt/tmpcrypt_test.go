@@ -0,0 +1,118 @@
+package tmpcrypt
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, key *Key, name, data string) {
+	w, err := key.Create(name)
+	require.Nil(t, err, "Could not create file")
+	_, err = io.WriteString(w, data)
+	require.Nil(t, err, "Could not write file")
+	require.Nil(t, w.Close(), "Could not close file")
+}
+
+func readFile(t *testing.T, key *Key, name string) string {
+	f, err := key.Open(name)
+	require.Nil(t, err, "Could not open file")
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.Nil(t, err, "Could not read file")
+	return string(data)
+}
+
+func TestEncryptsTempDir(t *testing.T) {
+	dir := t.TempDir()
+	key, err := New(dir)
+	require.Nil(t, err, "Could not create key")
+
+	require.True(t, key.Encrypts(filepath.Join(dir, "list")), "Could not encrypt file of the directory")
+	require.True(t, key.Encrypts(filepath.Join(dir, "sub", "list")), "Could not encrypt file of a subdirectory")
+	require.False(t, key.Encrypts(filepath.Join(dir, "..", "list")), "Could encrypt file outside of the directory")
+	require.False(t, (*Key)(nil).Encrypts(filepath.Join(dir, "list")), "Could encrypt file without key")
+}
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	key, err := New(dir)
+	require.Nil(t, err, "Could not create key")
+
+	name := filepath.Join(dir, "list")
+	data := strings.Repeat("www.example.com\n", 100)
+	writeFile(t, key, name, data)
+
+	raw, err := os.ReadFile(name)
+	require.Nil(t, err, "Could not read raw file")
+	require.False(t, bytes.Contains(raw, []byte("example.com")), "Could not encrypt file")
+	require.Equal(t, data, readFile(t, key, name), "Could not decrypt file")
+
+	other, err := New(dir)
+	require.Nil(t, err, "Could not create key")
+	require.NotEqual(t, data, readFile(t, other, name), "Could decrypt file with another key")
+
+	size, err := key.Size(name)
+	require.Nil(t, err, "Could not get file size")
+	require.Equal(t, int64(len(data)), size, "Could not get plaintext size")
+}
+
+func TestPlaintextOutsideDir(t *testing.T) {
+	key, err := New(t.TempDir())
+	require.Nil(t, err, "Could not create key")
+
+	name := filepath.Join(t.TempDir(), "list")
+	writeFile(t, key, name, "www.example.com\n")
+	raw, err := os.ReadFile(name)
+	require.Nil(t, err, "Could not read raw file")
+	require.Equal(t, "www.example.com\n", string(raw), "Could not keep file outside of the directory in plaintext")
+}
+
+func TestAppendSeekTruncate(t *testing.T) {
+	dir := t.TempDir()
+	key, err := New(dir)
+	require.Nil(t, err, "Could not create key")
+
+	name := filepath.Join(dir, "output")
+	// Write at offsets not aligned on blocks
+	for _, part := range []string{"a.example.com\n", "b.example.com\n", "c.exa"} {
+		w, err := key.Append(name)
+		require.Nil(t, err, "Could not open file for appending")
+		_, err = io.WriteString(w, part)
+		require.Nil(t, err, "Could not append to file")
+		require.Nil(t, w.Close(), "Could not close file")
+	}
+	require.Equal(t, "a.example.com\nb.example.com\nc.exa", readFile(t, key, name), "Could not decrypt appended file")
+
+	f, err := key.Open(name)
+	require.Nil(t, err, "Could not open file")
+	_, err = f.Seek(14, io.SeekStart)
+	require.Nil(t, err, "Could not seek file")
+	data, err := io.ReadAll(f)
+	require.Nil(t, err, "Could not read file")
+	require.Equal(t, "b.example.com\nc.exa", string(data), "Could not decrypt from offset")
+
+	chunk := make([]byte, 5)
+	_, err = f.ReadAt(chunk, 28)
+	require.Nil(t, err, "Could not read file at offset")
+	require.Equal(t, "c.exa", string(chunk), "Could not decrypt at offset")
+	f.Close()
+
+	require.Nil(t, key.Truncate(name, 28), "Could not truncate file")
+	require.Equal(t, "a.example.com\nb.example.com\n", readFile(t, key, name), "Could not truncate plaintext")
+}
+
+func TestOpenEmpty(t *testing.T) {
+	dir := t.TempDir()
+	key, err := New(dir)
+	require.Nil(t, err, "Could not create key")
+
+	name := filepath.Join(dir, "output")
+	require.Nil(t, os.WriteFile(name, nil, 0644), "Could not create file")
+	require.Equal(t, "", readFile(t, key, name), "Could not read empty file")
+}
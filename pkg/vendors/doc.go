@@ -0,0 +1,3 @@
+// Package vendors fingerprints the SaaS and cloud vendors serving
+// hostnames from their CNAME targets using a built-in pattern table.
+package vendors
@@ -0,0 +1,130 @@
+package vendors
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+//go:embed fingerprints.txt
+var builtinFingerprints string
+
+// fingerprint is a CNAME target pattern of a vendor
+type fingerprint struct {
+	pattern string
+	vendor  string
+	glob    bool
+}
+
+// matches returns true if a normalized CNAME target matches the pattern
+func (f fingerprint) matches(target string) bool {
+	if f.glob {
+		matched, _ := path.Match(f.pattern, target)
+		return matched
+	}
+	return target == f.pattern || strings.HasSuffix(target, "."+f.pattern)
+}
+
+// Fingerprints detects the vendors of CNAME targets
+type Fingerprints struct {
+	fingerprints []fingerprint
+}
+
+// New creates fingerprints with the built-in patterns extended with
+// the ones contained in the optional extra file.
+func New(extraFile string) (*Fingerprints, error) {
+	f := &Fingerprints{}
+	if err := f.read(strings.NewReader(builtinFingerprints)); err != nil {
+		return nil, err
+	}
+
+	if extraFile != "" {
+		file, err := os.Open(extraFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		if err := f.read(file); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// read reads patterns in the "pattern vendor" per line format, the
+// vendor name being the rest of the line.
+func (f *Fingerprints) read(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			return fmt.Errorf("invalid vendor fingerprint line: %s", line)
+		}
+		pattern := dnsname.Normalize(parts[0])
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid vendor fingerprint pattern: %s", parts[0])
+		}
+		f.fingerprints = append(f.fingerprints, fingerprint{
+			pattern: pattern,
+			vendor:  strings.Join(parts[1:], " "),
+			glob:    strings.Contains(pattern, "*"),
+		})
+	}
+	return scanner.Err()
+}
+
+// Match returns the vendor of a CNAME target, matched by the longest
+// pattern, or an empty string if it is unknown.
+func (f *Fingerprints) Match(target string) string {
+	target = dnsname.Normalize(target)
+
+	var vendor string
+	var length int
+	for _, fp := range f.fingerprints {
+		if len(fp.pattern) > length && fp.matches(target) {
+			vendor, length = fp.vendor, len(fp.pattern)
+		}
+	}
+	return vendor
+}
+
+// MatchChain returns the vendor of the first target of a CNAME chain
+// belonging to a known vendor, which is the closest to the hostname:
+// a Zendesk help center fronted by Cloudflare is reported as Zendesk.
+func (f *Fingerprints) MatchChain(chain []string) string {
+	for _, target := range chain {
+		if vendor := f.Match(target); vendor != "" {
+			return vendor
+		}
+	}
+	return ""
+}
+
+var (
+	builtinOnce sync.Once
+	builtin     *Fingerprints
+)
+
+// Match returns the vendor of a CNAME target using the built-in
+// patterns, or an empty string if it is unknown.
+func Match(target string) string {
+	builtinOnce.Do(func() {
+		builtin = &Fingerprints{}
+		if err := builtin.read(strings.NewReader(builtinFingerprints)); err != nil {
+			panic(err)
+		}
+	})
+	return builtin.Match(target)
+}
@@ -0,0 +1,41 @@
+package vendors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprintsMatch(t *testing.T) {
+	f, err := New("")
+	require.Nil(t, err, "Could not load built-in fingerprints")
+
+	require.Equal(t, "Zendesk", f.Match("acme.zendesk.com."), "Could not match a suffix")
+	require.Equal(t, "Fastly", f.Match("example.global.ssl.fastly.net"), "Could not match the longest suffix")
+	require.Equal(t, "AWS Elastic Load Balancing", f.Match("web-123.us-east-1.elb.amazonaws.com"), "Could not match aws elb")
+	require.Equal(t, "Amazon S3", f.Match("bucket.s3-website-us-east-1.amazonaws.com"), "Could not match a glob pattern")
+	require.Equal(t, "Salesforce", f.Match("acme.my.salesforce-sites.com"), "Could not match salesforce")
+	require.Equal(t, "", f.Match("www.example.com"), "Could not ignore an unknown target")
+	require.Equal(t, "", f.Match("notzendesk.com"), "Could not match on label boundaries")
+}
+
+func TestFingerprintsMatchChain(t *testing.T) {
+	f, err := New("")
+	require.Nil(t, err, "Could not load built-in fingerprints")
+
+	chain := []string{"support.example.org", "acme.zendesk.com", "acme.zendesk.com.cdn.cloudflare.net"}
+	require.Equal(t, "Zendesk", f.MatchChain(chain), "Could not match the closest vendor")
+	require.Equal(t, "", f.MatchChain([]string{"a.example.org"}), "Could not ignore an unknown chain")
+}
+
+func TestFingerprintsExtra(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "vendors.txt")
+	require.Nil(t, os.WriteFile(file, []byte("*.acme-edge.net Acme Edge\n"), 0644))
+
+	f, err := New(file)
+	require.Nil(t, err, "Could not load extra fingerprints")
+	require.Equal(t, "Acme Edge", f.Match("x.eu.acme-edge.net"), "Could not match an extra vendor")
+	require.Equal(t, "Zendesk", f.Match("acme.zendesk.com"), "Could not keep the built-in vendors")
+}
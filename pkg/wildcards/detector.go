@@ -0,0 +1,296 @@
+package wildcards
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+	"github.com/remeh/sizedwaitgroup"
+)
+
+// DefaultThreshold is the number of hostnames resolving to the same ip
+// after which the ip is checked for wildcards.
+const DefaultThreshold = 5
+
+// Transport resolves the A records of names. Names which don't exist
+// return no records and no error, while an error means the name could
+// not be resolved at all. *Resolver implements it with a list of
+// dns servers.
+type Transport interface {
+	Resolve(name string) ([]string, error)
+}
+
+// Detector finds wildcards among the results of an enumeration.
+//
+// Results are added with AddResult as they are found. When more than
+// a threshold of hostnames resolve to the same ip, the ip is checked
+// by Detect: random names are resolved at every level of one of its
+// hostnames and, if one of them resolves to the same ip as the
+// hostname, the ip is a wildcard ip and the level is a wildcard root.
+// It's safe for concurrent use.
+type Detector struct {
+	domain    string
+	transport Transport
+	strategy  WildcardStrategy
+	threshold int
+
+	mutex       sync.RWMutex
+	results     map[string]map[string]struct{}
+	checked     map[string]struct{}
+	wildcardIPs map[string]struct{}
+	roots       map[string]struct{}
+	found       map[string]*Wildcard
+}
+
+// NewDetector creates a detector for the subdomains of a domain
+// resolving the random names with the transport.
+func NewDetector(domain string, transport Transport) *Detector {
+	return &Detector{
+		domain:      domain,
+		transport:   transport,
+		strategy:    ExactIP{},
+		threshold:   DefaultThreshold,
+		results:     make(map[string]map[string]struct{}),
+		checked:     make(map[string]struct{}),
+		wildcardIPs: make(map[string]struct{}),
+		roots:       make(map[string]struct{}),
+		found:       make(map[string]*Wildcard),
+	}
+}
+
+// SetThreshold sets the number of hostnames resolving to the same ip
+// after which the ip is checked, 1 checking every ip.
+func (d *Detector) SetThreshold(threshold int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.threshold = threshold
+}
+
+// SetStrategy sets the strategy deciding whether hosts are answered by
+// wildcards, ExactIP being the default one.
+func (d *Detector) SetStrategy(strategy WildcardStrategy) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.strategy = strategy
+}
+
+// AddResult adds a hostname found during enumeration with its ips
+func (d *Detector) AddResult(host string, ips ...string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, ip := range ips {
+		hosts, ok := d.results[ip]
+		if !ok {
+			hosts = make(map[string]struct{})
+			d.results[ip] = hosts
+		}
+		hosts[host] = struct{}{}
+	}
+}
+
+// Detect checks the ips over the threshold which haven't been checked
+// yet, using at most threads concurrent checks.
+func (d *Detector) Detect(threads int) {
+	d.mutex.Lock()
+	pending := make(map[string][]string)
+	for ip, hosts := range d.results {
+		if _, ok := d.checked[ip]; ok || len(hosts) < d.threshold {
+			continue
+		}
+		d.checked[ip] = struct{}{}
+		for host := range hosts {
+			pending[ip] = append(pending[ip], host)
+		}
+		sort.Strings(pending[ip])
+	}
+	d.mutex.Unlock()
+
+	wg := sizedwaitgroup.New(threads)
+	for ip, hosts := range pending {
+		wg.Add()
+		go func(ip string, hosts []string) {
+			defer wg.Done()
+
+			for _, host := range hosts {
+				// The ip may have been found by another check meanwhile
+				if d.isWildcardIP(ip) {
+					return
+				}
+				if isWildcard, _ := d.LookupHost(host); isWildcard {
+					d.mutex.Lock()
+					// The ip is a wildcard as it resolved at least once for the host
+					d.wildcardIPs[ip] = struct{}{}
+					d.mutex.Unlock()
+					return
+				}
+			}
+		}(ip, hosts)
+	}
+	wg.Wait()
+}
+
+// IsWildcard returns true if the hostname resolves to a wildcard ip
+// found by Detect.
+func (d *Detector) IsWildcard(host string) bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	for ip := range d.wildcardIPs {
+		if _, ok := d.results[ip][host]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isWildcardIP returns true if an ip is a known wildcard ip
+func (d *Detector) isWildcardIP(ip string) bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	_, ok := d.wildcardIPs[ip]
+	return ok
+}
+
+// WildcardIPs returns the sorted wildcard ips found
+func (d *Detector) WildcardIPs() []string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return sortedKeys(d.wildcardIPs)
+}
+
+// Roots returns the sorted wildcard roots found (e.g. *.dev.example.com)
+func (d *Detector) Roots() []string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return sortedKeys(d.roots)
+}
+
+// Wildcards returns the wildcards found sorted by root, along with the
+// evidence of their detection
+func (d *Detector) Wildcards() []*Wildcard {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return SortedWildcards(d.found)
+}
+
+// LookupHost checks whether a host is a wildcard, returning the ips
+// random names resolved to at the levels of the host. To determine,
+// the strategy checks every level of the host, which with the default
+// one means that a random name is resolved at every level and the host
+// is a wildcard if one of them resolves to one of the host ips.
+// Both the wildcard ips and roots found are recorded in the detector.
+func (d *Detector) LookupHost(host string) (bool, map[string]struct{}) {
+	orig := make(map[string]struct{})
+	wildcards := make(map[string]struct{})
+
+	ips, err := d.transport.Resolve(host)
+	if err == nil {
+		for _, ip := range ips {
+			orig[ip] = struct{}{}
+		}
+	}
+
+	// The levels start at the registered domain of the hosts outside
+	// of the domain, and never at a public suffix like co.uk
+	domain := d.domain
+	if domain == "" || (host != domain && !strings.HasSuffix(host, "."+domain)) {
+		domain = dnsname.RegisteredDomain(host)
+	}
+	subdomainPart := strings.TrimSuffix(host, "."+domain)
+	subdomainTokens := strings.Split(subdomainPart, ".")
+
+	// We use a rand prefix at the beginning like %rand%.domain.tld
+	// A permutation is generated for each level of the subdomain,
+	// from the highest to the lowest one.
+	var levels []string
+	if !dnsname.IsPublicSuffix(domain) {
+		levels = append(levels, domain)
+	}
+	if host != domain {
+		for i := len(subdomainTokens) - 1; i >= 0; i-- {
+			levels = append(levels, strings.Join(subdomainTokens[i:], ".")+"."+domain)
+		}
+	}
+
+	d.mutex.RLock()
+	strategy := d.strategy
+	d.mutex.RUnlock()
+
+	isWildcard := false
+	for _, level := range levels {
+		wildcard, ips := strategy.IsWildcardLevel(d.transport, host, orig, level)
+		for _, ip := range ips {
+			wildcards[ip] = struct{}{}
+		}
+		if !wildcard {
+			continue
+		}
+
+		var found *Wildcard
+		if !isWildcard {
+			found = d.evidence(strategy, host, orig, level, ips)
+		}
+
+		d.mutex.Lock()
+		for _, ip := range ips {
+			if _, ok := orig[ip]; ok {
+				d.wildcardIPs[ip] = struct{}{}
+			}
+		}
+		// The levels below the highest wildcard root are implied by it
+		if !isWildcard {
+			d.roots["*."+level] = struct{}{}
+			MergeWildcard(d.found, found)
+		}
+		d.mutex.Unlock()
+		isWildcard = true
+	}
+	return isWildcard, wildcards
+}
+
+// evidence returns the wildcard found at a level for a host, with the
+// ips of the probes at the level
+func (d *Detector) evidence(strategy WildcardStrategy, host string, hostIPs map[string]struct{}, level string, probeIPs []string) *Wildcard {
+	wildcard := &Wildcard{
+		Root:       "*." + level,
+		Type:       "A",
+		Strategy:   StrategyName(strategy),
+		DetectedAt: time.Now(),
+		Evidence: Evidence{
+			Host:     host,
+			HostIPs:  sortedKeys(hostIPs),
+			ProbeIPs: mergeSorted(nil, probeIPs),
+		},
+	}
+	for _, ip := range wildcard.Evidence.ProbeIPs {
+		if _, ok := hostIPs[ip]; ok {
+			wildcard.IPs = append(wildcard.IPs, ip)
+		}
+	}
+	if _, ok := strategy.(CNAME); ok {
+		wildcard.Type = "CNAME"
+		if transport, ok := d.transport.(CNAMETransport); ok {
+			wildcard.CNAME, _ = transport.ResolveCNAME(host)
+		}
+	}
+	return wildcard
+}
+
+// sortedKeys returns the sorted keys of a set
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
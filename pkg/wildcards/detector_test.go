@@ -0,0 +1,100 @@
+package wildcards
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mohammadanaraki/shuffledns/internal/benchdata"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransport resolves every name under dev.example.com to the same ip
+type fakeTransport struct{}
+
+func (fakeTransport) Resolve(name string) ([]string, error) {
+	switch {
+	case strings.HasSuffix(name, ".dev.example.com"):
+		return []string{"1.2.3.4"}, nil
+	case name == "www.example.com":
+		return []string{"5.6.7.8"}, nil
+	}
+	return nil, nil
+}
+
+func TestDetectorDetect(t *testing.T) {
+	detector := NewDetector("example.com", fakeTransport{})
+	for _, host := range []string{"a", "b", "c", "d", "e"} {
+		detector.AddResult(host+".dev.example.com", "1.2.3.4")
+	}
+	detector.AddResult("www.example.com", "5.6.7.8")
+	detector.Detect(2)
+
+	require.True(t, detector.IsWildcard("a.dev.example.com"), "Could not detect wildcard")
+	require.False(t, detector.IsWildcard("www.example.com"), "Could not ignore ip under the threshold")
+	require.Equal(t, []string{"1.2.3.4"}, detector.WildcardIPs(), "Could not get wildcard ips")
+	require.Equal(t, []string{"*.dev.example.com"}, detector.Roots(), "Could not get wildcard roots")
+
+	found := detector.Wildcards()
+	require.Len(t, found, 1, "Could not get wildcards")
+	require.Equal(t, "*.dev.example.com", found[0].Root, "Could not get wildcard root")
+	require.Equal(t, "A", found[0].Type, "Could not get wildcard type")
+	require.Equal(t, []string{"1.2.3.4"}, found[0].IPs, "Could not get wildcard root ips")
+	require.Equal(t, "exact-ip", found[0].Strategy, "Could not get wildcard strategy")
+	require.True(t, strings.HasSuffix(found[0].Evidence.Host, ".dev.example.com"), "Could not get evidence host")
+	require.Equal(t, []string{"1.2.3.4"}, found[0].Evidence.ProbeIPs, "Could not get evidence probe ips")
+	require.False(t, found[0].DetectedAt.IsZero(), "Could not get detection time")
+}
+
+func TestDetectorThreshold(t *testing.T) {
+	detector := NewDetector("example.com", fakeTransport{})
+	detector.SetThreshold(1)
+	detector.AddResult("a.dev.example.com", "1.2.3.4")
+	detector.AddResult("www.example.com", "5.6.7.8")
+	detector.Detect(1)
+
+	require.True(t, detector.IsWildcard("a.dev.example.com"), "Could not detect wildcard under default threshold")
+	require.False(t, detector.IsWildcard("www.example.com"), "Could not detect non wildcard")
+}
+
+// suffixTransport resolves every name under co.uk to the same ip
+type suffixTransport struct{}
+
+func (suffixTransport) Resolve(name string) ([]string, error) {
+	if strings.HasSuffix(name, ".co.uk") {
+		return []string{"1.2.3.4"}, nil
+	}
+	return nil, nil
+}
+
+func TestDetectorPublicSuffix(t *testing.T) {
+	detector := NewDetector("", suffixTransport{})
+	isWildcard, _ := detector.LookupHost("a.dev.example.co.uk")
+
+	require.True(t, isWildcard, "Could not detect wildcard")
+	require.Equal(t, []string{"*.example.co.uk"}, detector.Roots(), "Could not stop wildcard roots at the registered domain")
+}
+
+// benchTransport resolves the names below the wildcard root of the fixtures
+type benchTransport struct{}
+
+func (benchTransport) Resolve(name string) ([]string, error) {
+	if strings.HasSuffix(name, "."+benchdata.WildcardRoot) {
+		return []string{benchdata.WildcardIP}, nil
+	}
+	return nil, nil
+}
+
+func BenchmarkDetectorDetect(b *testing.B) {
+	hostnames := benchdata.Hostnames(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		detector := NewDetector(benchdata.Domain, benchTransport{})
+		for j, hostname := range hostnames {
+			detector.AddResult(hostname, benchdata.IP(j))
+		}
+		detector.Detect(10)
+		for _, hostname := range hostnames {
+			detector.IsWildcard(hostname)
+		}
+	}
+}
@@ -1,3 +1,15 @@
 // Package wildcards contains helper functions for working with
 // wildcards during dns enumeration for removing wildcard subdomains.
+//
+// The Detector type implements the wildcard detection algorithm of
+// shuffledns for other tools, resolving names with a pluggable
+// Transport:
+//
+//	resolver, _ := wildcards.NewResolver("example.com", 5)
+//	resolver.AddServersFromList([]string{"1.1.1.1", "8.8.8.8"})
+//
+//	detector := wildcards.NewDetector("example.com", resolver)
+//	detector.AddResult("a.dev.example.com", "192.0.2.1")
+//	detector.Detect(25)
+//	detector.IsWildcard("a.dev.example.com")
 package wildcards
@@ -1,13 +1,14 @@
 package wildcards
 
 import (
-	"bufio"
-	"os"
+	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/miekg/dns"
+	"github.com/mohammadanaraki/shuffledns/pkg/backoff"
+	"github.com/mohammadanaraki/shuffledns/pkg/resolvers"
 	"github.com/projectdiscovery/roundrobin/transport"
-	"github.com/rs/xid"
 )
 
 // Resolver represents a dns resolver for removing wildcards
@@ -18,6 +19,16 @@ type Resolver struct {
 	domain string
 	// maxRetries is the maximum number of retries allowed
 	maxRetries int
+	// strategy decides whether hosts are answered by wildcards
+	strategy WildcardStrategy
+	// backoff is the policy for the delays between retries
+	backoff backoff.Policy
+
+	// roots contains the wildcard roots found by LookupHost, along
+	// with the wildcards found at them
+	roots      map[string]struct{}
+	found      map[string]*Wildcard
+	rootsMutex sync.Mutex
 }
 
 // NewResolver initializes and creates a new resolver to find wildcards
@@ -25,38 +36,56 @@ func NewResolver(domain string, retries int) (*Resolver, error) {
 	resolver := &Resolver{
 		domain:     domain,
 		maxRetries: retries,
+		strategy:   ExactIP{},
+		backoff:    backoff.DefaultPolicy,
+		roots:      make(map[string]struct{}),
+		found:      make(map[string]*Wildcard),
 	}
 	return resolver, nil
 }
 
+// ForDomain returns a resolver finding wildcards for another domain
+// using the same servers.
+func (w *Resolver) ForDomain(domain string) *Resolver {
+	return &Resolver{
+		servers:    w.servers,
+		domain:     domain,
+		maxRetries: w.maxRetries,
+		strategy:   w.strategy,
+		backoff:    w.backoff,
+		roots:      make(map[string]struct{}),
+		found:      make(map[string]*Wildcard),
+	}
+}
+
+// SetBackoff sets the policy for the delays between retries
+func (w *Resolver) SetBackoff(policy backoff.Policy) {
+	w.backoff = policy
+}
+
+// SetStrategy sets the strategy used by LookupHost
+func (w *Resolver) SetStrategy(strategy WildcardStrategy) {
+	w.strategy = strategy
+}
+
 // AddServersFromList adds the resolvers from a list of servers
-func (w *Resolver) AddServersFromList(list []string) {
-	for i := 0; i < len(list); i++ {
-		list[i] = list[i] + ":53"
+// (ip, ip:port or [ipv6]:port)
+func (w *Resolver) AddServersFromList(list []string) error {
+	servers, err := resolvers.ParseList(list)
+	if err != nil {
+		return err
 	}
-	w.servers, _ = transport.New(list...)
+	w.servers, _ = transport.New(servers...)
+	return nil
 }
 
 // AddServersFromFile adds the resolvers from a file to the list of servers
 func (w *Resolver) AddServersFromFile(file string) error {
-	f, err := os.Open(file)
+	servers, err := resolvers.ReadFile(file)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-
-	var servers []string
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		text := scanner.Text()
-		if text == "" {
-			continue
-		}
-		servers = append(servers, text+":53")
-	}
-
 	w.servers, _ = transport.New(servers...)
-
 	return nil
 }
 
@@ -65,80 +94,327 @@ func (w *Resolver) AddServersFromFile(file string) error {
 // of it's levels, check for wildcard on each one of them and if found any,
 // we remove all the hosts that have this IP from the map.
 func (w *Resolver) LookupHost(host string) (bool, map[string]struct{}) {
-	orig := make(map[string]struct{})
-	wildcards := make(map[string]struct{})
-
-	subdomainPart := strings.TrimSuffix(host, "."+w.domain)
-	subdomainTokens := strings.Split(subdomainPart, ".")
-
-	// Build an array by preallocating a slice of a length
-	// and create the wildcard generation prefix.
-	// We use a rand prefix at the beginning like %rand%.domain.tld
-	// A permutation is generated for each level of the subdomain.
-	var hosts []string
-	hosts = append(hosts, host)
-	hosts = append(hosts, xid.New().String()+"."+w.domain)
-
-	for i := 0; i < len(subdomainTokens); i++ {
-		newhost := xid.New().String() + "." + strings.Join(subdomainTokens[i:], ".") + "." + w.domain
-		hosts = append(hosts, newhost)
-	}
-
-	// Iterate over all the hosts generated for rand.
-	for _, h := range hosts {
-		resolver := w.servers.Next()
-		var retryCount int
-	retry:
-		// Create a dns message and send it to the server
-		m := new(dns.Msg)
-		m.Id = dns.Id()
-		m.RecursionDesired = true
-		m.Question = make([]dns.Question, 1)
-		question := dns.Fqdn(h)
-		m.Question[0] = dns.Question{
-			Name:   question,
-			Qtype:  dns.TypeA,
-			Qclass: dns.ClassINET,
+	detector := NewDetector(w.domain, w)
+	detector.SetStrategy(w.strategy)
+	isWildcard, ips := detector.LookupHost(host)
+
+	w.rootsMutex.Lock()
+	for _, root := range detector.Roots() {
+		w.roots[root] = struct{}{}
+	}
+	for _, wildcard := range detector.Wildcards() {
+		MergeWildcard(w.found, wildcard)
+	}
+	w.rootsMutex.Unlock()
+	return isWildcard, ips
+}
+
+// Roots returns the sorted wildcard roots found by LookupHost
+// (e.g. *.dev.example.com)
+func (w *Resolver) Roots() []string {
+	w.rootsMutex.Lock()
+	defer w.rootsMutex.Unlock()
+
+	return sortedKeys(w.roots)
+}
+
+// Wildcards returns the wildcards found by LookupHost sorted by root,
+// along with the evidence of their detection
+func (w *Resolver) Wildcards() []*Wildcard {
+	w.rootsMutex.Lock()
+	defer w.rootsMutex.Unlock()
+
+	return SortedWildcards(w.found)
+}
+
+// Resolve returns the A records of a host using the resolver servers.
+// It returns an error if none of the retries got an answer, while
+// a non-existent host returns no records and no error.
+func (w *Resolver) Resolve(host string) ([]string, error) {
+	in, err := w.exchange(dns.Fqdn(host), dns.TypeA)
+	if err != nil || in == nil {
+		return nil, err
+	}
+
+	var ips []string
+	for _, record := range in.Answer {
+		if t, ok := record.(*dns.A); ok {
+			ips = append(ips, t.A.String())
 		}
-		in, err := dns.Exchange(m, resolver)
-		if err != nil {
-			if retryCount < w.maxRetries {
-				retryCount++
-				goto retry
-			}
-			// Skip the current host if there are no more retries
-			retryCount = 0
+	}
+	return ips, nil
+}
+
+// ResolveCNAME returns the CNAME chain followed to resolve a host
+func (w *Resolver) ResolveCNAME(host string) ([]string, error) {
+	in, err := w.exchange(dns.Fqdn(host), dns.TypeA)
+	if err != nil || in == nil {
+		return nil, err
+	}
+
+	var chain []string
+	for _, record := range in.Answer {
+		if t, ok := record.(*dns.CNAME); ok {
+			chain = append(chain, strings.TrimSuffix(t.Target, "."))
+		}
+	}
+	return chain, nil
+}
+
+// LookupPTR returns the reverse names of an ip address
+func (w *Resolver) LookupPTR(ip string) ([]string, error) {
+	name, err := dns.ReverseAddr(ip)
+	if err != nil {
+		return nil, err
+	}
+	in, err := w.exchange(name, dns.TypePTR)
+	if err != nil || in == nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, record := range in.Answer {
+		if t, ok := record.(*dns.PTR); ok {
+			names = append(names, strings.TrimSuffix(t.Ptr, "."))
+		}
+	}
+	return names, nil
+}
+
+// LookupTXT returns the TXT records of a name, the strings of each
+// record being joined
+func (w *Resolver) LookupTXT(name string) ([]string, error) {
+	in, err := w.exchange(dns.Fqdn(name), dns.TypeTXT)
+	if err != nil || in == nil {
+		return nil, err
+	}
+
+	var records []string
+	for _, record := range in.Answer {
+		if t, ok := record.(*dns.TXT); ok {
+			records = append(records, strings.Join(t.Txt, ""))
+		}
+	}
+	return records, nil
+}
+
+// LookupNS returns the nameservers of a zone, an empty list if the name
+// is not the apex of a zone
+func (w *Resolver) LookupNS(name string) ([]string, error) {
+	in, err := w.exchange(dns.Fqdn(name), dns.TypeNS)
+	if err != nil || in == nil {
+		return nil, err
+	}
+
+	var nameservers []string
+	for _, record := range in.Answer {
+		if t, ok := record.(*dns.NS); ok {
+			nameservers = append(nameservers, strings.ToLower(strings.TrimSuffix(t.Ns, ".")))
+		}
+	}
+	return nameservers, nil
+}
+
+// anyFallbackTypes are the types queried one by one when the servers
+// refuse ANY queries
+var anyFallbackTypes = []uint16{
+	dns.TypeA, dns.TypeAAAA, dns.TypeCNAME, dns.TypeMX, dns.TypeNS,
+	dns.TypeTXT, dns.TypeSOA, dns.TypeCAA, dns.TypeSRV,
+}
+
+// LookupAny returns the records of a name per type, found with an ANY
+// query or, when the servers refuse it as allowed by RFC 8482, with a
+// query per common type. It also returns whether the fallback was used.
+func (w *Resolver) LookupAny(name string) (map[string][]string, bool, error) {
+	name = dns.Fqdn(name)
+	in, err := w.exchange(name, dns.TypeANY)
+	if err != nil {
+		return nil, false, err
+	}
+	if records := ownRecords(in, name); len(records) > 0 && !refusedAny(in) {
+		return records, false, nil
+	}
+
+	records, err := w.LookupTypes(name, anyFallbackTypes)
+	return records, true, err
+}
+
+// LookupTypes returns the records of a name per type with a query per
+// type, failing only if all the queries failed.
+func (w *Resolver) LookupTypes(name string, types []uint16) (map[string][]string, error) {
+	name = dns.Fqdn(name)
+	records := make(map[string][]string)
+	var failed int
+	var err error
+	for _, qtype := range types {
+		in, queryErr := w.exchange(name, qtype)
+		if queryErr != nil {
+			err = queryErr
+			failed++
 			continue
 		}
+		for recordType, values := range ownRecords(in, name) {
+			records[recordType] = appendUnique(records[recordType], values...)
+		}
+	}
+	if failed == len(types) {
+		return nil, err
+	}
+	return records, nil
+}
 
-		// Skip the current host since we can't resolve it
-		if in != nil && in.Rcode != dns.RcodeSuccess {
+// ParseRecordTypes parses a comma separated list of record types, like
+// A,AAAA,MX, in any case
+func ParseRecordTypes(value string) ([]uint16, error) {
+	var types []uint16
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
 			continue
 		}
+		qtype, ok := dns.StringToType[name]
+		if !ok || qtype == dns.TypeANY {
+			return nil, fmt.Errorf("unknown record type: %s", name)
+		}
+		types = append(types, qtype)
+	}
+	return types, nil
+}
 
-		// Get all the records and add them to the wildcard map
-		for _, record := range in.Answer {
-			if t, ok := record.(*dns.A); ok {
-				r := t.A.String()
+// refusedAny returns true if the response to an ANY query is the HINFO
+// record of RFC 8482 instead of the records of the name
+func refusedAny(in *dns.Msg) bool {
+	for _, record := range in.Answer {
+		if t, ok := record.(*dns.HINFO); !ok || t.Cpu != "RFC8482" {
+			return false
+		}
+	}
+	return true
+}
 
-				if host == h {
-					orig[r] = struct{}{}
-					continue
-				}
+// ownRecords returns the values of the records of a response owned by a
+// name per type, without the records of the CNAME targets
+func ownRecords(in *dns.Msg, name string) map[string][]string {
+	records := make(map[string][]string)
+	if in == nil {
+		return records
+	}
+	for _, record := range in.Answer {
+		header := record.Header()
+		if !strings.EqualFold(header.Name, name) {
+			continue
+		}
+		recordType := dns.TypeToString[header.Rrtype]
+		value := strings.TrimSpace(strings.TrimPrefix(record.String(), header.String()))
+		records[recordType] = appendUnique(records[recordType], value)
+	}
+	return records
+}
 
-				if _, ok := wildcards[r]; !ok {
-					wildcards[r] = struct{}{}
-				}
+// appendUnique appends the values not in a list yet
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		var found bool
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
 			}
 		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// ResolveFrom returns the A records and the CNAME chain of a host
+// using a specific server (ip, ip:port or [ipv6]:port) instead of
+// the resolver servers, like ResolveRecords.
+func (w *Resolver) ResolveFrom(server, host string) ([]string, []string, error) {
+	server, err := resolvers.Parse(server)
+	if err != nil {
+		return nil, nil, err
+	}
+	in, err := w.exchangeWith(func() string { return server }, dns.Fqdn(host), dns.TypeA)
+	if err != nil {
+		return nil, nil, err
 	}
+	return answerRecords(in)
+}
 
-	// check if original ip are among wildcards
-	for a := range orig {
-		if _, ok := wildcards[a]; ok {
-			return true, wildcards
+// ResolveRecords returns the A records and the CNAME chain of a host
+// using the resolver servers. A non-existent host (NXDOMAIN, or NOERROR
+// without records) returns no records and no error, while the other
+// response codes like SERVFAIL or REFUSED return an error as they don't
+// tell whether the host exists.
+func (w *Resolver) ResolveRecords(host string) ([]string, []string, error) {
+	in, err := w.exchangeWith(w.servers.Next, dns.Fqdn(host), dns.TypeA)
+	if err != nil {
+		return nil, nil, err
+	}
+	return answerRecords(in)
+}
+
+// answerRecords returns the A records and the CNAME chain of an answer,
+// or an error if its response code is neither NOERROR nor NXDOMAIN. The
+// CNAME chain of a NXDOMAIN answer is kept, its target being missing.
+func answerRecords(in *dns.Msg) ([]string, []string, error) {
+	if in.Rcode != dns.RcodeSuccess && in.Rcode != dns.RcodeNameError {
+		return nil, nil, fmt.Errorf("%s answer", dns.RcodeToString[in.Rcode])
+	}
+	ips, chain := hostRecords(in)
+	return ips, chain, nil
+}
+
+// hostRecords returns the A records and the CNAME chain of an answer
+func hostRecords(in *dns.Msg) ([]string, []string) {
+	var ips, chain []string
+	for _, record := range in.Answer {
+		switch t := record.(type) {
+		case *dns.A:
+			ips = append(ips, t.A.String())
+		case *dns.CNAME:
+			chain = append(chain, strings.TrimSuffix(t.Target, "."))
 		}
 	}
+	return ips, chain
+}
 
-	return false, wildcards
+// exchange sends a query to the resolver servers retrying on errors.
+// A nil message is returned if the query didn't succeed.
+func (w *Resolver) exchange(name string, qtype uint16) (*dns.Msg, error) {
+	in, err := w.exchangeWith(w.servers.Next, name, qtype)
+	if err != nil || in.Rcode != dns.RcodeSuccess {
+		return nil, err
+	}
+	return in, nil
+}
+
+// exchangeWith sends a query to the servers returned by next for each
+// attempt, retrying on errors. The answer is returned whatever its
+// response code.
+func (w *Resolver) exchangeWith(next func() string, name string, qtype uint16) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.Id = dns.Id()
+	m.RecursionDesired = true
+	m.Question = []dns.Question{{
+		Name:   name,
+		Qtype:  qtype,
+		Qclass: dns.ClassINET,
+	}}
+
+	var in *dns.Msg
+	var err error
+	for retryCount := 0; retryCount <= w.maxRetries; retryCount++ {
+		w.backoff.Sleep(retryCount)
+		in, err = dns.Exchange(m, next())
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return in, nil
 }
@@ -1,9 +1,11 @@
 package wildcards
 
 import (
+	"net"
 	"sync/atomic"
 	"testing"
 
+	"github.com/miekg/dns"
 	"github.com/stretchr/testify/require"
 )
 
@@ -24,3 +26,130 @@ func TestRotateRoundRobin(t *testing.T) {
 		atomic.AddInt32(&index, 1)
 	}
 }
+
+// startAnyServer starts a dns server answering the A and TXT records of
+// example.com, answering ANY queries as allowed by RFC 8482 if refuse
+func startAnyServer(t *testing.T, refuse bool) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err, "Could not listen")
+	server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		question := r.Question[0]
+		a, _ := dns.NewRR("example.com. 300 IN A 192.0.2.1")
+		txt, _ := dns.NewRR(`example.com. 300 IN TXT "v=spf1 -all"`)
+		switch question.Qtype {
+		case dns.TypeANY:
+			if refuse {
+				hinfo, _ := dns.NewRR(`example.com. 3789 IN HINFO "RFC8482" ""`)
+				m.Answer = []dns.RR{hinfo}
+			} else {
+				m.Answer = []dns.RR{a, txt}
+			}
+		case dns.TypeA:
+			m.Answer = []dns.RR{a}
+		case dns.TypeTXT:
+			m.Answer = []dns.RR{txt}
+		}
+		_ = w.WriteMsg(m)
+	})}
+	go func() { _ = server.ActivateAndServe() }()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return conn.LocalAddr().String()
+}
+
+func TestLookupAny(t *testing.T) {
+	expected := map[string][]string{"A": {"192.0.2.1"}, "TXT": {`"v=spf1 -all"`}}
+
+	for _, refuse := range []bool{false, true} {
+		resolver, err := NewResolver("example.com", 1)
+		require.Nil(t, err, "Could not create resolver")
+		require.Nil(t, resolver.AddServersFromList([]string{startAnyServer(t, refuse)}), "Could not add server")
+
+		records, fallback, err := resolver.LookupAny("example.com")
+		require.Nil(t, err, "Could not look up records")
+		require.Equal(t, refuse, fallback, "Could not fall back to per-type queries")
+		require.Equal(t, expected, records, "Could not collect records")
+	}
+}
+
+// startRcodeServer starts a dns server answering www.example.com with
+// an A record, alias.example.com with a CNAME to a missing target,
+// empty.example.com without records, fail.example.com with SERVFAIL and
+// the other names with NXDOMAIN
+func startRcodeServer(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err, "Could not listen")
+	server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		switch r.Question[0].Name {
+		case "www.example.com.":
+			a, _ := dns.NewRR("www.example.com. 300 IN A 192.0.2.1")
+			m.Answer = []dns.RR{a}
+		case "alias.example.com.":
+			cname, _ := dns.NewRR("alias.example.com. 300 IN CNAME gone.example.net.")
+			m.Answer = []dns.RR{cname}
+			m.Rcode = dns.RcodeNameError
+		case "empty.example.com.":
+		case "fail.example.com.":
+			m.Rcode = dns.RcodeServerFailure
+		default:
+			m.Rcode = dns.RcodeNameError
+		}
+		_ = w.WriteMsg(m)
+	})}
+	go func() { _ = server.ActivateAndServe() }()
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return conn.LocalAddr().String()
+}
+
+func TestResolveRecordsRcodes(t *testing.T) {
+	address := startRcodeServer(t)
+	resolver, err := NewResolver("example.com", 0)
+	require.Nil(t, err, "Could not create resolver")
+	require.Nil(t, resolver.AddServersFromList([]string{address}), "Could not add server")
+
+	ips, chain, err := resolver.ResolveRecords("www.example.com")
+	require.Nil(t, err, "Could not resolve existing host")
+	require.Equal(t, []string{"192.0.2.1"}, ips, "Could not get ips of existing host")
+	require.Empty(t, chain, "Could not get chain of existing host")
+
+	ips, chain, err = resolver.ResolveRecords("alias.example.com")
+	require.Nil(t, err, "Could not resolve CNAME-only host")
+	require.Empty(t, ips, "Could not get ips of CNAME-only host")
+	require.Equal(t, []string{"gone.example.net"}, chain, "Could not keep chain of CNAME-only host")
+
+	for _, host := range []string{"nope.example.com", "empty.example.com"} {
+		ips, chain, err = resolver.ResolveRecords(host)
+		require.Nil(t, err, "Could not resolve non-existent host %s", host)
+		require.Empty(t, ips, "Could not get no ips for %s", host)
+		require.Empty(t, chain, "Could not get no chain for %s", host)
+	}
+
+	_, _, err = resolver.ResolveRecords("fail.example.com")
+	require.NotNil(t, err, "Could not fail on SERVFAIL")
+	_, _, err = resolver.ResolveFrom(address, "fail.example.com")
+	require.NotNil(t, err, "Could not fail on SERVFAIL from server")
+}
+
+func TestParseRecordTypes(t *testing.T) {
+	types, err := ParseRecordTypes("a, AAAA,mx,")
+	require.Nil(t, err, "Could not parse record types")
+	require.Equal(t, []uint16{dns.TypeA, dns.TypeAAAA, dns.TypeMX}, types, "Could not get record types")
+
+	_, err = ParseRecordTypes("A,BOGUS")
+	require.NotNil(t, err, "Could not reject unknown record type")
+	_, err = ParseRecordTypes("ANY")
+	require.NotNil(t, err, "Could not reject ANY record type")
+}
+
+func TestLookupTypes(t *testing.T) {
+	resolver, err := NewResolver("example.com", 1)
+	require.Nil(t, err, "Could not create resolver")
+	require.Nil(t, resolver.AddServersFromList([]string{startAnyServer(t, true)}), "Could not add server")
+
+	records, err := resolver.LookupTypes("example.com", []uint16{dns.TypeTXT})
+	require.Nil(t, err, "Could not look up records")
+	require.Equal(t, map[string][]string{"TXT": {`"v=spf1 -all"`}}, records, "Could not collect records of the selected types")
+}
@@ -0,0 +1,165 @@
+package wildcards
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/xid"
+)
+
+// DefaultProbes is the number of random names resolved per level by
+// the strategies using more than one.
+const DefaultProbes = 3
+
+// WildcardStrategy decides whether a host is answered by a wildcard at
+// one of its levels (e.g. dev.example.com for a.dev.example.com).
+type WildcardStrategy interface {
+	// IsWildcardLevel returns true if the host, resolving to hostIPs,
+	// is answered by a wildcard at the level, along with the wildcard
+	// ips found at the level.
+	IsWildcardLevel(transport Transport, host string, hostIPs map[string]struct{}, level string) (bool, []string)
+}
+
+// CNAMETransport is a transport which also resolves the CNAME chain of
+// names, needed by the CNAME strategy.
+type CNAMETransport interface {
+	Transport
+	ResolveCNAME(name string) ([]string, error)
+}
+
+// ParseStrategy returns the strategy with a name: exact-ip, ip-set,
+// statistical or cname.
+func ParseStrategy(name string) (WildcardStrategy, error) {
+	switch name {
+	case "", "exact-ip":
+		return ExactIP{}, nil
+	case "ip-set":
+		return IPSet{Probes: DefaultProbes}, nil
+	case "statistical":
+		return Statistical{Probes: DefaultProbes}, nil
+	case "cname":
+		return CNAME{}, nil
+	}
+	return nil, fmt.Errorf("unknown wildcard mode %s", name)
+}
+
+// randomName returns a random name at a level
+func randomName(level string) string {
+	return xid.New().String() + "." + level
+}
+
+// ExactIP flags hosts resolving to the same ip as a random name at one
+// of their levels. It's the default strategy.
+type ExactIP struct{}
+
+// IsWildcardLevel implements WildcardStrategy
+func (ExactIP) IsWildcardLevel(transport Transport, host string, hostIPs map[string]struct{}, level string) (bool, []string) {
+	ips, err := transport.Resolve(randomName(level))
+	if err != nil {
+		return false, nil
+	}
+	return intersects(hostIPs, ips), ips
+}
+
+// IPSet flags hosts resolving to any of the ips of several random names
+// at one of their levels, for wildcards rotating over a pool of ips.
+type IPSet struct {
+	Probes int
+}
+
+// IsWildcardLevel implements WildcardStrategy
+func (s IPSet) IsWildcardLevel(transport Transport, host string, hostIPs map[string]struct{}, level string) (bool, []string) {
+	var pool []string
+	for i := 0; i < s.Probes; i++ {
+		ips, err := transport.Resolve(randomName(level))
+		if err != nil {
+			continue
+		}
+		pool = append(pool, ips...)
+	}
+	return intersects(hostIPs, pool), pool
+}
+
+// Statistical flags hosts at the levels where most random names resolve,
+// whatever their ips, for wildcards with geo-dependent answers. Real
+// hosts under such a level are flagged too.
+type Statistical struct {
+	Probes int
+}
+
+// IsWildcardLevel implements WildcardStrategy
+func (s Statistical) IsWildcardLevel(transport Transport, host string, hostIPs map[string]struct{}, level string) (bool, []string) {
+	var resolved int
+	var pool []string
+	for i := 0; i < s.Probes; i++ {
+		ips, err := transport.Resolve(randomName(level))
+		if err != nil || len(ips) == 0 {
+			continue
+		}
+		resolved++
+		pool = append(pool, ips...)
+	}
+	if resolved*2 <= s.Probes {
+		return false, pool
+	}
+	// The host ips are the wildcard ones, as answers vary
+	for ip := range hostIPs {
+		pool = append(pool, ip)
+	}
+	return true, pool
+}
+
+// CNAME flags hosts whose CNAME chain ends at the same target as the
+// one of a random name at one of their levels. The transport must
+// implement CNAMETransport.
+type CNAME struct{}
+
+// IsWildcardLevel implements WildcardStrategy
+func (CNAME) IsWildcardLevel(transport Transport, host string, hostIPs map[string]struct{}, level string) (bool, []string) {
+	cnameTransport, ok := transport.(CNAMETransport)
+	if !ok {
+		return false, nil
+	}
+	hostChain, err := cnameTransport.ResolveCNAME(host)
+	if err != nil || len(hostChain) == 0 {
+		return false, nil
+	}
+	chain, err := cnameTransport.ResolveCNAME(randomName(level))
+	if err != nil || len(chain) == 0 {
+		return false, nil
+	}
+	if !strings.EqualFold(chain[len(chain)-1], hostChain[len(hostChain)-1]) {
+		return false, nil
+	}
+
+	var ips []string
+	for ip := range hostIPs {
+		ips = append(ips, ip)
+	}
+	return true, ips
+}
+
+// ProbeLevel resolves random names at a level before any host under it
+// is known, returning true if all of them resolve, which means every
+// name under the level is answered by a wildcard, along with their ips.
+func ProbeLevel(transport Transport, level string, probes int) (bool, []string) {
+	var pool []string
+	for i := 0; i < probes; i++ {
+		ips, err := transport.Resolve(randomName(level))
+		if err != nil || len(ips) == 0 {
+			return false, nil
+		}
+		pool = append(pool, ips...)
+	}
+	return probes > 0, pool
+}
+
+// intersects returns true if one of the ips is in the set
+func intersects(set map[string]struct{}, ips []string) bool {
+	for _, ip := range ips {
+		if _, ok := set[ip]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package wildcards
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// strategyTransport simulates unusual wildcard behaviors:
+//   - names under rot.example.com rotate over a pool of ips
+//   - names under geo.example.com resolve to a different ip than the hosts
+//   - names under cdn.example.com are CNAMEs to the same target
+type strategyTransport struct {
+	next int
+}
+
+func (s *strategyTransport) Resolve(name string) ([]string, error) {
+	switch {
+	case name == "a.rot.example.com":
+		return []string{"10.0.0.3"}, nil
+	case strings.HasSuffix(name, ".rot.example.com"):
+		s.next++
+		return []string{fmt.Sprintf("10.0.0.%d", s.next%3+1)}, nil
+	case name == "a.geo.example.com":
+		return []string{"8.8.8.8"}, nil
+	case strings.HasSuffix(name, ".geo.example.com"):
+		return []string{"9.9.9.9"}, nil
+	case strings.HasSuffix(name, ".cdn.example.com"):
+		return []string{"7.7.7.7"}, nil
+	}
+	return nil, nil
+}
+
+func (s *strategyTransport) ResolveCNAME(name string) ([]string, error) {
+	if strings.HasSuffix(name, ".cdn.example.com") {
+		return []string{"edge.cdn.net"}, nil
+	}
+	return nil, nil
+}
+
+func TestStrategies(t *testing.T) {
+	for _, test := range []struct {
+		mode     string
+		host     string
+		wildcard bool
+	}{
+		{"exact-ip", "a.geo.example.com", false},
+		{"ip-set", "a.rot.example.com", true},
+		{"statistical", "a.geo.example.com", true},
+		{"statistical", "www.example.com", false},
+		{"cname", "a.cdn.example.com", true},
+		{"cname", "a.geo.example.com", false},
+	} {
+		strategy, err := ParseStrategy(test.mode)
+		require.Nil(t, err, "Could not parse strategy")
+
+		detector := NewDetector("example.com", &strategyTransport{})
+		detector.SetStrategy(strategy)
+		isWildcard, _ := detector.LookupHost(test.host)
+		require.Equal(t, test.wildcard, isWildcard, "Could not check %s with %s strategy", test.host, test.mode)
+	}
+
+	_, err := ParseStrategy("unknown")
+	require.NotNil(t, err, "Could not reject unknown strategy")
+}
+
+func TestProbeLevel(t *testing.T) {
+	isWildcard, ips := ProbeLevel(&strategyTransport{}, "geo.example.com", DefaultProbes)
+	require.True(t, isWildcard, "Could not detect a wildcard level")
+	require.Equal(t, []string{"9.9.9.9", "9.9.9.9", "9.9.9.9"}, ips, "Could not get the wildcard ips")
+
+	isWildcard, _ = ProbeLevel(&strategyTransport{}, "example.com", DefaultProbes)
+	require.False(t, isWildcard, "Could not detect a level without wildcard")
+}
@@ -0,0 +1,91 @@
+package wildcards
+
+import (
+	"sort"
+	"time"
+)
+
+// Wildcard is a wildcard root found along with the evidence of its
+// detection, written as json by the wildcard output
+type Wildcard struct {
+	// Root is the wildcard root (e.g. *.dev.example.com)
+	Root string `json:"root"`
+	// Type is the type of the records answered by the wildcard, A or
+	// CNAME with the cname strategy
+	Type string `json:"type"`
+	// IPs are the wildcard ips answered at the root
+	IPs []string `json:"ips,omitempty"`
+	// CNAME is the CNAME chain answered at the root
+	CNAME []string `json:"cname,omitempty"`
+	// Strategy is the strategy which detected the wildcard, or probe for
+	// the roots probed before resolving
+	Strategy string `json:"strategy"`
+	// Evidence is what the wildcard was detected from
+	Evidence Evidence `json:"evidence"`
+	// DetectedAt is the time the wildcard was first detected
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Evidence is the host found answered by a wildcard, if any, and the
+// answers of the random names probed at its root
+type Evidence struct {
+	Host     string   `json:"host,omitempty"`
+	HostIPs  []string `json:"host_ips,omitempty"`
+	ProbeIPs []string `json:"probe_ips,omitempty"`
+}
+
+// StrategyName returns the name of a strategy as parsed by ParseStrategy
+func StrategyName(strategy WildcardStrategy) string {
+	switch strategy.(type) {
+	case IPSet:
+		return "ip-set"
+	case Statistical:
+		return "statistical"
+	case CNAME:
+		return "cname"
+	}
+	return "exact-ip"
+}
+
+// MergeWildcard adds a wildcard to a set keyed by root, adding its ips
+// to the ones of the wildcard already found at the root if any. The ips
+// are kept sorted and unique.
+func MergeWildcard(set map[string]*Wildcard, wildcard *Wildcard) {
+	existing, ok := set[wildcard.Root]
+	if !ok {
+		copied := *wildcard
+		copied.IPs = mergeSorted(nil, wildcard.IPs)
+		copied.Evidence.ProbeIPs = mergeSorted(nil, wildcard.Evidence.ProbeIPs)
+		set[wildcard.Root] = &copied
+		return
+	}
+	existing.IPs = mergeSorted(existing.IPs, wildcard.IPs)
+	if wildcard.DetectedAt.Before(existing.DetectedAt) {
+		existing.DetectedAt = wildcard.DetectedAt
+	}
+}
+
+// SortedWildcards returns copies of the wildcards of a set sorted by root
+func SortedWildcards(set map[string]*Wildcard) []*Wildcard {
+	list := make([]*Wildcard, 0, len(set))
+	for _, wildcard := range set {
+		copied := *wildcard
+		list = append(list, &copied)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Root < list[j].Root
+	})
+	return list
+}
+
+// mergeSorted returns the sorted union of two lists
+func mergeSorted(a, b []string) []string {
+	set := make(map[string]struct{}, len(a)+len(b))
+	for _, value := range a {
+		set[value] = struct{}{}
+	}
+	for _, value := range b {
+		set[value] = struct{}{}
+	}
+	return sortedKeys(set)
+}
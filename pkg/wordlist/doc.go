@@ -0,0 +1,8 @@
+// Package wordlist normalizes bruteforce wordlists before they are
+// used, so that malformed entries don't generate wasted or invalid
+// queries.
+//
+// Words are lowercased, stripped of the characters not allowed in
+// dns labels and deduplicated, and the words with an overlong label
+// are dropped.
+package wordlist
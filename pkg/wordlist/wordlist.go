@@ -0,0 +1,103 @@
+package wordlist
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+// Stats contains the statistics of a normalized wordlist
+type Stats struct {
+	// Read is the number of words read, blank lines excluded
+	Read int
+	// Written is the number of words written
+	Written int
+	// Modified is the number of words lowercased or stripped of
+	// invalid characters
+	Modified int
+	// Duplicates is the number of duplicate words dropped
+	Duplicates int
+	// Invalid is the number of words without any valid character
+	Invalid int
+	// Overlong is the number of words with a label longer than 63
+	// characters dropped
+	Overlong int
+}
+
+// NormalizeWord returns the normalized form of a word, or an empty
+// string if it has no valid character. Words may contain several
+// labels separated by dots.
+func NormalizeWord(word string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(word)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			builder.WriteRune(r)
+		}
+	}
+
+	// Drop the empty labels and the hyphens labels can't start or end with
+	var labels []string
+	for _, label := range strings.Split(builder.String(), ".") {
+		if label = strings.Trim(label, "-"); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// overlong returns true if a word has a label longer than the maximum
+func overlong(word string) bool {
+	for _, label := range strings.Split(word, ".") {
+		if len(label) > dnsname.MaxLabelLength {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize reads the words of a wordlist and writes them normalized,
+// without duplicates and in their original order.
+func Normalize(r io.Reader, w io.Writer) (*Stats, error) {
+	stats := &Stats{}
+	seen := make(map[string]struct{})
+
+	writer := bufio.NewWriter(w)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" {
+			continue
+		}
+		stats.Read++
+
+		normalized := NormalizeWord(word)
+		switch {
+		case normalized == "":
+			stats.Invalid++
+			continue
+		case overlong(normalized):
+			stats.Overlong++
+			continue
+		}
+		if normalized != word {
+			stats.Modified++
+		}
+		if _, ok := seen[normalized]; ok {
+			stats.Duplicates++
+			continue
+		}
+		seen[normalized] = struct{}{}
+
+		if _, err := writer.WriteString(normalized + "\n"); err != nil {
+			return nil, err
+		}
+		stats.Written++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return stats, writer.Flush()
+}
@@ -0,0 +1,35 @@
+package wordlist
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeWord(t *testing.T) {
+	tests := map[string]string{
+		"WWW":          "www",
+		" api ":        "api",
+		"dev..api.":    "dev.api",
+		"-stg-":        "stg",
+		"_dmarc":       "_dmarc",
+		"shop!@#$":     "shop",
+		"café":         "caf",
+		"***":          "",
+		"mail.-corp-.": "mail.corp",
+	}
+	for word, expected := range tests {
+		require.Equal(t, expected, NormalizeWord(word), "Could not normalize %q", word)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	input := "www\nWWW\n\napi\n!!!\n" + strings.Repeat("a", 64) + "\ndev." + strings.Repeat("b", 64) + "\n" + strings.Repeat("c", 63) + "\nmail \n"
+
+	var output strings.Builder
+	stats, err := Normalize(strings.NewReader(input), &output)
+	require.Nil(t, err, "Could not normalize wordlist")
+	require.Equal(t, "www\napi\n"+strings.Repeat("c", 63)+"\nmail\n", output.String(), "Could not write normalized words")
+	require.Equal(t, &Stats{Read: 8, Written: 4, Modified: 1, Duplicates: 1, Invalid: 1, Overlong: 2}, stats, "Could not count words")
+}
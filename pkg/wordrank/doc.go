@@ -0,0 +1,4 @@
+// Package wordrank orders bruteforce words by their probability of
+// being found, using a built-in corpus of common subdomain words and
+// the words of the already known subdomains of a target.
+package wordrank
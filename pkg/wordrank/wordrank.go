@@ -0,0 +1,95 @@
+package wordrank
+
+import (
+	_ "embed"
+	"sort"
+	"strings"
+
+	"github.com/mohammadanaraki/shuffledns/pkg/dnsname"
+)
+
+//go:embed words.txt
+var builtinWords string
+
+// learnedWeight is the score given to each occurrence of a word in the
+// known subdomains, ranking them before any word of the corpus.
+const learnedWeight = 1.0
+
+// Ranker scores words by their probability of being found
+type Ranker struct {
+	scores map[string]float64
+}
+
+// New creates a ranker scoring the words of the built-in corpus
+func New() *Ranker {
+	var words []string
+	for _, line := range strings.Split(builtinWords, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+
+	// The corpus is ordered by frequency, its scores are all below
+	// the score of a single occurrence in the known subdomains.
+	ranker := &Ranker{scores: make(map[string]float64)}
+	for i, word := range words {
+		if _, ok := ranker.scores[word]; !ok {
+			ranker.scores[word] = float64(len(words)-i) / float64(len(words)+1)
+		}
+	}
+	return ranker
+}
+
+// Learn scores the words found in the labels of the known subdomains
+// of a domain.
+func (r *Ranker) Learn(domain string, hostnames []string) {
+	for _, hostname := range hostnames {
+		hostname = dnsname.Normalize(hostname)
+		if !strings.HasSuffix(hostname, "."+domain) {
+			continue
+		}
+		subdomain := strings.TrimSuffix(hostname, "."+domain)
+		for _, word := range strings.FieldsFunc(subdomain, isSeparator) {
+			r.scores[word] += learnedWeight
+		}
+	}
+}
+
+// Score returns the score of a word, higher being more likely found.
+// The words made of several tokens (e.g. dev-api or api.eu) which
+// aren't scored themselves get the average score of their tokens.
+func (r *Ranker) Score(word string) float64 {
+	if score, ok := r.scores[word]; ok {
+		return score
+	}
+	tokens := strings.FieldsFunc(word, isSeparator)
+	if len(tokens) < 2 {
+		return 0
+	}
+	var score float64
+	for _, token := range tokens {
+		score += r.scores[token]
+	}
+	return score / float64(len(tokens))
+}
+
+// Sort orders words from the most to the least likely found, keeping
+// the original order of the words with the same score.
+func (r *Ranker) Sort(words []string) {
+	scores := make(map[string]float64, len(words))
+	for _, word := range words {
+		if _, ok := scores[word]; !ok {
+			scores[word] = r.Score(word)
+		}
+	}
+	sort.SliceStable(words, func(i, j int) bool {
+		return scores[words[i]] > scores[words[j]]
+	})
+}
+
+// isSeparator returns true for the characters separating words in labels
+func isSeparator(r rune) bool {
+	return r == '.' || r == '-' || r == '_'
+}
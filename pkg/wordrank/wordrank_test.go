@@ -0,0 +1,36 @@
+package wordrank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankerCorpus(t *testing.T) {
+	ranker := New()
+
+	words := []string{"zzunknown", "api", "www"}
+	ranker.Sort(words)
+	require.Equal(t, []string{"www", "api", "zzunknown"}, words, "Could not order words by corpus frequency")
+}
+
+func TestRankerTokens(t *testing.T) {
+	ranker := New()
+	ranker.Learn("example.com", []string{"foo.example.com", "bar.example.com"})
+
+	require.Equal(t, ranker.Score("api"), ranker.Score("api-api"), "Could not score a word per token")
+	require.Equal(t, (ranker.Score("foo")+ranker.Score("zzunknown"))/2, ranker.Score("foo.zzunknown"), "Could not average the token scores")
+
+	words := []string{"zzunknown", "zzunknown-zz", "www-api", "foo-bar"}
+	ranker.Sort(words)
+	require.Equal(t, []string{"foo-bar", "www-api", "zzunknown", "zzunknown-zz"}, words, "Could not order multi-token words")
+}
+
+func TestRankerLearn(t *testing.T) {
+	ranker := New()
+	ranker.Learn("example.com", []string{"foo-api.example.com.", "foo.eu.example.com", "bar.example.net"})
+
+	words := []string{"www", "bar", "eu", "foo"}
+	ranker.Sort(words)
+	require.Equal(t, []string{"foo", "eu", "www", "bar"}, words, "Could not order learned words first")
+}